@@ -3,21 +3,18 @@ package main
 import (
 	"bytes"
 	"context"
-	"crypto/sha256"
 	"encoding/base64"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"image"
 	"image/png"
-	"io"
 	"log"
-	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
 	gRuntime "runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"goWeb3/common"
@@ -26,16 +23,20 @@ import (
 	"github.com/makiuchi-d/gozxing/qrcode"
 	qrcodegen "github.com/skip2/go-qrcode"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
-	"golang.design/x/clipboard"
 )
 
 // App struct
 type App struct {
 	ctx                  context.Context
 	isWindowHidden       bool
-	isUserSetAlwaysOnTop bool        // 用户是否设置了置顶
-	sayProcess           *os.Process // 保存 say 进程对象
-	sayProcessMutex      sync.Mutex  // 保护并发访问
+	isUserSetAlwaysOnTop bool            // 用户是否设置了置顶
+	sayProcess           *os.Process     // 保存 say 进程对象
+	sayProcessMutex      sync.Mutex      // 保护并发访问
+	monitor              *common.Monitor // 剪贴板捕获器
+	pendingCursorOffset  atomic.Int64    // 最近复制的片段中 {{cursor}} 之后的字符数，粘贴后用于定位光标
+	exhaustedPasteItem   atomic.Value    // 最近复制的、粘贴次数已用完的临时项目 ID，自动粘贴后立即过期
+
+	hotkeyBindings map[string]common.HotkeyBinding // 已注册的快捷键绑定（受 hotkeyRestartMutex 保护）
 }
 
 // ShowAbout 显示关于对话框
@@ -125,16 +126,11 @@ func (a *App) startup(ctx context.Context) {
 		go func() {
 			// 延迟执行，确保应用已完全启动
 			time.Sleep(300 * time.Millisecond)
-			settingsJSON, err := common.GetSetting("app_settings")
-			if err == nil && settingsJSON != "" {
-				var settings map[string]interface{}
-				if err := json.Unmarshal([]byte(settingsJSON), &settings); err == nil {
-					if backgroundMode, ok := settings["backgroundMode"].(bool); ok && backgroundMode {
-						// 开启后台模式：隐藏 Dock 图标
-						common.SetDockIconVisibility(2)
-						log.Println("已根据设置启用后台模式（隐藏 Dock 图标）")
-					}
-				}
+			settings, err := common.LoadSettings()
+			if err == nil && settings.BackgroundMode {
+				// 开启后台模式：隐藏 Dock 图标
+				common.SetDockIconVisibility(2)
+				log.Println("已根据设置启用后台模式（隐藏 Dock 图标）")
 			}
 		}()
 	}
@@ -152,11 +148,65 @@ func (a *App) startup(ctx context.Context) {
 			runtime.EventsEmit(a.ctx, eventName, data)
 		}
 	})
+
+	// 设置变化时自动应用并通知前端
+	a.subscribeSettings()
+	common.SetSettingsChangedCallback(func(change common.SettingsChange) {
+		if a.ctx != nil {
+			runtime.EventsEmit(a.ctx, "settings.changed", change)
+		}
+	})
+
+	// 启动剪贴板捕获（后台持续运行）
+	a.startClipboardMonitor()
+
+	// 启动历史记录自动清理
+	if common.DB != nil {
+		common.StartRetentionScheduler()
+		common.StartExpirySweeper()
+		common.StartAutoLock(func() {
+			runtime.EventsEmit(a.ctx, "app.locked")
+		})
+	}
+}
+
+// startClipboardMonitor 初始化系统剪贴板并启动捕获器
+func (a *App) startClipboardMonitor() {
+	if common.DB == nil {
+		log.Println("⚠️ 数据库未初始化，不启动剪贴板捕获")
+		return
+	}
+
+	backend, err := common.NewSystemClipboardBackend()
+	if err != nil {
+		log.Printf("初始化剪贴板失败: %v", err)
+		return
+	}
+
+	common.SetEphemeralClipboard(backend)
+	monitor := common.NewMonitor(backend, common.DefaultClipboardStore)
+	monitor.SetStatusCallback(func(status common.MonitorStatus) {
+		if a.ctx != nil {
+			runtime.EventsEmit(a.ctx, "capture.status", status)
+		}
+	})
+	if err := monitor.Start(a.ctx); err != nil {
+		log.Printf("启动剪贴板捕获失败: %v", err)
+		return
+	}
+	a.monitor = monitor
 }
 
 // shutdown is called when the app is closing
 func (a *App) shutdown(ctx context.Context) {
 	log.Println("Wails 应用关闭")
+	// 停止剪贴板捕获（先于关闭数据库）
+	if a.monitor != nil {
+		a.monitor.Stop()
+	}
+	common.StopRetentionScheduler()
+	common.StopExpirySweeper()
+	common.StopAutoLock()
 	// 停止脚本 HTTP 服务器
 	if err := common.StopScriptHTTPServer(); err != nil {
 		log.Printf("停止脚本 HTTP 服务器失败: %v", err)
@@ -169,9 +219,10 @@ func (a *App) shutdown(ctx context.Context) {
 }
 
 // SearchClipboardItems 搜索剪贴板项目（供前端调用）
-// loadImageData: 是否加载图片数据（极简模式下需要显示图片缩略图）
-func (a *App) SearchClipboardItems(isFavorite bool, keyword string, filterType string, limit int, loadImageData bool) ([]common.ClipboardItem, error) {
-	items, err := common.SearchClipboardItems(isFavorite, keyword, filterType, limit, loadImageData)
+// tagID: 按标签过滤（空字符串表示不过滤）
+// imageMode: 图片数据加载方式，""（不加载）、"full"（原图）、"thumbnail"（缩略图，极简模式列表使用）
+func (a *App) SearchClipboardItems(isFavorite bool, keyword string, filterType string, tagID string, limit int, imageMode string) ([]common.ClipboardItem, error) {
+	items, err := common.SearchClipboardItems(isFavorite, keyword, filterType, tagID, limit, imageMode)
 	if err != nil {
 		log.Printf("搜索剪贴板项目失败: %v", err)
 		return []common.ClipboardItem{}, err
@@ -179,6 +230,52 @@ func (a *App) SearchClipboardItems(isFavorite bool, keyword string, filterType s
 	return items, nil
 }
 
+// SearchClipboardItemsRanked 按相关度搜索剪贴板项目，返回得分与命中高亮（供前端调用）
+func (a *App) SearchClipboardItemsRanked(isFavorite bool, keyword string, filterType string, limit int) ([]common.SearchHit, error) {
+	hits, err := common.SearchClipboardItemsRanked(isFavorite, keyword, filterType, limit)
+	if err != nil {
+		log.Printf("全文搜索失败: %v", err)
+		return []common.SearchHit{}, err
+	}
+	return hits, nil
+}
+
+// GetThumbnails 批量获取图片缩略图（供前端调用）
+func (a *App) GetThumbnails(ids []string) (map[string][]byte, error) {
+	thumbnails, err := common.GetThumbnails(ids)
+	if err != nil {
+		log.Printf("获取缩略图失败: %v", err)
+		return map[string][]byte{}, err
+	}
+	return thumbnails, nil
+}
+
+// PauseCapture 暂停剪贴板捕获（隐私模式），minutes <= 0 表示直到手动恢复（供前端调用）
+func (a *App) PauseCapture(minutes int) error {
+	if a.monitor == nil {
+		return fmt.Errorf("剪贴板捕获未启动")
+	}
+	a.monitor.Pause(time.Duration(minutes) * time.Minute)
+	return nil
+}
+
+// ResumeCapture 恢复剪贴板捕获（供前端调用）
+func (a *App) ResumeCapture() error {
+	if a.monitor == nil {
+		return fmt.Errorf("剪贴板捕获未启动")
+	}
+	a.monitor.Resume()
+	return nil
+}
+
+// GetCaptureStatus 获取剪贴板捕获状态（供前端调用）
+func (a *App) GetCaptureStatus() (common.MonitorStatus, error) {
+	if a.monitor == nil {
+		return common.MonitorStatus{}, nil
+	}
+	return a.monitor.Status(), nil
+}
+
 // ToggleFavorite 切换收藏状态（供前端调用）
 func (a *App) ToggleFavorite(id string) (int, error) {
 	newVal, err := common.ToggleFavorite(id)
@@ -221,22 +318,86 @@ func (a *App) DeleteClipboardItem(id string) error {
 
 // CopyTextToClipboard 复制文本到剪贴板（供前端调用）
 func (a *App) CopyTextToClipboard(text string) error {
-	clipboard.Write(clipboard.FmtText, []byte(text))
+	if err := common.WriteClipboardText(text); err != nil {
+		log.Printf("复制文本失败: %v", err)
+		return err
+	}
 	log.Printf("已复制文本到剪贴板: %s", text)
 	return nil
 }
 
 // CopyToClipboard 复制项目到剪贴板（供前端调用）
 func (a *App) CopyToClipboard(id string) error {
+	return a.copyItemToClipboard(id, nil, false)
+}
+
+// CopySnippetToClipboard 展开片段（使用前端收集的输入值）并复制到剪贴板（供前端调用）
+func (a *App) CopySnippetToClipboard(id string, inputs map[string]string) error {
+	return a.copyItemToClipboard(id, inputs, false)
+}
+
+// ExpandSnippet 预览片段展开结果（供前端调用）
+func (a *App) ExpandSnippet(content string, inputs map[string]string) *common.SnippetExpansion {
+	return common.ExpandSnippet(content, inputs)
+}
+
+// SaveSnippet 保存片段，id 为空时新建，返回片段 ID（供前端调用）
+func (a *App) SaveSnippet(id string, content string) (string, error) {
+	return common.SaveSnippet(id, content)
+}
+
+// writeOwnClipboardText 把复制历史项目得到的文本写入剪贴板，并让捕获器跳过这次写入
+// （on_copy 脚本或片段展开改写过的文本不会作为新记录再次保存）
+func (a *App) writeOwnClipboardText(text string) error {
+	if a.monitor != nil {
+		a.monitor.IgnoreOwnWrite(text)
+	}
+	return common.WriteClipboardText(text)
+}
+
+// copyItemToClipboard 复制项目到剪贴板，片段会先展开占位符
+// plainText 时图片和文件也按文本复制（见 common.HotkeyPlainText），片段和文本本来就是纯文本
+func (a *App) copyItemToClipboard(id string, inputs map[string]string, plainText bool) error {
 	item, err := common.GetClipboardItemByID(id)
 	if err != nil {
 		return fmt.Errorf("获取项目失败: %v", err)
 	}
+	a.pendingCursorOffset.Store(0)
 
 	// 根据类型复制到剪贴板
-	if item.ContentType == "Image" && len(item.ImageData) > 0 {
+	if plainText && item.ContentType != common.SnippetContentType {
+		text, err := common.HotkeyPlainText(item)
+		if err != nil {
+			return err
+		}
+		if text == "" {
+			return fmt.Errorf("项目没有可以复制的文本")
+		}
+		text = common.RunOnCopyScripts(item, text)
+		if err := a.writeOwnClipboardText(text); err != nil {
+			return err
+		}
+		log.Printf("已按纯文本复制到剪贴板: %s", id)
+	} else if item.ContentType == common.SnippetContentType {
+		// 片段：展开占位符后按文本复制，记录光标位置供粘贴后使用
+		expansion, err := common.ExpandSnippetForCopy(item.Content, inputs)
+		if err != nil {
+			return err
+		}
+		text := common.RunOnCopyScripts(item, expansion.Text)
+		if err := a.writeOwnClipboardText(text); err != nil {
+			return err
+		}
+		// on_copy 脚本修改了文本时光标位置不再准确
+		if text == expansion.Text {
+			a.pendingCursorOffset.Store(int64(expansion.CursorOffset))
+		}
+		log.Printf("已展开片段并复制到剪贴板: %s", id)
+	} else if item.ContentType == "Image" && len(item.ImageData) > 0 {
 		// 复制图片
-		clipboard.Write(clipboard.FmtImage, []byte(item.ImageData))
+		if err := common.WriteClipboardImage(item.ImageData); err != nil {
+			return err
+		}
 		log.Printf("已复制图片到剪贴板: %s", id)
 	} else if item.ContentType == "File" && item.FilePaths != "" {
 		// 复制文件（不是文本，而是真实的文件 URL）
@@ -247,11 +408,44 @@ func (a *App) CopyToClipboard(id string) error {
 		}
 		log.Printf("已复制文件到剪贴板: %s", id)
 	} else {
-		// 复制文本
-		clipboard.Write(clipboard.FmtText, []byte(item.Content))
+		// 复制文本（on_copy 脚本可以修改复制出去的文本）
+		text := common.RunOnCopyScripts(item, item.Content)
+		if err := a.writeOwnClipboardText(text); err != nil {
+			return err
+		}
 		log.Printf("已复制文本到剪贴板: %s", id)
 	}
 
+	// 临时项目：记录粘贴次数，次数用完后在宽限期内删除
+	exhausted, err := common.RecordItemPaste(id)
+	if err != nil {
+		log.Printf("记录粘贴次数失败: %v", err)
+	}
+	if exhausted {
+		a.exhaustedPasteItem.Store(id)
+	} else {
+		a.exhaustedPasteItem.Store("")
+	}
+	return nil
+}
+
+// expireExhaustedPasteItem 自动粘贴完成后让粘贴次数已用完的临时项目立即过期
+func (a *App) expireExhaustedPasteItem() {
+	id, _ := a.exhaustedPasteItem.Swap("").(string)
+	if id == "" {
+		return
+	}
+	if err := common.ExpireAfterAutoPaste(id); err != nil {
+		log.Printf("设置临时项目过期失败: %v", err)
+	}
+}
+
+// SetItemExpiry 设置临时项目：minutes 分钟后删除、粘贴 maxPastes 次后删除，都为 0 时取消（供前端调用）
+func (a *App) SetItemExpiry(id string, minutes int, maxPastes int) error {
+	if err := common.SetItemExpiry(id, minutes, maxPastes); err != nil {
+		log.Printf("设置临时项目失败: %v", err)
+		return err
+	}
 	return nil
 }
 
@@ -265,6 +459,71 @@ func (a *App) GetStatistics() (map[string]interface{}, error) {
 	return stats, nil
 }
 
+// GetSchemaMigrations 获取数据库迁移记录（供前端调用）
+func (a *App) GetSchemaMigrations() ([]common.MigrationInfo, error) {
+	migrations, err := common.GetSchemaMigrations()
+	if err != nil {
+		log.Printf("获取数据库迁移记录失败: %v", err)
+		return []common.MigrationInfo{}, err
+	}
+	return migrations, nil
+}
+
+// GetAllTags 获取所有标签（供前端调用）
+func (a *App) GetAllTags() ([]common.Tag, error) {
+	tags, err := common.GetAllTags()
+	if err != nil {
+		log.Printf("获取标签失败: %v", err)
+		return []common.Tag{}, err
+	}
+	return tags, nil
+}
+
+// CreateTag 创建标签（供前端调用）
+func (a *App) CreateTag(name string, color string) (*common.Tag, error) {
+	return common.CreateTag(name, color)
+}
+
+// RenameTag 重命名标签（供前端调用）
+func (a *App) RenameTag(id string, name string) error {
+	return common.RenameTag(id, name)
+}
+
+// SetTagColor 设置标签颜色（供前端调用）
+func (a *App) SetTagColor(id string, color string) error {
+	return common.SetTagColor(id, color)
+}
+
+// SetTagProtected 设置标签是否保护其项目不被清理（供前端调用）
+func (a *App) SetTagProtected(id string, protected bool) error {
+	return common.SetTagProtected(id, protected)
+}
+
+// MergeTags 合并标签（供前端调用）
+func (a *App) MergeTags(sourceIDs []string, targetID string) error {
+	return common.MergeTags(sourceIDs, targetID)
+}
+
+// DeleteTag 删除标签（供前端调用）
+func (a *App) DeleteTag(id string) error {
+	return common.DeleteTag(id)
+}
+
+// AddTagsToItems 批量为项目添加标签（供前端调用）
+func (a *App) AddTagsToItems(itemIDs []string, tagIDs []string) error {
+	return common.AddTagsToItems(itemIDs, tagIDs)
+}
+
+// RemoveTagsFromItems 批量移除项目的标签（供前端调用）
+func (a *App) RemoveTagsFromItems(itemIDs []string, tagIDs []string) error {
+	return common.RemoveTagsFromItems(itemIDs, tagIDs)
+}
+
+// GetTagsForItems 批量获取项目的标签（供前端调用）
+func (a *App) GetTagsForItems(itemIDs []string) (map[string][]common.Tag, error) {
+	return common.GetTagsForItems(itemIDs)
+}
+
 // ClearItemsOlderThanDays 清除超过指定天数的项目（供前端调用）
 func (a *App) ClearItemsOlderThanDays(days int) error {
 	err := common.ClearItemsOlderThanDays(days)
@@ -275,6 +534,73 @@ func (a *App) ClearItemsOlderThanDays(days int) error {
 	return nil
 }
 
+// GetRetentionStatus 获取最近一次自动清理的结果（供前端调用），从未执行过时返回 nil
+func (a *App) GetRetentionStatus() (*common.RetentionResult, error) {
+	result, err := common.GetLastRetentionResult()
+	if err != nil {
+		log.Printf("获取自动清理结果失败: %v", err)
+		return nil, err
+	}
+	return result, nil
+}
+
+// RunRetentionNow 按当前保留策略立即执行一次清理（供前端调用）
+func (a *App) RunRetentionNow() (*common.RetentionResult, error) {
+	result, err := common.RunRetention()
+	if err != nil {
+		log.Printf("执行自动清理失败: %v", err)
+		return result, err
+	}
+	return result, nil
+}
+
+// GetSensitiveCategories 获取敏感内容类别、检测器和当前处理动作（供前端调用）
+func (a *App) GetSensitiveCategories() ([]common.SensitiveCategoryInfo, error) {
+	categories, err := common.GetSensitiveCategories()
+	if err != nil {
+		log.Printf("获取敏感内容类别失败: %v", err)
+		return nil, err
+	}
+	return categories, nil
+}
+
+// GetAllCaptureRules 获取所有捕获规则（供前端调用）
+func (a *App) GetAllCaptureRules() ([]common.CaptureRule, error) {
+	rules, err := common.GetAllCaptureRules()
+	if err != nil {
+		log.Printf("获取捕获规则失败: %v", err)
+		return nil, err
+	}
+	return rules, nil
+}
+
+// SaveCaptureRule 保存捕获规则（ID 为空时新建），返回保存后的规则（供前端调用）
+func (a *App) SaveCaptureRule(rule common.CaptureRule) (*common.CaptureRule, error) {
+	if err := common.SaveCaptureRule(&rule); err != nil {
+		log.Printf("保存捕获规则失败: %v", err)
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// DeleteCaptureRule 删除捕获规则（供前端调用）
+func (a *App) DeleteCaptureRule(id string) error {
+	if err := common.DeleteCaptureRule(id); err != nil {
+		log.Printf("删除捕获规则失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// ReorderCaptureRules 按给定的 ID 顺序重新排列捕获规则（供前端调用）
+func (a *App) ReorderCaptureRules(ids []string) error {
+	if err := common.ReorderCaptureRules(ids); err != nil {
+		log.Printf("调整捕获规则顺序失败: %v", err)
+		return err
+	}
+	return nil
+}
+
 // ClearAllItems 清除所有剪贴板项目（供前端调用）
 func (a *App) ClearAllItems() error {
 	err := common.ClearAllItems()
@@ -286,23 +612,37 @@ func (a *App) ClearAllItems() error {
 }
 
 // SaveAppSettings 保存应用设置（供前端调用）
+// settingsJSON 只需包含要修改的字段，未出现的字段保持不变；校验失败时不保存
 func (a *App) SaveAppSettings(settingsJSON string) error {
-	err := common.SaveSetting("app_settings", settingsJSON)
-	if err != nil {
+	if _, err := common.PatchSettingsJSON(settingsJSON); err != nil {
 		log.Printf("保存应用设置失败: %v", err)
 		return err
 	}
-	log.Printf("已保存应用设置")
 	return nil
 }
 
-// GetAppSettings 获取应用设置（供前端调用）
+// GetAppSettings 获取应用设置 JSON（已补齐默认值，供前端调用）
 func (a *App) GetAppSettings() (string, error) {
-	settings, err := common.GetSetting("app_settings")
+	settings, err := common.LoadSettings()
 	if err != nil {
 		log.Printf("获取应用设置失败: %v", err)
 		return "", err
 	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		log.Printf("获取应用设置失败: %v", err)
+		return "", err
+	}
+	return string(data), nil
+}
+
+// GetSettings 获取应用设置（供前端调用）
+func (a *App) GetSettings() (*common.Settings, error) {
+	settings, err := common.LoadSettings()
+	if err != nil {
+		log.Printf("获取应用设置失败: %v", err)
+		return nil, err
+	}
 	return settings, nil
 }
 
@@ -356,52 +696,89 @@ func (a *App) GetSupportedLanguages() ([]string, error) {
 	return common.GetSupportedLanguages(), nil
 }
 
-// VerifyPassword 验证密码（供前端调用）
+// VerifyPassword 验证密码（供前端调用），成功时解锁应用和加密存储
 func (a *App) VerifyPassword(password string) (bool, error) {
-	// 获取设置
-	settingsJSON, err := common.GetSetting("app_settings")
+	valid, err := common.VerifyPassword(password)
 	if err != nil {
-		log.Printf("获取设置失败: %v", err)
+		log.Printf("验证密码失败: %v", err)
 		return false, err
 	}
+	return valid, nil
+}
 
-	if settingsJSON == "" {
-		// 没有设置，密码验证失败
-		return false, nil
+// GetPasswordStatus 获取是否设置了密码、是否需要解锁以及输错锁定状态（供前端调用）
+func (a *App) GetPasswordStatus() (*common.PasswordStatus, error) {
+	status, err := common.GetPasswordStatus()
+	if err != nil {
+		log.Printf("获取密码状态失败: %v", err)
+		return nil, err
 	}
+	return status, nil
+}
 
-	// 解析设置
-	var settings map[string]interface{}
-	if err := json.Unmarshal([]byte(settingsJSON), &settings); err != nil {
-		log.Printf("解析设置失败: %v", err)
-		return false, err
+// SetPassword 设置或修改应用密码（供前端调用），首次设置时 oldPassword 传空字符串
+func (a *App) SetPassword(oldPassword string, newPassword string) error {
+	if err := common.SetPassword(oldPassword, newPassword); err != nil {
+		log.Printf("设置密码失败: %v", err)
+		return err
 	}
+	return nil
+}
 
-	// 获取存储的密码hash
-	storedPassword, ok := settings["password"].(string)
-	if !ok || storedPassword == "" {
-		// 没有设置密码
-		return false, nil
+// ClearPassword 移除应用密码（供前端调用）
+func (a *App) ClearPassword(oldPassword string) error {
+	if err := common.ClearPassword(oldPassword); err != nil {
+		log.Printf("移除密码失败: %v", err)
+		return err
 	}
+	return nil
+}
 
-	// 计算输入密码的hash
-	inputHash := hashPassword(password)
+// RecordActivity 记录用户操作，用于空闲自动锁定（供前端调用）
+func (a *App) RecordActivity() {
+	common.RecordActivity()
+}
 
-	// 比较hash
-	isValid := inputHash == storedPassword
-	if isValid {
-		log.Println("✅ 密码验证成功")
-	} else {
-		log.Println("❌ 密码验证失败")
-	}
+// LockApp 立即锁定应用（供前端调用），之后需要重新输入密码
+func (a *App) LockApp() {
+	common.LockSession()
+}
 
-	return isValid, nil
+// GetEncryptionStatus 获取加密存储状态（供前端调用）
+func (a *App) GetEncryptionStatus() common.EncryptionStatus {
+	return common.GetEncryptionStatus()
 }
 
-// hashPassword 计算密码的SHA-256哈希
-func hashPassword(password string) string {
-	hash := sha256.Sum256([]byte(password))
-	return hex.EncodeToString(hash[:])
+// EnableEncryption 使用应用密码启用加密存储并加密已有数据（供前端调用）
+func (a *App) EnableEncryption(password string) error {
+	valid, err := a.VerifyPassword(password)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return common.ErrWrongPassword
+	}
+	if err := common.EnableEncryption(password); err != nil {
+		log.Printf("启用加密存储失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// DisableEncryption 关闭加密存储并把数据还原为明文（供前端调用）
+func (a *App) DisableEncryption(password string) error {
+	valid, err := a.VerifyPassword(password)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return common.ErrWrongPassword
+	}
+	if err := common.DisableEncryption(password); err != nil {
+		log.Printf("关闭加密存储失败: %v", err)
+		return err
+	}
+	return nil
 }
 
 // OpenFileInFinder 在系统文件管理器中显示/打开文件（供前端调用）
@@ -733,10 +1110,27 @@ func (a *App) HideWindowAndQuit() {
 	}
 }
 
+// AutoPasteCurrentItem 粘贴剪贴板内容到当前应用
+// 剪贴板内容由前面的 CopyToClipboard 写入，on_copy 脚本已经在复制时执行过
 func (a *App) AutoPasteCurrentItem() {
 	if a.ctx != nil {
-		go common.PasteCmdV()
+		go func() {
+			common.PasteCmdV()
+			a.applyPendingCursorOffset()
+			a.expireExhaustedPasteItem()
+		}()
+	}
+}
+
+// applyPendingCursorOffset 粘贴片段后把光标移动到 {{cursor}} 的位置
+func (a *App) applyPendingCursorOffset() {
+	offset := a.pendingCursorOffset.Swap(0)
+	if offset <= 0 {
+		return
 	}
+	// 等待目标应用处理完粘贴
+	time.Sleep(80 * time.Millisecond)
+	common.MoveCursorLeft(int(offset))
 }
 
 // 激活应用
@@ -747,12 +1141,78 @@ func (a *App) ActivatePreviousApp() {
 }
 
 // AutoPasteCurrentItemToPreviousApp 自动粘贴到之前的前台应用（直接发送到进程）
+// 和 AutoPasteCurrentItem 一样，on_copy 脚本已经在 CopyToClipboard 时执行过
 func (a *App) AutoPasteCurrentItemToPreviousApp() {
 	if a.ctx != nil {
-		go common.PasteCmdVToPreviousApp()
+		go func() {
+			common.PasteCmdVToPreviousApp()
+			a.applyPendingCursorOffset()
+			a.expireExhaustedPasteItem()
+		}()
 	}
 }
 
+// ExportHistory 导出剪贴板历史到 zip 文件，path 为空时弹出保存对话框（供前端调用）
+func (a *App) ExportHistory(filter common.ExportFilter, path string) (*common.ExportResult, error) {
+	if path == "" {
+		if a.ctx == nil {
+			return nil, fmt.Errorf("应用上下文未初始化")
+		}
+		now := time.Now()
+		selected, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+			DefaultFilename: fmt.Sprintf("clipsave-%s.zip", now.Format("20060102-150405")),
+			Filters: []runtime.FileFilter{
+				{DisplayName: "ClipSave 导出文件", Pattern: "*.zip"},
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("选择保存路径失败: %v", err)
+		}
+		if selected == "" {
+			// 用户取消
+			return nil, nil
+		}
+		path = selected
+	}
+
+	result, err := common.ExportHistory(filter, path)
+	if err != nil {
+		log.Printf("导出历史失败: %v", err)
+		return nil, err
+	}
+	return result, nil
+}
+
+// ImportHistory 从 zip 文件导入剪贴板历史，path 为空时弹出选择对话框（供前端调用）
+// mode: "merge"（默认，保留本地数据）或 "overwrite"（以导入数据为准）
+func (a *App) ImportHistory(path string, mode string) (*common.ImportReport, error) {
+	if path == "" {
+		if a.ctx == nil {
+			return nil, fmt.Errorf("应用上下文未初始化")
+		}
+		selected, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+			Filters: []runtime.FileFilter{
+				{DisplayName: "ClipSave 导出文件", Pattern: "*.zip"},
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("选择导入文件失败: %v", err)
+		}
+		if selected == "" {
+			// 用户取消
+			return nil, nil
+		}
+		path = selected
+	}
+
+	report, err := common.ImportHistory(path, mode)
+	if err != nil {
+		log.Printf("导入历史失败: %v", err)
+		return report, err
+	}
+	return report, nil
+}
+
 // SaveImagePNG 通过系统对话框将 Base64 PNG 保存到本地（供前端调用）
 func (a *App) SaveImagePNG(base64Data string, suggestedName string) (string, error) {
 	if a.ctx == nil {
@@ -913,8 +1373,10 @@ func (a *App) CopyImageToClipboard(base64Data string) error {
 	}
 
 	// 写入剪贴板
-	done := clipboard.Write(clipboard.FmtImage, data)
-	<-done // 等待写入完成
+	if err := common.WriteClipboardImage(data); err != nil {
+		log.Printf("复制图片失败: %v", err)
+		return err
+	}
 
 	log.Printf("图片已复制到剪贴板，大小: %d bytes", len(data))
 	return nil
@@ -937,47 +1399,217 @@ func (a *App) PlayCurrentItem() {
 // 添加互斥锁防止重复调用
 var hotkeyRestartMutex sync.Mutex
 
+// RestartRegisterHotkey 按已保存的设置重新注册所有快捷键（启动时调用；修改设置后由设置订阅自动注册）
 func (a *App) RestartRegisterHotkey() error {
+	settings, err := common.LoadSettings()
+	if err != nil {
+		log.Printf("获取应用设置失败: %v", err)
+	}
+	return a.applyHotkeyBindings(common.EffectiveHotkeyBindings(settings), true)
+}
+
+// ValidateHotkey 检查快捷键：返回规范形式、当前系统支持的按键、与已保存绑定的冲突以及系统注册失败的原因
+func (a *App) ValidateHotkey(hotkeyStr string) *common.HotkeyValidation {
+	result := common.ValidateHotkey(hotkeyStr)
+	if result.Error != "" {
+		log.Printf("⚠️ 快捷键 %s 无效: %s", hotkeyStr, result.Error)
+	} else if result.RegisterError != "" {
+		log.Printf("⚠️ 快捷键 %s 无法注册: %s", hotkeyStr, result.RegisterError)
+	}
+	return result
+}
+
+// applyHotkeyBindings 按绑定列表注册快捷键：只重新注册新增或修改过的绑定，force 时全部重新注册
+// 任何一个绑定注册失败时恢复原来的绑定并返回错误（设置修改随之回滚）
+func (a *App) applyHotkeyBindings(bindings []common.HotkeyBinding, force bool) error {
 	// 使用互斥锁防止重复调用
 	hotkeyRestartMutex.Lock()
 	defer hotkeyRestartMutex.Unlock()
 
-	log.Println("🔄 重启注册快捷键")
+	if a.hotkeyBindings == nil {
+		a.hotkeyBindings = make(map[string]common.HotkeyBinding)
+	}
+	previous := make(map[string]common.HotkeyBinding, len(a.hotkeyBindings))
+	for id, binding := range a.hotkeyBindings {
+		previous[id] = binding
+	}
+	desired := make(map[string]common.HotkeyBinding, len(bindings))
+	for _, binding := range bindings {
+		desired[binding.ID] = binding
+	}
 
-	// 先取消当前注册的快捷键
-	common.UnregisterHotkey()
+	// 先取消删除或修改过的绑定，快捷键在两个绑定之间交换时不会冲突
+	unregistered := false
+	for id, current := range a.hotkeyBindings {
+		if binding, ok := desired[id]; ok && binding == current && !force {
+			continue
+		}
+		common.UnregisterHotkey(id)
+		delete(a.hotkeyBindings, id)
+		unregistered = true
+	}
+	if unregistered {
+		// 等待一小段时间确保旧快捷键完全清理
+		time.Sleep(100 * time.Millisecond)
+	}
 
-	// 等待一小段时间确保旧快捷键完全清理
-	time.Sleep(100 * time.Millisecond)
+	for _, binding := range bindings {
+		if _, ok := a.hotkeyBindings[binding.ID]; ok {
+			continue
+		}
+		if err := a.registerHotkeyBinding(binding); err != nil {
+			log.Printf("⚠️ 注册快捷键失败: %v", err)
+			a.restoreHotkeyBindings(previous)
+			return err
+		}
+	}
+	return nil
+}
 
-	// 获取设置
-	settingsJSON, err := common.GetSetting("app_settings")
-	if err != nil {
-		log.Printf("获取应用设置失败: %v", err)
+// restoreHotkeyBindings 注册新绑定失败后恢复为 previous：取消本次新注册的绑定，重新注册已取消的原绑定
+func (a *App) restoreHotkeyBindings(previous map[string]common.HotkeyBinding) {
+	unregistered := false
+	for id, current := range a.hotkeyBindings {
+		if binding, ok := previous[id]; ok && binding == current {
+			continue
+		}
+		common.UnregisterHotkey(id)
+		delete(a.hotkeyBindings, id)
+		unregistered = true
+	}
+	if unregistered {
+		time.Sleep(100 * time.Millisecond)
 	}
 
-	// 解析设置
-	var settings map[string]interface{}
-	if err := json.Unmarshal([]byte(settingsJSON), &settings); err != nil {
-		log.Printf("解析应用设置失败: %v", err)
+	for id, binding := range previous {
+		if _, ok := a.hotkeyBindings[id]; ok {
+			continue
+		}
+		if err := a.registerHotkeyBinding(binding); err != nil {
+			log.Printf("⚠️ 恢复快捷键失败: %v", err)
+		}
 	}
+}
 
-	// 获取快捷键设置
-	hotkey := "Command+Option+c" // 默认快捷键
-	if hotkeyVal, ok := settings["hotkey"].(string); ok && hotkeyVal != "" {
-		hotkey = hotkeyVal
+// registerHotkeyBinding 注册单个绑定并记录到 a.hotkeyBindings（调用方持有 hotkeyRestartMutex）
+func (a *App) registerHotkeyBinding(binding common.HotkeyBinding) error {
+	if err := common.RegisterHotkey(binding.ID, binding.Hotkey, func() {
+		a.runHotkeyAction(binding)
+	}); err != nil {
+		return err
 	}
+	a.hotkeyBindings[binding.ID] = binding
+	log.Printf("✅ 快捷键注册成功: %s (%s)", binding.Hotkey, binding.Action)
+	return nil
+}
 
-	// 注册快捷键
-	if err := common.RegisterHotkey(hotkey, func() {
+// runHotkeyAction 执行快捷键绑定的动作
+func (a *App) runHotkeyAction(binding common.HotkeyBinding) {
+	log.Printf("⌨️ 快捷键 %s 触发: %s", binding.Hotkey, binding.Action)
+	switch binding.Action {
+	case common.HotkeyActionShowWindow:
 		a.ShowWindow()
-	}); err != nil {
-		log.Printf("⚠️ 注册快捷键失败: %v", err)
-		return fmt.Errorf("注册快捷键失败: %v", err)
+	case common.HotkeyActionPasteLatest:
+		go a.pasteLatestAsPlainText()
+	case common.HotkeyActionPasteNth:
+		go a.pasteNthItem(binding.Index)
+	case common.HotkeyActionOpenFavorites:
+		a.ShowWindow()
+		a.SwitchLeftTab("fav")
+	case common.HotkeyActionToggleCapture:
+		a.toggleCapture()
+	case common.HotkeyActionRunScript:
+		a.runScriptOnLatestItem(binding.ScriptID)
 	}
+}
 
-	log.Printf("✅ 快捷键注册成功: %s", hotkey)
-	return nil
+// latestClipboardItem 获取最近的第 n 条项目（从 1 开始）
+func latestClipboardItem(n int) (*common.ClipboardItem, error) {
+	items, err := common.GetClipboardItems(n)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) < n {
+		return nil, fmt.Errorf("历史记录中没有第 %d 条", n)
+	}
+	return common.GetClipboardItemByID(items[n-1].ID)
+}
+
+// pasteLatestAsPlainText 以纯文本粘贴最近一条到当前应用
+func (a *App) pasteLatestAsPlainText() {
+	item, err := latestClipboardItem(1)
+	if err != nil {
+		log.Printf("⚠️ 粘贴最近一条失败: %v", err)
+		return
+	}
+	// 与其他粘贴方式一样经过 copyItemToClipboard：执行 on_copy 脚本并计入临时项目的粘贴次数
+	if err := a.copyItemToClipboard(item.ID, nil, true); err != nil {
+		log.Printf("⚠️ 粘贴最近一条失败: %v", err)
+		return
+	}
+	common.PasteCmdV()
+	a.applyPendingCursorOffset()
+	a.expireExhaustedPasteItem()
+}
+
+// pasteNthItem 粘贴第 n 条到当前应用
+func (a *App) pasteNthItem(n int) {
+	item, err := latestClipboardItem(n)
+	if err != nil {
+		log.Printf("⚠️ 粘贴第 %d 条失败: %v", n, err)
+		return
+	}
+	if err := a.copyItemToClipboard(item.ID, nil, false); err != nil {
+		log.Printf("⚠️ 粘贴第 %d 条失败: %v", n, err)
+		return
+	}
+	common.PasteCmdV()
+	a.applyPendingCursorOffset()
+	a.expireExhaustedPasteItem()
+}
+
+// toggleCapture 暂停 / 恢复剪贴板捕获
+func (a *App) toggleCapture() {
+	if a.monitor == nil {
+		return
+	}
+	if a.monitor.Status().Paused {
+		a.monitor.Resume()
+	} else {
+		a.monitor.Pause(0)
+	}
+}
+
+// runScriptOnLatestItem 对最近一条运行脚本
+func (a *App) runScriptOnLatestItem(scriptID string) {
+	item, err := latestClipboardItem(1)
+	if err != nil {
+		log.Printf("⚠️ 运行脚本失败: %v", err)
+		return
+	}
+	if err := common.RunScriptOnItem(scriptID, item); err != nil {
+		log.Printf("⚠️ 运行脚本失败: %v", err)
+	}
+}
+
+// subscribeSettings 订阅需要由应用层应用的设置（快捷键、后台模式、开机自启）
+// 失败时设置修改不会保存，错误返回给调用 SaveAppSettings 的前端
+func (a *App) subscribeSettings() {
+	common.SubscribeSettings("hotkey", []string{"hotkey", "hotkeys"}, func(change common.SettingsChange) error {
+		return a.applyHotkeyBindings(common.EffectiveHotkeyBindings(change.Settings), false)
+	})
+	common.SubscribeSettings("dock", []string{"backgroundMode"}, func(change common.SettingsChange) error {
+		if gRuntime.GOOS != "darwin" {
+			return nil
+		}
+		if change.Settings.BackgroundMode {
+			return a.SetDockIconVisibility(2)
+		}
+		return a.SetDockIconVisibility(1)
+	})
+	common.SubscribeSettings("autostart", []string{"autoStart"}, func(change common.SettingsChange) error {
+		return common.SetAutoStart(change.Settings.AutoStart)
+	})
 }
 
 // GetAllUserScripts 获取所有用户脚本
@@ -1045,14 +1677,34 @@ func (a *App) GetScriptHTTPURL(scriptID string) (string, error) {
 	return common.GetScriptHTTPURL(scriptID)
 }
 
-// SetScriptHTTPResult 设置脚本执行结果（供前端调用）
-func (a *App) SetScriptHTTPResult(requestID string, resultJSON string) error {
-	var result common.ScriptHTTPResult
-	if err := json.Unmarshal([]byte(resultJSON), &result); err != nil {
-		return fmt.Errorf("解析结果失败: %v", err)
-	}
-	common.SetScriptHTTPResult(requestID, result)
-	return nil
+// GetScriptStorage 读取脚本存储的值（JSON），不存在时返回空字符串（供前端调用）
+func (a *App) GetScriptStorage(scriptID string, key string) (string, error) {
+	return common.GetScriptStorage(scriptID, key)
+}
+
+// SetScriptStorage 保存脚本存储的值（JSON）（供前端调用）
+func (a *App) SetScriptStorage(scriptID string, key string, valueJSON string) error {
+	return common.SetScriptStorage(scriptID, key, valueJSON)
+}
+
+// DeleteScriptStorage 删除脚本存储的值（供前端调用）
+func (a *App) DeleteScriptStorage(scriptID string, key string) error {
+	return common.DeleteScriptStorage(scriptID, key)
+}
+
+// ListScriptRuns 按条件查询脚本执行记录（最新的在前）（供前端调用）
+func (a *App) ListScriptRuns(filter common.ScriptRunFilter) ([]common.ScriptRun, error) {
+	return common.ListScriptRuns(filter)
+}
+
+// PurgeScriptRuns 删除符合条件的脚本执行记录，条件为空时删除全部（供前端调用）
+func (a *App) PurgeScriptRuns(filter common.ScriptRunFilter) (int64, error) {
+	return common.PurgeScriptRuns(filter)
+}
+
+// GetScriptRunSummaries 获取每个脚本的执行成功/失败统计（供前端调用）
+func (a *App) GetScriptRunSummaries() ([]common.ScriptRunSummary, error) {
+	return common.GetScriptRunSummaries()
 }
 
 // HttpRequest 通用的 HTTP 请求代理函数（用于绕过 CORS 限制）
@@ -1062,74 +1714,5 @@ func (a *App) SetScriptHTTPResult(requestID string, resultJSON string) error {
 // bodyJson: 请求体 JSON 字符串（GET 请求可为空字符串）
 // 返回响应体的 JSON 字符串和错误信息
 func (a *App) HttpRequest(method string, requestUrl string, headersJson string, bodyJson string) (string, error) {
-	// 解析请求头
-	var headers map[string]string
-	if headersJson != "" {
-		if err := json.Unmarshal([]byte(headersJson), &headers); err != nil {
-			return "", fmt.Errorf("解析请求头失败: %v", err)
-		}
-	} else {
-		headers = make(map[string]string)
-	}
-
-	// 创建请求体
-	var bodyReader io.Reader
-	if bodyJson != "" && (method == "POST" || method == "PUT" || method == "PATCH") {
-		bodyReader = bytes.NewBufferString(bodyJson)
-	}
-
-	// 创建 HTTP 请求
-	req, err := http.NewRequest(method, requestUrl, bodyReader)
-	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %v", err)
-	}
-
-	// 设置请求头
-	for key, value := range headers {
-		req.Header.Set(key, value)
-	}
-
-	// 如果没有设置 Content-Type 且有请求体，默认设置为 application/json
-	if bodyReader != nil && req.Header.Get("Content-Type") == "" {
-		req.Header.Set("Content-Type", "application/json")
-	}
-
-	// 发送请求
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("发送请求失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	// 读取响应体
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %v", err)
-	}
-
-	// 构建响应对象
-	response := map[string]interface{}{
-		"status":     resp.StatusCode,
-		"statusText": resp.Status,
-		"headers":    resp.Header,
-		"body":       string(body),
-	}
-
-	// 如果响应是 JSON，尝试解析
-	var bodyObj interface{}
-	if err := json.Unmarshal(body, &bodyObj); err == nil {
-		response["body"] = bodyObj
-	}
-
-	// 返回 JSON 格式的响应
-	responseJSON, err := json.Marshal(response)
-	if err != nil {
-		return "", fmt.Errorf("序列化响应失败: %v", err)
-	}
-
-	return string(responseJSON), nil
+	return common.ScriptHTTPRequest(context.Background(), method, requestUrl, headersJson, bodyJson)
 }
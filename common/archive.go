@@ -0,0 +1,662 @@
+package common
+
+import (
+	"archive/zip"
+	"bufio"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+// 导出文件（zip）结构：
+//
+//	manifest.json       格式版本、应用版本、导出时间
+//	items.jsonl         剪贴板项目，每行一个 JSON
+//	blobs/<hash>        图片原图（PNG），文件名为图片数据的 sha256
+//	tags.json           标签
+//	user_scripts.json   用户脚本
+//	app_settings.json   应用设置（不包含密码）
+const (
+	archiveFormat       = "clipsave-export"
+	archiveVersion      = 1
+	archiveManifestFile = "manifest.json"
+	archiveItemsFile    = "items.jsonl"
+	archiveBlobDir      = "blobs"
+	archiveTagsFile     = "tags.json"
+	archiveScriptsFile  = "user_scripts.json"
+	archiveSettingsFile = "app_settings.json"
+)
+
+// 导入模式
+const (
+	ImportModeMerge     = "merge"     // 合并：ID 冲突时保留本地数据，导入项目使用新 ID；脚本和设置只补充本地没有的
+	ImportModeOverwrite = "overwrite" // 覆盖：ID 冲突时以导入数据为准；脚本和设置以导入数据为准
+)
+
+// ExportFilter 导出过滤条件（零值表示导出全部）
+type ExportFilter struct {
+	FavoritesOnly bool
+	Keyword       string
+	ContentType   string
+	TagID         string
+	Days          int // 只导出最近 N 天的项目，0 表示不限制
+}
+
+// ExportResult 导出结果
+type ExportResult struct {
+	Path    string
+	Items   int
+	Images  int
+	Scripts int
+}
+
+// ImportReport 导入结果
+// 项目列表中每个非空行只计入 Added、Skipped、Conflicted、Failed 中的一个，四者之和等于非空行数
+type ImportReport struct {
+	Added           int      // 使用原 ID 新增的项目
+	Skipped         int      // 本地已有相同内容（content_hash 相同）而没有新增的项目
+	Conflicted      int      // ID 被本地其他内容占用的项目：合并模式下使用新 ID 新增，覆盖模式下替换本地项目
+	Failed          int      // 解析失败、图片数据与哈希不一致等原因没有导入的项目
+	ScriptsAdded    int      // 新增的脚本
+	ScriptsSkipped  int      // 本地已存在而保留本地版本的脚本
+	ScriptsReplaced int      // 被导入版本覆盖的脚本
+	SettingsUpdated bool     // 是否修改了应用设置
+	Errors          []string // 失败原因（最多记录 20 条）
+}
+
+// archiveManifest 导出文件清单
+type archiveManifest struct {
+	Format        string    `json:"format"`
+	Version       int       `json:"version"`
+	AppVersion    string    `json:"appVersion"`
+	SchemaVersion int       `json:"schemaVersion"`
+	ExportedAt    time.Time `json:"exportedAt"`
+	Items         int       `json:"items"`
+}
+
+// archiveItem 导出文件中的项目
+type archiveItem struct {
+	ID          string    `json:"id"`
+	Content     string    `json:"content"`
+	ContentType string    `json:"contentType"`
+	ContentHash string    `json:"contentHash"`
+	FilePaths   string    `json:"filePaths,omitempty"`
+	FileInfo    string    `json:"fileInfo,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+	Source      string    `json:"source"`
+	CharCount   int       `json:"charCount"`
+	WordCount   int       `json:"wordCount"`
+	IsFavorite  int       `json:"isFavorite"`
+	OCRText     string    `json:"ocrText,omitempty"`
+	Image       string    `json:"image,omitempty"` // 图片在压缩包中的路径
+	Tags        []string  `json:"tags,omitempty"`  // 标签名称
+}
+
+// archiveTag 导出文件中的标签
+type archiveTag struct {
+	Name      string `json:"name"`
+	Color     string `json:"color"`
+	Protected bool   `json:"protected"`
+}
+
+// ExportHistory 按过滤条件导出剪贴板历史到 zip 文件（临时项目不导出）
+func ExportHistory(filter ExportFilter, path string) (*ExportResult, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	items, err := SearchClipboardItems(filter.FavoritesOnly, filter.Keyword, filter.ContentType, filter.TagID, -1, ImageLoadNone)
+	if err != nil {
+		return nil, err
+	}
+	if filter.Days > 0 {
+		cutoff := time.Now().AddDate(0, 0, -filter.Days)
+		filtered := items[:0]
+		for _, item := range items {
+			if !item.Timestamp.Before(cutoff) {
+				filtered = append(filtered, item)
+			}
+		}
+		items = filtered
+	}
+
+	// 临时项目（有到期时间或粘贴次数限制）不导出，避免导入后变成永久保存的项目
+	kept := items[:0]
+	for _, item := range items {
+		if item.ExpiresAt == nil && item.MaxPastes == 0 {
+			kept = append(kept, item)
+		}
+	}
+	if skipped := len(items) - len(kept); skipped > 0 {
+		log.Printf("⏳ 跳过 %d 个临时项目，不导出", skipped)
+	}
+	items = kept
+
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	itemTags, err := GetTagsForItems(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// 先写临时文件，完成后再重命名，避免留下不完整的导出文件
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("创建导出文件失败: %v", err)
+	}
+	defer os.Remove(tmpPath)
+
+	result := &ExportResult{Path: path, Items: len(items)}
+	zw := zip.NewWriter(f)
+	if err := writeArchive(zw, items, itemTags, result); err != nil {
+		zw.Close()
+		f.Close()
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("写入导出文件失败: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("写入导出文件失败: %v", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return nil, fmt.Errorf("保存导出文件失败: %v", err)
+	}
+
+	log.Printf("✅ 已导出 %d 条项目（%d 张图片，%d 个脚本）到 %s", result.Items, result.Images, result.Scripts, path)
+	return result, nil
+}
+
+// writeArchive 写入导出文件的全部内容
+func writeArchive(zw *zip.Writer, items []ClipboardItem, itemTags map[string][]Tag, result *ExportResult) error {
+	schemaVersion, _ := CurrentSchemaVersion()
+	if err := writeArchiveJSON(zw, archiveManifestFile, archiveManifest{
+		Format:        archiveFormat,
+		Version:       archiveVersion,
+		AppVersion:    AppVersion,
+		SchemaVersion: schemaVersion,
+		ExportedAt:    time.Now(),
+		Items:         len(items),
+	}); err != nil {
+		return err
+	}
+
+	// 图片（按数据的 sha256 去重）；zip 同一时间只能写一个文件，所以先写图片再写项目列表
+	imagePaths := make(map[string]string)
+	for i := range items {
+		item := &items[i]
+		if item.ContentType != "Image" {
+			continue
+		}
+		fillImageData(item)
+		if len(item.ImageData) == 0 {
+			continue
+		}
+		// 使用图片数据的 sha256 作为文件名，导入时据此校验数据（本地的 content_hash 可能不是 sha256）
+		sum := sha256.Sum256(item.ImageData)
+		hash := hex.EncodeToString(sum[:])
+		name := archiveBlobDir + "/" + hash
+		if _, written := imagePaths[hash]; !written {
+			if err := writeArchiveFile(zw, name, item.ImageData); err != nil {
+				return err
+			}
+			result.Images++
+		}
+		imagePaths[hash] = name
+		item.ContentHash = hash
+		item.ImageData = nil
+	}
+
+	// 项目（每行一个 JSON）
+	w, err := zw.Create(archiveItemsFile)
+	if err != nil {
+		return fmt.Errorf("写入项目失败: %v", err)
+	}
+	enc := json.NewEncoder(w)
+	tagSet := make(map[string]Tag)
+	for _, item := range items {
+		record := archiveItem{
+			ID:          item.ID,
+			Content:     item.Content,
+			ContentType: item.ContentType,
+			ContentHash: item.ContentHash,
+			FilePaths:   item.FilePaths,
+			FileInfo:    item.FileInfo,
+			Timestamp:   item.Timestamp,
+			Source:      item.Source,
+			CharCount:   item.CharCount,
+			WordCount:   item.WordCount,
+			IsFavorite:  item.IsFavorite,
+			OCRText:     item.OCRText,
+		}
+		if item.ContentType == "Image" {
+			record.Image = imagePaths[item.ContentHash]
+		}
+		for _, tag := range itemTags[item.ID] {
+			record.Tags = append(record.Tags, tag.Name)
+			tagSet[tag.ID] = tag
+		}
+		if err := enc.Encode(record); err != nil {
+			return fmt.Errorf("写入项目失败: %v", err)
+		}
+	}
+
+	tags := make([]archiveTag, 0, len(tagSet))
+	for _, tag := range tagSet {
+		tags = append(tags, archiveTag{Name: tag.Name, Color: tag.Color, Protected: tag.Protected})
+	}
+	if err := writeArchiveJSON(zw, archiveTagsFile, tags); err != nil {
+		return err
+	}
+
+	scripts, err := GetAllUserScripts()
+	if err != nil {
+		return err
+	}
+	if scripts == nil {
+		scripts = []UserScript{}
+	}
+	result.Scripts = len(scripts)
+	if err := writeArchiveJSON(zw, archiveScriptsFile, scripts); err != nil {
+		return err
+	}
+
+	settings, err := exportableSettings()
+	if err != nil {
+		return err
+	}
+	return writeArchiveJSON(zw, archiveSettingsFile, settings)
+}
+
+// exportableSettings 返回可以导出的设置（去掉密码，避免在其他机器上意外锁定）
+func exportableSettings() (*Settings, error) {
+	settings, err := LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+	delete(settings.extra, "password")
+	return settings, nil
+}
+
+// writeArchiveJSON 把 v 以 JSON 写入压缩包
+func writeArchiveJSON(zw *zip.Writer, name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化 %s 失败: %v", name, err)
+	}
+	return writeArchiveFile(zw, name, data)
+}
+
+// writeArchiveFile 写入压缩包中的单个文件
+func writeArchiveFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("写入 %s 失败: %v", name, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("写入 %s 失败: %v", name, err)
+	}
+	return nil
+}
+
+// ImportHistory 从 ExportHistory 生成的 zip 文件导入剪贴板历史
+// 项目按 content_hash 合并：本地已有相同内容时沿用 SaveClipboardItem 的去重逻辑（只在导入项目更新时刷新时间和来源）
+func ImportHistory(path string, mode string) (*ImportReport, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	if mode == "" {
+		mode = ImportModeMerge
+	}
+	if mode != ImportModeMerge && mode != ImportModeOverwrite {
+		return nil, fmt.Errorf("不支持的导入模式: %s", mode)
+	}
+
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开导入文件失败: %v", err)
+	}
+	defer zr.Close()
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var manifest archiveManifest
+	if err := readArchiveJSON(files, archiveManifestFile, &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Format != archiveFormat {
+		return nil, fmt.Errorf("不是 ClipSave 导出文件")
+	}
+	if manifest.Version > archiveVersion {
+		return nil, fmt.Errorf("导出文件版本 %d 高于当前应用支持的版本 %d，请升级 ClipSave", manifest.Version, archiveVersion)
+	}
+
+	report := &ImportReport{}
+
+	tagIDs, err := importTags(files)
+	if err != nil {
+		return nil, err
+	}
+	if err := importItems(files, mode, tagIDs, report); err != nil {
+		return report, err
+	}
+	if err := importScripts(files, mode, report); err != nil {
+		return report, err
+	}
+	if err := importSettings(files, mode, report); err != nil {
+		return report, err
+	}
+
+	if report.Added > 0 || report.Conflicted > 0 {
+		go backfillThumbnails()
+		notifyListeners()
+	}
+	log.Printf("✅ 导入完成: 新增 %d，跳过 %d，冲突 %d，失败 %d", report.Added, report.Skipped, report.Conflicted, report.Failed)
+	return report, nil
+}
+
+// readArchiveJSON 读取压缩包中的 JSON 文件
+func readArchiveJSON(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("导入文件缺少 %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("读取 %s 失败: %v", name, err)
+	}
+	defer rc.Close()
+	if err := json.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("解析 %s 失败: %v", name, err)
+	}
+	return nil
+}
+
+// readArchiveFile 读取压缩包中的文件
+func readArchiveFile(files map[string]*zip.File, name string) ([]byte, error) {
+	f, ok := files[name]
+	if !ok {
+		return nil, fmt.Errorf("导入文件缺少 %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 失败: %v", name, err)
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// addError 记录导入失败原因
+func (r *ImportReport) addError(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	log.Printf("⚠️ 导入: %s", msg)
+	if len(r.Errors) < 20 {
+		r.Errors = append(r.Errors, msg)
+	}
+}
+
+// importTags 导入标签，返回 标签名称 -> 本地标签 ID
+func importTags(files map[string]*zip.File) (map[string]string, error) {
+	tagIDs := make(map[string]string)
+	var tags []archiveTag
+	if _, ok := files[archiveTagsFile]; !ok {
+		return tagIDs, nil
+	}
+	if err := readArchiveJSON(files, archiveTagsFile, &tags); err != nil {
+		return nil, err
+	}
+	for _, t := range tags {
+		id, err := findTagIDByName(t.Name)
+		if err != nil {
+			return nil, fmt.Errorf("查询标签失败: %v", err)
+		}
+		if id == "" {
+			tag, err := CreateTag(t.Name, t.Color)
+			if err != nil {
+				log.Printf("⚠️ 导入标签 %s 失败: %v", t.Name, err)
+				continue
+			}
+			id = tag.ID
+			if t.Protected {
+				SetTagProtected(id, true)
+			}
+		}
+		tagIDs[t.Name] = id
+	}
+	return tagIDs, nil
+}
+
+// importItems 逐条导入项目
+func importItems(files map[string]*zip.File, mode string, tagIDs map[string]string, report *ImportReport) error {
+	f, ok := files[archiveItemsFile]
+	if !ok {
+		return fmt.Errorf("导入文件缺少 %s", archiveItemsFile)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("读取 %s 失败: %v", archiveItemsFile, err)
+	}
+	defer rc.Close()
+
+	scanner := bufio.NewScanner(rc)
+	// 单条项目可能包含很长的文本
+	scanner.Buffer(make([]byte, 0, 1024*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record archiveItem
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			report.Failed++
+			report.addError("第 %d 行解析失败: %v", line, err)
+			continue
+		}
+		if err := importItem(files, mode, tagIDs, &record, report); err != nil {
+			report.Failed++
+			report.addError("项目 %s 导入失败: %v", record.ID, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("读取 %s 失败: %v", archiveItemsFile, err)
+	}
+	return nil
+}
+
+// importItem 导入单个项目
+func importItem(files map[string]*zip.File, mode string, tagIDs map[string]string, record *archiveItem, report *ImportReport) error {
+	item := &ClipboardItem{
+		ID:          record.ID,
+		Content:     record.Content,
+		ContentType: record.ContentType,
+		ContentHash: record.ContentHash,
+		FilePaths:   record.FilePaths,
+		FileInfo:    record.FileInfo,
+		Timestamp:   record.Timestamp,
+		Source:      record.Source,
+		CharCount:   record.CharCount,
+		WordCount:   record.WordCount,
+		IsFavorite:  record.IsFavorite,
+		OCRText:     record.OCRText,
+	}
+	if record.Image != "" {
+		data, err := readArchiveFile(files, record.Image)
+		if err != nil {
+			return err
+		}
+		// 图片按内容寻址保存，数据和哈希不一致时写入会让相同哈希的其他图片显示错误的内容
+		sum := sha256.Sum256(data)
+		if hash := hex.EncodeToString(sum[:]); record.ContentHash != hash {
+			return fmt.Errorf("图片数据与哈希不一致")
+		}
+		item.ImageData = data
+	} else if item.ContentType == "Image" {
+		return fmt.Errorf("缺少图片数据")
+	}
+	// 按本地的规则重新计算哈希，不使用导入文件中的值
+	item.ContentHash = calculateContentHash(item)
+
+	// 本地已有相同内容：与捕获时的去重一致，不新增记录
+	var existingID string
+	var existingTime time.Time
+	err := DB.QueryRow(`SELECT id, timestamp FROM clipboard_items WHERE content_hash = ? AND content_type = ? LIMIT 1`,
+		item.ContentHash, item.ContentType).Scan(&existingID, &existingTime)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("查询重复项目失败: %v", err)
+	}
+	if existingID != "" {
+		// 只有导入的记录更新时才刷新时间和来源，避免旧备份把项目"顶"到最前面
+		if item.Timestamp.After(existingTime) {
+			if err := SaveClipboardItem(item); err != nil {
+				return err
+			}
+		}
+		mergeImportedItemMeta(existingID, record, tagIDs)
+		report.Skipped++
+		return nil
+	}
+
+	// ID 已被其他内容占用
+	var idTaken int
+	DB.QueryRow(`SELECT COUNT(*) FROM clipboard_items WHERE id = ?`, item.ID).Scan(&idTaken)
+	if idTaken > 0 {
+		if mode == ImportModeOverwrite {
+			if err := DeleteClipboardItem(item.ID); err != nil {
+				return err
+			}
+		} else {
+			item.ID = fmt.Sprintf("%d", time.Now().UnixNano())
+		}
+	}
+
+	if err := SaveClipboardItem(item); err != nil {
+		return err
+	}
+	if idTaken > 0 {
+		report.Conflicted++
+	} else {
+		report.Added++
+	}
+	mergeImportedItemMeta(item.ID, record, tagIDs)
+	return nil
+}
+
+// mergeImportedItemMeta 合并收藏状态、OCR 文字和标签（只增加，不移除本地已有的）
+func mergeImportedItemMeta(id string, record *archiveItem, tagIDs map[string]string) {
+	if record.IsFavorite == 1 {
+		DB.Exec(`UPDATE clipboard_items SET is_favorite = 1 WHERE id = ?`, id)
+	}
+	if record.OCRText != "" {
+		if ocrText, err := encryptText(record.OCRText); err == nil {
+			DB.Exec(`UPDATE clipboard_items SET ocr_text = ? WHERE id = ? AND COALESCE(ocr_text, '') = ''`, ocrText, id)
+		}
+	}
+	var ids []string
+	for _, name := range record.Tags {
+		if tagID := tagIDs[name]; tagID != "" {
+			ids = append(ids, tagID)
+		}
+	}
+	if err := AddTagsToItems([]string{id}, ids); err != nil {
+		log.Printf("⚠️ 导入项目标签失败: %v", err)
+	}
+}
+
+// importScripts 导入用户脚本
+func importScripts(files map[string]*zip.File, mode string, report *ImportReport) error {
+	if _, ok := files[archiveScriptsFile]; !ok {
+		return nil
+	}
+	var scripts []UserScript
+	if err := readArchiveJSON(files, archiveScriptsFile, &scripts); err != nil {
+		return err
+	}
+	for i := range scripts {
+		script := &scripts[i]
+		existing, err := GetUserScriptByID(script.ID)
+		if err == nil && existing != nil {
+			if mode != ImportModeOverwrite {
+				report.ScriptsSkipped++
+				continue
+			}
+			report.ScriptsReplaced++
+		} else {
+			report.ScriptsAdded++
+		}
+		if err := SaveUserScript(script); err != nil {
+			report.addError("脚本 %s 导入失败: %v", script.Name, err)
+		}
+	}
+	return nil
+}
+
+// importSettings 导入应用设置：合并模式只补充本地没有的设置项，覆盖模式以导入数据为准（密码除外）
+// 无效的设置项会被跳过，不影响其他设置项
+func importSettings(files map[string]*zip.File, mode string, report *ImportReport) error {
+	if _, ok := files[archiveSettingsFile]; !ok {
+		return nil
+	}
+	var imported map[string]json.RawMessage
+	if err := readArchiveJSON(files, archiveSettingsFile, &imported); err != nil {
+		return err
+	}
+	delete(imported, "password")
+	delete(imported, "version")
+
+	// 本地已保存的设置项（合并模式下不覆盖）
+	stored := make(map[string]json.RawMessage)
+	settingsJSON, err := GetSetting(settingsKey)
+	if err != nil {
+		return err
+	}
+	if settingsJSON != "" {
+		if err := json.Unmarshal([]byte(settingsJSON), &stored); err != nil {
+			return fmt.Errorf("解析设置失败: %v", err)
+		}
+	}
+
+	changed := false
+	_, err = UpdateSettings(func(s *Settings) error {
+		for key, value := range imported {
+			if _, exists := stored[key]; exists && mode != ImportModeOverwrite {
+				continue
+			}
+			candidate := s.Clone()
+			err := candidate.setField(key, value)
+			if err == nil {
+				err = candidate.Validate()
+			}
+			if err != nil {
+				log.Printf("⚠️ 跳过无效的导入设置 %s: %v", key, err)
+				continue
+			}
+			if len(changedSettingsKeys(s, candidate)) > 0 {
+				*s = *candidate
+				changed = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return nil
+	}
+	report.SettingsUpdated = true
+	return nil
+}
@@ -0,0 +1,221 @@
+package common
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestArchive 生成导入文件：lines 为 items.jsonl 的各行，blobs 为 文件名 -> 数据
+func writeTestArchive(t *testing.T, lines []string, blobs map[string][]byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "import.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	manifest, _ := json.Marshal(archiveManifest{Format: archiveFormat, Version: archiveVersion})
+	files := map[string][]byte{
+		archiveManifestFile: manifest,
+		archiveItemsFile:    []byte(strings.Join(lines, "\n") + "\n"),
+	}
+	for name, data := range blobs {
+		files[name] = data
+	}
+	for name, data := range files {
+		if err := writeArchiveFile(zw, name, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func archiveLine(t *testing.T, record archiveItem) string {
+	t.Helper()
+	if record.Timestamp.IsZero() {
+		record.Timestamp = time.Now()
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestImportHistoryReport(t *testing.T) {
+	openTestDB(t, 0)
+	for _, item := range []ClipboardItem{
+		{ID: "local-1", Content: "already here", ContentType: "Text"},
+		{ID: "taken", Content: "local content", ContentType: "Text"},
+	} {
+		item.ContentHash = calculateContentHash(&item)
+		item.Timestamp = time.Now()
+		if err := SaveClipboardItem(&item); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	goodImage := testPNG(t, color.White)
+	goodHash := sha256Hex(goodImage)
+	forged := []byte("forged image data")
+	victimHash := sha256Hex([]byte("some other image"))
+
+	lines := []string{
+		archiveLine(t, archiveItem{ID: "new", Content: "brand new", ContentType: "Text"}),
+		archiveLine(t, archiveItem{ID: "dup", Content: "already here", ContentType: "Text"}),
+		archiveLine(t, archiveItem{ID: "taken", Content: "imported content", ContentType: "Text"}),
+		"{not json",
+		"",
+		archiveLine(t, archiveItem{ID: "img", Content: "图片", ContentType: "Image", ContentHash: goodHash, Image: "blobs/" + goodHash}),
+		archiveLine(t, archiveItem{ID: "bad-img", Content: "图片", ContentType: "Image", ContentHash: victimHash, Image: "blobs/" + victimHash}),
+		archiveLine(t, archiveItem{ID: "no-img", Content: "图片", ContentType: "Image", ContentHash: goodHash}),
+	}
+	path := writeTestArchive(t, lines, map[string][]byte{
+		"blobs/" + goodHash:   goodImage,
+		"blobs/" + victimHash: forged,
+	})
+
+	report, err := ImportHistory(path, ImportModeMerge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Added != 2 || report.Skipped != 1 || report.Conflicted != 1 || report.Failed != 3 {
+		t.Fatalf("report = %+v，期望 Added 2, Skipped 1, Conflicted 1, Failed 3", report)
+	}
+	if total := report.Added + report.Skipped + report.Conflicted + report.Failed; total != 7 {
+		t.Fatalf("计数之和 %d 不等于非空行数 7", total)
+	}
+
+	if p, _ := blobPath(victimHash); fileExists(p) {
+		t.Fatal("哈希不一致的图片被写入了图片存储")
+	}
+	if p, _ := blobPath(goodHash); !fileExists(p) {
+		t.Fatal("正常的图片没有写入图片存储")
+	}
+
+	// 合并模式下冲突的项目使用新 ID 保存，本地项目不变
+	local, err := GetClipboardItemByID("taken")
+	if err != nil || local.Content != "local content" {
+		t.Fatalf("本地项目被修改: %+v, %v", local, err)
+	}
+	var count int
+	DB.QueryRow(`SELECT COUNT(*) FROM clipboard_items WHERE content = 'imported content'`).Scan(&count)
+	if count != 1 {
+		t.Fatalf("冲突的项目保存了 %d 条，期望 1", count)
+	}
+}
+
+func TestImportHistoryOverwriteConflict(t *testing.T) {
+	openTestDB(t, 0)
+	item := ClipboardItem{ID: "taken", Content: "local content", ContentType: "Text", Timestamp: time.Now()}
+	item.ContentHash = calculateContentHash(&item)
+	if err := SaveClipboardItem(&item); err != nil {
+		t.Fatal(err)
+	}
+
+	path := writeTestArchive(t, []string{
+		archiveLine(t, archiveItem{ID: "taken", Content: "imported content", ContentType: "Text"}),
+	}, nil)
+	report, err := ImportHistory(path, ImportModeOverwrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Conflicted != 1 || report.Added != 0 {
+		t.Fatalf("report = %+v，期望 Conflicted 1", report)
+	}
+	got, err := GetClipboardItemByID("taken")
+	if err != nil || got.Content != "imported content" {
+		t.Fatalf("覆盖模式应该替换本地项目: %+v, %v", got, err)
+	}
+}
+
+func TestExportHistorySkipsEphemeralItems(t *testing.T) {
+	openTestDB(t, 0)
+	later := time.Now().Add(time.Hour)
+	tests := []struct {
+		id         string
+		expiresAt  *time.Time
+		maxPastes  int
+		wantExport bool
+	}{
+		{id: "permanent", wantExport: true},
+		{id: "timed", expiresAt: &later},
+		{id: "paste-once", maxPastes: 1},
+		{id: "both", expiresAt: &later, maxPastes: 3},
+	}
+	for _, tt := range tests {
+		item := ClipboardItem{ID: tt.id, Content: "content " + tt.id, ContentType: "Text", Timestamp: time.Now(), ExpiresAt: tt.expiresAt}
+		item.ContentHash = calculateContentHash(&item)
+		if err := SaveClipboardItem(&item); err != nil {
+			t.Fatal(err)
+		}
+		if tt.maxPastes > 0 {
+			if _, err := DB.Exec(`UPDATE clipboard_items SET max_pastes = ? WHERE id = ?`, tt.maxPastes, tt.id); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "export.zip")
+	result, err := ExportHistory(ExportFilter{}, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	exported := make(map[string]bool)
+	for _, f := range zr.File {
+		if f.Name != archiveItemsFile {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			var record archiveItem
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatal(err)
+			}
+			exported[record.ID] = true
+		}
+	}
+
+	for _, tt := range tests {
+		if exported[tt.id] != tt.wantExport {
+			t.Errorf("%s 导出 = %v，期望 %v", tt.id, exported[tt.id], tt.wantExport)
+		}
+	}
+	if result.Items != len(exported) {
+		t.Errorf("ExportResult.Items = %d，实际导出 %d", result.Items, len(exported))
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,307 @@
+package common
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 图片数据不再写入数据库，而是按 content_hash（sha256，启用加密存储时为 HMAC）存放在 ~/.clipsave/blobs/<前两位>/<hash>
+// image_blobs 表记录每个文件被多少条记录引用，引用计数由触发器随 clipboard_items 的增删自动维护，
+// 计数归零的文件在删除记录后由 collectImageBlobs 清理
+
+// blobDir 图片文件目录（由 InitDB 设置）
+var blobDir string
+
+// blobMu 保证"写文件 + 插入记录"与垃圾回收互斥，避免刚复用的文件被当作无引用删除
+var blobMu sync.Mutex
+
+// orphanBlobGracePeriod 没有引用记录的文件至少存在这么久才会被清理（覆盖写文件和插入记录之间的窗口）
+const orphanBlobGracePeriod = 10 * time.Minute
+
+// imageBlobTriggerSQL 维护 image_blobs 引用计数的触发器
+const imageBlobTriggerSQL = `
+CREATE TRIGGER IF NOT EXISTS image_blobs_ai AFTER INSERT ON clipboard_items
+WHEN NEW.content_type = 'Image' AND COALESCE(NEW.content_hash, '') != '' BEGIN
+	INSERT INTO image_blobs (hash, ref_count) VALUES (NEW.content_hash, 1)
+	ON CONFLICT(hash) DO UPDATE SET ref_count = ref_count + 1;
+END;
+
+CREATE TRIGGER IF NOT EXISTS image_blobs_ad AFTER DELETE ON clipboard_items
+WHEN OLD.content_type = 'Image' AND COALESCE(OLD.content_hash, '') != '' BEGIN
+	UPDATE image_blobs SET ref_count = ref_count - 1 WHERE hash = OLD.content_hash;
+END;
+
+CREATE TRIGGER IF NOT EXISTS image_blobs_au AFTER UPDATE OF content_hash, content_type ON clipboard_items BEGIN
+	UPDATE image_blobs SET ref_count = ref_count - 1
+	WHERE OLD.content_type = 'Image' AND hash = COALESCE(OLD.content_hash, '');
+	INSERT INTO image_blobs (hash, ref_count)
+	SELECT NEW.content_hash, 1 WHERE NEW.content_type = 'Image' AND COALESCE(NEW.content_hash, '') != ''
+	ON CONFLICT(hash) DO UPDATE SET ref_count = ref_count + 1;
+END;
+`
+
+// initBlobStore 设置并创建图片文件目录
+func initBlobStore(appDir string) error {
+	dir := filepath.Join(appDir, "blobs")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("创建图片目录失败: %v", err)
+	}
+	blobDir = dir
+	return nil
+}
+
+// blobPath 返回 hash 对应的文件路径
+func blobPath(hash string) (string, error) {
+	if blobDir == "" {
+		return "", fmt.Errorf("图片存储未初始化")
+	}
+	if len(hash) != sha256.Size*2 {
+		return "", fmt.Errorf("无效的图片哈希: %q", hash)
+	}
+	if _, err := hex.DecodeString(hash); err != nil {
+		return "", fmt.Errorf("无效的图片哈希: %q", hash)
+	}
+	return filepath.Join(blobDir, hash[:2], hash), nil
+}
+
+// imageBlobHash 返回图片记录使用的文件地址（content_hash，缺失时按数据计算）
+func imageBlobHash(item *ClipboardItem) string {
+	if item.ContentHash != "" {
+		return item.ContentHash
+	}
+	return calculateContentHash(item)
+}
+
+// writeImageBlob 写入图片文件，文件已存在时直接复用
+func writeImageBlob(hash string, data []byte) error {
+	path, err := blobPath(hash)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建图片目录失败: %v", err)
+	}
+	// 启用加密存储时写入密文
+	data, err = encryptBytes(data)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, hash, data)
+}
+
+// writeFileAtomic 写入（或覆盖）图片文件
+func writeFileAtomic(path string, hash string, data []byte) error {
+	// 先写临时文件再重命名，避免崩溃时留下不完整的图片
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-"+hash[:8]+"-*")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	tmpName := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("写入图片文件失败: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("写入图片文件失败: %v", err)
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("保存图片文件失败: %v", err)
+	}
+	return nil
+}
+
+// readImageBlob 读取图片文件
+func readImageBlob(hash string) ([]byte, error) {
+	path, err := blobPath(hash)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取图片文件失败: %v", err)
+	}
+	return decryptBytes(data)
+}
+
+// fillImageData 为图片记录填充 ImageData（老数据仍在 image_data 字段中时直接使用）
+func fillImageData(item *ClipboardItem) {
+	if item.ContentType != "Image" || len(item.ImageData) > 0 || item.ContentHash == "" {
+		return
+	}
+	data, err := readImageBlob(item.ContentHash)
+	if err != nil {
+		log.Printf("⚠️ 加载图片数据失败: ID=%s, error=%v", item.ID, err)
+		return
+	}
+	item.ImageData = data
+}
+
+// collectImageBlobs 删除引用计数归零的图片文件，返回删除的文件数
+func collectImageBlobs() (int, error) {
+	if DB == nil {
+		return 0, fmt.Errorf("数据库未初始化")
+	}
+	blobMu.Lock()
+	defer blobMu.Unlock()
+
+	rows, err := DB.Query(`SELECT hash FROM image_blobs WHERE ref_count <= 0`)
+	if err != nil {
+		return 0, fmt.Errorf("查询无引用图片失败: %v", err)
+	}
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err == nil {
+			hashes = append(hashes, hash)
+		}
+	}
+	rows.Close()
+
+	removed := 0
+	for _, hash := range hashes {
+		path, err := blobPath(hash)
+		if err == nil {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				log.Printf("⚠️ 删除图片文件失败: %s, error=%v", hash, err)
+				continue
+			}
+			removed++
+		}
+		if _, err := DB.Exec(`DELETE FROM image_blobs WHERE hash = ? AND ref_count <= 0`, hash); err != nil {
+			log.Printf("⚠️ 删除图片引用记录失败: %s, error=%v", hash, err)
+		}
+	}
+	if removed > 0 {
+		log.Printf("🧹 已清理 %d 个无引用的图片文件", removed)
+	}
+	return removed, nil
+}
+
+// sweepOrphanBlobs 清理目录中没有引用记录的文件（例如写入文件后保存记录失败、应用在迁移中途退出）
+func sweepOrphanBlobs() (int, error) {
+	if DB == nil || blobDir == "" {
+		return 0, fmt.Errorf("图片存储未初始化")
+	}
+	blobMu.Lock()
+	defer blobMu.Unlock()
+
+	referenced := make(map[string]bool)
+	rows, err := DB.Query(`SELECT hash FROM image_blobs WHERE ref_count > 0`)
+	if err != nil {
+		return 0, fmt.Errorf("查询图片引用失败: %v", err)
+	}
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err == nil {
+			referenced[hash] = true
+		}
+	}
+	rows.Close()
+
+	cutoff := time.Now().Add(-orphanBlobGracePeriod)
+	removed := 0
+	err = filepath.WalkDir(blobDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if !strings.HasPrefix(name, ".tmp-") && referenced[name] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			return nil
+		}
+		if err := os.Remove(path); err == nil {
+			removed++
+		}
+		return nil
+	})
+	if removed > 0 {
+		log.Printf("🧹 已清理 %d 个孤立的图片文件", removed)
+	}
+	return removed, err
+}
+
+// migrateExtractImageBlobs v7: 把 image_data 中的图片移到文件存储
+// 试运行（migrationDryRun）时只在事务中修改数据库，不写图片文件，事务回滚后磁盘上不留下任何内容
+func migrateExtractImageBlobs(tx *sql.Tx) error {
+	if _, err := tx.Exec(`
+	CREATE TABLE IF NOT EXISTS image_blobs (
+		hash TEXT PRIMARY KEY,
+		ref_count INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		return err
+	}
+
+	// 先取 ID 再逐条读取，避免一次性把所有图片加载到内存
+	rows, err := tx.Query(`SELECT id FROM clipboard_items WHERE content_type = 'Image' AND image_data IS NOT NULL`)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		var item ClipboardItem
+		item.ID = id
+		if err := tx.QueryRow(`SELECT COALESCE(content_hash, ''), image_data FROM clipboard_items WHERE id = ?`, id).
+			Scan(&item.ContentHash, &item.ImageData); err != nil {
+			return err
+		}
+		if len(item.ImageData) == 0 {
+			continue
+		}
+		hash := imageBlobHash(&item)
+		// 试运行时只统计，不写文件（事务回滚后文件不会被引用）
+		if !migrationDryRun {
+			if err := writeImageBlob(hash, item.ImageData); err != nil {
+				return fmt.Errorf("导出图片 %s 失败: %v", id, err)
+			}
+		}
+		if _, err := tx.Exec(`UPDATE clipboard_items SET content_hash = ?, image_data = NULL WHERE id = ?`, hash, id); err != nil {
+			return err
+		}
+	}
+	if len(ids) > 0 && migrationDryRun {
+		log.Printf("🔍 [dry-run] 将把 %d 张图片移到文件存储", len(ids))
+	} else if len(ids) > 0 {
+		log.Printf("✅ 已将 %d 张图片移到文件存储", len(ids))
+	}
+
+	// 按现有记录初始化引用计数，然后由触发器接管
+	if _, err := tx.Exec(`
+	INSERT INTO image_blobs (hash, ref_count)
+	SELECT content_hash, COUNT(*) FROM clipboard_items
+	WHERE content_type = 'Image' AND COALESCE(content_hash, '') != ''
+	GROUP BY content_hash
+	ON CONFLICT(hash) DO UPDATE SET ref_count = excluded.ref_count`); err != nil {
+		return err
+	}
+	_, err = tx.Exec(imageBlobTriggerSQL)
+	return err
+}
@@ -0,0 +1,61 @@
+package common
+
+import (
+	"io/fs"
+	"path/filepath"
+	"testing"
+)
+
+// countBlobFiles 统计图片目录中的文件数
+func countBlobFiles(t *testing.T) int {
+	t.Helper()
+	count := 0
+	err := filepath.WalkDir(blobDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestMigrateExtractImageBlobsDryRun(t *testing.T) {
+	// v6 之前图片保存在 image_data 字段中
+	openTestDB(t, 6)
+	data := []byte("not really a png")
+	if _, err := DB.Exec(`INSERT INTO clipboard_items (id, content, content_type, image_data, timestamp)
+		VALUES ('img', '图片', 'Image', ?, CURRENT_TIMESTAMP)`, data); err != nil {
+		t.Fatal(err)
+	}
+
+	planned, err := MigrateDB(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(planned) == 0 {
+		t.Fatal("应该有待执行的迁移")
+	}
+	if n := countBlobFiles(t); n != 0 {
+		t.Fatalf("试运行写入了 %d 个图片文件", n)
+	}
+	var stored []byte
+	if err := DB.QueryRow(`SELECT image_data FROM clipboard_items WHERE id = 'img'`).Scan(&stored); err != nil {
+		t.Fatal(err)
+	}
+	if string(stored) != string(data) {
+		t.Fatal("试运行修改了数据库中的图片")
+	}
+
+	if _, err := MigrateDB(false); err != nil {
+		t.Fatal(err)
+	}
+	if n := countBlobFiles(t); n != 1 {
+		t.Fatalf("迁移后有 %d 个图片文件，期望 1", n)
+	}
+}
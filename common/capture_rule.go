@@ -0,0 +1,378 @@
+package common
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 捕获规则：按来源应用（glob 或正则）、内容类型和大小匹配，
+// 在捕获循环中读取到剪贴板内容后、解码图片之前执行，第一条匹配的规则生效
+
+// 来源应用的匹配方式
+const (
+	CaptureMatchGlob  = "glob"  // 通配符，如 "1Password*"（不区分大小写）
+	CaptureMatchRegex = "regex" // 正则表达式
+)
+
+// 捕获规则的动作
+const (
+	CaptureActionIgnore = "ignore" // 不记录
+	CaptureActionNoOCR  = "no_ocr" // 记录但不做 OCR 识别
+	CaptureActionExpire = "expire" // 记录并在到期后自动删除
+)
+
+// defaultCaptureExpireMinutes 过期动作未指定时间时的默认保留时间
+const defaultCaptureExpireMinutes = 10
+
+// CaptureRule 捕获规则
+type CaptureRule struct {
+	ID            string
+	Name          string
+	Enabled       bool
+	AppPattern    string   // 来源应用名称的匹配模式（为空表示所有应用）
+	MatchMode     string   // glob / regex
+	ContentTypes  []string // 内容类型（空数组表示所有类型）
+	MinSize       int64    // 内容大小下限（字节，0 表示不限制）
+	MaxSize       int64    // 内容大小上限（字节，0 表示不限制）
+	Action        string   // ignore / no_ocr / expire
+	ExpireMinutes int      // 过期动作的保留时间（分钟）
+	SortOrder     int      // 排序顺序（升序执行）
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+
+	appMatcher func(appName string) bool
+}
+
+// captureSubject 待匹配的剪贴板内容；大小按需计算（文件需要读取文件信息）
+type captureSubject struct {
+	appName     string
+	contentType string
+	size        func() int64
+}
+
+// captureOptions 规则对本次捕获的影响
+type captureOptions struct {
+	skipOCR   bool
+	expiresAt *time.Time
+}
+
+// captureOptionsFor 根据匹配的规则生成捕获选项（rule 为 nil 时返回默认选项）
+func captureOptionsFor(rule *CaptureRule) captureOptions {
+	var opts captureOptions
+	if rule == nil {
+		return opts
+	}
+	switch rule.Action {
+	case CaptureActionNoOCR:
+		opts.skipOCR = true
+	case CaptureActionExpire:
+		expiresAt := time.Now().Add(time.Duration(rule.ExpireMinutes) * time.Minute)
+		opts.expiresAt = &expiresAt
+	}
+	return opts
+}
+
+// filePathsTotalSize 计算文件剪贴板中所有文件的总大小（字节）
+func filePathsTotalSize(fileJSON string) int64 {
+	var filePaths []string
+	if err := json.Unmarshal([]byte(fileJSON), &filePaths); err != nil {
+		return 0
+	}
+	var total int64
+	for _, p := range filePaths {
+		total += getFileInfo(p).Size
+	}
+	return total
+}
+
+// captureRulesCache 已编译的启用规则，规则变化时清空
+var (
+	captureRulesMu    sync.Mutex
+	captureRulesCache []CaptureRule
+	captureRulesValid bool
+)
+
+// invalidateCaptureRules 规则变化后清空缓存
+func invalidateCaptureRules() {
+	captureRulesMu.Lock()
+	captureRulesValid = false
+	captureRulesCache = nil
+	captureRulesMu.Unlock()
+}
+
+// compileAppMatcher 编译来源应用匹配模式
+func compileAppMatcher(pattern string, mode string) (func(appName string) bool, error) {
+	if pattern == "" {
+		return func(string) bool { return true }, nil
+	}
+	switch mode {
+	case CaptureMatchRegex:
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("无效的正则表达式: %v", err)
+		}
+		return re.MatchString, nil
+	case CaptureMatchGlob, "":
+		pattern = strings.ToLower(pattern)
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("无效的通配符: %v", err)
+		}
+		return func(appName string) bool {
+			matched, _ := path.Match(pattern, strings.ToLower(appName))
+			return matched
+		}, nil
+	default:
+		return nil, fmt.Errorf("不支持的匹配方式: %s", mode)
+	}
+}
+
+// validateCaptureRule 校验并规范化规则
+func validateCaptureRule(rule *CaptureRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	rule.AppPattern = strings.TrimSpace(rule.AppPattern)
+	if rule.MatchMode == "" {
+		rule.MatchMode = CaptureMatchGlob
+	}
+	matcher, err := compileAppMatcher(rule.AppPattern, rule.MatchMode)
+	if err != nil {
+		return err
+	}
+	rule.appMatcher = matcher
+
+	switch rule.Action {
+	case CaptureActionIgnore, CaptureActionNoOCR:
+	case CaptureActionExpire:
+		if rule.ExpireMinutes <= 0 {
+			rule.ExpireMinutes = defaultCaptureExpireMinutes
+		}
+	default:
+		return fmt.Errorf("不支持的动作: %s", rule.Action)
+	}
+	if rule.MinSize < 0 || rule.MaxSize < 0 || (rule.MaxSize > 0 && rule.MinSize > rule.MaxSize) {
+		return fmt.Errorf("无效的大小范围: %d - %d", rule.MinSize, rule.MaxSize)
+	}
+	if rule.ContentTypes == nil {
+		rule.ContentTypes = []string{}
+	}
+	return nil
+}
+
+// matches 判断规则是否匹配剪贴板内容
+func (rule *CaptureRule) matches(subject captureSubject) bool {
+	if rule.appMatcher == nil || !rule.appMatcher(subject.appName) {
+		return false
+	}
+	if len(rule.ContentTypes) > 0 {
+		matched := false
+		for _, contentType := range rule.ContentTypes {
+			if strings.EqualFold(contentType, subject.contentType) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if rule.MinSize > 0 || rule.MaxSize > 0 {
+		size := subject.size()
+		if size < rule.MinSize || (rule.MaxSize > 0 && size > rule.MaxSize) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchCaptureRule 返回第一条匹配的规则，没有匹配时返回 nil
+func matchCaptureRule(rules []CaptureRule, subject captureSubject) *CaptureRule {
+	for i := range rules {
+		if rules[i].matches(subject) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// scanCaptureRules 读取规则行
+func scanCaptureRules(rows *sql.Rows) []CaptureRule {
+	rules := []CaptureRule{}
+	for rows.Next() {
+		var rule CaptureRule
+		var enabled int
+		var contentTypesJSON string
+		err := rows.Scan(&rule.ID, &rule.Name, &enabled, &rule.AppPattern, &rule.MatchMode, &contentTypesJSON,
+			&rule.MinSize, &rule.MaxSize, &rule.Action, &rule.ExpireMinutes, &rule.SortOrder, &rule.CreatedAt, &rule.UpdatedAt)
+		if err != nil {
+			log.Printf("扫描捕获规则失败: %v", err)
+			continue
+		}
+		rule.Enabled = enabled == 1
+		if err := json.Unmarshal([]byte(contentTypesJSON), &rule.ContentTypes); err != nil || rule.ContentTypes == nil {
+			rule.ContentTypes = []string{}
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+const captureRuleColumns = `id, name, enabled, app_pattern, match_mode, content_types, min_size, max_size, action, expire_minutes, sort_order, created_at, updated_at`
+
+// GetAllCaptureRules 获取所有捕获规则（按执行顺序）
+func GetAllCaptureRules() ([]CaptureRule, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	rows, err := DB.Query(`SELECT ` + captureRuleColumns + ` FROM capture_rules ORDER BY sort_order ASC, created_at ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("查询捕获规则失败: %v", err)
+	}
+	defer rows.Close()
+	return scanCaptureRules(rows), nil
+}
+
+// GetEnabledCaptureRules 获取启用的捕获规则（已编译，结果会缓存到规则变化为止）
+func GetEnabledCaptureRules() ([]CaptureRule, error) {
+	captureRulesMu.Lock()
+	defer captureRulesMu.Unlock()
+	if captureRulesValid {
+		return captureRulesCache, nil
+	}
+
+	all, err := GetAllCaptureRules()
+	if err != nil {
+		return nil, err
+	}
+	var rules []CaptureRule
+	for _, rule := range all {
+		if !rule.Enabled {
+			continue
+		}
+		if err := validateCaptureRule(&rule); err != nil {
+			log.Printf("⚠️ 跳过无效的捕获规则 %s: %v", rule.Name, err)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	captureRulesCache = rules
+	captureRulesValid = true
+	return rules, nil
+}
+
+// SaveCaptureRule 保存捕获规则（ID 为空时新建，新规则排在最后）
+func SaveCaptureRule(rule *CaptureRule) error {
+	if DB == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	if err := validateCaptureRule(rule); err != nil {
+		return err
+	}
+
+	if rule.ID == "" {
+		rule.ID = fmt.Sprintf("%d", time.Now().UnixNano())
+		var maxSortOrder sql.NullInt64
+		if err := DB.QueryRow(`SELECT MAX(sort_order) FROM capture_rules`).Scan(&maxSortOrder); err == nil && maxSortOrder.Valid {
+			rule.SortOrder = int(maxSortOrder.Int64) + 1
+		}
+	}
+
+	contentTypesJSON, _ := json.Marshal(rule.ContentTypes)
+	enabled := 0
+	if rule.Enabled {
+		enabled = 1
+	}
+
+	_, err := DB.Exec(`
+	INSERT INTO capture_rules
+	(id, name, enabled, app_pattern, match_mode, content_types, min_size, max_size, action, expire_minutes, sort_order, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'))
+	ON CONFLICT(id) DO UPDATE SET
+		name = excluded.name,
+		enabled = excluded.enabled,
+		app_pattern = excluded.app_pattern,
+		match_mode = excluded.match_mode,
+		content_types = excluded.content_types,
+		min_size = excluded.min_size,
+		max_size = excluded.max_size,
+		action = excluded.action,
+		expire_minutes = excluded.expire_minutes,
+		sort_order = excluded.sort_order,
+		updated_at = datetime('now')
+	`, rule.ID, rule.Name, enabled, rule.AppPattern, rule.MatchMode, string(contentTypesJSON),
+		rule.MinSize, rule.MaxSize, rule.Action, rule.ExpireMinutes, rule.SortOrder)
+	if err != nil {
+		return fmt.Errorf("保存捕获规则失败: %v", err)
+	}
+	invalidateCaptureRules()
+	log.Printf("✅ 已保存捕获规则: %s", rule.Name)
+	return nil
+}
+
+// DeleteCaptureRule 删除捕获规则
+func DeleteCaptureRule(id string) error {
+	if DB == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	result, err := DB.Exec(`DELETE FROM capture_rules WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("删除捕获规则失败: %v", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return fmt.Errorf("未找到捕获规则")
+	}
+	invalidateCaptureRules()
+	return nil
+}
+
+// ReorderCaptureRules 按给定的 ID 顺序重新排列规则
+func ReorderCaptureRules(ids []string) error {
+	if DB == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("开始事务失败: %v", err)
+	}
+	defer tx.Rollback()
+
+	for i, id := range ids {
+		if _, err := tx.Exec(`UPDATE capture_rules SET sort_order = ?, updated_at = datetime('now') WHERE id = ?`, i+1, id); err != nil {
+			return fmt.Errorf("更新捕获规则 %s 顺序失败: %v", id, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %v", err)
+	}
+	invalidateCaptureRules()
+	return nil
+}
+
+// migrateCreateCaptureRules v11: 按来源应用的捕获规则
+func migrateCreateCaptureRules(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+	CREATE TABLE IF NOT EXISTS capture_rules (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL DEFAULT '',
+		enabled INTEGER NOT NULL DEFAULT 1,
+		app_pattern TEXT NOT NULL DEFAULT '',
+		match_mode TEXT NOT NULL DEFAULT 'glob',
+		content_types TEXT NOT NULL DEFAULT '[]',
+		min_size INTEGER NOT NULL DEFAULT 0,
+		max_size INTEGER NOT NULL DEFAULT 0,
+		action TEXT NOT NULL,
+		expire_minutes INTEGER NOT NULL DEFAULT 0,
+		sort_order INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return fmt.Errorf("创建 capture_rules 表失败: %v", err)
+	}
+	return nil
+}
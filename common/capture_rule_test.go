@@ -0,0 +1,127 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+// compileTestRules 校验并编译规则（和从数据库读取时一样）
+func compileTestRules(t *testing.T, rules ...CaptureRule) []CaptureRule {
+	t.Helper()
+	for i := range rules {
+		if err := validateCaptureRule(&rules[i]); err != nil {
+			t.Fatalf("规则 %s: %v", rules[i].Name, err)
+		}
+	}
+	return rules
+}
+
+func TestMatchCaptureRule(t *testing.T) {
+	rules := compileTestRules(t,
+		CaptureRule{Name: "password-manager", AppPattern: "1password*", Action: CaptureActionIgnore},
+		CaptureRule{Name: "terminal-text", AppPattern: `^(Terminal|iTerm2)$`, MatchMode: CaptureMatchRegex,
+			ContentTypes: []string{"text"}, Action: CaptureActionExpire},
+		CaptureRule{Name: "large-image", ContentTypes: []string{"Image"}, MinSize: 1000, Action: CaptureActionNoOCR},
+		CaptureRule{Name: "small-file", ContentTypes: []string{"File"}, MaxSize: 100, Action: CaptureActionIgnore},
+		CaptureRule{Name: "catch-all", Action: CaptureActionNoOCR},
+	)
+
+	tests := []struct {
+		name        string
+		app         string
+		contentType string
+		size        int64
+		want        string // 期望匹配的规则名称
+	}{
+		{name: "通配符不区分大小写", app: "1Password 7", contentType: "Text", want: "password-manager"},
+		{name: "第一条匹配的规则生效", app: "1Password", contentType: "Image", size: 5000, want: "password-manager"},
+		{name: "正则匹配", app: "iTerm2", contentType: "Text", want: "terminal-text"},
+		{name: "正则区分大小写", app: "iterm2", contentType: "Text", want: "catch-all"},
+		{name: "锚定的正则不匹配更长的名称", app: "Terminal Pro", contentType: "Text", want: "catch-all"},
+		{name: "内容类型不匹配", app: "Terminal", contentType: "URL", want: "catch-all"},
+		{name: "达到大小下限", app: "Preview", contentType: "Image", size: 1000, want: "large-image"},
+		{name: "低于大小下限", app: "Preview", contentType: "Image", size: 999, want: "catch-all"},
+		{name: "不超过大小上限", app: "Finder", contentType: "File", size: 100, want: "small-file"},
+		{name: "超过大小上限", app: "Finder", contentType: "File", size: 101, want: "catch-all"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := matchCaptureRule(rules, captureSubject{appName: tt.app, contentType: tt.contentType,
+				size: func() int64 { return tt.size }})
+			got := ""
+			if rule != nil {
+				got = rule.Name
+			}
+			if got != tt.want {
+				t.Errorf("匹配规则 = %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+
+	// 没有大小条件的规则不计算大小（文件大小需要读取文件信息）
+	sizeCalled := false
+	matchCaptureRule(rules[:1], captureSubject{appName: "1Password", contentType: "File",
+		size: func() int64 { sizeCalled = true; return 0 }})
+	if sizeCalled {
+		t.Error("没有大小条件时不应该计算大小")
+	}
+	if rule := matchCaptureRule(rules[:2], captureSubject{appName: "Safari", contentType: "Text"}); rule != nil {
+		t.Errorf("没有匹配时返回了 %q", rule.Name)
+	}
+}
+
+func TestValidateCaptureRule(t *testing.T) {
+	tests := []struct {
+		name        string
+		rule        CaptureRule
+		wantErr     bool
+		wantMinutes int
+	}{
+		{name: "默认使用通配符", rule: CaptureRule{AppPattern: " Slack ", Action: CaptureActionIgnore}},
+		{name: "无效的正则", rule: CaptureRule{AppPattern: "(", MatchMode: CaptureMatchRegex, Action: CaptureActionIgnore}, wantErr: true},
+		{name: "无效的通配符", rule: CaptureRule{AppPattern: "[", Action: CaptureActionIgnore}, wantErr: true},
+		{name: "不支持的匹配方式", rule: CaptureRule{AppPattern: "x", MatchMode: "exact", Action: CaptureActionIgnore}, wantErr: true},
+		{name: "不支持的动作", rule: CaptureRule{Action: "delete"}, wantErr: true},
+		{name: "大小范围颠倒", rule: CaptureRule{MinSize: 10, MaxSize: 5, Action: CaptureActionIgnore}, wantErr: true},
+		{name: "过期时间默认值", rule: CaptureRule{Action: CaptureActionExpire}, wantMinutes: defaultCaptureExpireMinutes},
+		{name: "指定过期时间", rule: CaptureRule{Action: CaptureActionExpire, ExpireMinutes: 3}, wantMinutes: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := tt.rule
+			err := validateCaptureRule(&rule)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("错误 = %v，期望出错 %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if tt.rule.MatchMode == "" && rule.MatchMode != CaptureMatchGlob {
+				t.Errorf("MatchMode = %q，期望 %q", rule.MatchMode, CaptureMatchGlob)
+			}
+			if rule.ExpireMinutes != tt.wantMinutes {
+				t.Errorf("ExpireMinutes = %d，期望 %d", rule.ExpireMinutes, tt.wantMinutes)
+			}
+			if rule.ContentTypes == nil || rule.appMatcher == nil {
+				t.Error("校验后应该初始化 ContentTypes 和 appMatcher")
+			}
+		})
+	}
+}
+
+func TestCaptureOptionsFor(t *testing.T) {
+	if opts := captureOptionsFor(nil); opts.skipOCR || opts.expiresAt != nil {
+		t.Errorf("没有规则时选项 = %+v", opts)
+	}
+	if opts := captureOptionsFor(&CaptureRule{Action: CaptureActionNoOCR}); !opts.skipOCR || opts.expiresAt != nil {
+		t.Errorf("no_ocr 选项 = %+v", opts)
+	}
+	start := time.Now()
+	opts := captureOptionsFor(&CaptureRule{Action: CaptureActionExpire, ExpireMinutes: 15})
+	if opts.skipOCR || opts.expiresAt == nil {
+		t.Fatalf("expire 选项 = %+v", opts)
+	}
+	if d := opts.expiresAt.Sub(start); d < 15*time.Minute || d > 15*time.Minute+time.Second {
+		t.Errorf("到期时间在 %v 之后，期望 15 分钟", d)
+	}
+}
@@ -22,8 +22,6 @@ import (
 	_ "golang.org/x/image/bmp"  // BMP 格式支持
 	_ "golang.org/x/image/tiff" // TIFF 格式支持
 	_ "golang.org/x/image/webp" // WebP 格式支持
-
-	"golang.design/x/clipboard"
 )
 
 // ClipboardItem 剪贴板项目结构
@@ -33,6 +31,7 @@ type ClipboardItem struct {
 	ContentType string
 	ContentHash string // 内容哈希值，用于去重
 	ImageData   []byte // 图片数据（PNG格式）
+	Thumbnail   []byte // 图片缩略图（PNG格式，列表渲染使用）
 	FilePaths   string // 文件路径（JSON 数组格式）
 	FileInfo    string // 文件信息（JSON 格式）
 	Timestamp   time.Time
@@ -41,25 +40,17 @@ type ClipboardItem struct {
 	WordCount   int
 	IsFavorite  int    // 0/1
 	OCRText     string // OCR识别的文字内容
+
+	SensitiveCategory string     // 命中的敏感内容类别（逗号分隔），为空表示未命中
+	SensitiveAction   string     // 对敏感内容执行的动作：allow / expire / redact
+	ExpiresAt         *time.Time // 到期时间，到期后自动删除；nil 表示不过期
+	MaxPastes         int        // 粘贴多少次后删除，0 表示不限
+	PasteCount        int        // 已复制/粘贴的次数
 }
 
 // 剪贴板更新通知监听器（只发送信号，不传递数据）
 var clipboardListener chan struct{}
 
-func init() {
-	// 初始化剪贴板
-	err := clipboard.Init()
-	if err != nil {
-		log.Printf("初始化剪贴板失败: %v", err)
-		return
-	}
-
-	// 数据库已在应用启动时初始化，这里不再重复初始化
-
-	// 启动剪贴板
-	go run()
-}
-
 // RegisterClipboardListener 注册剪贴板更新监听器
 func RegisterClipboardListener() chan struct{} {
 	clipboardListener = make(chan struct{}, 10)
@@ -78,85 +69,6 @@ func notifyListeners() {
 	}
 }
 
-func run() {
-	var lastTextContent string
-	var lastImageHash string
-	var lastFileHash string
-	var lastPasteboardChangeCount int
-
-	// 用于追踪应用切换历史
-	// var currentAppName string
-	// var previousAppName string
-	// var appSwitchTime time.Time
-
-	// 缩短轮询间隔到 50ms，以便更及时地捕获剪贴板变化
-	ticker := time.NewTicker(50 * time.Millisecond)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		// 使用 changeCount 精确检测剪贴板是否变化
-		currentChangeCount := GetPasteboardChangeCount()
-		// log.Printf("🔄 剪贴板变化计数: %d", currentChangeCount)
-		if currentChangeCount == lastPasteboardChangeCount {
-			// 剪贴板没有变化，继续下一次循环
-			continue
-		}
-		// 获取当前活动应用
-		lastPasteboardChangeCount = currentChangeCount
-
-		// 获取当前活动应用
-		sourceAppName := GetFrontmostAppName()
-
-		// 优先级1: 先检测图片（截图场景最常见）
-		imgData := tryReadImage()
-		if len(imgData) > 0 {
-			// 统一转换为PNG格式后计算哈希，确保相同图片内容产生相同哈希值
-			pngData, err := convertToPNG(imgData)
-			if err != nil {
-				log.Printf("❌ 转换图片为PNG失败: %v", err)
-				continue
-			}
-			// 对PNG数据计算哈希值来判断是否是新图片
-			h := sha256.Sum256(pngData)
-			imageHash := hex.EncodeToString(h[:])
-			if imageHash != lastImageHash {
-				lastImageHash = imageHash
-				lastTextContent = ""
-				lastFileHash = ""
-
-				handleImageClipboard(imgData, sourceAppName, imageHash)
-			}
-			continue
-		}
-
-		// 优先级2: 不是图片，再检测文件
-		fileJSON, fileCount := ReadFileURLs()
-		if fileCount > 0 && fileJSON != "" {
-			// 使用完整路径集合的稳定哈希，避免前缀相同导致的误判
-			fileHash := calculateFilePathsHash(fileJSON)
-			if fileHash != lastFileHash {
-				lastFileHash = fileHash
-				lastTextContent = ""
-				lastImageHash = ""
-				handleFileClipboard(fileJSON, fileCount, sourceAppName, fileHash)
-			}
-			continue
-		}
-
-		// 优先级3: 没有图片和文件，检查文本
-		textData := clipboard.Read(clipboard.FmtText)
-		if len(textData) > 0 {
-			content := string(textData)
-			if content != lastTextContent && content != "" {
-				lastTextContent = content
-				lastImageHash = ""
-				lastFileHash = ""
-				handleTextClipboard(content, sourceAppName)
-			}
-		}
-	}
-}
-
 // tryReadImage 尝试从剪贴板读取图片，支持多种格式
 func tryReadImage() []byte {
 	// 常见的图片 UTI 类型
@@ -178,10 +90,10 @@ func tryReadImage() []byte {
 		}
 	}
 
-	// 如果没有找到图片，尝试标准的 clipboard.FmtImage
-	imgData := clipboard.Read(clipboard.FmtImage)
+	// 如果没有找到图片，尝试标准的 PNG 图片格式
+	imgData := readClipboardImage()
 	if len(imgData) > 0 {
-		log.Printf("🎨 从 clipboard.FmtImage 读取到图片，大小: %d bytes", len(imgData))
+		log.Printf("🎨 从 PNG 图片格式读取到图片，大小: %d bytes", len(imgData))
 		return imgData
 	}
 
@@ -207,7 +119,7 @@ func convertToPNG(imgData []byte) ([]byte, error) {
 }
 
 // handleTextClipboard 处理文本剪贴板
-func handleTextClipboard(content string, appName string) {
+func (m *Monitor) handleTextClipboard(content string, appName string, opts captureOptions) {
 	timestamp := time.Now()
 	item := ClipboardItem{
 		ID:          fmt.Sprintf("%d", timestamp.UnixNano()),
@@ -217,6 +129,26 @@ func handleTextClipboard(content string, appName string) {
 		Source:      appName,
 		CharCount:   len([]rune(content)),
 		WordCount:   countWords(content),
+		ExpiresAt:   opts.expiresAt,
+	}
+
+	// 敏感内容检测（在计算哈希和保存之前，丢弃或打码的原文不会写入数据库）
+	policy, err := m.store.LoadSensitivePolicy()
+	if err != nil {
+		log.Printf("⚠️ 读取敏感内容设置失败，使用默认设置: %v", err)
+	}
+	if !applySensitivePolicy(&item, policy) {
+		return
+	}
+	// 捕获规则和敏感内容都设置了过期时间时取较早的
+	if opts.expiresAt != nil && (item.ExpiresAt == nil || opts.expiresAt.Before(*item.ExpiresAt)) {
+		item.ExpiresAt = opts.expiresAt
+	}
+
+	// 执行 before_save 脚本（可能改写内容，需要在计算哈希之前）
+	tags, ok := m.runBeforeSaveScripts(&item)
+	if !ok {
+		return
 	}
 
 	// 计算内容哈希
@@ -225,11 +157,13 @@ func handleTextClipboard(content string, appName string) {
 	// log.Printf("📝 新文本剪贴板: %s, 类型: %s", truncateString(item.Content, 50), item.ContentType)
 
 	// 保存到数据库
-	if err := SaveClipboardItem(&item); err != nil {
+	if err := m.store.SaveClipboardItem(&item); err != nil {
 		log.Printf("保存剪贴板内容失败: %v", err)
 	} else {
+		m.addBeforeSaveTags(&item, tags)
+
 		// 执行 after_save 脚本
-		executeAfterSaveScripts(&item)
+		m.executeAfterSaveScripts(&item)
 
 		// 通知监听器
 		notifyListeners()
@@ -237,7 +171,7 @@ func handleTextClipboard(content string, appName string) {
 }
 
 // handleImageClipboard 处理图片剪贴板
-func handleImageClipboard(imgData []byte, appName string, precomputedHash string) {
+func (m *Monitor) handleImageClipboard(imgData []byte, appName string, precomputedHash string, opts captureOptions) {
 	// 解码图片
 	img, format, err := image.Decode(bytes.NewReader(imgData))
 	if err != nil {
@@ -262,6 +196,13 @@ func handleImageClipboard(imgData []byte, appName string, precomputedHash string
 	// 清空 buf，帮助 GC（虽然已经复制了，但显式清空更明确）
 	buf.Reset()
 
+	// 生成列表使用的缩略图（失败时由后台回填重试）
+	thumbnail, err := makeThumbnail(img)
+	if err != nil {
+		log.Printf("⚠️ 生成缩略图失败: %v", err)
+		thumbnail = nil
+	}
+
 	// 生成缩略图描述
 	bounds := img.Bounds()
 	imageDesc := fmt.Sprintf("图片 %dx%d (%s)", bounds.Dx(), bounds.Dy(), format)
@@ -271,48 +212,57 @@ func handleImageClipboard(imgData []byte, appName string, precomputedHash string
 		Content:     imageDesc,
 		ContentType: "Image",
 		ImageData:   imageDataCopy, // 使用复制的数据，不持有 buf 的引用
+		Thumbnail:   thumbnail,
 		Timestamp:   timestamp,
 		Source:      appName,
 		CharCount:   len(imageDataCopy),
 		WordCount:   0,
 		OCRText:     "", // 初始为空，异步填充
+		ExpiresAt:   opts.expiresAt,
 	}
 
-	// 计算内容哈希（优先使用外部预计算避免重复开销）
+	// 执行 before_save 脚本（图片只能添加标签或拒绝保存）
+	tags, ok := m.runBeforeSaveScripts(&item)
+	if !ok {
+		return
+	}
+
+	// 计算内容哈希（优先使用外部预计算的明文哈希避免重复开销）
 	if precomputedHash != "" {
-		item.ContentHash = precomputedHash
+		item.ContentHash = keyContentHash(precomputedHash)
 	} else {
 		item.ContentHash = calculateContentHash(&item)
 	}
 
 	// 保存到数据库（先保存，OCR异步进行）
-	if err := SaveClipboardItem(&item); err != nil {
+	if err := m.store.SaveClipboardItem(&item); err != nil {
 		log.Printf("❌ 保存图片剪贴板失败: %v", err)
 		return
 	}
+	m.addBeforeSaveTags(&item, tags)
+
+	// 捕获规则要求不做 OCR
+	if opts.skipOCR {
+		m.executeAfterSaveScripts(&item)
+		notifyListeners()
+		return
+	}
 
 	// 检查是否已有 OCR 结果（避免重复识别）
 	// 如果 content_hash 不为空，检查是否有相同哈希的记录已有 OCR 结果
-	if item.ContentHash != "" && DB != nil {
-		var existingOCRText string
-		checkOCRSQL := `SELECT ocr_text FROM clipboard_items WHERE content_hash = ? AND content_type = 'Image' AND (ocr_text IS NOT NULL AND ocr_text != '') LIMIT 1`
-		err := DB.QueryRow(checkOCRSQL, item.ContentHash).Scan(&existingOCRText)
+	if item.ContentHash != "" {
+		existingOCRText, ownerID, err := m.store.FindOCRTextByHash(item.ContentHash)
 		if err == nil && existingOCRText != "" {
-			// 已有 OCR 结果，检查当前记录是否需要更新 OCR
-			var currentOCRText string
-			checkCurrentSQL := `SELECT ocr_text FROM clipboard_items WHERE id = ?`
-			if err := DB.QueryRow(checkCurrentSQL, item.ID).Scan(&currentOCRText); err == nil {
-				if currentOCRText == "" {
-					// 当前记录没有 OCR，复用已有的 OCR 结果
-					if err := UpdateOCRText(item.ID, existingOCRText); err != nil {
-						log.Printf("⚠️ 复制OCR文字失败: ID=%s, error=%v", item.ID, err)
-					} else {
-						log.Printf("⏭️ 复用已有OCR结果: ID=%s, 文字长度=%d", item.ID, len(existingOCRText))
-					}
+			// 已有 OCR 结果，当前记录不是该结果的所属记录时复用
+			if ownerID != item.ID {
+				if err := m.store.UpdateOCRText(item.ID, existingOCRText); err != nil {
+					log.Printf("⚠️ 复制OCR文字失败: ID=%s, error=%v", item.ID, err)
+				} else {
+					log.Printf("⏭️ 复用已有OCR结果: ID=%s, 文字长度=%d", item.ID, len(existingOCRText))
 				}
 			}
 			// 跳过 OCR 识别，直接执行后续流程
-			executeAfterSaveScripts(&item)
+			m.executeAfterSaveScripts(&item)
 			notifyListeners()
 			return
 		}
@@ -333,7 +283,7 @@ func handleImageClipboard(imgData []byte, appName string, precomputedHash string
 
 		// 更新数据库
 		if ocrText != "" {
-			if err := UpdateOCRText(itemID, ocrText); err != nil {
+			if err := m.store.UpdateOCRText(itemID, ocrText); err != nil {
 				log.Printf("⚠️ 更新OCR文字失败: ID=%s, error=%v", itemID, err)
 			} else {
 				log.Printf("✅ OCR识别完成: ID=%s, 文字长度=%d", itemID, len(ocrText))
@@ -342,7 +292,7 @@ func handleImageClipboard(imgData []byte, appName string, precomputedHash string
 	}(imageDataCopy, item.ID)
 
 	// 执行 after_save 脚本
-	executeAfterSaveScripts(&item)
+	m.executeAfterSaveScripts(&item)
 
 	// 通知监听器
 	notifyListeners()
@@ -501,7 +451,7 @@ type FileInfo struct {
 }
 
 // handleFileClipboard 处理文件剪贴板
-func handleFileClipboard(fileJSON string, fileCount int, appName string, precomputedHash string) {
+func (m *Monitor) handleFileClipboard(fileJSON string, fileCount int, appName string, precomputedHash string, opts captureOptions) {
 	// 解析文件路径列表
 	var filePaths []string
 	if err := json.Unmarshal([]byte(fileJSON), &filePaths); err != nil {
@@ -554,11 +504,18 @@ func handleFileClipboard(fileJSON string, fileCount int, appName string, precomp
 		Source:      appName,
 		CharCount:   len(content),
 		WordCount:   len(filePaths),
+		ExpiresAt:   opts.expiresAt,
+	}
+
+	// 执行 before_save 脚本（文件只能添加标签或拒绝保存）
+	tags, ok := m.runBeforeSaveScripts(&item)
+	if !ok {
+		return
 	}
 
-	// 计算内容哈希（优先使用外部预计算避免重复开销）
+	// 计算内容哈希（优先使用外部预计算的明文哈希避免重复开销）
 	if precomputedHash != "" {
-		item.ContentHash = precomputedHash
+		item.ContentHash = keyContentHash(precomputedHash)
 	} else {
 		item.ContentHash = calculateContentHash(&item)
 	}
@@ -566,11 +523,13 @@ func handleFileClipboard(fileJSON string, fileCount int, appName string, precomp
 	log.Printf("📁 新文件剪贴板: %s", content)
 
 	// 保存到数据库
-	if err := SaveClipboardItem(&item); err != nil {
+	if err := m.store.SaveClipboardItem(&item); err != nil {
 		log.Printf("❌ 保存文件剪贴板失败: %v", err)
 	} else {
+		m.addBeforeSaveTags(&item, tags)
+
 		// 执行 after_save 脚本
-		executeAfterSaveScripts(&item)
+		m.executeAfterSaveScripts(&item)
 
 		// 通知监听器
 		notifyListeners()
@@ -617,8 +576,13 @@ func formatFileSize(size int64) string {
 	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
 }
 
-// calculateContentHash 计算剪贴板项目的内容哈希值
+// calculateContentHash 计算写入 content_hash 的内容哈希值（启用加密存储时为 HMAC，见 keyContentHash）
 func calculateContentHash(item *ClipboardItem) string {
+	return keyContentHash(plainContentHash(item))
+}
+
+// plainContentHash 计算剪贴板项目明文内容的 SHA-256
+func plainContentHash(item *ClipboardItem) string {
 	switch item.ContentType {
 	case "Text", "URL", "Color":
 		// 文本类型直接对内容计算哈希
@@ -780,9 +744,9 @@ func shouldTriggerScript(script *UserScript, item *ClipboardItem) bool {
 	return true
 }
 
-// executeAfterSaveScripts 执行保存后的脚本（发送事件到前端）
-func executeAfterSaveScripts(item *ClipboardItem) {
-	scripts, err := GetEnabledUserScripts("after_save")
+// executeAfterSaveScripts 在后台执行保存后的脚本
+func (m *Monitor) executeAfterSaveScripts(item *ClipboardItem) {
+	scripts, err := m.store.GetEnabledUserScripts("after_save")
 	if err != nil {
 		log.Printf("❌ 获取 after_save 脚本失败: %v", err)
 		return
@@ -793,24 +757,27 @@ func executeAfterSaveScripts(item *ClipboardItem) {
 		return
 	}
 
-	// 过滤匹配的脚本，只收集ID
-	var matchedScriptIDs []string
+	// 过滤匹配的脚本
+	var matched []UserScript
 	for i := range scripts {
 		if shouldTriggerScript(&scripts[i], item) {
-			matchedScriptIDs = append(matchedScriptIDs, scripts[i].ID)
+			matched = append(matched, scripts[i])
 		}
 	}
 
-	if len(matchedScriptIDs) == 0 {
+	if len(matched) == 0 {
 		log.Printf("ℹ️ 没有匹配的 after_save 脚本")
 		return
 	}
 
-	log.Printf("🔧 找到 %d 个匹配的 after_save 脚本，发送事件到前端执行...", len(matchedScriptIDs))
+	log.Printf("🔧 找到 %d 个匹配的 after_save 脚本，开始执行...", len(matched))
 
-	// 准备 item 数据（不包含 ImageData，避免事件数据过大）
-	// ImageData 如果脚本需要，前端可以延迟加载
-	itemData := map[string]interface{}{
+	runScriptsInBackground(item, "after_save", matched)
+}
+
+// scriptItemData 传给脚本的 item 数据（不包含 ImageData，脚本用到时才读取）
+func scriptItemData(item *ClipboardItem) map[string]interface{} {
+	return map[string]interface{}{
 		"ID":          item.ID,
 		"Content":     item.Content,
 		"ContentType": item.ContentType,
@@ -822,18 +789,18 @@ func executeAfterSaveScripts(item *ClipboardItem) {
 		"CharCount":   item.CharCount,
 		"WordCount":   item.WordCount,
 		"IsFavorite":  item.IsFavorite,
-		// 注意：不传递 ImageData，如果脚本需要，前端会延迟加载
 	}
+}
 
-	// 发送事件到前端，包含匹配的脚本ID列表和 item 数据
-	if globalScriptEventCallback != nil {
-		globalScriptEventCallback("clipboard.script.execute", map[string]interface{}{
-			"itemId":    item.ID,
-			"trigger":   "after_save",
-			"scriptIds": matchedScriptIDs, // 直接传递匹配的脚本ID列表
-			"item":      itemData,         // 传递 item 数据，避免前端再次查询
-		})
-	} else {
-		log.Printf("⚠️ 脚本事件回调未设置，无法执行脚本")
+// RunScriptOnItem 在后台对项目运行指定脚本（不检查触发条件）
+func RunScriptOnItem(scriptID string, item *ClipboardItem) error {
+	script, err := GetUserScriptByID(scriptID)
+	if err != nil {
+		return fmt.Errorf("获取脚本失败: %v", err)
+	}
+	if !script.Enabled {
+		return fmt.Errorf("脚本 %s 未启用", script.Name)
 	}
+	runScriptsInBackground(item, "hotkey", []UserScript{*script})
+	return nil
 }
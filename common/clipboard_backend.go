@@ -0,0 +1,105 @@
+package common
+
+// ClipboardBackend 剪贴板后端接口，抽象捕获流程依赖的系统剪贴板读取操作
+// 系统实现见 NewSystemClipboardBackend，内存实现见 FakeClipboardBackend
+type ClipboardBackend interface {
+	// ChangeCount 返回剪贴板变化计数，计数变化表示剪贴板内容已更新
+	ChangeCount() int
+	// FrontmostAppName 返回当前前台应用的名称，用作剪贴板来源
+	FrontmostAppName() string
+	// ReadImage 读取剪贴板中的图片原始数据，没有图片时返回 nil
+	ReadImage() []byte
+	// ReadFileURLs 读取剪贴板中的文件路径（JSON 数组）和文件数量
+	ReadFileURLs() (string, int)
+	// ReadText 读取剪贴板中的文本，没有文本时返回空字符串
+	ReadText() string
+	// Clear 清空剪贴板（临时项目过期时使用）
+	Clear()
+}
+
+// ClipboardStore 捕获流程依赖的存储接口
+type ClipboardStore interface {
+	// SaveClipboardItem 保存剪贴板项目（按 content_hash 去重，重复时把 item.ID 对齐为已有记录）
+	SaveClipboardItem(item *ClipboardItem) error
+	// UpdateOCRText 更新图片的 OCR 文字
+	UpdateOCRText(id string, ocrText string) error
+	// FindOCRTextByHash 查找相同哈希的图片已有的 OCR 结果，返回文字和所属记录 ID
+	FindOCRTextByHash(contentHash string) (string, string, error)
+	// GetEnabledUserScripts 获取指定触发时机下启用的脚本
+	GetEnabledUserScripts(trigger string) ([]UserScript, error)
+	// LoadSensitivePolicy 获取敏感内容处理设置
+	LoadSensitivePolicy() (SensitivePolicy, error)
+	// GetEnabledCaptureRules 获取启用的捕获规则（按执行顺序）
+	GetEnabledCaptureRules() ([]CaptureRule, error)
+	// AddTagNamesToItem 按名称为项目添加标签（before_save 脚本添加的标签）
+	AddTagNamesToItem(itemID string, names []string) error
+}
+
+// systemClipboardBackend 基于系统剪贴板的后端（各平台实现见 clipboard_<os>.go）
+type systemClipboardBackend struct{}
+
+// NewSystemClipboardBackend 初始化系统剪贴板并返回对应的后端
+func NewSystemClipboardBackend() (ClipboardBackend, error) {
+	if err := initSystemClipboard(); err != nil {
+		return nil, err
+	}
+	return systemClipboardBackend{}, nil
+}
+
+func (systemClipboardBackend) ChangeCount() int {
+	return GetPasteboardChangeCount()
+}
+
+func (systemClipboardBackend) FrontmostAppName() string {
+	return GetFrontmostAppName()
+}
+
+func (systemClipboardBackend) ReadImage() []byte {
+	return tryReadImage()
+}
+
+func (systemClipboardBackend) ReadFileURLs() (string, int) {
+	return ReadFileURLs()
+}
+
+func (systemClipboardBackend) ReadText() string {
+	return readClipboardText()
+}
+
+func (systemClipboardBackend) Clear() {
+	clearClipboard()
+}
+
+// dbClipboardStore 基于 SQLite（全局 DB）的存储实现
+type dbClipboardStore struct{}
+
+// DefaultClipboardStore 默认存储，直接读写应用数据库
+var DefaultClipboardStore ClipboardStore = dbClipboardStore{}
+
+func (dbClipboardStore) SaveClipboardItem(item *ClipboardItem) error {
+	return SaveClipboardItem(item)
+}
+
+func (dbClipboardStore) UpdateOCRText(id string, ocrText string) error {
+	return UpdateOCRText(id, ocrText)
+}
+
+func (dbClipboardStore) FindOCRTextByHash(contentHash string) (string, string, error) {
+	return FindOCRTextByHash(contentHash)
+}
+
+func (dbClipboardStore) GetEnabledUserScripts(trigger string) ([]UserScript, error) {
+	return GetEnabledUserScripts(trigger)
+}
+
+func (dbClipboardStore) LoadSensitivePolicy() (SensitivePolicy, error) {
+	return LoadSensitivePolicy()
+}
+
+func (dbClipboardStore) GetEnabledCaptureRules() ([]CaptureRule, error) {
+	return GetEnabledCaptureRules()
+}
+
+func (dbClipboardStore) AddTagNamesToItem(itemID string, names []string) error {
+	return AddTagNamesToItem(itemID, names)
+}
@@ -0,0 +1,115 @@
+package common
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// FakeClipboardBackend 内存中的剪贴板后端
+// 每次写入都会让变化计数加一，用于在没有桌面会话时驱动 Monitor 的完整捕获流程
+type FakeClipboardBackend struct {
+	mu          sync.Mutex
+	changeCount int
+	appName     string
+	text        string
+	image       []byte
+	fileJSON    string
+	fileCount   int
+}
+
+// NewFakeClipboardBackend 创建一个空的内存剪贴板
+func NewFakeClipboardBackend() *FakeClipboardBackend {
+	return &FakeClipboardBackend{appName: "Fake"}
+}
+
+// SetText 模拟复制文本
+func (f *FakeClipboardBackend) SetText(text string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reset()
+	f.text = text
+}
+
+// SetImage 模拟复制图片（任意 image.Decode 支持的格式）
+func (f *FakeClipboardBackend) SetImage(data []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reset()
+	f.image = append([]byte(nil), data...)
+}
+
+// SetFiles 模拟复制文件
+func (f *FakeClipboardBackend) SetFiles(paths []string) error {
+	fileJSON, err := json.Marshal(paths)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reset()
+	f.fileJSON = string(fileJSON)
+	f.fileCount = len(paths)
+	return nil
+}
+
+// SetFrontmostApp 设置之后捕获时报告的来源应用
+func (f *FakeClipboardBackend) SetFrontmostApp(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.appName = name
+}
+
+// Touch 只增加变化计数而不修改内容（模拟其他应用重新写入相同内容）
+func (f *FakeClipboardBackend) Touch() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.changeCount++
+}
+
+// reset 清空剪贴板内容并增加变化计数（调用方需持有锁）
+func (f *FakeClipboardBackend) reset() {
+	f.changeCount++
+	f.text = ""
+	f.image = nil
+	f.fileJSON = ""
+	f.fileCount = 0
+}
+
+func (f *FakeClipboardBackend) ChangeCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.changeCount
+}
+
+func (f *FakeClipboardBackend) FrontmostAppName() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.appName
+}
+
+func (f *FakeClipboardBackend) ReadImage() []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.image) == 0 {
+		return nil
+	}
+	return append([]byte(nil), f.image...)
+}
+
+func (f *FakeClipboardBackend) ReadFileURLs() (string, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.fileJSON, f.fileCount
+}
+
+func (f *FakeClipboardBackend) ReadText() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.text
+}
+
+func (f *FakeClipboardBackend) Clear() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reset()
+}
@@ -0,0 +1,597 @@
+//go:build linux
+// +build linux
+
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.design/x/clipboard"
+)
+
+// Linux 下没有统一的剪贴板原生 API，这里通过桌面环境常用的命令行工具实现：
+//   - Wayland: wl-clipboard（wl-paste / wl-copy）
+//   - X11:     xclip + xprop
+// 前台应用在 Wayland 下只支持 sway / Hyprland，其余合成器回退到 XWayland 的 xprop。
+// 文本和图片的读写也走这些工具；golang.design/x/clipboard 依赖 X11，只在两种工具都没有时兜底，
+// 纯 Wayland 会话中它初始化失败不影响剪贴板监听。
+
+const (
+	// linuxToolTimeout 单次调用外部工具的超时时间
+	linuxToolTimeout = time.Second
+	// linuxProbeInterval 轮询模式下两次探测剪贴板的最小间隔（避免 50ms 一次地启动子进程）
+	linuxProbeInterval = 250 * time.Millisecond
+	// linuxTargetsTTL 剪贴板类型列表缓存时间（一次变化内 tryReadImage 会连续查询多种类型）
+	linuxTargetsTTL = 200 * time.Millisecond
+)
+
+// 会话类型
+const (
+	linuxSessionNone = iota
+	linuxSessionWayland
+	linuxSessionX11
+)
+
+var (
+	linuxSessionOnce sync.Once
+	linuxSession     int
+
+	// x11ClipboardReady golang.design/x/clipboard 是否初始化成功（失败后调用它会崩溃）
+	x11ClipboardReady atomic.Bool
+
+	// wl-paste --watch 推送的变化计数
+	waylandWatchOnce  sync.Once
+	waylandWatchAlive atomic.Bool
+	waylandWatchCount atomic.Int64
+
+	// 轮询模式下的变化计数
+	linuxChangeMutex     sync.Mutex
+	linuxChangeCount     int
+	linuxLastProbe       time.Time
+	linuxLastProbeResult string
+
+	// 剪贴板类型列表缓存
+	linuxTargetsMutex sync.Mutex
+	linuxTargetsAt    time.Time
+	linuxTargets      []string
+)
+
+// UTI（tryReadImage 使用的 macOS 类型名）到 MIME 类型的映射
+var utiToMIME = map[string]string{
+	"public.tiff":        "image/tiff",
+	"public.png":         "image/png",
+	"public.jpeg":        "image/jpeg",
+	"com.compuserve.gif": "image/gif",
+	"com.microsoft.bmp":  "image/bmp",
+}
+
+// detectLinuxSession 检测当前图形会话类型（优先 Wayland）
+func detectLinuxSession() int {
+	linuxSessionOnce.Do(func() {
+		switch {
+		case os.Getenv("WAYLAND_DISPLAY") != "" && hasCommand("wl-paste"):
+			linuxSession = linuxSessionWayland
+		case os.Getenv("DISPLAY") != "" && hasCommand("xclip"):
+			linuxSession = linuxSessionX11
+		default:
+			linuxSession = linuxSessionNone
+			log.Printf("⚠️ 未检测到可用的剪贴板工具（需要 wl-clipboard 或 xclip），剪贴板监听不可用")
+		}
+	})
+	return linuxSession
+}
+
+// initSystemClipboard 初始化系统剪贴板：有 wl-clipboard 或 xclip 时即可使用，
+// X11 剪贴板库初始化失败（例如纯 Wayland 会话）只记录日志
+func initSystemClipboard() error {
+	err := clipboard.Init()
+	if err == nil {
+		x11ClipboardReady.Store(true)
+		return nil
+	}
+	if detectLinuxSession() == linuxSessionNone {
+		return err
+	}
+	log.Printf("⚠️ X11 剪贴板初始化失败，使用命令行工具读写剪贴板: %v", err)
+	return nil
+}
+
+// readClipboardText 读取剪贴板中的文本，没有文本时返回空字符串
+func readClipboardText() string {
+	switch detectLinuxSession() {
+	case linuxSessionWayland:
+		// --type text 由 wl-paste 选择合适的文本类型，剪贴板中没有文本时返回错误
+		data, _ := runClipboardTool(nil, "wl-paste", "--no-newline", "--type", "text")
+		return string(data)
+	case linuxSessionX11:
+		data, _ := runClipboardTool(nil, "xclip", "-selection", "clipboard", "-o", "-t", "UTF8_STRING")
+		return string(data)
+	}
+	if x11ClipboardReady.Load() {
+		return string(clipboard.Read(clipboard.FmtText))
+	}
+	return ""
+}
+
+// readClipboardImage 读取剪贴板中的 PNG 图片（只在没有命令行工具时使用，工具可用时由 ReadPasteboardData 读取）
+func readClipboardImage() []byte {
+	if detectLinuxSession() == linuxSessionNone && x11ClipboardReady.Load() {
+		return clipboard.Read(clipboard.FmtImage)
+	}
+	return nil
+}
+
+// WriteClipboardText 将文本写入剪贴板
+func WriteClipboardText(text string) error {
+	return writeClipboardType([]byte(text), "text/plain;charset=utf-8", "UTF8_STRING", clipboard.FmtText)
+}
+
+// WriteClipboardImage 将 PNG 图片写入剪贴板
+func WriteClipboardImage(data []byte) error {
+	return writeClipboardType(data, "image/png", "image/png", clipboard.FmtImage)
+}
+
+// clearClipboard 清空剪贴板
+func clearClipboard() {
+	var err error
+	switch detectLinuxSession() {
+	case linuxSessionWayland:
+		err = runClipboardWriter(nil, "wl-copy", "--clear")
+	default:
+		err = WriteClipboardText("")
+	}
+	if err != nil {
+		log.Printf("⚠️ 清空剪贴板失败: %v", err)
+	}
+}
+
+// writeClipboardType 按会话类型写入剪贴板：Wayland 使用 MIME 类型，X11 使用 xclip 的目标名
+func writeClipboardType(data []byte, mimeType string, x11Target string, format clipboard.Format) error {
+	var err error
+	switch detectLinuxSession() {
+	case linuxSessionWayland:
+		err = runClipboardWriter(data, "wl-copy", "--type", mimeType)
+	case linuxSessionX11:
+		err = runClipboardWriter(data, "xclip", "-selection", "clipboard", "-t", x11Target, "-i")
+	default:
+		if !x11ClipboardReady.Load() {
+			return fmt.Errorf("未找到可用的剪贴板工具（wl-clipboard 或 xclip）")
+		}
+		if clipboard.Write(format, data) == nil {
+			err = fmt.Errorf("X11 剪贴板不可用")
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("写入剪贴板失败: %v", err)
+	}
+	return nil
+}
+
+// hasCommand 检查命令是否存在于 PATH 中
+func hasCommand(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// runClipboardTool 执行外部工具并返回标准输出
+func runClipboardTool(stdin []byte, name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), linuxToolTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
+
+// runClipboardWriter 执行写入剪贴板的工具（wl-copy / xclip）
+// 这两个工具会在后台进程中继续持有剪贴板，不能接管标准输出，否则会一直等到后台进程退出
+func runClipboardWriter(stdin []byte, name string, args ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), linuxToolTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	return cmd.Run()
+}
+
+// readClipboardType 按 MIME 类型读取剪贴板内容
+func readClipboardType(mimeType string) []byte {
+	var data []byte
+	var err error
+	switch detectLinuxSession() {
+	case linuxSessionWayland:
+		data, err = runClipboardTool(nil, "wl-paste", "--no-newline", "--type", mimeType)
+	case linuxSessionX11:
+		data, err = runClipboardTool(nil, "xclip", "-selection", "clipboard", "-o", "-t", mimeType)
+	default:
+		return nil
+	}
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// getClipboardTargets 获取剪贴板当前提供的 MIME 类型列表（带短暂缓存）
+func getClipboardTargets() []string {
+	linuxTargetsMutex.Lock()
+	defer linuxTargetsMutex.Unlock()
+
+	if time.Since(linuxTargetsAt) < linuxTargetsTTL {
+		return linuxTargets
+	}
+
+	var out []byte
+	var err error
+	switch detectLinuxSession() {
+	case linuxSessionWayland:
+		out, err = runClipboardTool(nil, "wl-paste", "--list-types")
+	case linuxSessionX11:
+		out, err = runClipboardTool(nil, "xclip", "-selection", "clipboard", "-o", "-t", "TARGETS")
+	default:
+		return nil
+	}
+
+	var targets []string
+	if err == nil {
+		for _, line := range strings.Split(string(out), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				targets = append(targets, line)
+			}
+		}
+	}
+
+	linuxTargets = targets
+	linuxTargetsAt = time.Now()
+	return targets
+}
+
+// hasClipboardTarget 判断剪贴板是否提供指定类型
+func hasClipboardTarget(mimeType string) bool {
+	for _, t := range getClipboardTargets() {
+		if t == mimeType {
+			return true
+		}
+	}
+	return false
+}
+
+// GetFrontmostAppName 获取当前活动窗口所属应用程序的名称
+func GetFrontmostAppName() string {
+	if os.Getenv("SWAYSOCK") != "" && hasCommand("swaymsg") {
+		if name := swayFocusedAppName(); name != "" {
+			return name
+		}
+	}
+	if os.Getenv("HYPRLAND_INSTANCE_SIGNATURE") != "" && hasCommand("hyprctl") {
+		if name := hyprlandActiveAppName(); name != "" {
+			return name
+		}
+	}
+	if os.Getenv("DISPLAY") != "" && hasCommand("xprop") {
+		if name := x11ActiveAppName(); name != "" {
+			return name
+		}
+	}
+	return "Unknown"
+}
+
+// x11ActiveAppName 通过 _NET_ACTIVE_WINDOW 查询前台窗口的 WM_CLASS / 进程名
+func x11ActiveAppName() string {
+	out, err := runClipboardTool(nil, "xprop", "-root", "_NET_ACTIVE_WINDOW")
+	if err != nil {
+		return ""
+	}
+	// 输出格式: _NET_ACTIVE_WINDOW(WINDOW): window id # 0x3a00007
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 {
+		return ""
+	}
+	windowID := fields[len(fields)-1]
+	if !strings.HasPrefix(windowID, "0x") || windowID == "0x0" {
+		return ""
+	}
+
+	out, err = runClipboardTool(nil, "xprop", "-id", windowID, "WM_CLASS", "_NET_WM_PID")
+	if err != nil {
+		return ""
+	}
+
+	var className string
+	var pid int
+	for _, line := range strings.Split(string(out), "\n") {
+		switch {
+		case strings.HasPrefix(line, "WM_CLASS"):
+			// WM_CLASS(STRING) = "gnome-terminal-server", "Gnome-terminal"
+			if idx := strings.Index(line, "="); idx >= 0 {
+				parts := strings.Split(line[idx+1:], ",")
+				className = strings.Trim(strings.TrimSpace(parts[len(parts)-1]), `"`)
+			}
+		case strings.HasPrefix(line, "_NET_WM_PID"):
+			if idx := strings.Index(line, "="); idx >= 0 {
+				pid, _ = strconv.Atoi(strings.TrimSpace(line[idx+1:]))
+			}
+		}
+	}
+
+	if className != "" {
+		return className
+	}
+	return processNameByPID(pid)
+}
+
+// swayFocusedAppName 在 sway 的窗口树中查找获得焦点的节点
+func swayFocusedAppName() string {
+	out, err := runClipboardTool(nil, "swaymsg", "-t", "get_tree", "-r")
+	if err != nil {
+		return ""
+	}
+
+	type swayNode struct {
+		Focused          bool       `json:"focused"`
+		AppID            string     `json:"app_id"`
+		PID              int        `json:"pid"`
+		Nodes            []swayNode `json:"nodes"`
+		FloatingNodes    []swayNode `json:"floating_nodes"`
+		WindowProperties struct {
+			Class string `json:"class"`
+		} `json:"window_properties"`
+	}
+
+	var root swayNode
+	if err := json.Unmarshal(out, &root); err != nil {
+		return ""
+	}
+
+	var find func(n *swayNode) string
+	find = func(n *swayNode) string {
+		if n.Focused {
+			if n.AppID != "" {
+				return n.AppID
+			}
+			if n.WindowProperties.Class != "" {
+				return n.WindowProperties.Class
+			}
+			return processNameByPID(n.PID)
+		}
+		for i := range n.Nodes {
+			if name := find(&n.Nodes[i]); name != "" {
+				return name
+			}
+		}
+		for i := range n.FloatingNodes {
+			if name := find(&n.FloatingNodes[i]); name != "" {
+				return name
+			}
+		}
+		return ""
+	}
+	return find(&root)
+}
+
+// hyprlandActiveAppName 查询 Hyprland 当前活动窗口
+func hyprlandActiveAppName() string {
+	out, err := runClipboardTool(nil, "hyprctl", "activewindow", "-j")
+	if err != nil {
+		return ""
+	}
+	var win struct {
+		Class string `json:"class"`
+		PID   int    `json:"pid"`
+	}
+	if err := json.Unmarshal(out, &win); err != nil {
+		return ""
+	}
+	if win.Class != "" {
+		return win.Class
+	}
+	return processNameByPID(win.PID)
+}
+
+// processNameByPID 通过 /proc 读取进程名
+func processNameByPID(pid int) string {
+	if pid <= 0 {
+		return ""
+	}
+	comm, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", pid))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(comm))
+}
+
+// ReadPasteboardData 读取指定类型的剪贴板数据
+// typeName 可以是 MIME 类型，也可以是 tryReadImage 使用的 macOS UTI（自动映射为 MIME）
+func ReadPasteboardData(typeName string) []byte {
+	mimeType := typeName
+	if !strings.Contains(typeName, "/") {
+		var ok bool
+		if mimeType, ok = utiToMIME[typeName]; !ok {
+			return nil
+		}
+	}
+
+	// 只读取剪贴板确实提供的类型，避免工具在类型不存在时回退输出文本
+	if !hasClipboardTarget(mimeType) {
+		return nil
+	}
+	return readClipboardType(mimeType)
+}
+
+// ReadFileURLs 读取剪贴板中的文件 URL 列表（text/uri-list 或 GNOME 的 x-special/gnome-copied-files）
+// 返回 JSON 格式的文件路径数组和文件数量
+func ReadFileURLs() (string, int) {
+	var raw []byte
+	switch {
+	case hasClipboardTarget("text/uri-list"):
+		raw = readClipboardType("text/uri-list")
+	case hasClipboardTarget("x-special/gnome-copied-files"):
+		raw = readClipboardType("x-special/gnome-copied-files")
+	default:
+		return "", 0
+	}
+
+	paths := parseURIList(raw)
+	if len(paths) == 0 {
+		return "", 0
+	}
+
+	jsonBytes, err := json.Marshal(paths)
+	if err != nil {
+		return "", 0
+	}
+	return string(jsonBytes), len(paths)
+}
+
+// parseURIList 解析 URI 列表（RFC 2483），只保留本地文件路径
+func parseURIList(raw []byte) []string {
+	var paths []string
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// 跳过空行、注释以及 gnome-copied-files 的首行操作标记（copy/cut）
+		if line == "" || strings.HasPrefix(line, "#") || line == "copy" || line == "cut" {
+			continue
+		}
+		u, err := url.Parse(line)
+		if err != nil || u.Scheme != "file" || u.Path == "" {
+			continue
+		}
+		paths = append(paths, u.Path)
+	}
+	return paths
+}
+
+// WriteFileURLs 将文件 URL 写入剪贴板
+// filePaths 是 JSON 格式的文件路径数组
+func WriteFileURLs(filePaths string) error {
+	var paths []string
+	if err := json.Unmarshal([]byte(filePaths), &paths); err != nil {
+		return fmt.Errorf("解析文件路径 JSON 失败: %w", err)
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("空文件列表")
+	}
+
+	var uriList strings.Builder
+	for _, p := range paths {
+		u := url.URL{Scheme: "file", Path: p}
+		uriList.WriteString(u.String())
+		uriList.WriteString("\r\n")
+	}
+
+	var err error
+	switch detectLinuxSession() {
+	case linuxSessionWayland:
+		err = runClipboardWriter([]byte(uriList.String()), "wl-copy", "--type", "text/uri-list")
+	case linuxSessionX11:
+		err = runClipboardWriter([]byte(uriList.String()), "xclip", "-selection", "clipboard", "-t", "text/uri-list", "-i")
+	default:
+		return fmt.Errorf("未找到可用的剪贴板工具（wl-clipboard 或 xclip）")
+	}
+	if err != nil {
+		return fmt.Errorf("写入文件 URL 失败: %v", err)
+	}
+	return nil
+}
+
+// GetPasteboardChangeCount 获取剪贴板的变化计数
+// Wayland 下优先使用 wl-paste --watch 推送变化；否则按间隔探测剪贴板指纹，指纹变化时计数加一
+func GetPasteboardChangeCount() int {
+	session := detectLinuxSession()
+	if session == linuxSessionNone {
+		return 0
+	}
+
+	if session == linuxSessionWayland {
+		startWaylandWatcher()
+		if waylandWatchAlive.Load() {
+			return int(waylandWatchCount.Load())
+		}
+	}
+
+	linuxChangeMutex.Lock()
+	defer linuxChangeMutex.Unlock()
+
+	if time.Since(linuxLastProbe) < linuxProbeInterval {
+		return linuxChangeCount
+	}
+	linuxLastProbe = time.Now()
+
+	result := probeClipboardFingerprint(session)
+	if result != linuxLastProbeResult {
+		linuxLastProbeResult = result
+		linuxChangeCount++
+	}
+	return linuxChangeCount
+}
+
+// startWaylandWatcher 启动 wl-paste --watch，每次剪贴板变化时输出一行
+// 需要合成器支持 wlr-data-control 协议，不支持时进程会退出并回退到轮询
+func startWaylandWatcher() {
+	waylandWatchOnce.Do(func() {
+		cmd := exec.Command("wl-paste", "--watch", "echo", "changed")
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			log.Printf("⚠️ 启动 wl-paste --watch 失败: %v，回退到轮询", err)
+			return
+		}
+		if err := cmd.Start(); err != nil {
+			log.Printf("⚠️ 启动 wl-paste --watch 失败: %v，回退到轮询", err)
+			return
+		}
+
+		waylandWatchAlive.Store(true)
+		go func() {
+			scanner := bufio.NewScanner(stdout)
+			for scanner.Scan() {
+				waylandWatchCount.Add(1)
+			}
+			err := cmd.Wait()
+			waylandWatchAlive.Store(false)
+			log.Printf("⚠️ wl-paste --watch 已退出: %v，回退到轮询", err)
+		}()
+	})
+}
+
+// probeClipboardFingerprint 计算剪贴板当前内容的指纹
+// X11 下优先使用选区所有者的 TIMESTAMP（开销最小），否则对类型列表和文本内容做哈希
+func probeClipboardFingerprint(session int) string {
+	if session == linuxSessionX11 {
+		if out, err := runClipboardTool(nil, "xclip", "-selection", "clipboard", "-o", "-t", "TIMESTAMP"); err == nil {
+			if ts := strings.TrimSpace(string(out)); ts != "" && ts != "0" {
+				return "ts:" + ts
+			}
+		}
+	}
+
+	h := sha256.New()
+	for _, t := range getClipboardTargets() {
+		h.Write([]byte(t))
+		h.Write([]byte{0})
+	}
+	h.Write(readClipboardType("text/plain;charset=utf-8"))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,46 @@
+//go:build !linux
+// +build !linux
+
+package common
+
+import (
+	"fmt"
+
+	"golang.design/x/clipboard"
+)
+
+// initSystemClipboard 初始化系统剪贴板
+func initSystemClipboard() error {
+	return clipboard.Init()
+}
+
+// readClipboardText 读取剪贴板中的文本，没有文本时返回空字符串
+func readClipboardText() string {
+	return string(clipboard.Read(clipboard.FmtText))
+}
+
+// readClipboardImage 读取剪贴板中的 PNG 图片，没有时返回 nil
+func readClipboardImage() []byte {
+	return clipboard.Read(clipboard.FmtImage)
+}
+
+// WriteClipboardText 将文本写入剪贴板
+func WriteClipboardText(text string) error {
+	if clipboard.Write(clipboard.FmtText, []byte(text)) == nil {
+		return fmt.Errorf("写入剪贴板失败")
+	}
+	return nil
+}
+
+// WriteClipboardImage 将 PNG 图片写入剪贴板
+func WriteClipboardImage(data []byte) error {
+	if clipboard.Write(clipboard.FmtImage, data) == nil {
+		return fmt.Errorf("写入剪贴板失败")
+	}
+	return nil
+}
+
+// clearClipboard 清空剪贴板
+func clearClipboard() {
+	clipboard.Write(clipboard.FmtText, []byte(""))
+}
@@ -0,0 +1,581 @@
+package common
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"runtime"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// 应用密码：argon2id 加盐哈希保存在 app_settings 的 credential 中
+// 老版本把 SHA-256 保存在设置 JSON 的 password 字段，下次登录成功时自动升级并删除旧字段
+// 连续输错后按指数退避锁定，失败次数持久化，重启应用不会清零
+
+const (
+	credentialSetting = "credential"
+	lockoutSetting    = "credential_lockout"
+
+	// argon2id 参数（约 64MB 内存），随结果一起保存，以后调整不影响已有数据
+	kdfAlgorithm = "argon2id"
+	kdfTime      = 3
+	kdfMemory    = 64 * 1024
+	kdfThreads   = 4
+	kdfSaltSize  = 16
+	// credentialHashSize 密码哈希长度
+	credentialHashSize = 32
+
+	// MinPasswordLength 密码最少字符数
+	MinPasswordLength = 4
+
+	// 连续失败 lockoutFreeAttempts 次后开始锁定，之后每多失败一次锁定时间翻倍（lockoutBaseDelay 起，最长 lockoutMaxDelay）
+	lockoutFreeAttempts = 5
+	lockoutBaseDelay    = 30 * time.Second
+	lockoutMaxDelay     = time.Hour
+
+	// autoLockCheckInterval 检查是否空闲超时的间隔
+	autoLockCheckInterval = 15 * time.Second
+)
+
+// ErrPasswordNotSet 尚未设置应用密码
+var ErrPasswordNotSet = errors.New("尚未设置密码")
+
+// LockoutError 密码输错次数过多，Until 之前拒绝验证
+type LockoutError struct {
+	Until time.Time
+}
+
+func (e *LockoutError) Error() string {
+	return fmt.Sprintf("密码错误次数过多，请在 %d 秒后重试", int(time.Until(e.Until).Seconds())+1)
+}
+
+// kdfParams 密钥派生参数（与派生结果一起保存）
+type kdfParams struct {
+	KDF     string `json:"kdf"`
+	Salt    string `json:"salt"`
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"`
+	Threads uint8  `json:"threads"`
+}
+
+// newKDFParams 使用默认参数和随机盐
+func newKDFParams() (kdfParams, error) {
+	salt := make([]byte, kdfSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return kdfParams{}, fmt.Errorf("生成盐失败: %v", err)
+	}
+	return kdfParams{
+		KDF:     kdfAlgorithm,
+		Salt:    base64.StdEncoding.EncodeToString(salt),
+		Time:    kdfTime,
+		Memory:  kdfMemory,
+		Threads: uint8(min(runtime.NumCPU(), kdfThreads)),
+	}, nil
+}
+
+// derive 从密码派生 size 字节的密钥
+func (p kdfParams) derive(password string, size uint32) ([]byte, error) {
+	if p.KDF != kdfAlgorithm {
+		return nil, fmt.Errorf("不支持的密钥派生算法: %s", p.KDF)
+	}
+	salt, err := base64.StdEncoding.DecodeString(p.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("解析盐失败: %v", err)
+	}
+	return argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, size), nil
+}
+
+// passwordCredential 保存在 app_settings 中的密码哈希
+type passwordCredential struct {
+	Version int `json:"version"`
+	kdfParams
+	Hash string `json:"hash"`
+}
+
+// lockoutState 连续失败次数和锁定截止时间
+type lockoutState struct {
+	Failures    int       `json:"failures"`
+	LockedUntil time.Time `json:"lockedUntil"`
+}
+
+// PasswordStatus 密码与锁定状态（供前端调用）
+type PasswordStatus struct {
+	HasPassword     bool      `json:"hasPassword"`
+	Locked          bool      `json:"locked"`          // 当前会话是否需要输入密码
+	LockedOutUntil  time.Time `json:"lockedOutUntil"`  // 输错次数过多时的锁定截止时间
+	FailedAttempts  int       `json:"failedAttempts"`  // 连续输错次数
+	AutoLockMinutes int       `json:"autoLockMinutes"` // 空闲多少分钟后自动锁定（0 表示不自动锁定）
+}
+
+var (
+	// credentialMu 串行化密码验证与修改，保证失败计数准确
+	credentialMu sync.Mutex
+
+	// sessionUnlocked 当前会话是否已输入密码
+	sessionUnlocked bool
+	lastActivity    = time.Now()
+	sessionMu       sync.Mutex
+
+	autoLockMu     sync.Mutex
+	autoLockCancel context.CancelFunc
+	// onAutoLock 自动锁定后的回调（通知前端显示登录界面）
+	onAutoLock func()
+)
+
+// newPasswordCredential 为密码生成新的加盐哈希
+func newPasswordCredential(password string) (*passwordCredential, error) {
+	params, err := newKDFParams()
+	if err != nil {
+		return nil, err
+	}
+	hash, err := params.derive(password, credentialHashSize)
+	if err != nil {
+		return nil, err
+	}
+	return &passwordCredential{Version: 1, kdfParams: params, Hash: base64.StdEncoding.EncodeToString(hash)}, nil
+}
+
+// matches 以常量时间比较密码
+func (c *passwordCredential) matches(password string) (bool, error) {
+	expected, err := base64.StdEncoding.DecodeString(c.Hash)
+	if err != nil {
+		return false, fmt.Errorf("解析密码哈希失败: %v", err)
+	}
+	actual, err := c.derive(password, uint32(len(expected)))
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(actual, expected) == 1, nil
+}
+
+// loadPasswordCredential 读取密码哈希，返回 (新格式, 老版本的 SHA-256)，都没有时均为空
+func loadPasswordCredential() (*passwordCredential, string, error) {
+	value, err := GetSetting(credentialSetting)
+	if err != nil {
+		return nil, "", err
+	}
+	if value != "" {
+		var credential passwordCredential
+		if err := json.Unmarshal([]byte(value), &credential); err != nil {
+			return nil, "", fmt.Errorf("解析密码信息失败: %v", err)
+		}
+		return &credential, "", nil
+	}
+
+	legacy, err := legacyPasswordHash()
+	return nil, legacy, err
+}
+
+// legacyPasswordHash 读取老版本保存在设置 JSON 中的 SHA-256 密码哈希
+func legacyPasswordHash() (string, error) {
+	settings, err := LoadSettings()
+	if err != nil {
+		return "", err
+	}
+	return settings.legacyPassword(), nil
+}
+
+// HasPassword 是否设置了应用密码
+func HasPassword() (bool, error) {
+	if DB == nil {
+		return false, fmt.Errorf("数据库未初始化")
+	}
+	credential, legacy, err := loadPasswordCredential()
+	if err != nil {
+		return false, err
+	}
+	return credential != nil || legacy != "", nil
+}
+
+// GetPasswordStatus 获取密码与锁定状态
+func GetPasswordStatus() (*PasswordStatus, error) {
+	hasPassword, err := HasPassword()
+	if err != nil {
+		return nil, err
+	}
+	lockout, err := loadLockoutState()
+	if err != nil {
+		return nil, err
+	}
+	status := &PasswordStatus{
+		HasPassword:     hasPassword,
+		Locked:          hasPassword && !isSessionUnlocked(),
+		FailedAttempts:  lockout.Failures,
+		AutoLockMinutes: loadAutoLockMinutes(),
+	}
+	if time.Now().Before(lockout.LockedUntil) {
+		status.LockedOutUntil = lockout.LockedUntil
+	}
+	return status, nil
+}
+
+// VerifyPassword 验证应用密码：成功时解锁会话和加密存储，并把老版本的哈希升级为 argon2id；
+// 输错次数过多时返回 *LockoutError
+func VerifyPassword(password string) (bool, error) {
+	if DB == nil {
+		return false, fmt.Errorf("数据库未初始化")
+	}
+	credentialMu.Lock()
+	defer credentialMu.Unlock()
+
+	valid, err := checkPasswordLocked(password)
+	if err != nil || !valid {
+		return false, err
+	}
+
+	if err := unlockStore(password); err != nil {
+		return false, err
+	}
+	sessionMu.Lock()
+	sessionUnlocked = true
+	lastActivity = time.Now()
+	sessionMu.Unlock()
+	log.Println("✅ 密码验证成功")
+	return true, nil
+}
+
+// checkPasswordLocked 验证密码并维护失败计数（调用方持有 credentialMu）
+// 没有设置密码时返回 ErrPasswordNotSet
+func checkPasswordLocked(password string) (bool, error) {
+	lockout, err := loadLockoutState()
+	if err != nil {
+		return false, err
+	}
+	if time.Now().Before(lockout.LockedUntil) {
+		return false, &LockoutError{Until: lockout.LockedUntil}
+	}
+
+	credential, legacy, err := loadPasswordCredential()
+	if err != nil {
+		return false, err
+	}
+
+	var valid bool
+	switch {
+	case credential != nil:
+		if valid, err = credential.matches(password); err != nil {
+			return false, err
+		}
+	case legacy != "":
+		sum := sha256.Sum256([]byte(password))
+		valid = subtle.ConstantTimeCompare([]byte(hex.EncodeToString(sum[:])), []byte(legacy)) == 1
+	default:
+		return false, ErrPasswordNotSet
+	}
+
+	if !valid {
+		lockout.Failures++
+		if over := lockout.Failures - lockoutFreeAttempts; over >= 0 {
+			delay := lockoutBaseDelay << min(over, 7)
+			if delay > lockoutMaxDelay {
+				delay = lockoutMaxDelay
+			}
+			lockout.LockedUntil = time.Now().Add(delay)
+			log.Printf("❌ 密码验证失败（连续 %d 次），锁定 %v", lockout.Failures, delay)
+		} else {
+			log.Printf("❌ 密码验证失败（连续 %d 次）", lockout.Failures)
+		}
+		if err := saveLockoutState(lockout); err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+
+	if lockout.Failures > 0 {
+		if err := saveLockoutState(lockoutState{}); err != nil {
+			return false, err
+		}
+	}
+	if credential == nil {
+		if err := upgradeLegacyPassword(password); err != nil {
+			// 升级失败不影响本次登录，下次登录时重试
+			log.Printf("⚠️ 升级密码存储失败: %v", err)
+		}
+	}
+	return true, nil
+}
+
+// upgradeLegacyPassword 把老版本的 SHA-256 哈希替换为 argon2id 哈希
+func upgradeLegacyPassword(password string) error {
+	credential, err := newPasswordCredential(password)
+	if err != nil {
+		return err
+	}
+	if err := saveCredentialSettings(credential, nil); err != nil {
+		return err
+	}
+	log.Printf("🔐 已将密码升级为 %s 存储", kdfAlgorithm)
+	return nil
+}
+
+// SetPassword 设置或修改应用密码（已有密码时需要提供正确的旧密码）
+// 启用加密存储时同时用新密码重新加密数据密钥
+func SetPassword(oldPassword string, newPassword string) error {
+	if DB == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	if len([]rune(newPassword)) < MinPasswordLength {
+		return fmt.Errorf("密码至少需要 %d 个字符", MinPasswordLength)
+	}
+	credentialMu.Lock()
+	defer credentialMu.Unlock()
+
+	hasPassword := true
+	valid, err := checkPasswordLocked(oldPassword)
+	if errors.Is(err, ErrPasswordNotSet) {
+		hasPassword = false
+	} else if err != nil {
+		return err
+	} else if !valid {
+		return fmt.Errorf("旧密码错误")
+	}
+
+	var encryptionRecord *encryptionKeyRecord
+	if hasPassword {
+		if encryptionRecord, err = rewrapEncryptionKey(oldPassword, newPassword); err != nil {
+			return err
+		}
+	}
+	credential, err := newPasswordCredential(newPassword)
+	if err != nil {
+		return err
+	}
+	if err := saveCredentialSettings(credential, encryptionRecord); err != nil {
+		return err
+	}
+	if encryptionRecord != nil {
+		applyEncryptionKeyRecord(encryptionRecord)
+	}
+
+	// 设置密码的就是当前用户，不需要立刻再输入一次
+	sessionMu.Lock()
+	sessionUnlocked = true
+	lastActivity = time.Now()
+	sessionMu.Unlock()
+	if hasPassword {
+		log.Printf("✅ 已修改密码")
+	} else {
+		log.Printf("✅ 已设置密码")
+	}
+	return nil
+}
+
+// ClearPassword 移除应用密码（需要提供当前密码；启用加密存储时需要先关闭加密）
+func ClearPassword(oldPassword string) error {
+	if DB == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	if IsEncryptionEnabled() {
+		return fmt.Errorf("已启用加密存储，请先关闭加密再移除密码")
+	}
+	credentialMu.Lock()
+	defer credentialMu.Unlock()
+
+	valid, err := checkPasswordLocked(oldPassword)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return fmt.Errorf("密码错误")
+	}
+	if err := saveCredentialSettings(nil, nil); err != nil {
+		return err
+	}
+	log.Printf("✅ 已移除密码")
+	return nil
+}
+
+// saveCredentialSettings 在一个事务中保存密码哈希（nil 表示删除）和重新加密的数据密钥，
+// 同时删除设置 JSON 中老版本的 password 字段
+func saveCredentialSettings(credential *passwordCredential, encryptionRecord *encryptionKeyRecord) error {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %v", err)
+	}
+	defer tx.Rollback()
+
+	if credential != nil {
+		data, err := json.Marshal(credential)
+		if err != nil {
+			return fmt.Errorf("序列化密码信息失败: %v", err)
+		}
+		if err := saveSettingTx(tx, credentialSetting, string(data)); err != nil {
+			return err
+		}
+	} else if _, err := tx.Exec(`DELETE FROM app_settings WHERE key = ?`, credentialSetting); err != nil {
+		return fmt.Errorf("删除密码失败: %v", err)
+	}
+
+	if encryptionRecord != nil {
+		data, err := json.Marshal(encryptionRecord)
+		if err != nil {
+			return fmt.Errorf("序列化加密密钥失败: %v", err)
+		}
+		if err := saveSettingTx(tx, encryptionKeySetting, string(data)); err != nil {
+			return err
+		}
+	}
+
+	if err := removeLegacyPasswordTx(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("保存密码失败: %v", err)
+	}
+	return nil
+}
+
+// saveSettingTx 在事务中写入设置
+func saveSettingTx(tx *sql.Tx, key string, value string) error {
+	_, err := tx.Exec(`INSERT INTO app_settings (key, value, updated_at) VALUES (?, ?, datetime('now'))
+	ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = datetime('now')`, key, value)
+	if err != nil {
+		return fmt.Errorf("保存设置失败: %v", err)
+	}
+	return nil
+}
+
+// removeLegacyPasswordTx 删除设置 JSON 中老版本的 password 字段（调用方需持有 settingsMu）
+func removeLegacyPasswordTx(tx *sql.Tx) error {
+	settings, err := loadSettingsTx(tx)
+	if err != nil {
+		return err
+	}
+	if _, ok := settings.extra["password"]; !ok {
+		return nil
+	}
+	delete(settings.extra, "password")
+	return saveSettingsTx(tx, settings)
+}
+
+func loadLockoutState() (lockoutState, error) {
+	var state lockoutState
+	value, err := GetSetting(lockoutSetting)
+	if err != nil || value == "" {
+		return state, err
+	}
+	if err := json.Unmarshal([]byte(value), &state); err != nil {
+		// 损坏的记录按未锁定处理，避免用户被永久锁在外面
+		log.Printf("⚠️ 解析密码锁定状态失败: %v", err)
+		return lockoutState{}, nil
+	}
+	return state, nil
+}
+
+func saveLockoutState(state lockoutState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("序列化密码锁定状态失败: %v", err)
+	}
+	return SaveSetting(lockoutSetting, string(data))
+}
+
+// isSessionUnlocked 当前会话是否已输入密码
+func isSessionUnlocked() bool {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	return sessionUnlocked
+}
+
+// RecordActivity 记录用户操作，重新计算空闲时间
+func RecordActivity() {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	lastActivity = time.Now()
+}
+
+// LockSession 锁定当前会话（需要重新输入密码）并锁定加密存储
+func LockSession() {
+	sessionMu.Lock()
+	wasUnlocked := sessionUnlocked
+	sessionUnlocked = false
+	sessionMu.Unlock()
+	LockStore()
+	if wasUnlocked {
+		log.Printf("🔒 应用已锁定")
+	}
+}
+
+// loadAutoLockMinutes 读取设置中的空闲自动锁定时间（autoLockMinutes，0 表示不自动锁定）
+func loadAutoLockMinutes() int {
+	settings, err := LoadSettings()
+	if err != nil {
+		return 0
+	}
+	return settings.AutoLockMinutes
+}
+
+// StartAutoLock 启动空闲自动锁定（重复调用会先停止之前的检查），onLock 在自动锁定后调用
+func StartAutoLock(onLock func()) {
+	StopAutoLock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	autoLockMu.Lock()
+	autoLockCancel = cancel
+	onAutoLock = onLock
+	autoLockMu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(autoLockCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				checkAutoLock()
+			}
+		}
+	}()
+}
+
+// StopAutoLock 停止空闲自动锁定
+func StopAutoLock() {
+	autoLockMu.Lock()
+	defer autoLockMu.Unlock()
+	if autoLockCancel != nil {
+		autoLockCancel()
+		autoLockCancel = nil
+	}
+}
+
+// checkAutoLock 已解锁且空闲超过设置的时间时锁定
+func checkAutoLock() {
+	minutes := loadAutoLockMinutes()
+	if minutes <= 0 {
+		return
+	}
+	sessionMu.Lock()
+	idle := time.Since(lastActivity)
+	unlocked := sessionUnlocked
+	sessionMu.Unlock()
+	if !unlocked || idle < time.Duration(minutes)*time.Minute {
+		return
+	}
+	if hasPassword, err := HasPassword(); err != nil || !hasPassword {
+		return
+	}
+
+	log.Printf("⏰ 已空闲 %v，自动锁定", idle.Round(time.Second))
+	LockSession()
+	autoLockMu.Lock()
+	callback := onAutoLock
+	autoLockMu.Unlock()
+	if callback != nil {
+		callback()
+	}
+}
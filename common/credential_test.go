@@ -0,0 +1,301 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// resetCredentialState 测试结束后恢复会话状态
+func resetCredentialState(t *testing.T) {
+	t.Cleanup(func() {
+		sessionMu.Lock()
+		sessionUnlocked = false
+		lastActivity = time.Now()
+		sessionMu.Unlock()
+	})
+}
+
+// setTestPassword 设置应用密码（测试数据库中还没有密码）
+func setTestPassword(t *testing.T, password string) {
+	t.Helper()
+	resetCredentialState(t)
+	if err := SetPassword("", password); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustLoadLockout(t *testing.T) lockoutState {
+	t.Helper()
+	state, err := loadLockoutState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+func TestPasswordLockoutGrowth(t *testing.T) {
+	openTestDB(t, 0)
+	setTestPassword(t, "right")
+
+	// 之前连续失败的次数 → 再输错一次后的锁定时间
+	tests := []struct {
+		failures  int
+		wantDelay time.Duration // 0 表示不锁定
+	}{
+		{failures: 0},
+		{failures: lockoutFreeAttempts - 2},
+		{failures: lockoutFreeAttempts - 1, wantDelay: lockoutBaseDelay},
+		{failures: lockoutFreeAttempts, wantDelay: 2 * lockoutBaseDelay},
+		{failures: lockoutFreeAttempts + 1, wantDelay: 4 * lockoutBaseDelay},
+		{failures: lockoutFreeAttempts + 6, wantDelay: lockoutMaxDelay},
+		{failures: lockoutFreeAttempts + 100, wantDelay: lockoutMaxDelay}, // 位移次数有上限，不会溢出
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("失败%d次后", tt.failures), func(t *testing.T) {
+			// 上一次锁定已经过期
+			if err := saveLockoutState(lockoutState{Failures: tt.failures, LockedUntil: time.Now().Add(-time.Second)}); err != nil {
+				t.Fatal(err)
+			}
+			start := time.Now()
+			valid, err := VerifyPassword("wrong")
+			if valid || err != nil {
+				t.Fatalf("VerifyPassword = %v, %v，期望 false, nil", valid, err)
+			}
+			state := mustLoadLockout(t)
+			if state.Failures != tt.failures+1 {
+				t.Errorf("Failures = %d，期望 %d", state.Failures, tt.failures+1)
+			}
+			if tt.wantDelay == 0 {
+				if state.LockedUntil.After(time.Now()) {
+					t.Errorf("不应该锁定，LockedUntil = %v", state.LockedUntil)
+				}
+				return
+			}
+			if delay := state.LockedUntil.Sub(start); delay < tt.wantDelay || delay > tt.wantDelay+time.Minute {
+				t.Errorf("锁定时间 = %v，期望 %v", delay, tt.wantDelay)
+			}
+
+			// 锁定期间正确的密码也被拒绝，且不再增加失败次数
+			_, err = VerifyPassword("right")
+			var lockoutErr *LockoutError
+			if !errors.As(err, &lockoutErr) {
+				t.Fatalf("锁定期间 VerifyPassword 错误 = %v，期望 *LockoutError", err)
+			}
+			if got := mustLoadLockout(t).Failures; got != tt.failures+1 {
+				t.Errorf("锁定期间失败次数变为 %d", got)
+			}
+		})
+	}
+
+	// 锁定过期后输入正确的密码，失败次数清零
+	if err := saveLockoutState(lockoutState{Failures: 9, LockedUntil: time.Now().Add(-time.Second)}); err != nil {
+		t.Fatal(err)
+	}
+	if valid, err := VerifyPassword("right"); !valid || err != nil {
+		t.Fatalf("VerifyPassword = %v, %v", valid, err)
+	}
+	if state := mustLoadLockout(t); state.Failures != 0 || !state.LockedUntil.IsZero() {
+		t.Errorf("成功后锁定状态 = %+v，期望清零", state)
+	}
+}
+
+func TestPasswordFailuresCounted(t *testing.T) {
+	openTestDB(t, 0)
+	resetEncryptionState(t)
+	setTestPassword(t, "right")
+	if err := EnableEncryption("right"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 每种验证密码的入口输错都计入同一个失败次数
+	tests := []struct {
+		name  string
+		wrong func() error
+	}{
+		{name: "VerifyPassword", wrong: func() error {
+			valid, err := VerifyPassword("wrong")
+			if err == nil && !valid {
+				return ErrWrongPassword
+			}
+			return err
+		}},
+		{name: "UnlockStore", wrong: func() error { return UnlockStore("wrong") }},
+		{name: "DisableEncryption", wrong: func() error { return DisableEncryption("wrong") }},
+		{name: "SetPassword", wrong: func() error { return SetPassword("wrong", "newpass") }},
+	}
+	for i, tt := range tests {
+		if err := tt.wrong(); err == nil {
+			t.Fatalf("%s: 错误的密码没有返回错误", tt.name)
+		}
+		if got := mustLoadLockout(t).Failures; got != i+1 {
+			t.Errorf("%s 之后失败次数 = %d，期望 %d", tt.name, got, i+1)
+		}
+	}
+	if !IsEncryptionEnabled() {
+		t.Fatal("输错密码后加密存储被关闭")
+	}
+
+	// 达到锁定次数后 UnlockStore 和 DisableEncryption 同样被拒绝
+	if err := saveLockoutState(lockoutState{Failures: lockoutFreeAttempts, LockedUntil: time.Now().Add(time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	for _, unlock := range []func() error{
+		func() error { return UnlockStore("right") },
+		func() error { return DisableEncryption("right") },
+	} {
+		var lockoutErr *LockoutError
+		if err := unlock(); !errors.As(err, &lockoutErr) {
+			t.Errorf("锁定期间错误 = %v，期望 *LockoutError", err)
+		}
+	}
+	if !IsEncryptionEnabled() {
+		t.Fatal("锁定期间加密存储被关闭")
+	}
+}
+
+func TestLegacyPasswordUpgrade(t *testing.T) {
+	openTestDB(t, 0)
+	resetCredentialState(t)
+	sum := sha256.Sum256([]byte("legacy"))
+	if err := SaveSetting(settingsKey, `{"password":"`+hex.EncodeToString(sum[:])+`"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		password       string
+		wantValid      bool
+		wantCredential bool // 之后是否已保存 argon2id 哈希
+	}{
+		{password: "wrong", wantValid: false, wantCredential: false},
+		{password: "legacy", wantValid: true, wantCredential: true},
+		{password: "legacy", wantValid: true, wantCredential: true}, // 升级后用新哈希验证
+		{password: "wrong", wantValid: false, wantCredential: true},
+	}
+	for i, tt := range tests {
+		valid, err := VerifyPassword(tt.password)
+		if err != nil || valid != tt.wantValid {
+			t.Fatalf("#%d VerifyPassword(%q) = %v, %v，期望 %v", i, tt.password, valid, err, tt.wantValid)
+		}
+		credential, legacy, err := loadPasswordCredential()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (credential != nil) != tt.wantCredential {
+			t.Errorf("#%d credential = %+v，期望存在 %v", i, credential, tt.wantCredential)
+		}
+		if credential != nil && (credential.KDF != kdfAlgorithm || legacy != "") {
+			t.Errorf("#%d 升级后 kdf = %s，老哈希 = %q", i, credential.KDF, legacy)
+		}
+	}
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.legacyPassword() != "" {
+		t.Error("升级后设置中仍有老版本的 password 字段")
+	}
+}
+
+func TestSetAndClearPassword(t *testing.T) {
+	openTestDB(t, 0)
+	resetEncryptionState(t)
+	resetCredentialState(t)
+
+	tests := []struct {
+		name      string
+		run       func() error
+		wantErr   bool
+		wantValid string // 之后能通过验证的密码，空表示没有密码
+	}{
+		{name: "密码太短", run: func() error { return SetPassword("", "abc") }, wantErr: true},
+		{name: "首次设置", run: func() error { return SetPassword("", "first") }, wantValid: "first"},
+		{name: "旧密码错误时不修改", run: func() error { return SetPassword("nope", "second") }, wantErr: true, wantValid: "first"},
+		{name: "修改密码", run: func() error { return SetPassword("first", "second") }, wantValid: "second"},
+		{name: "启用加密后不能移除", run: func() error {
+			if err := EnableEncryption("second"); err != nil {
+				return err
+			}
+			return ClearPassword("second")
+		}, wantErr: true, wantValid: "second"},
+		{name: "修改密码后仍能解锁加密存储", run: func() error {
+			if err := SetPassword("second", "third"); err != nil {
+				return err
+			}
+			if err := UnlockStore("third"); err != nil {
+				return err
+			}
+			return DisableEncryption("third")
+		}, wantValid: "third"},
+		{name: "密码错误时不移除", run: func() error { return ClearPassword("second") }, wantErr: true, wantValid: "third"},
+		{name: "移除密码", run: func() error { return ClearPassword("third") }},
+		{name: "没有密码时移除", run: func() error { return ClearPassword("third") }, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := tt.run(); (err != nil) != tt.wantErr {
+			t.Fatalf("%s: 错误 = %v，期望出错 %v", tt.name, err, tt.wantErr)
+		}
+		hasPassword, err := HasPassword()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hasPassword != (tt.wantValid != "") {
+			t.Fatalf("%s: HasPassword = %v", tt.name, hasPassword)
+		}
+		if tt.wantValid == "" {
+			continue
+		}
+		if valid, err := VerifyPassword(tt.wantValid); !valid || err != nil {
+			t.Errorf("%s: VerifyPassword(%q) = %v, %v", tt.name, tt.wantValid, valid, err)
+		}
+	}
+}
+
+func TestCheckAutoLock(t *testing.T) {
+	openTestDB(t, 0)
+	setTestPassword(t, "right")
+
+	tests := []struct {
+		name       string
+		minutes    int
+		idle       time.Duration
+		unlocked   bool
+		wantLocked bool
+	}{
+		{name: "未设置自动锁定", minutes: 0, idle: time.Hour, unlocked: true},
+		{name: "空闲时间不够", minutes: 5, idle: 4 * time.Minute, unlocked: true},
+		{name: "空闲超时", minutes: 5, idle: 6 * time.Minute, unlocked: true, wantLocked: true},
+		{name: "已锁定时不重复回调", minutes: 5, idle: time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SaveSetting(settingsKey, fmt.Sprintf(`{"autoLockMinutes":%d}`, tt.minutes)); err != nil {
+				t.Fatal(err)
+			}
+			sessionMu.Lock()
+			sessionUnlocked = tt.unlocked
+			lastActivity = time.Now().Add(-tt.idle)
+			sessionMu.Unlock()
+			called := false
+			autoLockMu.Lock()
+			onAutoLock = func() { called = true }
+			autoLockMu.Unlock()
+			t.Cleanup(func() {
+				autoLockMu.Lock()
+				onAutoLock = nil
+				autoLockMu.Unlock()
+			})
+
+			checkAutoLock()
+			if called != tt.wantLocked {
+				t.Errorf("回调调用 = %v，期望 %v", called, tt.wantLocked)
+			}
+			if want := tt.unlocked && !tt.wantLocked; isSessionUnlocked() != want {
+				t.Errorf("会话解锁 = %v，期望 %v", isSessionUnlocked(), want)
+			}
+		})
+	}
+}
@@ -28,6 +28,11 @@ func InitDB() error {
 		return fmt.Errorf("创建应用目录失败: %v", err)
 	}
 
+	// 图片文件目录（迁移时会把老数据中的图片移到这里）
+	if err := initBlobStore(appDir); err != nil {
+		return err
+	}
+
 	// 数据库文件路径
 	dbPath := filepath.Join(appDir, "clipboard.db")
 	log.Printf("数据库路径: %s", dbPath)
@@ -40,23 +45,51 @@ func InitDB() error {
 
 	DB = db
 
-	// 创建表
-	if err := createTables(); err != nil {
-		return fmt.Errorf("创建表失败: %v", err)
+	// 设置 CLIPSAVE_MIGRATE_DRY_RUN=1 时只试运行迁移并输出计划，不修改数据库
+	if os.Getenv("CLIPSAVE_MIGRATE_DRY_RUN") == "1" {
+		planned, err := MigrateDB(true)
+		DB.Close()
+		DB = nil
+		if err != nil {
+			return fmt.Errorf("试运行数据库迁移失败: %v", err)
+		}
+		return fmt.Errorf("试运行完成，共 %d 个待执行迁移，未修改数据库", len(planned))
+	}
+
+	// 执行版本化的数据库迁移（包括建表和老用户的字段补齐）
+	if _, err := MigrateDB(false); err != nil {
+		// 迁移失败（或数据库来自更新的版本）时不再使用这个连接，避免写入不兼容的数据
+		DB.Close()
+		DB = nil
+		return fmt.Errorf("数据库迁移失败: %v", err)
+	}
+
+	// 读取加密存储的密钥信息（启用加密时存储处于锁定状态，需要输入密码解锁）
+	if err := loadEncryptionState(); err != nil {
+		DB.Close()
+		DB = nil
+		return err
 	}
 
-	// 检查并添加新字段（兼容老用户）
-	if err := checkAndAddNewFields(); err != nil {
-		return fmt.Errorf("添加新字段失败: %v", err)
+	// 清理删除记录后残留的图片文件
+	if _, err := collectImageBlobs(); err != nil {
+		log.Printf("警告: 清理图片文件失败: %v", err)
 	}
+	if _, err := sweepOrphanBlobs(); err != nil {
+		log.Printf("警告: 清理孤立图片文件失败: %v", err)
+	}
+
+	// 后台为老图片生成缩略图
+	go backfillThumbnails()
 
-	// 检查并添加脚本表
-	if err := checkAndAddScriptTable(); err != nil {
-		return fmt.Errorf("初始化脚本表失败: %v", err)
+	// 创建/回填全文索引（失败时搜索回退到 LIKE）
+	if err := ensureFTSIndex(); err != nil {
+		log.Printf("警告: 初始化全文索引失败: %v", err)
+		ftsEnabled.Store(false)
 	}
 
-	// 初始化默认设置
-	if err := initDefaultSettings(); err != nil {
+	// 初始化默认设置，升级老版本的设置
+	if err := initSettings(); err != nil {
 		log.Printf("警告: 初始化默认设置失败: %v", err)
 		// 不返回错误，允许应用继续运行
 	}
@@ -78,48 +111,20 @@ func InitDB() error {
 	return nil
 }
 
-// createTables 创建数据表
-func createTables() error {
-	createTableSQL := `
-	CREATE TABLE IF NOT EXISTS clipboard_items (
-		id TEXT PRIMARY KEY,
-		content TEXT NOT NULL,
-		content_type TEXT NOT NULL,
-		image_data BLOB,
-		file_paths TEXT,
-		file_info TEXT,
-		timestamp DATETIME NOT NULL,
-		source TEXT,
-		char_count INTEGER,
-        word_count INTEGER,
-		content_hash TEXT,
-        is_favorite INTEGER DEFAULT 0,
-		ocr_text TEXT,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_timestamp ON clipboard_items(timestamp DESC);
-	CREATE INDEX IF NOT EXISTS idx_content_type ON clipboard_items(content_type);
-	-- 移除这行：CREATE INDEX IF NOT EXISTS idx_ocr_text ON clipboard_items(ocr_text);
-	-- ocr_text 索引在 checkAndAddNewFields() 中添加字段时创建
-
-	CREATE TABLE IF NOT EXISTS app_settings (
-		key TEXT PRIMARY KEY,
-		value TEXT NOT NULL,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-	`
-
-	_, err := DB.Exec(createTableSQL)
-	return err
-}
-
 // SaveClipboardItem 保存剪贴板项目（支持去重）
 func SaveClipboardItem(item *ClipboardItem) error {
 	if DB == nil {
 		return fmt.Errorf("数据库未初始化")
 	}
 
+	// 图片数据写入文件存储（按 content_hash 寻址），数据库中不再保存 image_data
+	isImage := item.ContentType == "Image" && len(item.ImageData) > 0
+	if isImage {
+		item.ContentHash = imageBlobHash(item)
+		blobMu.Lock()
+		defer blobMu.Unlock()
+	}
+
 	// 检查是否存在相同内容的项目（只在哈希值不为空时检查）
 	if item.ContentHash != "" {
 		var existingID string
@@ -127,9 +132,21 @@ func SaveClipboardItem(item *ClipboardItem) error {
 		err := DB.QueryRow(checkSQL, item.ContentHash, item.ContentType).Scan(&existingID)
 
 		if err == nil {
-			// 找到重复项：不删除，更新其时间戳与来源
-			updateSQL := `UPDATE clipboard_items SET timestamp = ?, source = ? WHERE id = ?`
-			_, updErr := DB.Exec(updateSQL, item.Timestamp, item.Source, existingID)
+			// 找到重复项：不删除，更新其时间戳与来源（敏感内容同时刷新检测结果）；
+			// 新的过期时间（敏感内容或捕获规则）只会让已有项目提前过期，不会延长或取消原有的过期时间
+			updateSQL := `UPDATE clipboard_items SET timestamp = ?, source = ?`
+			updateArgs := []interface{}{item.Timestamp, item.Source}
+			if item.SensitiveAction != "" {
+				updateSQL += `, sensitive_category = ?, sensitive_action = ?`
+				updateArgs = append(updateArgs, item.SensitiveCategory, item.SensitiveAction)
+			}
+			if item.ExpiresAt != nil {
+				updateSQL += `, expires_at = CASE WHEN expires_at IS NULL OR expires_at > ? THEN ? ELSE expires_at END`
+				updateArgs = append(updateArgs, *item.ExpiresAt, *item.ExpiresAt)
+			}
+			updateSQL += ` WHERE id = ?`
+			updateArgs = append(updateArgs, existingID)
+			_, updErr := DB.Exec(updateSQL, updateArgs...)
 			if updErr != nil {
 				log.Printf("⚠️ 更新重复项目时间失败: %v", updErr)
 			} else {
@@ -141,25 +158,41 @@ func SaveClipboardItem(item *ClipboardItem) error {
 		}
 	}
 
+	// 启用加密存储时加密内容字段（锁定状态下返回 ErrStoreLocked）
+	fields, err := encryptItemFields(item)
+	if err != nil {
+		return err
+	}
+
+	if isImage {
+		if err := writeImageBlob(item.ContentHash, item.ImageData); err != nil {
+			return fmt.Errorf("保存图片失败: %v", err)
+		}
+	}
+
 	// 插入新记录
 	insertSQL := `
-	INSERT INTO clipboard_items (id, content, content_type, content_hash, image_data, file_paths, file_info, timestamp, source, char_count, word_count, ocr_text)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	INSERT INTO clipboard_items (id, content, content_type, content_hash, image_data, thumbnail, file_paths, file_info, timestamp, source, char_count, word_count, ocr_text, sensitive_category, sensitive_action, expires_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := DB.Exec(insertSQL,
+	_, err = DB.Exec(insertSQL,
 		item.ID,
-		item.Content,
+		fields.content,
 		item.ContentType,
 		item.ContentHash,
-		item.ImageData,
-		item.FilePaths,
-		item.FileInfo,
+		nil, // image_data
+		fields.thumbnail,
+		fields.filePaths,
+		fields.fileInfo,
 		item.Timestamp,
 		item.Source,
 		item.CharCount,
 		item.WordCount,
-		item.OCRText,
+		fields.ocrText,
+		item.SensitiveCategory,
+		item.SensitiveAction,
+		item.ExpiresAt,
 	)
 
 	if err != nil {
@@ -184,12 +217,16 @@ func GetClipboardItems(limit int) ([]ClipboardItem, error) {
 
 	// 列表查询时不加载 image_data，节省内存
 	query := `
-    SELECT id, content, content_type, COALESCE(content_hash, '') as content_hash, NULL as image_data, file_paths, file_info, timestamp, source, char_count, word_count, COALESCE(is_favorite, 0) as is_favorite, COALESCE(ocr_text, '') as ocr_text
+    SELECT id, content, content_type, COALESCE(content_hash, '') as content_hash, NULL as image_data, file_paths, file_info, timestamp, source, char_count, word_count, COALESCE(is_favorite, 0) as is_favorite, COALESCE(ocr_text, '') as ocr_text, COALESCE(sensitive_category, '') as sensitive_category, COALESCE(sensitive_action, '') as sensitive_action, expires_at, COALESCE(max_pastes, 0) as max_pastes, COALESCE(paste_count, 0) as paste_count
 	FROM clipboard_items
 	ORDER BY timestamp DESC
 	LIMIT ?
 	`
 
+	if err := checkStoreUnlocked(); err != nil {
+		return nil, err
+	}
+
 	rows, err := DB.Query(query, limit)
 	if err != nil {
 		return nil, fmt.Errorf("查询剪贴板项目失败: %v", err)
@@ -213,11 +250,20 @@ func GetClipboardItems(limit int) ([]ClipboardItem, error) {
 			&item.WordCount,
 			&item.IsFavorite,
 			&item.OCRText,
+			&item.SensitiveCategory,
+			&item.SensitiveAction,
+			&item.ExpiresAt,
+			&item.MaxPastes,
+			&item.PasteCount,
 		)
 		if err != nil {
 			log.Printf("扫描行失败: %v", err)
 			continue
 		}
+		if err := decryptItem(&item); err != nil {
+			log.Printf("解密剪贴板项目失败: ID=%s, error=%v", item.ID, err)
+			continue
+		}
 		items = append(items, item)
 	}
 
@@ -231,7 +277,7 @@ func GetClipboardItemByID(id string) (*ClipboardItem, error) {
 	}
 
 	query := `
-    SELECT id, content, content_type, COALESCE(content_hash, '') as content_hash, image_data, file_paths, file_info, timestamp, source, char_count, word_count, COALESCE(is_favorite, 0) as is_favorite, COALESCE(ocr_text, '') as ocr_text
+    SELECT id, content, content_type, COALESCE(content_hash, '') as content_hash, image_data, file_paths, file_info, timestamp, source, char_count, word_count, COALESCE(is_favorite, 0) as is_favorite, COALESCE(ocr_text, '') as ocr_text, COALESCE(sensitive_category, '') as sensitive_category, COALESCE(sensitive_action, '') as sensitive_action, expires_at, COALESCE(max_pastes, 0) as max_pastes, COALESCE(paste_count, 0) as paste_count
 	FROM clipboard_items
 	WHERE id = ?
 	`
@@ -251,6 +297,11 @@ func GetClipboardItemByID(id string) (*ClipboardItem, error) {
 		&item.WordCount,
 		&item.IsFavorite,
 		&item.OCRText,
+		&item.SensitiveCategory,
+		&item.SensitiveAction,
+		&item.ExpiresAt,
+		&item.MaxPastes,
+		&item.PasteCount,
 	)
 
 	if err == sql.ErrNoRows {
@@ -259,7 +310,11 @@ func GetClipboardItemByID(id string) (*ClipboardItem, error) {
 	if err != nil {
 		return nil, fmt.Errorf("查询剪贴板项目失败: %v", err)
 	}
+	if err := decryptItem(&item); err != nil {
+		return nil, err
+	}
 
+	fillImageData(&item)
 	return &item, nil
 }
 
@@ -281,6 +336,7 @@ func DeleteClipboardItem(id string) error {
 	}
 
 	log.Printf("已删除剪贴板项目: ID=%s", id)
+	collectImageBlobs()
 	return nil
 }
 
@@ -295,7 +351,7 @@ func ClearItemsOlderThanDays(days int) error {
 
 	deleteSQL := `
     DELETE FROM clipboard_items
-    WHERE is_favorite != 1 AND timestamp < ?
+    WHERE ` + protectedItemsClause + ` AND timestamp < ?
     `
 
 	result, err := DB.Exec(deleteSQL, cutoffDate.Format("2006-01-02 15:04:05"))
@@ -306,6 +362,7 @@ func ClearItemsOlderThanDays(days int) error {
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected > 0 {
 		log.Printf("已清除 %d 条超过 %d 天的剪贴板项目", rowsAffected, days)
+		collectImageBlobs()
 	}
 	return nil
 }
@@ -316,7 +373,8 @@ func ClearAllItems() error {
 		return fmt.Errorf("数据库未初始化")
 	}
 
-	deleteSQL := `DELETE FROM clipboard_items WHERE is_favorite != 1`
+	// 收藏和带有受保护标签的项目不会被清除
+	deleteSQL := `DELETE FROM clipboard_items WHERE ` + protectedItemsClause
 
 	result, err := DB.Exec(deleteSQL)
 	if err != nil {
@@ -325,6 +383,7 @@ func ClearAllItems() error {
 
 	rowsAffected, _ := result.RowsAffected()
 	log.Printf("已清除所有剪贴板项目，共 %d 条", rowsAffected)
+	collectImageBlobs()
 	return nil
 }
 
@@ -334,13 +393,39 @@ func UpdateOCRText(id string, ocrText string) error {
 		return fmt.Errorf("数据库未初始化")
 	}
 
-	_, err := DB.Exec(`UPDATE clipboard_items SET ocr_text = ? WHERE id = ?`, ocrText, id)
+	ocrText, err := encryptText(ocrText)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(`UPDATE clipboard_items SET ocr_text = ? WHERE id = ?`, ocrText, id)
 	if err != nil {
 		return fmt.Errorf("更新OCR文字失败: %v", err)
 	}
 	return nil
 }
 
+// FindOCRTextByHash 查找相同哈希的图片已有的 OCR 结果
+// 返回 OCR 文字和所属记录 ID，没有结果时返回空字符串
+func FindOCRTextByHash(contentHash string) (string, string, error) {
+	if DB == nil {
+		return "", "", fmt.Errorf("数据库未初始化")
+	}
+
+	var ocrText, id string
+	query := `SELECT ocr_text, id FROM clipboard_items WHERE content_hash = ? AND content_type = 'Image' AND (ocr_text IS NOT NULL AND ocr_text != '') LIMIT 1`
+	err := DB.QueryRow(query, contentHash).Scan(&ocrText, &id)
+	if err == sql.ErrNoRows {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("查询OCR结果失败: %v", err)
+	}
+	if ocrText, err = decryptText(ocrText); err != nil {
+		return "", "", err
+	}
+	return ocrText, id, nil
+}
+
 // ToggleFavorite 切换收藏状态
 func ToggleFavorite(id string) (int, error) {
 	if DB == nil {
@@ -365,19 +450,29 @@ func ToggleFavorite(id string) (int, error) {
 	return newVal, nil
 }
 
+// 搜索结果中图片数据的加载方式
+const (
+	ImageLoadNone      = ""          // 不加载图片数据
+	ImageLoadFull      = "full"      // 加载原图（ImageData）
+	ImageLoadThumbnail = "thumbnail" // 只加载缩略图（Thumbnail），用于列表渲染
+)
+
 // SearchClipboardItems 搜索剪贴板项目
-// loadImageData: 是否加载图片数据（极简模式下需要显示图片缩略图）
-func SearchClipboardItems(isFavorite bool, keyword string, filterType string, limit int, loadImageData bool) ([]ClipboardItem, error) {
+// tagID: 只返回带有该标签的项目（空字符串表示不过滤）
+// imageMode: 图片数据加载方式，见 ImageLoadNone / ImageLoadFull / ImageLoadThumbnail
+func SearchClipboardItems(isFavorite bool, keyword string, filterType string, tagID string, limit int, imageMode string) ([]ClipboardItem, error) {
 	if DB == nil {
 		return nil, fmt.Errorf("数据库未初始化")
 	}
 
-	// 根据 loadImageData 参数决定是否加载图片数据
-	var imageDataField string
-	if loadImageData {
+	// 根据 imageMode 参数决定加载原图还是缩略图
+	imageDataField := "NULL as image_data"
+	thumbnailField := "NULL as thumbnail"
+	switch imageMode {
+	case ImageLoadFull:
 		imageDataField = "image_data"
-	} else {
-		imageDataField = "NULL as image_data"
+	case ImageLoadThumbnail:
+		thumbnailField = "thumbnail"
 	}
 
 	// 构建 WHERE 子句（优化：先应用可以使用索引的条件，减少后续 LIKE 扫描的数据量）
@@ -394,11 +489,27 @@ func SearchClipboardItems(isFavorite bool, keyword string, filterType string, li
 		args = append(args, filterType)
 	}
 
-	// 最后应用无法使用索引的 LIKE 搜索（此时数据量已减少，性能更好）
-	if keyword != "" {
-		whereClauses = append(whereClauses, `(content LIKE ? COLLATE NOCASE OR ocr_text LIKE ? COLLATE NOCASE)`)
-		keywordPattern := "%" + keyword + "%"
-		args = append(args, keywordPattern, keywordPattern)
+	if tagID != "" {
+		whereClauses = append(whereClauses, tagFilterClause)
+		args = append(args, tagID)
+	}
+
+	if err := checkStoreUnlocked(); err != nil {
+		return nil, err
+	}
+
+	// 关键词优先走全文索引；关键词过短或 FTS5 不可用时回退到 LIKE（此时数据量已减少，性能更好）
+	// 启用加密存储时数据库中是密文，改为解密后在内存中匹配
+	matchInMemory := keyword != "" && IsEncryptionEnabled()
+	if keyword != "" && !matchInMemory {
+		if useFTS(keyword) {
+			whereClauses = append(whereClauses, ftsMatchClause())
+			args = append(args, ftsPhraseQuery(keyword))
+		} else {
+			whereClauses = append(whereClauses, `(content LIKE ? COLLATE NOCASE OR ocr_text LIKE ? COLLATE NOCASE)`)
+			keywordPattern := "%" + keyword + "%"
+			args = append(args, keywordPattern, keywordPattern)
+		}
 	}
 
 	// 构建完整的 WHERE 子句
@@ -408,13 +519,17 @@ func SearchClipboardItems(isFavorite bool, keyword string, filterType string, li
 	}
 
 	query := fmt.Sprintf(`
-    SELECT id, content, content_type, COALESCE(content_hash, '') as content_hash, %s, file_paths, file_info, timestamp, source, char_count, word_count, COALESCE(is_favorite, 0) as is_favorite, COALESCE(ocr_text, '') as ocr_text
+    SELECT id, content, content_type, COALESCE(content_hash, '') as content_hash, %s, %s, file_paths, file_info, timestamp, source, char_count, word_count, COALESCE(is_favorite, 0) as is_favorite, COALESCE(ocr_text, '') as ocr_text, COALESCE(sensitive_category, '') as sensitive_category, COALESCE(sensitive_action, '') as sensitive_action, expires_at, COALESCE(max_pastes, 0) as max_pastes, COALESCE(paste_count, 0) as paste_count
 	FROM clipboard_items
 	%s
 	ORDER BY timestamp DESC LIMIT ?
-	`, imageDataField, whereClause)
+	`, imageDataField, thumbnailField, whereClause)
 
-	args = append(args, limit)
+	if matchInMemory {
+		args = append(args, -1)
+	} else {
+		args = append(args, limit)
+	}
 
 	rows, err := DB.Query(query, args...)
 	if err != nil {
@@ -431,6 +546,7 @@ func SearchClipboardItems(isFavorite bool, keyword string, filterType string, li
 			&item.ContentType,
 			&item.ContentHash,
 			&item.ImageData,
+			&item.Thumbnail,
 			&item.FilePaths,
 			&item.FileInfo,
 			&item.Timestamp,
@@ -439,12 +555,30 @@ func SearchClipboardItems(isFavorite bool, keyword string, filterType string, li
 			&item.WordCount,
 			&item.IsFavorite,
 			&item.OCRText,
+			&item.SensitiveCategory,
+			&item.SensitiveAction,
+			&item.ExpiresAt,
+			&item.MaxPastes,
+			&item.PasteCount,
 		)
 		if err != nil {
 			log.Printf("扫描行失败: %v", err)
 			continue
 		}
+		if err := decryptItem(&item); err != nil {
+			log.Printf("解密剪贴板项目失败: ID=%s, error=%v", item.ID, err)
+			continue
+		}
+		if matchInMemory && !containsFold(item.Content, keyword) && !containsFold(item.OCRText, keyword) {
+			continue
+		}
+		if imageMode == ImageLoadFull {
+			fillImageData(&item)
+		}
 		items = append(items, item)
+		if matchInMemory && limit >= 0 && len(items) >= limit {
+			break
+		}
 	}
 
 	return items, nil
@@ -495,42 +629,6 @@ func GetStatistics() (map[string]interface{}, error) {
 	return stats, nil
 }
 
-// initDefaultSettings 初始化默认设置
-func initDefaultSettings() error {
-	if DB == nil {
-		return fmt.Errorf("数据库未初始化")
-	}
-
-	// 检查设置是否已存在
-	var count int
-	err := DB.QueryRow("SELECT COUNT(*) FROM app_settings WHERE key = 'app_settings'").Scan(&count)
-	if err != nil {
-		return fmt.Errorf("检查设置失败: %v", err)
-	}
-
-	// 如果设置已存在，不覆盖
-	if count > 0 {
-		log.Println("设置已存在，跳过初始化")
-		return nil
-	}
-
-	// 创建默认设置 JSON（密码默认为空，表示不需要密码，快捷键默认为 Command+Option++c）
-	defaultSettings := `{"autoClean":true,"retentionDays":30,"pageSize":50,"password":"","hotkey":"Command+Option+c","doubleClickPaste":true}`
-
-	insertSQL := `
-	INSERT INTO app_settings (key, value, updated_at) 
-	VALUES ('app_settings', ?, datetime('now'))
-	`
-
-	_, err = DB.Exec(insertSQL, defaultSettings)
-	if err != nil {
-		return fmt.Errorf("初始化默认设置失败: %v", err)
-	}
-
-	log.Printf("✅ 已初始化默认设置: %s", defaultSettings)
-	return nil
-}
-
 // initDefaultTextRecord 初始化默认文本记录
 func initDefaultTextRecord() error {
 	if DB == nil {
@@ -631,169 +729,6 @@ func GetAllSettings() (map[string]string, error) {
 	return settings, nil
 }
 
-// migrateContentHash 为现有数据添加哈希值
-func migrateContentHash() error {
-	if DB == nil {
-		return fmt.Errorf("数据库未初始化")
-	}
-
-	// 查找所有没有哈希值的记录
-	query := `
-	SELECT id, content, content_type, image_data, file_paths
-	FROM clipboard_items 
-	WHERE content_hash IS NULL OR content_hash = ''
-	`
-
-	rows, err := DB.Query(query)
-	if err != nil {
-		return fmt.Errorf("查询需要迁移的记录失败: %v", err)
-	}
-	defer rows.Close()
-
-	var migratedCount int
-	updateSQL := `UPDATE clipboard_items SET content_hash = ? WHERE id = ?`
-
-	for rows.Next() {
-		var item ClipboardItem
-		err := rows.Scan(
-			&item.ID,
-			&item.Content,
-			&item.ContentType,
-			&item.ImageData,
-			&item.FilePaths,
-		)
-		if err != nil {
-			log.Printf("扫描迁移记录失败: %v", err)
-			continue
-		}
-
-		// 计算哈希值
-		contentHash := calculateContentHash(&item)
-		if contentHash == "" {
-			log.Printf("计算哈希失败，跳过记录: %s", item.ID)
-			continue
-		}
-
-		// 更新数据库
-		_, err = DB.Exec(updateSQL, contentHash, item.ID)
-		if err != nil {
-			log.Printf("更新记录哈希失败: %v (ID: %s)", err, item.ID)
-			continue
-		}
-
-		migratedCount++
-	}
-
-	if migratedCount > 0 {
-		log.Printf("✅ 成功为 %d 条现有记录添加了哈希值", migratedCount)
-	} else {
-		log.Printf("✅ 所有记录都已有哈希值，无需迁移")
-	}
-
-	return nil
-}
-
-// checkAndAddNewFields 检查并添加新字段（兼容老用户）
-func checkAndAddNewFields() error {
-	if DB == nil {
-		return fmt.Errorf("数据库未初始化")
-	}
-
-	// 检查content_hash字段是否存在
-	checkSQL := `SELECT COUNT(*) FROM pragma_table_info('clipboard_items') WHERE name = 'content_hash'`
-	var count int
-	err := DB.QueryRow(checkSQL).Scan(&count)
-	if err != nil {
-		return fmt.Errorf("检查content_hash字段是否存在失败: %v", err)
-	}
-
-	if count == 0 {
-		// 字段不存在，需要添加
-		log.Printf("🔧 检测到老版本数据库，正在添加content_hash字段...")
-
-		// 添加content_hash字段
-		alterSQL := `ALTER TABLE clipboard_items ADD COLUMN content_hash TEXT`
-		_, err := DB.Exec(alterSQL)
-		if err != nil {
-			return fmt.Errorf("添加content_hash字段失败: %v", err)
-		}
-		log.Printf("✅ 已添加content_hash字段")
-
-		// 创建索引
-		indexSQL := `CREATE INDEX IF NOT EXISTS idx_content_hash ON clipboard_items(content_hash, content_type)`
-		_, err = DB.Exec(indexSQL)
-		if err != nil {
-			return fmt.Errorf("创建content_hash索引失败: %v", err)
-		}
-		log.Printf("✅ 已创建content_hash索引")
-
-		// 为现有数据添加哈希值（只在字段刚添加时进行）
-		// if err := migrateContentHash(); err != nil {
-		// 	log.Printf("⚠️ 警告: 为现有数据添加哈希值失败: %v", err)
-		// 	// 不返回错误，允许应用继续运行
-		// }
-	} else {
-		log.Printf("✅ content_hash字段已存在")
-		// 字段已存在，但检查是否有未设置哈希值的记录（处理意外情况）
-		// if err := migrateContentHash(); err != nil {
-		// 	log.Printf("⚠️ 警告: 检查并更新哈希值失败: %v", err)
-		// 	// 不返回错误，允许应用继续运行
-		// }
-	}
-
-	// 检查 ocr_text 字段是否存在
-	checkOCRSQL := `SELECT COUNT(*) FROM pragma_table_info('clipboard_items') WHERE name = 'ocr_text'`
-	var ocrCount int
-	err = DB.QueryRow(checkOCRSQL).Scan(&ocrCount)
-	if err != nil {
-		return fmt.Errorf("检查ocr_text字段是否存在失败: %v", err)
-	}
-
-	if ocrCount == 0 {
-		// 字段不存在，需要添加
-		log.Printf("🔧 检测到老版本数据库，正在添加ocr_text字段...")
-
-		// 添加ocr_text字段
-		alterSQL := `ALTER TABLE clipboard_items ADD COLUMN ocr_text TEXT`
-		_, err := DB.Exec(alterSQL)
-		if err != nil {
-			return fmt.Errorf("添加ocr_text字段失败: %v", err)
-		}
-		log.Printf("✅ 已添加ocr_text字段")
-
-		// 创建索引 前后都有通配符索引不用上
-		// indexSQL := `CREATE INDEX IF NOT EXISTS idx_ocr_text ON clipboard_items(ocr_text)`
-		// _, err = DB.Exec(indexSQL)
-		// if err != nil {
-		// 	return fmt.Errorf("创建ocr_text索引失败: %v", err)
-		// }
-		// log.Printf("✅ 已创建ocr_text索引")
-	} else {
-		log.Printf("✅ ocr_text字段已存在")
-	}
-
-	// 检查 is_favorite 字段是否存在
-	checkFavSQL := `SELECT COUNT(*) FROM pragma_table_info('clipboard_items') WHERE name = 'is_favorite'`
-	var favCount int
-	if err := DB.QueryRow(checkFavSQL).Scan(&favCount); err != nil {
-		return fmt.Errorf("检查is_favorite字段是否存在失败: %v", err)
-	}
-	if favCount == 0 {
-		log.Printf("🔧 检测到老版本数据库，正在添加is_favorite字段...")
-		alterFavSQL := `ALTER TABLE clipboard_items ADD COLUMN is_favorite INTEGER DEFAULT 0`
-		if _, err := DB.Exec(alterFavSQL); err != nil {
-			return fmt.Errorf("添加is_favorite字段失败: %v", err)
-		}
-		log.Printf("✅ 已添加is_favorite字段")
-		// 索引可选：按收藏筛选时提升性能
-		_, _ = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_is_favorite ON clipboard_items(is_favorite)`)
-	} else {
-		log.Printf("✅ is_favorite字段已存在")
-	}
-
-	return nil
-}
-
 // CloseDB 关闭数据库连接
 func CloseDB() error {
 	if DB != nil {
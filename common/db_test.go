@@ -0,0 +1,93 @@
+package common
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// openTestDB 在临时目录中打开数据库并执行前 upTo 个迁移（upTo <= 0 表示全部），测试结束后关闭
+func openTestDB(t *testing.T, upTo int) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := initBlobStore(dir); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "clipboard.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	DB = db
+	t.Cleanup(func() {
+		db.Close()
+		DB = nil
+		blobDir = ""
+	})
+
+	if err := ensureMigrationTable(); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range migrations {
+		if upTo > 0 && m.version > upTo {
+			break
+		}
+		if _, err := applyMigration(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSaveClipboardItemDuplicateExpiry(t *testing.T) {
+	now := time.Now()
+	soon, later := now.Add(5*time.Minute), now.Add(time.Hour)
+	tests := []struct {
+		name      string
+		existing  *time.Time
+		recapture *time.Time
+		sensitive bool
+		want      *time.Time
+	}{
+		{name: "永久项目不受无过期时间的重复捕获影响", existing: nil, recapture: nil, want: nil},
+		{name: "已有过期时间不会被取消", existing: &soon, recapture: nil, want: &soon},
+		{name: "捕获规则让永久项目过期", existing: nil, recapture: &later, want: &later},
+		{name: "更晚的过期时间不会延长原值", existing: &soon, recapture: &later, sensitive: true, want: &soon},
+		{name: "更早的过期时间生效", existing: &later, recapture: &soon, sensitive: true, want: &soon},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			openTestDB(t, 0)
+			first := ClipboardItem{ID: "first", Content: "token", ContentType: "Text", Timestamp: now, ExpiresAt: tt.existing}
+			first.ContentHash = calculateContentHash(&first)
+			if err := SaveClipboardItem(&first); err != nil {
+				t.Fatal(err)
+			}
+
+			again := ClipboardItem{ID: "again", Content: "token", ContentType: "Text", ContentHash: first.ContentHash,
+				Timestamp: now.Add(time.Second), ExpiresAt: tt.recapture}
+			if tt.sensitive {
+				again.SensitiveCategory, again.SensitiveAction = "password", SensitiveActionExpire
+			}
+			if err := SaveClipboardItem(&again); err != nil {
+				t.Fatal(err)
+			}
+			if again.ID != "first" {
+				t.Fatalf("重复项目 ID = %s，期望对齐为 first", again.ID)
+			}
+
+			got, err := GetClipboardItemByID("first")
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch {
+			case tt.want == nil && got.ExpiresAt != nil:
+				t.Errorf("ExpiresAt = %v，期望不过期", *got.ExpiresAt)
+			case tt.want != nil && (got.ExpiresAt == nil || !got.ExpiresAt.Equal(*tt.want)):
+				t.Errorf("ExpiresAt = %v，期望 %v", got.ExpiresAt, *tt.want)
+			}
+			if tt.sensitive && got.SensitiveAction != SensitiveActionExpire {
+				t.Errorf("SensitiveAction = %q，期望刷新为 %q", got.SensitiveAction, SensitiveActionExpire)
+			}
+		})
+	}
+}
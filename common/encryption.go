@@ -0,0 +1,881 @@
+package common
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// 加密存储：content、ocr_text、file_paths、file_info、thumbnail 和图片文件使用 AES-256-GCM 加密
+//
+// 数据密钥（DEK）随机生成，用应用密码经 argon2id 派生的密钥（KEK）加密后保存在 app_settings 的
+// encryption_key 中；修改密码只需要重新加密 DEK，不必重写所有数据。
+// 启动后存储处于锁定状态，输入密码解锁后才能读写加密内容。
+//
+// 加密后的文本字段以 "enc:v1:" 开头，图片文件以 "CSENC1" 开头；没有前缀的数据视为明文，
+// 因此加密/解密迁移中断后可以继续执行，不会丢失数据。
+// content_hash 用于去重和图片文件寻址，不能加密；启用加密后改为以数据密钥派生的 HMAC（见 keyContentHash），
+// 不再是明文内容的 SHA-256，无法通过哈希确认数据库中是否保存了某段已知内容。
+
+// ErrStoreLocked 存储已加密但尚未解锁
+var ErrStoreLocked = errors.New("存储已加密，请先解锁")
+
+// ErrWrongPassword 密码无法解开数据密钥
+var ErrWrongPassword = errors.New("密码错误")
+
+const (
+	encryptionKeySetting = "encryption_key"
+	encryptedTextPrefix  = "enc:v1:"
+	encryptedBlobMagic   = "CSENC1"
+	encryptionKeySize    = 32
+
+	// encryptionMigrateBatch 加密/解密迁移每批处理的记录数
+	encryptionMigrateBatch = 200
+)
+
+// 迁移状态：记录在密钥中，中断后解锁时继续执行
+const (
+	encryptionStateReady      = ""
+	encryptionStateEncrypting = "encrypting"
+	encryptionStateDecrypting = "decrypting"
+)
+
+// encryptionKeyRecord 保存在 app_settings 中的密钥信息
+type encryptionKeyRecord struct {
+	Version int `json:"version"`
+	kdfParams
+	WrappedKey string `json:"wrappedKey"`
+	State      string `json:"state,omitempty"`
+}
+
+// EncryptionStatus 加密存储状态
+type EncryptionStatus struct {
+	Enabled   bool   `json:"enabled"`
+	Locked    bool   `json:"locked"`
+	Migrating string `json:"migrating"` // 正在进行（或中断）的迁移：encrypting / decrypting
+}
+
+var (
+	encryptionMu sync.RWMutex
+	// encryptionKey 为 nil 表示未启用加密
+	encryptionKey *encryptionKeyRecord
+	// dataAEAD 为 nil 表示已锁定
+	dataAEAD cipher.AEAD
+	// contentHashKey 计算 content_hash 的 HMAC 密钥，与 dataAEAD 同时设置和清除
+	contentHashKey []byte
+
+	// encryptionMigrateMu 保证同一时间只有一个加密/解密迁移
+	encryptionMigrateMu sync.Mutex
+)
+
+// loadEncryptionState 从数据库读取密钥信息（启动时调用，存储处于锁定状态）
+func loadEncryptionState() error {
+	value, err := GetSetting(encryptionKeySetting)
+	if err != nil {
+		return err
+	}
+
+	encryptionMu.Lock()
+	defer encryptionMu.Unlock()
+	dataAEAD = nil
+	encryptionKey = nil
+	if value == "" {
+		return nil
+	}
+
+	var record encryptionKeyRecord
+	if err := json.Unmarshal([]byte(value), &record); err != nil {
+		return fmt.Errorf("解析加密密钥失败: %v", err)
+	}
+	encryptionKey = &record
+	log.Printf("🔐 剪贴板历史已加密，等待解锁")
+	return nil
+}
+
+// IsEncryptionEnabled 是否启用了加密存储
+func IsEncryptionEnabled() bool {
+	encryptionMu.RLock()
+	defer encryptionMu.RUnlock()
+	return encryptionKey != nil
+}
+
+// GetEncryptionStatus 获取加密存储状态
+func GetEncryptionStatus() EncryptionStatus {
+	encryptionMu.RLock()
+	defer encryptionMu.RUnlock()
+	if encryptionKey == nil {
+		return EncryptionStatus{}
+	}
+	return EncryptionStatus{Enabled: true, Locked: dataAEAD == nil, Migrating: encryptionKey.State}
+}
+
+// checkStoreUnlocked 启用加密但未解锁时返回 ErrStoreLocked
+func checkStoreUnlocked() error {
+	encryptionMu.RLock()
+	defer encryptionMu.RUnlock()
+	if encryptionKey != nil && dataAEAD == nil {
+		return ErrStoreLocked
+	}
+	return nil
+}
+
+// EnableEncryption 启用加密存储并加密已有数据（password 为应用密码，由调用方验证）
+func EnableEncryption(password string) error {
+	if DB == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	if password == "" {
+		return fmt.Errorf("请先设置应用密码")
+	}
+	if IsEncryptionEnabled() {
+		return fmt.Errorf("已启用加密存储")
+	}
+
+	dek := make([]byte, encryptionKeySize)
+	if _, err := rand.Read(dek); err != nil {
+		return fmt.Errorf("生成数据密钥失败: %v", err)
+	}
+	record, err := newEncryptionKeyRecord(password, dek)
+	if err != nil {
+		return err
+	}
+	aead, err := newAEAD(dek)
+	if err != nil {
+		return err
+	}
+
+	// 先保存密钥再加密数据：迁移中断时已加密的数据仍然可以解开
+	record.State = encryptionStateEncrypting
+	if err := saveEncryptionKeyRecord(record); err != nil {
+		return err
+	}
+	encryptionMu.Lock()
+	encryptionKey = record
+	dataAEAD = aead
+	contentHashKey = deriveContentHashKey(dek)
+	encryptionMu.Unlock()
+
+	// 全文索引保存的是明文，启用加密后删除，搜索改为解密后匹配
+	if err := dropFTSIndex(); err != nil {
+		return err
+	}
+
+	log.Printf("🔐 已启用加密存储，开始加密已有数据")
+	return runEncryptionMigration(encryptionStateEncrypting)
+}
+
+// DisableEncryption 关闭加密存储并把数据还原为明文（通过 UnlockStore 验证密码，输错计入失败次数）
+func DisableEncryption(password string) error {
+	if DB == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	if !IsEncryptionEnabled() {
+		return fmt.Errorf("未启用加密存储")
+	}
+	if err := UnlockStore(password); err != nil {
+		return err
+	}
+
+	encryptionMu.Lock()
+	record := *encryptionKey
+	record.State = encryptionStateDecrypting
+	encryptionKey = &record
+	encryptionMu.Unlock()
+	if err := saveEncryptionKeyRecord(&record); err != nil {
+		return err
+	}
+
+	log.Printf("🔓 正在关闭加密存储，开始解密已有数据")
+	if err := runEncryptionMigration(encryptionStateDecrypting); err != nil {
+		return err
+	}
+
+	// 全部解密后再删除密钥
+	if _, err := DB.Exec(`DELETE FROM app_settings WHERE key = ?`, encryptionKeySetting); err != nil {
+		return fmt.Errorf("删除加密密钥失败: %v", err)
+	}
+	encryptionMu.Lock()
+	encryptionKey = nil
+	dataAEAD = nil
+	contentHashKey = nil
+	encryptionMu.Unlock()
+
+	if err := ensureFTSIndex(); err != nil {
+		log.Printf("警告: 重建全文索引失败: %v", err)
+		ftsEnabled.Store(false)
+	}
+	log.Printf("✅ 已关闭加密存储")
+	return nil
+}
+
+// UnlockStore 用应用密码解锁加密存储（未启用加密时直接返回）
+// 与 VerifyPassword 一样先检查锁定状态并记录失败次数，输错次数过多时返回 *LockoutError
+func UnlockStore(password string) error {
+	if !IsEncryptionEnabled() {
+		return nil
+	}
+	credentialMu.Lock()
+	defer credentialMu.Unlock()
+
+	valid, err := checkPasswordLocked(password)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return ErrWrongPassword
+	}
+	return unlockStore(password)
+}
+
+// unlockStore 用已经验证过的密码解开数据密钥（调用方持有 credentialMu）
+func unlockStore(password string) error {
+	encryptionMu.RLock()
+	record := encryptionKey
+	unlocked := dataAEAD != nil
+	encryptionMu.RUnlock()
+	if record == nil {
+		return nil
+	}
+
+	dek, err := unwrapDataKey(record, password)
+	if err != nil {
+		return err
+	}
+	aead, err := newAEAD(dek)
+	if err != nil {
+		return err
+	}
+	encryptionMu.Lock()
+	dataAEAD = aead
+	contentHashKey = deriveContentHashKey(dek)
+	encryptionMu.Unlock()
+	if unlocked {
+		return nil
+	}
+	log.Printf("🔓 加密存储已解锁")
+
+	// 继续上次中断的迁移，并补上锁定期间跳过的缩略图
+	if record.State != encryptionStateReady {
+		go func() {
+			if err := runEncryptionMigration(record.State); err != nil {
+				log.Printf("⚠️ 继续加密迁移失败: %v", err)
+			}
+		}()
+	}
+	go backfillThumbnails()
+	return nil
+}
+
+// LockStore 锁定加密存储，丢弃内存中的数据密钥
+func LockStore() {
+	encryptionMu.Lock()
+	defer encryptionMu.Unlock()
+	if encryptionKey == nil || dataAEAD == nil {
+		return
+	}
+	dataAEAD = nil
+	contentHashKey = nil
+	log.Printf("🔒 加密存储已锁定")
+}
+
+// rewrapEncryptionKey 用新密码重新加密 DEK（修改密码时调用），返回新的密钥信息，由调用方保存
+// 未启用加密时返回 nil
+func rewrapEncryptionKey(oldPassword string, newPassword string) (*encryptionKeyRecord, error) {
+	encryptionMu.RLock()
+	record := encryptionKey
+	encryptionMu.RUnlock()
+	if record == nil {
+		return nil, nil
+	}
+
+	dek, err := unwrapDataKey(record, oldPassword)
+	if err != nil {
+		return nil, err
+	}
+	rewrapped, err := newEncryptionKeyRecord(newPassword, dek)
+	if err != nil {
+		return nil, err
+	}
+	rewrapped.State = record.State
+	return rewrapped, nil
+}
+
+// applyEncryptionKeyRecord 保存后更新内存中的密钥信息
+func applyEncryptionKeyRecord(record *encryptionKeyRecord) {
+	encryptionMu.Lock()
+	defer encryptionMu.Unlock()
+	if encryptionKey != nil {
+		encryptionKey = record
+	}
+}
+
+// newEncryptionKeyRecord 生成新的盐并用密码派生的密钥加密 DEK
+func newEncryptionKeyRecord(password string, dek []byte) (*encryptionKeyRecord, error) {
+	params, err := newKDFParams()
+	if err != nil {
+		return nil, err
+	}
+	record := &encryptionKeyRecord{Version: 1, kdfParams: params}
+	kek, err := record.derive(password, encryptionKeySize)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(kek)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := sealWith(aead, dek)
+	if err != nil {
+		return nil, err
+	}
+	record.WrappedKey = base64.StdEncoding.EncodeToString(wrapped)
+	return record, nil
+}
+
+// unwrapDataKey 用密码解开 DEK，密码错误时返回 ErrWrongPassword
+func unwrapDataKey(record *encryptionKeyRecord, password string) ([]byte, error) {
+	kek, err := record.derive(password, encryptionKeySize)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(kek)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(record.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("解析加密密钥失败: %v", err)
+	}
+	dek, err := openWith(aead, wrapped)
+	if err != nil {
+		return nil, ErrWrongPassword
+	}
+	return dek, nil
+}
+
+// saveEncryptionKeyRecord 保存密钥信息
+func saveEncryptionKeyRecord(record *encryptionKeyRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("序列化加密密钥失败: %v", err)
+	}
+	return SaveSetting(encryptionKeySetting, string(data))
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("初始化加密失败: %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("初始化加密失败: %v", err)
+	}
+	return aead, nil
+}
+
+// sealWith 加密数据，返回 nonce + 密文
+func sealWith(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("生成随机数失败: %v", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// openWith 解密 sealWith 的输出
+func openWith(aead cipher.AEAD, data []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("密文长度无效")
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
+
+// currentAEAD 返回当前的数据密钥；未启用加密时返回 nil，已锁定时返回 ErrStoreLocked
+func currentAEAD() (cipher.AEAD, error) {
+	encryptionMu.RLock()
+	defer encryptionMu.RUnlock()
+	if encryptionKey == nil {
+		return nil, nil
+	}
+	if dataAEAD == nil {
+		return nil, ErrStoreLocked
+	}
+	return dataAEAD, nil
+}
+
+// writeAEAD 返回写入新数据时使用的数据密钥；正在关闭加密时返回 nil，新数据直接以明文写入
+func writeAEAD() (cipher.AEAD, error) {
+	encryptionMu.RLock()
+	decrypting := encryptionKey != nil && encryptionKey.State == encryptionStateDecrypting
+	encryptionMu.RUnlock()
+	if decrypting {
+		return nil, nil
+	}
+	return currentAEAD()
+}
+
+// deriveContentHashKey 从数据密钥派生计算 content_hash 的 HMAC 密钥（不直接复用加密密钥）
+func deriveContentHashKey(dek []byte) []byte {
+	mac := hmac.New(sha256.New, dek)
+	mac.Write([]byte("clipsave content hash v1"))
+	return mac.Sum(nil)
+}
+
+// keyContentHash 把明文内容的 SHA-256（十六进制）转换为写入 content_hash 的值：
+// 未启用加密或正在关闭加密时原样返回；启用加密时返回 HMAC-SHA256，长度不变，仍可作为图片文件名；
+// 已锁定时返回空字符串（不做去重，保存时由 encryptItemFields 返回 ErrStoreLocked）
+func keyContentHash(plainHash string) string {
+	if plainHash == "" {
+		return ""
+	}
+	encryptionMu.RLock()
+	defer encryptionMu.RUnlock()
+	if encryptionKey == nil || encryptionKey.State == encryptionStateDecrypting {
+		return plainHash
+	}
+	if contentHashKey == nil {
+		return ""
+	}
+	mac := hmac.New(sha256.New, contentHashKey)
+	mac.Write([]byte(plainHash))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// encryptText 加密文本字段（未启用加密或内容为空时原样返回）
+func encryptText(s string) (string, error) {
+	if s == "" || strings.HasPrefix(s, encryptedTextPrefix) {
+		return s, nil
+	}
+	aead, err := writeAEAD()
+	if err != nil || aead == nil {
+		return s, err
+	}
+	sealed, err := sealWith(aead, []byte(s))
+	if err != nil {
+		return "", err
+	}
+	return encryptedTextPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptText 解密文本字段（明文原样返回）
+func decryptText(s string) (string, error) {
+	if !strings.HasPrefix(s, encryptedTextPrefix) {
+		return s, nil
+	}
+	aead, err := currentAEAD()
+	if err != nil {
+		return "", err
+	}
+	if aead == nil {
+		// 密钥已删除但仍有密文，只可能是数据损坏
+		return "", fmt.Errorf("缺少加密密钥")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(s[len(encryptedTextPrefix):])
+	if err != nil {
+		return "", fmt.Errorf("解析密文失败: %v", err)
+	}
+	plain, err := openWith(aead, sealed)
+	if err != nil {
+		return "", fmt.Errorf("解密失败: %v", err)
+	}
+	return string(plain), nil
+}
+
+// encryptBytes 加密图片等二进制数据（未启用加密或数据为空时原样返回）
+func encryptBytes(data []byte) ([]byte, error) {
+	if len(data) == 0 || bytes.HasPrefix(data, []byte(encryptedBlobMagic)) {
+		return data, nil
+	}
+	aead, err := writeAEAD()
+	if err != nil || aead == nil {
+		return data, err
+	}
+	sealed, err := sealWith(aead, data)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(encryptedBlobMagic), sealed...), nil
+}
+
+// decryptBytes 解密 encryptBytes 的输出（明文原样返回）
+func decryptBytes(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte(encryptedBlobMagic)) {
+		return data, nil
+	}
+	aead, err := currentAEAD()
+	if err != nil {
+		return nil, err
+	}
+	if aead == nil {
+		return nil, fmt.Errorf("缺少加密密钥")
+	}
+	plain, err := openWith(aead, data[len(encryptedBlobMagic):])
+	if err != nil {
+		return nil, fmt.Errorf("解密失败: %v", err)
+	}
+	return plain, nil
+}
+
+// encryptedItemFields 加密后写入数据库的字段
+type encryptedItemFields struct {
+	content, ocrText, filePaths, fileInfo string
+	thumbnail                             []byte
+}
+
+// encryptItemFields 加密项目的敏感字段（不修改 item 本身）
+func encryptItemFields(item *ClipboardItem) (*encryptedItemFields, error) {
+	var fields encryptedItemFields
+	var err error
+	if fields.content, err = encryptText(item.Content); err != nil {
+		return nil, err
+	}
+	if fields.ocrText, err = encryptText(item.OCRText); err != nil {
+		return nil, err
+	}
+	if fields.filePaths, err = encryptText(item.FilePaths); err != nil {
+		return nil, err
+	}
+	if fields.fileInfo, err = encryptText(item.FileInfo); err != nil {
+		return nil, err
+	}
+	if fields.thumbnail, err = encryptBytes(item.Thumbnail); err != nil {
+		return nil, err
+	}
+	return &fields, nil
+}
+
+// decryptItem 解密从数据库读出的项目
+func decryptItem(item *ClipboardItem) error {
+	var err error
+	if item.Content, err = decryptText(item.Content); err != nil {
+		return err
+	}
+	if item.OCRText, err = decryptText(item.OCRText); err != nil {
+		return err
+	}
+	if item.FilePaths, err = decryptText(item.FilePaths); err != nil {
+		return err
+	}
+	if item.FileInfo, err = decryptText(item.FileInfo); err != nil {
+		return err
+	}
+	if item.Thumbnail, err = decryptBytes(item.Thumbnail); err != nil {
+		return err
+	}
+	return nil
+}
+
+// runEncryptionMigration 加密（或解密）所有记录和图片文件，完成后清除迁移状态并整理数据库
+func runEncryptionMigration(state string) error {
+	encryptionMigrateMu.Lock()
+	defer encryptionMigrateMu.Unlock()
+
+	// 已被其他调用完成
+	encryptionMu.RLock()
+	record := encryptionKey
+	encryptionMu.RUnlock()
+	if record == nil || record.State != state {
+		return nil
+	}
+
+	encrypt := state == encryptionStateEncrypting
+	rowCount, err := migrateEncryptedRows(encrypt)
+	if err != nil {
+		return err
+	}
+	runCount, err := migrateScriptRunOutputs(encrypt)
+	if err != nil {
+		return err
+	}
+	// content_hash 改用（或不再使用）HMAC，图片文件随之换到新地址，旧文件引用归零后清理
+	hashCount, err := rekeyContentHashes()
+	if err != nil {
+		return err
+	}
+	collectImageBlobs()
+	blobCount, err := migrateEncryptedBlobs(encrypt)
+	if err != nil {
+		return err
+	}
+	log.Printf("✅ 已处理 %d 条记录、%d 条脚本执行记录、%d 个内容哈希、%d 个图片文件", rowCount, runCount, hashCount, blobCount)
+
+	if encrypt {
+		encryptionMu.Lock()
+		done := *encryptionKey
+		done.State = encryptionStateReady
+		encryptionKey = &done
+		encryptionMu.Unlock()
+		if err := saveEncryptionKeyRecord(&done); err != nil {
+			return err
+		}
+	}
+
+	// 重写后旧的明文仍可能留在空闲页中，整理数据库将其清除
+	if _, err := DB.Exec(`VACUUM`); err != nil {
+		log.Printf("⚠️ 整理数据库失败: %v", err)
+	}
+	return nil
+}
+
+// encryptedColumns 需要加密的文本列
+var encryptedColumns = []string{"content", "ocr_text", "file_paths", "file_info"}
+
+// migrateEncryptedRows 分批加密或解密 clipboard_items 中的字段，返回修改的记录数
+// 每个字段按原值条件更新，迁移期间被其他写入修改过的字段保持不变
+func migrateEncryptedRows(encrypt bool) (int, error) {
+	transformText := decryptText
+	transformBytes := decryptBytes
+	if encrypt {
+		transformText = encryptText
+		transformBytes = encryptBytes
+	}
+
+	changed := 0
+	var lastRowID int64
+	for {
+		rows, err := DB.Query(`SELECT rowid, id, COALESCE(content, ''), COALESCE(ocr_text, ''), COALESCE(file_paths, ''), COALESCE(file_info, ''), thumbnail
+		FROM clipboard_items WHERE rowid > ? ORDER BY rowid LIMIT ?`, lastRowID, encryptionMigrateBatch)
+		if err != nil {
+			return changed, fmt.Errorf("查询待迁移记录失败: %v", err)
+		}
+		type pendingUpdate struct {
+			id     string
+			column string
+			old    interface{}
+			value  interface{}
+		}
+		var updates []pendingUpdate
+		count := 0
+		for rows.Next() {
+			var id string
+			values := make([]string, len(encryptedColumns))
+			var thumbnail []byte
+			dest := []interface{}{&lastRowID, &id}
+			for i := range values {
+				dest = append(dest, &values[i])
+			}
+			dest = append(dest, &thumbnail)
+			if err := rows.Scan(dest...); err != nil {
+				rows.Close()
+				return changed, fmt.Errorf("扫描待迁移记录失败: %v", err)
+			}
+			count++
+
+			for i, column := range encryptedColumns {
+				value, err := transformText(values[i])
+				if err != nil {
+					rows.Close()
+					return changed, fmt.Errorf("迁移记录失败: ID=%s, %v", id, err)
+				}
+				if value != values[i] {
+					updates = append(updates, pendingUpdate{id, column, values[i], value})
+				}
+			}
+			value, err := transformBytes(thumbnail)
+			if err != nil {
+				rows.Close()
+				return changed, fmt.Errorf("迁移缩略图失败: ID=%s, %v", id, err)
+			}
+			if !bytes.Equal(value, thumbnail) {
+				updates = append(updates, pendingUpdate{id, "thumbnail", thumbnail, value})
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return changed, fmt.Errorf("查询待迁移记录失败: %v", err)
+		}
+		if count == 0 {
+			break
+		}
+
+		if len(updates) > 0 {
+			tx, err := DB.Begin()
+			if err != nil {
+				return changed, fmt.Errorf("开启事务失败: %v", err)
+			}
+			ids := map[string]bool{}
+			for _, u := range updates {
+				result, err := tx.Exec(fmt.Sprintf(`UPDATE clipboard_items SET %[1]s = ? WHERE id = ? AND COALESCE(%[1]s, '') = ?`, u.column), u.value, u.id, u.old)
+				if err != nil {
+					tx.Rollback()
+					return changed, fmt.Errorf("更新记录失败: %v", err)
+				}
+				if n, _ := result.RowsAffected(); n > 0 {
+					ids[u.id] = true
+				}
+			}
+			if err := tx.Commit(); err != nil {
+				return changed, fmt.Errorf("提交迁移失败: %v", err)
+			}
+			changed += len(ids)
+		}
+	}
+	return changed, nil
+}
+
+// rekeyContentHashes 按明文内容重新计算所有记录的 content_hash（keyContentHash 决定是否使用 HMAC），返回修改的记录数
+// 结果只取决于明文内容，迁移中断后重复执行不会重复转换；图片记录先把数据写到新地址再更新哈希
+func rekeyContentHashes() (int, error) {
+	changed := 0
+	var lastRowID int64
+	for {
+		rows, err := DB.Query(`SELECT rowid, id, content_type, COALESCE(content, ''), COALESCE(file_paths, ''), content_hash
+		FROM clipboard_items WHERE rowid > ? AND COALESCE(content_hash, '') != '' ORDER BY rowid LIMIT ?`, lastRowID, encryptionMigrateBatch)
+		if err != nil {
+			return changed, fmt.Errorf("查询待迁移记录失败: %v", err)
+		}
+		var batch []ClipboardItem
+		for rows.Next() {
+			var item ClipboardItem
+			if err := rows.Scan(&lastRowID, &item.ID, &item.ContentType, &item.Content, &item.FilePaths, &item.ContentHash); err != nil {
+				rows.Close()
+				return changed, fmt.Errorf("扫描待迁移记录失败: %v", err)
+			}
+			batch = append(batch, item)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return changed, fmt.Errorf("查询待迁移记录失败: %v", err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		for i := range batch {
+			ok, err := rekeyContentHash(&batch[i])
+			if err != nil {
+				return changed, err
+			}
+			if ok {
+				changed++
+			}
+		}
+	}
+	return changed, nil
+}
+
+// rekeyContentHash 重新计算单条记录的 content_hash（图片记录与写入和垃圾回收互斥）
+func rekeyContentHash(item *ClipboardItem) (bool, error) {
+	oldHash := item.ContentHash
+	var err error
+	if item.Content, err = decryptText(item.Content); err != nil {
+		return false, fmt.Errorf("迁移记录失败: ID=%s, %v", item.ID, err)
+	}
+	if item.FilePaths, err = decryptText(item.FilePaths); err != nil {
+		return false, fmt.Errorf("迁移记录失败: ID=%s, %v", item.ID, err)
+	}
+
+	if item.ContentType == "Image" {
+		blobMu.Lock()
+		defer blobMu.Unlock()
+		data, err := readImageBlob(oldHash)
+		if err != nil {
+			// 文件已丢失的记录保留原哈希（无法显示，也无法按内容重新寻址）
+			log.Printf("⚠️ 跳过图片记录: ID=%s, %v", item.ID, err)
+			return false, nil
+		}
+		item.ImageData = data
+	}
+	newHash := calculateContentHash(item)
+	if newHash == "" || newHash == oldHash {
+		return false, nil
+	}
+	if item.ContentType == "Image" {
+		if err := writeImageBlob(newHash, item.ImageData); err != nil {
+			return false, fmt.Errorf("迁移图片文件失败: ID=%s, %v", item.ID, err)
+		}
+	}
+
+	result, err := DB.Exec(`UPDATE clipboard_items SET content_hash = ? WHERE id = ? AND content_hash = ?`, newHash, item.ID, oldHash)
+	if err != nil {
+		return false, fmt.Errorf("更新内容哈希失败: %v", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// migrateEncryptedBlobs 加密或解密图片目录中的所有文件（包括尚未清理的无引用文件），返回修改的文件数
+func migrateEncryptedBlobs(encrypt bool) (int, error) {
+	if blobDir == "" {
+		return 0, fmt.Errorf("图片存储未初始化")
+	}
+	var hashes []string
+	err := filepath.WalkDir(blobDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		// 跳过临时文件等不是按哈希命名的文件
+		if _, err := blobPath(d.Name()); err != nil {
+			return nil
+		}
+		hashes = append(hashes, d.Name())
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("遍历图片目录失败: %v", err)
+	}
+
+	changed := 0
+	for _, hash := range hashes {
+		ok, err := migrateEncryptedBlob(hash, encrypt)
+		if err != nil {
+			return changed, err
+		}
+		if ok {
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+// migrateEncryptedBlob 加密或解密单个图片文件（与写入和垃圾回收互斥）
+func migrateEncryptedBlob(hash string, encrypt bool) (bool, error) {
+	blobMu.Lock()
+	defer blobMu.Unlock()
+
+	path, err := blobPath(hash)
+	if err != nil {
+		return false, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("读取图片文件失败: %v", err)
+	}
+
+	var converted []byte
+	if encrypt {
+		converted, err = encryptBytes(data)
+	} else {
+		converted, err = decryptBytes(data)
+	}
+	if err != nil {
+		return false, fmt.Errorf("迁移图片文件失败: %s, %v", hash, err)
+	}
+	if bytes.Equal(converted, data) {
+		return false, nil
+	}
+	if err := writeFileAtomic(path, hash, converted); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,162 @@
+package common
+
+import (
+	"bytes"
+	"encoding/hex"
+	"image/color"
+	"os"
+	"testing"
+	"time"
+)
+
+// resetEncryptionState 测试结束后清除内存中的加密状态（数据库由 openTestDB 关闭）
+func resetEncryptionState(t *testing.T) {
+	t.Cleanup(func() {
+		encryptionMu.Lock()
+		encryptionKey = nil
+		dataAEAD = nil
+		contentHashKey = nil
+		encryptionMu.Unlock()
+	})
+}
+
+func storedContentHash(t *testing.T, id string) string {
+	t.Helper()
+	var hash string
+	if err := DB.QueryRow(`SELECT content_hash FROM clipboard_items WHERE id = ?`, id).Scan(&hash); err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestEncryptionRekeysContentHashes(t *testing.T) {
+	openTestDB(t, 0)
+	resetEncryptionState(t)
+	setTestPassword(t, "test-pw")
+
+	imageData := testPNG(t, color.RGBA{R: 200, A: 255})
+	items := []ClipboardItem{
+		{ID: "text", Content: "hello", ContentType: "Text"},
+		{ID: "url", Content: "https://example.com", ContentType: "URL"},
+		{ID: "file", Content: "a.txt", ContentType: "File", FilePaths: `["/tmp/b.txt","/tmp/a.txt"]`},
+		{ID: "image", Content: "图片 4x4 (png)", ContentType: "Image", ImageData: imageData},
+	}
+	plain := make(map[string]string)
+	for i := range items {
+		item := items[i]
+		item.Timestamp = time.Now()
+		item.ContentHash = calculateContentHash(&item)
+		plain[item.ID] = item.ContentHash
+		if item.ContentHash != plainContentHash(&item) {
+			t.Fatalf("%s: 未启用加密时 content_hash 应该是明文 SHA-256", item.ID)
+		}
+		if err := SaveClipboardItem(&item); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := EnableEncryption("test-pw"); err != nil {
+		t.Fatal(err)
+	}
+	keyed := make(map[string]string)
+	for _, item := range items {
+		got := storedContentHash(t, item.ID)
+		if got == plain[item.ID] || len(got) != len(plain[item.ID]) {
+			t.Errorf("%s: 启用加密后 content_hash = %s，期望不同于明文哈希的 HMAC", item.ID, got)
+		}
+		if want := keyContentHash(plain[item.ID]); got != want {
+			t.Errorf("%s: content_hash = %s，期望 %s", item.ID, got, want)
+		}
+		keyed[item.ID] = got
+	}
+
+	// 图片文件换到新地址并加密，明文地址的文件被清理
+	if p, _ := blobPath(plain["image"]); fileExists(p) {
+		t.Error("明文哈希命名的图片文件没有被删除")
+	}
+	p, _ := blobPath(keyed["image"])
+	raw, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatalf("新地址的图片文件不存在: %v", err)
+	}
+	if !bytes.HasPrefix(raw, []byte(encryptedBlobMagic)) {
+		t.Error("新地址的图片文件没有加密")
+	}
+	if got, err := GetClipboardItemByID("image"); err != nil || !bytes.Equal(got.ImageData, imageData) {
+		t.Fatalf("启用加密后读取图片失败: %v", err)
+	}
+
+	// 去重使用新的哈希
+	again := ClipboardItem{ID: "again", Content: "hello", ContentType: "Text", Timestamp: time.Now()}
+	again.ContentHash = calculateContentHash(&again)
+	if err := SaveClipboardItem(&again); err != nil || again.ID != "text" {
+		t.Fatalf("启用加密后重复内容没有去重: ID=%s, %v", again.ID, err)
+	}
+
+	// 存储保持解锁：从锁定状态解锁会启动后台补缩略图，测试结束关闭数据库后仍可能访问（锁定的情况见 TestKeyContentHash）
+	if err := DisableEncryption("test-pw"); err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range items {
+		if got := storedContentHash(t, item.ID); got != plain[item.ID] {
+			t.Errorf("%s: 关闭加密后 content_hash = %s，期望还原为 %s", item.ID, got, plain[item.ID])
+		}
+	}
+	if p, _ := blobPath(keyed["image"]); fileExists(p) {
+		t.Error("HMAC 命名的图片文件没有被删除")
+	}
+	p, _ = blobPath(plain["image"])
+	if raw, err := os.ReadFile(p); err != nil || !bytes.Equal(raw, imageData) {
+		t.Fatalf("关闭加密后图片文件没有还原为明文: %v", err)
+	}
+}
+
+func TestKeyContentHash(t *testing.T) {
+	resetEncryptionState(t)
+	const plainHash = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	key := deriveContentHashKey(bytes.Repeat([]byte{1}, encryptionKeySize))
+	otherKey := deriveContentHashKey(bytes.Repeat([]byte{2}, encryptionKeySize))
+
+	tests := []struct {
+		name   string
+		record *encryptionKeyRecord
+		key    []byte
+		plain  string
+		want   string // "keyed" 表示应为 HMAC
+	}{
+		{name: "未启用加密", plain: plainHash, want: plainHash},
+		{name: "空哈希", record: &encryptionKeyRecord{}, key: key, plain: "", want: ""},
+		{name: "已解锁", record: &encryptionKeyRecord{}, key: key, plain: plainHash, want: "keyed"},
+		{name: "正在加密", record: &encryptionKeyRecord{State: encryptionStateEncrypting}, key: key, plain: plainHash, want: "keyed"},
+		{name: "正在关闭加密", record: &encryptionKeyRecord{State: encryptionStateDecrypting}, key: key, plain: plainHash, want: plainHash},
+		{name: "已锁定", record: &encryptionKeyRecord{}, plain: plainHash, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encryptionMu.Lock()
+			encryptionKey, contentHashKey = tt.record, tt.key
+			encryptionMu.Unlock()
+
+			got := keyContentHash(tt.plain)
+			if tt.want != "keyed" {
+				if got != tt.want {
+					t.Errorf("keyContentHash = %q，期望 %q", got, tt.want)
+				}
+				return
+			}
+			if got == tt.plain || len(got) != len(tt.plain) {
+				t.Errorf("keyContentHash = %q，期望与明文哈希等长的 HMAC", got)
+			}
+			if _, err := hex.DecodeString(got); err != nil {
+				t.Errorf("HMAC 不是十六进制，不能作为图片文件名: %v", err)
+			}
+
+			encryptionMu.Lock()
+			contentHashKey = otherKey
+			encryptionMu.Unlock()
+			if keyContentHash(tt.plain) == got {
+				t.Error("不同的数据密钥应该得到不同的 content_hash")
+			}
+		})
+	}
+}
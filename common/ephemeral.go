@@ -0,0 +1,240 @@
+package common
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// 临时项目：到期（expires_at）或粘贴次数用完（max_pastes）后自动删除，
+// 删除时如果系统剪贴板中仍是该内容，一并清空剪贴板
+
+const (
+	// expiredItemsSweepInterval 定期检查过期项目的间隔（到期时间已知时会按时唤醒，不必等待）
+	expiredItemsSweepInterval = time.Minute
+	// pasteLimitGracePeriod 粘贴次数用完后保留的时间，留给用户手动粘贴
+	pasteLimitGracePeriod = time.Minute
+	// autoPasteExpireDelay 自动粘贴完成后删除的延迟，等待目标应用读取剪贴板
+	autoPasteExpireDelay = 2 * time.Second
+)
+
+var (
+	expirySweeperMu     sync.Mutex
+	expirySweeperCancel context.CancelFunc
+	expirySweeperWake   = make(chan struct{}, 1)
+
+	// ephemeralClipboard 用于检查和清空系统剪贴板（捕获器启动时设置）
+	ephemeralClipboard ClipboardBackend
+)
+
+// SetEphemeralClipboard 设置过期项目删除时检查和清空的剪贴板后端
+func SetEphemeralClipboard(backend ClipboardBackend) {
+	expirySweeperMu.Lock()
+	defer expirySweeperMu.Unlock()
+	ephemeralClipboard = backend
+}
+
+// SetItemExpiry 设置项目的过期方式：minutes 分钟后删除（0 表示不限时间），
+// 粘贴 maxPastes 次后删除（0 表示不限次数，1 即粘贴一次后删除）；两者都为 0 时取消过期
+func SetItemExpiry(id string, minutes int, maxPastes int) error {
+	if DB == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	if minutes < 0 || maxPastes < 0 {
+		return fmt.Errorf("无效的过期设置: %d 分钟, %d 次", minutes, maxPastes)
+	}
+
+	var expiresAt *time.Time
+	if minutes > 0 {
+		t := time.Now().Add(time.Duration(minutes) * time.Minute)
+		expiresAt = &t
+	}
+	result, err := DB.Exec(`UPDATE clipboard_items SET expires_at = ?, max_pastes = ?, paste_count = 0 WHERE id = ?`,
+		expiresAt, maxPastes, id)
+	if err != nil {
+		return fmt.Errorf("设置过期时间失败: %v", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return fmt.Errorf("未找到剪贴板项目")
+	}
+	if expiresAt != nil {
+		scheduleExpirySweep(*expiresAt)
+	}
+	return nil
+}
+
+// RecordItemPaste 记录一次复制/粘贴，粘贴次数用完时返回 true，项目在宽限期后删除
+func RecordItemPaste(id string) (bool, error) {
+	if DB == nil {
+		return false, fmt.Errorf("数据库未初始化")
+	}
+	var maxPastes, pasteCount int
+	err := DB.QueryRow(`UPDATE clipboard_items SET paste_count = COALESCE(paste_count, 0) + 1 WHERE id = ?
+		RETURNING COALESCE(max_pastes, 0), paste_count`, id).Scan(&maxPastes, &pasteCount)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("记录粘贴次数失败: %v", err)
+	}
+	if maxPastes <= 0 || pasteCount < maxPastes {
+		return false, nil
+	}
+	log.Printf("⏳ 项目粘贴次数已用完（%d/%d），即将删除: ID=%s", pasteCount, maxPastes, id)
+	return true, expireItemAfter(id, pasteLimitGracePeriod)
+}
+
+// ExpireAfterAutoPaste 自动粘贴完成后缩短粘贴次数已用完的项目的宽限期
+func ExpireAfterAutoPaste(id string) error {
+	return expireItemAfter(id, autoPasteExpireDelay)
+}
+
+// expireItemAfter 让项目在 delay 后过期（已有更早的到期时间时保留原值）
+func expireItemAfter(id string, delay time.Duration) error {
+	expiresAt := time.Now().Add(delay)
+	_, err := DB.Exec(`UPDATE clipboard_items SET expires_at = ? WHERE id = ? AND (expires_at IS NULL OR expires_at > ?)`,
+		expiresAt, id, expiresAt)
+	if err != nil {
+		return fmt.Errorf("设置过期时间失败: %v", err)
+	}
+	scheduleExpirySweep(expiresAt)
+	return nil
+}
+
+// DeleteExpiredItems 删除已过期的项目，系统剪贴板中仍是被删除的内容时清空剪贴板
+// 过期时间是为这个项目单独设置的，收藏和受保护标签不阻止删除（与批量清理不同）
+func DeleteExpiredItems() (int, error) {
+	if DB == nil {
+		return 0, fmt.Errorf("数据库未初始化")
+	}
+
+	rows, err := DB.Query(`DELETE FROM clipboard_items WHERE expires_at IS NOT NULL AND expires_at <= ?
+		RETURNING id, content, content_type, COALESCE(content_hash, ''), COALESCE(file_paths, '')`, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("删除过期项目失败: %v", err)
+	}
+	var expired []ClipboardItem
+	for rows.Next() {
+		var item ClipboardItem
+		if err := rows.Scan(&item.ID, &item.Content, &item.ContentType, &item.ContentHash, &item.FilePaths); err != nil {
+			log.Printf("扫描过期项目失败: %v", err)
+			continue
+		}
+		// 加密存储锁定时无法比较内容，只按图片哈希检查剪贴板
+		if err := decryptItem(&item); err != nil {
+			item.Content, item.FilePaths = "", ""
+		}
+		expired = append(expired, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("删除过期项目失败: %v", err)
+	}
+
+	if len(expired) > 0 {
+		log.Printf("⏰ 已删除 %d 条过期项目", len(expired))
+		clearClipboardIfHolds(expired)
+		collectImageBlobs()
+		notifyListeners()
+	}
+	return len(expired), nil
+}
+
+// clearClipboardIfHolds 系统剪贴板中仍是某个已删除项目的内容时清空剪贴板
+func clearClipboardIfHolds(items []ClipboardItem) {
+	expirySweeperMu.Lock()
+	backend := ephemeralClipboard
+	expirySweeperMu.Unlock()
+	if backend == nil {
+		return
+	}
+
+	// 按 Monitor 的优先级读取当前内容：图片 > 文件 > 文本
+	var imageHash, fileHash, text string
+	if imgData := backend.ReadImage(); len(imgData) > 0 {
+		if pngData, err := convertToPNG(imgData); err == nil {
+			h := sha256.Sum256(pngData)
+			imageHash = keyContentHash(hex.EncodeToString(h[:]))
+		}
+	} else if fileJSON, fileCount := backend.ReadFileURLs(); fileCount > 0 && fileJSON != "" {
+		fileHash = calculateFilePathsHash(fileJSON)
+	} else {
+		text = backend.ReadText()
+	}
+
+	for _, item := range items {
+		var holds bool
+		switch item.ContentType {
+		case "Image":
+			holds = imageHash != "" && imageHash == item.ContentHash
+		case "File":
+			holds = fileHash != "" && item.FilePaths != "" && fileHash == calculateFilePathsHash(item.FilePaths)
+		default:
+			holds = text != "" && text == item.Content
+		}
+		if holds {
+			backend.Clear()
+			log.Printf("🧽 已清空剪贴板中的过期内容: ID=%s", item.ID)
+			return
+		}
+	}
+}
+
+// scheduleExpirySweep 在 at 时刻唤醒过期清理（不必等到下一次定期检查）
+func scheduleExpirySweep(at time.Time) {
+	time.AfterFunc(max(time.Until(at), 0)+100*time.Millisecond, func() {
+		select {
+		case expirySweeperWake <- struct{}{}:
+		default:
+		}
+	})
+}
+
+// StartExpirySweeper 启动过期项目清理（重复调用会先停止之前的清理）
+func StartExpirySweeper() {
+	StopExpirySweeper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	expirySweeperMu.Lock()
+	expirySweeperCancel = cancel
+	expirySweeperMu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(expiredItemsSweepInterval)
+		defer ticker.Stop()
+		for {
+			if _, err := DeleteExpiredItems(); err != nil {
+				log.Printf("⚠️ 删除过期项目失败: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-expirySweeperWake:
+			}
+		}
+	}()
+}
+
+// StopExpirySweeper 停止过期项目清理
+func StopExpirySweeper() {
+	expirySweeperMu.Lock()
+	defer expirySweeperMu.Unlock()
+	if expirySweeperCancel != nil {
+		expirySweeperCancel()
+		expirySweeperCancel = nil
+	}
+}
+
+// migrateAddPasteLimit v12: 临时项目的粘贴次数限制
+func migrateAddPasteLimit(tx *sql.Tx) error {
+	if err := addColumnIfMissing(tx, "clipboard_items", "max_pastes", "INTEGER DEFAULT 0"); err != nil {
+		return err
+	}
+	return addColumnIfMissing(tx, "clipboard_items", "paste_count", "INTEGER DEFAULT 0")
+}
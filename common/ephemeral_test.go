@@ -0,0 +1,112 @@
+package common
+
+import (
+	"fmt"
+	"image/color"
+	"testing"
+	"time"
+)
+
+func TestPasteLimitExpiry(t *testing.T) {
+	openTestDB(t, 0)
+	backend := NewFakeClipboardBackend()
+	SetEphemeralClipboard(backend)
+	t.Cleanup(func() { SetEphemeralClipboard(nil) })
+
+	protectedTag, err := CreateTag("保密", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SetTagProtected(protectedTag.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	imageData := testPNG(t, color.RGBA{G: 200, A: 255})
+
+	tests := []struct {
+		name        string
+		item        ClipboardItem
+		maxPastes   int
+		favorite    bool
+		protected   bool
+		onClipboard func() // 粘贴后系统剪贴板中的内容
+		wantDeleted bool
+		wantCleared bool
+	}{
+		{name: "剪贴板中仍是该内容", item: ClipboardItem{Content: "otp 1234", ContentType: "Text"}, maxPastes: 1,
+			onClipboard: func() { backend.SetText("otp 1234") }, wantDeleted: true, wantCleared: true},
+		{name: "剪贴板已被其他内容覆盖", item: ClipboardItem{Content: "otp 5678", ContentType: "Text"}, maxPastes: 1,
+			onClipboard: func() { backend.SetText("something else") }, wantDeleted: true},
+		{name: "收藏的项目同样过期", item: ClipboardItem{Content: "starred secret", ContentType: "Text"}, maxPastes: 1, favorite: true,
+			onClipboard: func() { backend.SetText("starred secret") }, wantDeleted: true, wantCleared: true},
+		{name: "受保护标签的项目同样过期", item: ClipboardItem{Content: "tagged secret", ContentType: "Text"}, maxPastes: 1, protected: true,
+			onClipboard: func() { backend.SetText("tagged secret") }, wantDeleted: true, wantCleared: true},
+		{name: "图片按内容哈希比较", item: ClipboardItem{Content: "图片 4x4 (png)", ContentType: "Image", ImageData: imageData}, maxPastes: 1,
+			onClipboard: func() { backend.SetImage(imageData) }, wantDeleted: true, wantCleared: true},
+		{name: "粘贴次数没有用完", item: ClipboardItem{Content: "twice", ContentType: "Text"}, maxPastes: 2,
+			onClipboard: func() { backend.SetText("twice") }},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := tt.item
+			item.ID = fmt.Sprintf("paste-%d", i)
+			item.Timestamp = time.Now()
+			item.ContentHash = calculateContentHash(&item)
+			if err := SaveClipboardItem(&item); err != nil {
+				t.Fatal(err)
+			}
+			if err := SetItemExpiry(item.ID, 0, tt.maxPastes); err != nil {
+				t.Fatal(err)
+			}
+			if tt.favorite {
+				if _, err := DB.Exec(`UPDATE clipboard_items SET is_favorite = 1 WHERE id = ?`, item.ID); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.protected {
+				if err := AddTagsToItems([]string{item.ID}, []string{protectedTag.ID}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			tt.onClipboard()
+			exhausted, err := RecordItemPaste(item.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exhausted != tt.wantDeleted {
+				t.Fatalf("RecordItemPaste = %v，期望 %v", exhausted, tt.wantDeleted)
+			}
+
+			var expiresAt *time.Time
+			if err := DB.QueryRow(`SELECT expires_at FROM clipboard_items WHERE id = ?`, item.ID).Scan(&expiresAt); err != nil {
+				t.Fatal(err)
+			}
+			if exhausted {
+				// 粘贴次数用完后保留 pasteLimitGracePeriod，这里直接把到期时间移到过去
+				if expiresAt == nil || time.Until(*expiresAt) > pasteLimitGracePeriod {
+					t.Fatalf("expires_at = %v，期望在 %v 内", expiresAt, pasteLimitGracePeriod)
+				}
+				if _, err := DB.Exec(`UPDATE clipboard_items SET expires_at = ? WHERE id = ?`, time.Now().Add(-time.Second), item.ID); err != nil {
+					t.Fatal(err)
+				}
+			} else if expiresAt != nil {
+				t.Fatalf("粘贴次数没有用完时不应该设置 expires_at = %v", expiresAt)
+			}
+
+			changeCount := backend.ChangeCount()
+			removed, err := DeleteExpiredItems()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (removed == 1) != tt.wantDeleted {
+				t.Errorf("DeleteExpiredItems 删除 %d 条，期望删除 %v", removed, tt.wantDeleted)
+			}
+			if _, err := GetClipboardItemByID(item.ID); (err != nil) != tt.wantDeleted {
+				t.Errorf("GetClipboardItemByID 错误 = %v，期望已删除 %v", err, tt.wantDeleted)
+			}
+			if cleared := backend.ChangeCount() != changeCount; cleared != tt.wantCleared {
+				t.Errorf("剪贴板被清空 = %v，期望 %v", cleared, tt.wantCleared)
+			}
+		})
+	}
+}
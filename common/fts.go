@@ -0,0 +1,403 @@
+package common
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// 全文索引：clipboard_fts（FTS5，trigram 分词，对中日韩文本按子串匹配）
+// clipboard_fts_docs 维护 FTS 行号与 clipboard_items.id 的映射（clipboard_items 没有稳定的整数主键，VACUUM 可能改变 rowid）
+// 三个触发器在插入、更新 content/ocr_text、删除时同步索引
+//
+// FTS5 需要使用 sqlite_fts5 构建标签编译 go-sqlite3（wails build -tags sqlite_fts5），
+// 未启用时自动回退到 LIKE 搜索，并删除触发器避免写入失败；发布构建没有该标签时无法编译（见 fts_release.go）。
+
+// ftsMinQueryRunes trigram 分词至少需要 3 个字符，更短的关键词回退到 LIKE
+const ftsMinQueryRunes = 3
+
+// snippet() 使用的高亮标记与上下文长度
+const (
+	ftsHighlightStart = "\x02"
+	ftsHighlightEnd   = "\x03"
+	ftsSnippetTokens  = 24
+	snippetContext    = 20 // LIKE 回退时命中位置前后保留的字符数
+)
+
+var ftsTriggerNames = []string{"clipboard_fts_ai", "clipboard_fts_au", "clipboard_fts_ad"}
+
+// ftsEnabled 全文索引是否可用
+var ftsEnabled atomic.Bool
+
+// MatchRange 高亮区间（Snippet 中的字符下标，左闭右开）
+type MatchRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// SearchHit 全文搜索命中结果
+type SearchHit struct {
+	Item         ClipboardItem `json:"item"`
+	Score        float64       `json:"score"`        // BM25 相关度，越大越相关（LIKE 回退时为 0）
+	MatchedField string        `json:"matchedField"` // 命中的字段：content 或 ocr_text
+	Snippet      string        `json:"snippet"`      // 命中位置附近的摘要
+	Highlights   []MatchRange  `json:"highlights"`   // 摘要中的高亮区间
+}
+
+// ensureFTSIndex 创建全文索引并在索引缺失或过期时回填
+func ensureFTSIndex() error {
+	if DB == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	var fts5Compiled int
+	if err := DB.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&fts5Compiled); err != nil {
+		return fmt.Errorf("检查FTS5支持失败: %v", err)
+	}
+
+	// 全文索引保存明文，启用加密存储时不建立索引
+	if IsEncryptionEnabled() {
+		return dropFTSIndex()
+	}
+
+	if fts5Compiled == 0 {
+		// 当前构建不支持 FTS5：删除触发器，否则写入 clipboard_items 会因找不到 fts5 模块而失败
+		ftsEnabled.Store(false)
+		for _, name := range ftsTriggerNames {
+			if _, err := DB.Exec(`DROP TRIGGER IF EXISTS ` + name); err != nil {
+				return fmt.Errorf("删除全文索引触发器失败: %v", err)
+			}
+		}
+		log.Printf("⚠️ 当前构建未启用 FTS5（需要 -tags sqlite_fts5），搜索回退到 LIKE")
+		return nil
+	}
+
+	createSQL := `
+	CREATE VIRTUAL TABLE IF NOT EXISTS clipboard_fts USING fts5(content, ocr_text, tokenize = 'trigram');
+	CREATE TABLE IF NOT EXISTS clipboard_fts_docs (
+		rowid INTEGER PRIMARY KEY,
+		item_id TEXT NOT NULL UNIQUE
+	);
+	`
+	if _, err := DB.Exec(createSQL); err != nil {
+		return fmt.Errorf("创建全文索引表失败: %v", err)
+	}
+
+	// 触发器不完整说明索引是新建的，或者曾被不支持 FTS5 的构建打开过，需要整体重建
+	var triggerCount int
+	checkSQL := fmt.Sprintf(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name IN ('%s')`, strings.Join(ftsTriggerNames, "','"))
+	if err := DB.QueryRow(checkSQL).Scan(&triggerCount); err != nil {
+		return fmt.Errorf("检查全文索引触发器失败: %v", err)
+	}
+	if triggerCount == len(ftsTriggerNames) {
+		ftsEnabled.Store(true)
+		return nil
+	}
+
+	log.Printf("🔧 正在重建全文索引...")
+	if err := rebuildFTSIndex(); err != nil {
+		return err
+	}
+	ftsEnabled.Store(true)
+	return nil
+}
+
+// rebuildFTSIndex 清空并回填全文索引，然后创建同步触发器（单个事务内完成）
+func rebuildFTSIndex() error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %v", err)
+	}
+	defer tx.Rollback()
+
+	rebuildSQL := `
+	DELETE FROM clipboard_fts;
+	DELETE FROM clipboard_fts_docs;
+
+	INSERT INTO clipboard_fts_docs (item_id) SELECT id FROM clipboard_items;
+	INSERT INTO clipboard_fts (rowid, content, ocr_text)
+		SELECT d.rowid, c.content, COALESCE(c.ocr_text, '')
+		FROM clipboard_fts_docs d JOIN clipboard_items c ON c.id = d.item_id;
+
+	DROP TRIGGER IF EXISTS clipboard_fts_ai;
+	DROP TRIGGER IF EXISTS clipboard_fts_au;
+	DROP TRIGGER IF EXISTS clipboard_fts_ad;
+
+	CREATE TRIGGER clipboard_fts_ai AFTER INSERT ON clipboard_items BEGIN
+		INSERT INTO clipboard_fts_docs (item_id) VALUES (NEW.id);
+		INSERT INTO clipboard_fts (rowid, content, ocr_text)
+			VALUES ((SELECT rowid FROM clipboard_fts_docs WHERE item_id = NEW.id), NEW.content, COALESCE(NEW.ocr_text, ''));
+	END;
+
+	CREATE TRIGGER clipboard_fts_au AFTER UPDATE OF content, ocr_text ON clipboard_items BEGIN
+		UPDATE clipboard_fts SET content = NEW.content, ocr_text = COALESCE(NEW.ocr_text, '')
+			WHERE rowid = (SELECT rowid FROM clipboard_fts_docs WHERE item_id = NEW.id);
+	END;
+
+	CREATE TRIGGER clipboard_fts_ad AFTER DELETE ON clipboard_items BEGIN
+		DELETE FROM clipboard_fts WHERE rowid = (SELECT rowid FROM clipboard_fts_docs WHERE item_id = OLD.id);
+		DELETE FROM clipboard_fts_docs WHERE item_id = OLD.id;
+	END;
+	`
+	if _, err := tx.Exec(rebuildSQL); err != nil {
+		return fmt.Errorf("重建全文索引失败: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交全文索引失败: %v", err)
+	}
+
+	var count int
+	DB.QueryRow(`SELECT COUNT(*) FROM clipboard_fts_docs`).Scan(&count)
+	log.Printf("✅ 全文索引已重建，共 %d 条记录", count)
+	return nil
+}
+
+// dropFTSIndex 删除全文索引及其触发器（启用加密存储时调用，避免明文留在索引中）
+func dropFTSIndex() error {
+	ftsEnabled.Store(false)
+	for _, name := range ftsTriggerNames {
+		if _, err := DB.Exec(`DROP TRIGGER IF EXISTS ` + name); err != nil {
+			return fmt.Errorf("删除全文索引触发器失败: %v", err)
+		}
+	}
+	var tableCount int
+	DB.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'clipboard_fts'`).Scan(&tableCount)
+	if tableCount == 0 {
+		return nil
+	}
+	// 不支持 FTS5 的构建无法删除虚拟表，下次用支持 FTS5 的构建启动时由 ensureFTSIndex 删除
+	var fts5Compiled int
+	DB.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&fts5Compiled)
+	if fts5Compiled == 0 {
+		log.Printf("⚠️ 当前构建未启用 FTS5，无法删除旧的全文索引")
+		return nil
+	}
+	if _, err := DB.Exec(`DROP TABLE IF EXISTS clipboard_fts; DROP TABLE IF EXISTS clipboard_fts_docs;`); err != nil {
+		return fmt.Errorf("删除全文索引失败: %v", err)
+	}
+	log.Printf("🗑️ 已删除全文索引")
+	return nil
+}
+
+// useFTS 判断关键词是否可以走全文索引
+func useFTS(keyword string) bool {
+	return ftsEnabled.Load() && utf8.RuneCountInString(keyword) >= ftsMinQueryRunes
+}
+
+// ftsPhraseQuery 把关键词转成 FTS5 短语查询（整体按子串匹配，与 LIKE 语义一致）
+func ftsPhraseQuery(keyword string) string {
+	return `"` + strings.ReplaceAll(keyword, `"`, `""`) + `"`
+}
+
+// ftsMatchClause 返回按关键词过滤 clipboard_items.id 的子查询
+func ftsMatchClause() string {
+	return `id IN (SELECT d.item_id FROM clipboard_fts f JOIN clipboard_fts_docs d ON d.rowid = f.rowid WHERE clipboard_fts MATCH ?)`
+}
+
+// SearchClipboardItemsRanked 按相关度搜索剪贴板项目，返回 BM25 得分和命中摘要
+// 关键词少于 3 个字符、FTS5 不可用或启用了加密存储时回退到 LIKE，按时间倒序返回
+func SearchClipboardItemsRanked(isFavorite bool, keyword string, filterType string, limit int) ([]SearchHit, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" {
+		return []SearchHit{}, nil
+	}
+
+	if !useFTS(keyword) {
+		items, err := SearchClipboardItems(isFavorite, keyword, filterType, "", limit, ImageLoadNone)
+		if err != nil {
+			return nil, err
+		}
+		hits := make([]SearchHit, 0, len(items))
+		for _, item := range items {
+			hits = append(hits, likeSearchHit(item, keyword))
+		}
+		return hits, nil
+	}
+
+	whereClauses := []string{`clipboard_fts MATCH ?`}
+	args := []interface{}{ftsPhraseQuery(keyword)}
+	if isFavorite {
+		whereClauses = append(whereClauses, `c.is_favorite = 1`)
+	}
+	if filterType != "" {
+		whereClauses = append(whereClauses, `c.content_type = ?`)
+		args = append(args, filterType)
+	}
+	args = append(args, limit)
+
+	// content 权重高于 ocr_text
+	query := fmt.Sprintf(`
+	SELECT c.id, c.content, c.content_type, COALESCE(c.content_hash, '') as content_hash, c.file_paths, c.file_info, c.timestamp, c.source, c.char_count, c.word_count, COALESCE(c.is_favorite, 0) as is_favorite, COALESCE(c.ocr_text, '') as ocr_text,
+	       COALESCE(c.sensitive_category, '') as sensitive_category, COALESCE(c.sensitive_action, '') as sensitive_action, c.expires_at,
+	       COALESCE(c.max_pastes, 0) as max_pastes, COALESCE(c.paste_count, 0) as paste_count,
+	       bm25(clipboard_fts, 1.0, 0.5) as rank,
+	       snippet(clipboard_fts, 0, '%[1]s', '%[2]s', '…', %[3]d) as content_snippet,
+	       snippet(clipboard_fts, 1, '%[1]s', '%[2]s', '…', %[3]d) as ocr_snippet
+	FROM clipboard_fts f
+	JOIN clipboard_fts_docs d ON d.rowid = f.rowid
+	JOIN clipboard_items c ON c.id = d.item_id
+	WHERE %[4]s
+	ORDER BY rank LIMIT ?
+	`, ftsHighlightStart, ftsHighlightEnd, ftsSnippetTokens, strings.Join(whereClauses, " AND "))
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("全文搜索失败: %v", err)
+	}
+	defer rows.Close()
+
+	hits := []SearchHit{}
+	for rows.Next() {
+		var hit SearchHit
+		var rank float64
+		var contentSnippet, ocrSnippet string
+		item := &hit.Item
+		err := rows.Scan(
+			&item.ID,
+			&item.Content,
+			&item.ContentType,
+			&item.ContentHash,
+			&item.FilePaths,
+			&item.FileInfo,
+			&item.Timestamp,
+			&item.Source,
+			&item.CharCount,
+			&item.WordCount,
+			&item.IsFavorite,
+			&item.OCRText,
+			&item.SensitiveCategory,
+			&item.SensitiveAction,
+			&item.ExpiresAt,
+			&item.MaxPastes,
+			&item.PasteCount,
+			&rank,
+			&contentSnippet,
+			&ocrSnippet,
+		)
+		if err != nil {
+			log.Printf("扫描行失败: %v", err)
+			continue
+		}
+
+		// bm25() 越小越相关，取反后作为得分
+		hit.Score = -rank
+		if strings.Contains(contentSnippet, ftsHighlightStart) {
+			hit.MatchedField = "content"
+			hit.Snippet, hit.Highlights = parseSnippetMarkers(contentSnippet)
+		} else {
+			hit.MatchedField = "ocr_text"
+			hit.Snippet, hit.Highlights = parseSnippetMarkers(ocrSnippet)
+		}
+		hits = append(hits, hit)
+	}
+
+	return hits, nil
+}
+
+// parseSnippetMarkers 去掉 snippet() 输出中的高亮标记，返回纯文本和高亮区间
+func parseSnippetMarkers(marked string) (string, []MatchRange) {
+	var sb strings.Builder
+	ranges := []MatchRange{}
+	pos := 0
+	start := -1
+	for _, r := range marked {
+		switch string(r) {
+		case ftsHighlightStart:
+			start = pos
+		case ftsHighlightEnd:
+			if start >= 0 && pos > start {
+				ranges = append(ranges, MatchRange{Start: start, End: pos})
+			}
+			start = -1
+		default:
+			sb.WriteRune(r)
+			pos++
+		}
+	}
+	return sb.String(), ranges
+}
+
+// containsFold 不区分大小写判断 text 是否包含 keyword（加密存储时代替 LIKE）
+func containsFold(text string, keyword string) bool {
+	return strings.Contains(strings.ToLower(text), strings.ToLower(keyword))
+}
+
+// likeSearchHit 为 LIKE 回退结果构造摘要和高亮区间
+func likeSearchHit(item ClipboardItem, keyword string) SearchHit {
+	hit := SearchHit{Item: item, MatchedField: "content"}
+	snippet, ranges := buildSnippet(item.Content, keyword)
+	if len(ranges) == 0 && item.OCRText != "" {
+		if ocrSnippet, ocrRanges := buildSnippet(item.OCRText, keyword); len(ocrRanges) > 0 {
+			hit.MatchedField = "ocr_text"
+			snippet, ranges = ocrSnippet, ocrRanges
+		}
+	}
+	hit.Snippet = snippet
+	hit.Highlights = ranges
+	return hit
+}
+
+// buildSnippet 截取文本中第一次命中附近的片段，并标出片段内所有命中（不区分大小写）
+func buildSnippet(text string, keyword string) (string, []MatchRange) {
+	runes := []rune(text)
+	lowerRunes := []rune(strings.ToLower(text))
+	needle := []rune(strings.ToLower(keyword))
+	ranges := []MatchRange{}
+	if len(needle) == 0 || len(lowerRunes) != len(runes) {
+		// 大小写转换改变了长度（极少数字符），无法可靠定位，只返回开头片段
+		return truncateString(text, snippetContext*2), ranges
+	}
+
+	first := indexRunes(lowerRunes, needle, 0)
+	if first < 0 {
+		return truncateString(text, snippetContext*2), ranges
+	}
+
+	begin := first - snippetContext
+	if begin < 0 {
+		begin = 0
+	}
+	end := first + len(needle) + snippetContext
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	prefix := ""
+	if begin > 0 {
+		prefix = "…"
+	}
+	offset := len([]rune(prefix))
+	for i := first; i >= 0 && i+len(needle) <= end; i = indexRunes(lowerRunes, needle, i+len(needle)) {
+		ranges = append(ranges, MatchRange{Start: i - begin + offset, End: i - begin + offset + len(needle)})
+	}
+
+	snippet := prefix + string(runes[begin:end])
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet, ranges
+}
+
+// indexRunes 在 haystack 中从 from 开始查找 needle，未找到返回 -1
+func indexRunes(haystack, needle []rune, from int) int {
+	for i := from; i+len(needle) <= len(haystack); i++ {
+		match := true
+		for j := range needle {
+			if haystack[i+j] != needle[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,9 @@
+//go:build production && !sqlite_fts5 && !fts5
+// +build production,!sqlite_fts5,!fts5
+
+package common
+
+// 发布构建（wails build 会带上 production 构建标签）必须启用 FTS5，
+// 否则搜索会一直回退到 LIKE，排序和高亮都不可用。
+// 没有加 -tags sqlite_fts5 时引用一个不存在的名称，让构建直接失败并在错误信息中说明原因。
+var _ = wailsBuildRequiresTagSqliteFTS5 // 请使用 wails build -tags sqlite_fts5
@@ -0,0 +1,230 @@
+package common
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// openFTSTestDB 打开测试数据库并建立全文索引；没有使用 -tags sqlite_fts5 运行测试时跳过
+func openFTSTestDB(t *testing.T) {
+	t.Helper()
+	openTestDB(t, 0)
+	t.Cleanup(func() { ftsEnabled.Store(false) })
+	if err := ensureFTSIndex(); err != nil {
+		t.Fatal(err)
+	}
+	if !ftsEnabled.Load() {
+		t.Skip("FTS5 不可用，使用 go test -tags sqlite_fts5 运行全文索引测试")
+	}
+}
+
+// saveFTSTestItems 按顺序保存项目，时间依次递增
+func saveFTSTestItems(t *testing.T, items []ClipboardItem) {
+	t.Helper()
+	base := time.Now().Add(-time.Hour)
+	for i := range items {
+		item := items[i]
+		item.Timestamp = base.Add(time.Duration(i) * time.Minute)
+		item.ContentHash = calculateContentHash(&item)
+		if err := SaveClipboardItem(&item); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// sortedStrings 返回排序后的副本
+func sortedStrings(values []string) []string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// highlightedText 按高亮区间取出摘要中被标记的文字
+func highlightedText(hit SearchHit) []string {
+	runes := []rune(hit.Snippet)
+	var marked []string
+	for _, r := range hit.Highlights {
+		marked = append(marked, string(runes[r.Start:r.End]))
+	}
+	return marked
+}
+
+func TestSearchRankedFTS(t *testing.T) {
+	openFTSTestDB(t)
+	saveFTSTestItems(t, []ClipboardItem{
+		{ID: "long", Content: "a banana appears once in this much longer note about groceries and errands", ContentType: "Text"},
+		{ID: "dense", Content: "banana banana banana", ContentType: "Text"},
+		{ID: "ocr", Content: "图片 4x4 (png)", ContentType: "Image", OCRText: "banana"},
+		{ID: "other", Content: "cherry tart", ContentType: "Text"},
+		{ID: "fav", Content: "favorite banana bread", ContentType: "Text"},
+		{ID: "cjk", Content: "今天下午三点的会议纪要已经发到群里了", ContentType: "Text"},
+	})
+	if _, err := DB.Exec(`UPDATE clipboard_items SET is_favorite = 1 WHERE id = 'fav'`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		keyword     string
+		favorite    bool
+		filterType  string
+		wantIDs     []string // 期望的结果（按 ID 排序后比较）
+		wantFirst   string   // 得分最高的结果，空表示不检查
+		wantField   map[string]string
+		wantMarkers []string // 第一条结果摘要中的高亮文字
+	}{
+		{name: "词频高的排在前面", keyword: "banana", wantIDs: []string{"dense", "fav", "long", "ocr"}, wantFirst: "dense",
+			wantField: map[string]string{"dense": "content", "long": "content", "ocr": "ocr_text"}},
+		{name: "不区分大小写", keyword: "BANANA", wantIDs: []string{"dense", "fav", "long", "ocr"}},
+		{name: "只搜索收藏", keyword: "banana", favorite: true, wantIDs: []string{"fav"}},
+		{name: "按类型过滤", keyword: "banana", filterType: "Image", wantIDs: []string{"ocr"},
+			wantField: map[string]string{"ocr": "ocr_text"}},
+		{name: "短语整体匹配", keyword: "banana bread", wantIDs: []string{"fav"}, wantMarkers: []string{"banana bread"}},
+		{name: "中文按子串匹配", keyword: "会议纪要", wantIDs: []string{"cjk"}, wantMarkers: []string{"会议纪要"}},
+		{name: "没有命中", keyword: "durian", wantIDs: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, err := SearchClipboardItemsRanked(tt.favorite, tt.keyword, tt.filterType, 20)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for i, hit := range hits {
+				ids = append(ids, hit.Item.ID)
+				if i > 0 && hit.Score > hits[i-1].Score {
+					t.Errorf("结果没有按得分排序: %s(%.3f) 在 %s(%.3f) 之后", hit.Item.ID, hit.Score, hits[i-1].Item.ID, hits[i-1].Score)
+				}
+				if want, ok := tt.wantField[hit.Item.ID]; ok && hit.MatchedField != want {
+					t.Errorf("%s 命中字段 = %s，期望 %s", hit.Item.ID, hit.MatchedField, want)
+				}
+			}
+			if !equalStrings(sortedStrings(ids), tt.wantIDs) {
+				t.Fatalf("结果 = %v，期望 %v", ids, tt.wantIDs)
+			}
+			if tt.wantFirst != "" && hits[0].Item.ID != tt.wantFirst {
+				t.Errorf("第一条 = %s，期望 %s", hits[0].Item.ID, tt.wantFirst)
+			}
+			if tt.wantMarkers != nil {
+				if got := highlightedText(hits[0]); !equalStrings(got, tt.wantMarkers) {
+					t.Errorf("摘要 %q 的高亮 = %q，期望 %q", hits[0].Snippet, got, tt.wantMarkers)
+				}
+			}
+		})
+	}
+}
+
+func TestFTSTriggersSync(t *testing.T) {
+	openFTSTestDB(t)
+	saveFTSTestItems(t, []ClipboardItem{
+		{ID: "a", Content: "quarterly report draft", ContentType: "Text"},
+		{ID: "b", Content: "图片 4x4 (png)", ContentType: "Image"},
+	})
+
+	search := func(keyword string) []string {
+		t.Helper()
+		hits, err := SearchClipboardItemsRanked(false, keyword, "", 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := []string{}
+		for _, hit := range hits {
+			ids = append(ids, hit.Item.ID)
+		}
+		return ids
+	}
+
+	steps := []struct {
+		name   string
+		change func() error
+		want   map[string][]string // 关键词 → 期望命中的 ID
+	}{
+		{name: "插入后可以搜索", change: func() error { return nil },
+			want: map[string][]string{"quarterly": {"a"}, "invoice": {}}},
+		{name: "修改内容后更新索引", change: func() error {
+			_, err := DB.Exec(`UPDATE clipboard_items SET content = 'final invoice' WHERE id = 'a'`)
+			return err
+		}, want: map[string][]string{"quarterly": {}, "invoice": {"a"}}},
+		{name: "更新 OCR 文字后可以搜索", change: func() error { return UpdateOCRText("b", "scanned invoice total") },
+			want: map[string][]string{"invoice": {"a", "b"}, "scanned": {"b"}}},
+		{name: "只修改其他字段不影响索引", change: func() error {
+			_, err := DB.Exec(`UPDATE clipboard_items SET is_favorite = 1 WHERE id = 'b'`)
+			return err
+		}, want: map[string][]string{"scanned": {"b"}}},
+		{name: "删除后移出索引", change: func() error { return DeleteClipboardItem("a") },
+			want: map[string][]string{"invoice": {"b"}, "final": {}}},
+	}
+	for _, step := range steps {
+		if err := step.change(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		for keyword, want := range step.want {
+			if got := sortedStrings(search(keyword)); !equalStrings(got, want) {
+				t.Errorf("%s: 搜索 %q = %v，期望 %v", step.name, keyword, got, want)
+			}
+		}
+	}
+
+	// 索引映射表与项目一一对应，删除的项目不留下残留行
+	var items, docs, indexed int
+	DB.QueryRow(`SELECT COUNT(*) FROM clipboard_items`).Scan(&items)
+	DB.QueryRow(`SELECT COUNT(*) FROM clipboard_fts_docs`).Scan(&docs)
+	DB.QueryRow(`SELECT COUNT(*) FROM clipboard_fts`).Scan(&indexed)
+	if docs != items || indexed != items {
+		t.Errorf("项目 %d 条，映射 %d 条，索引 %d 条", items, docs, indexed)
+	}
+}
+
+func TestParseSnippetMarkers(t *testing.T) {
+	mark := func(s string) string { return ftsHighlightStart + s + ftsHighlightEnd }
+	tests := []struct {
+		marked string
+		want   string
+		ranges []MatchRange
+	}{
+		{marked: "no markers", want: "no markers", ranges: []MatchRange{}},
+		{marked: "…the " + mark("key") + " is here", want: "…the key is here", ranges: []MatchRange{{5, 8}}},
+		{marked: mark("ab") + "-" + mark("ab"), want: "ab-ab", ranges: []MatchRange{{0, 2}, {3, 5}}},
+		{marked: "会议" + mark("纪要") + "已发", want: "会议纪要已发", ranges: []MatchRange{{2, 4}}}, // 按字符而不是字节计算下标
+		{marked: "empty" + mark(""), want: "empty", ranges: []MatchRange{}},
+	}
+	for _, tt := range tests {
+		got, ranges := parseSnippetMarkers(tt.marked)
+		if got != tt.want || fmt.Sprint(ranges) != fmt.Sprint(tt.ranges) {
+			t.Errorf("parseSnippetMarkers(%q) = %q %v，期望 %q %v", tt.marked, got, ranges, tt.want, tt.ranges)
+		}
+	}
+}
+
+func TestBuildSnippet(t *testing.T) {
+	long := strings.Repeat("x", 30) + "Needle" + strings.Repeat("y", 30)
+	tests := []struct {
+		name    string
+		text    string
+		keyword string
+		want    string
+		marked  []string
+	}{
+		{name: "短文本", text: "find the needle", keyword: "needle", want: "find the needle", marked: []string{"needle"}},
+		{name: "不区分大小写", text: "NEEDLE and needle", keyword: "Needle", want: "NEEDLE and needle", marked: []string{"NEEDLE", "needle"}},
+		{name: "长文本截取命中位置附近", text: long, keyword: "needle",
+			want:   "…" + strings.Repeat("x", snippetContext) + "Needle" + strings.Repeat("y", snippetContext) + "…",
+			marked: []string{"Needle"}},
+		{name: "中文", text: "今天下午的会议纪要", keyword: "会议", want: "今天下午的会议纪要", marked: []string{"会议"}},
+		{name: "没有命中时返回开头", text: "nothing here", keyword: "needle", want: "nothing here"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snippet, ranges := buildSnippet(tt.text, tt.keyword)
+			if snippet != tt.want {
+				t.Errorf("摘要 = %q，期望 %q", snippet, tt.want)
+			}
+			if got := highlightedText(SearchHit{Snippet: snippet, Highlights: ranges}); !equalStrings(got, tt.marked) {
+				t.Errorf("高亮 = %q，期望 %q", got, tt.marked)
+			}
+		})
+	}
+}
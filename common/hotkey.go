@@ -7,193 +7,135 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"strings"
+	"sync"
+	"time"
 
 	"golang.design/x/hotkey"
 )
 
+// registeredHotkey 已注册的快捷键
+type registeredHotkey struct {
+	hk     *hotkey.Hotkey
+	cancel context.CancelFunc
+	hotkey string
+	combo  string // hotkeyCombo.id()，用来判断某组按键是否已由本应用注册
+}
+
 var (
-	hk           *hotkey.Hotkey
-	hotkeyCancel context.CancelFunc
+	hotkeysMu sync.Mutex
+	hotkeys   = make(map[string]*registeredHotkey) // 绑定 ID -> 已注册的快捷键
 )
 
+// hotkeyRegisterTimeout 等待系统注册快捷键的最长时间
+const hotkeyRegisterTimeout = 2 * time.Second
+
 // HotkeyCallback 快捷键回调函数类型
 type HotkeyCallback func()
 
-func parseHotkeyString(hotkeyStr string) ([]hotkey.Modifier, hotkey.Key, error) {
-	parts := strings.Split(strings.ToLower(hotkeyStr), "+")
-	if len(parts) < 2 {
-		return nil, 0, fmt.Errorf("快捷键格式错误，至少需要两个键，例如: ctrl+shift+k")
+// globalHotkeysSupported 当前平台支持全局快捷键
+const globalHotkeysSupported = true
+
+// hotkeyProbeID 检查快捷键能否注册时使用的临时绑定 ID
+const hotkeyProbeID = "__validate__"
+
+// hotkeyKeyCodes 主键内部名称 -> 按键（golang.design/x/hotkey 中有名称的按键），其他按键见各平台的 platformKeyCodes
+var hotkeyKeyCodes = map[string]hotkey.Key{
+	"a": hotkey.KeyA, "b": hotkey.KeyB, "c": hotkey.KeyC, "d": hotkey.KeyD, "e": hotkey.KeyE,
+	"f": hotkey.KeyF, "g": hotkey.KeyG, "h": hotkey.KeyH, "i": hotkey.KeyI, "j": hotkey.KeyJ,
+	"k": hotkey.KeyK, "l": hotkey.KeyL, "m": hotkey.KeyM, "n": hotkey.KeyN, "o": hotkey.KeyO,
+	"p": hotkey.KeyP, "q": hotkey.KeyQ, "r": hotkey.KeyR, "s": hotkey.KeyS, "t": hotkey.KeyT,
+	"u": hotkey.KeyU, "v": hotkey.KeyV, "w": hotkey.KeyW, "x": hotkey.KeyX, "y": hotkey.KeyY,
+	"z": hotkey.KeyZ,
+	"0": hotkey.Key0, "1": hotkey.Key1, "2": hotkey.Key2, "3": hotkey.Key3, "4": hotkey.Key4,
+	"5": hotkey.Key5, "6": hotkey.Key6, "7": hotkey.Key7, "8": hotkey.Key8, "9": hotkey.Key9,
+	"space":  hotkey.KeySpace,
+	"enter":  hotkey.KeyReturn,
+	"tab":    hotkey.KeyTab,
+	"escape": hotkey.KeyEscape,
+	"delete": hotkey.KeyDelete,
+	"up":     hotkey.KeyUp,
+	"down":   hotkey.KeyDown,
+	"left":   hotkey.KeyLeft,
+	"right":  hotkey.KeyRight,
+	"f1":     hotkey.KeyF1, "f2": hotkey.KeyF2, "f3": hotkey.KeyF3, "f4": hotkey.KeyF4, "f5": hotkey.KeyF5,
+	"f6": hotkey.KeyF6, "f7": hotkey.KeyF7, "f8": hotkey.KeyF8, "f9": hotkey.KeyF9, "f10": hotkey.KeyF10,
+	"f11": hotkey.KeyF11, "f12": hotkey.KeyF12, "f13": hotkey.KeyF13, "f14": hotkey.KeyF14, "f15": hotkey.KeyF15,
+	"f16": hotkey.KeyF16, "f17": hotkey.KeyF17, "f18": hotkey.KeyF18, "f19": hotkey.KeyF19, "f20": hotkey.KeyF20,
+}
+
+// parseKey 主键内部名称 -> 按键（macOS 上 A 键的键码是 0，所以用 ok 判断是否存在）
+func parseKey(name string) (hotkey.Key, bool) {
+	if key, ok := hotkeyKeyCodes[name]; ok {
+		return key, true
 	}
+	key, ok := platformKeyCodes[name]
+	return key, ok
+}
+
+// hotkeyKeySupported 当前平台能否注册该主键
+func hotkeyKeySupported(name string) bool {
+	_, ok := parseKey(name)
+	return ok
+}
 
+// comboToHotkey 把解析后的一组按键转换为 golang.design/x/hotkey 的修饰键和主键
+func comboToHotkey(combo hotkeyCombo) ([]hotkey.Modifier, hotkey.Key, error) {
 	var mods []hotkey.Modifier
-	var key hotkey.Key
-
-	// 解析修饰键
-	for i := 0; i < len(parts)-1; i++ {
-		part := strings.TrimSpace(parts[i])
-		if m, ok := mapModifier(part); ok {
-			mods = append(mods, m)
-		} else {
+	for _, part := range combo.Modifiers {
+		m, ok := mapModifier(part)
+		if !ok {
 			return nil, 0, fmt.Errorf("不支持的修饰键: %s", part)
 		}
+		mods = append(mods, m)
 	}
 
-	// 解析主键
-	keyStr := strings.TrimSpace(parts[len(parts)-1])
-	key = parseKey(keyStr)
-	if key == 0 {
-		return nil, 0, fmt.Errorf("不支持的按键: %s", keyStr)
+	key, ok := parseKey(combo.Key)
+	if !ok {
+		return nil, 0, fmt.Errorf("不支持的按键: %s", combo.Key)
 	}
 
 	return mods, key, nil
 }
 
-// parseKey 解析按键字符串
-func parseKey(keyStr string) hotkey.Key {
-	switch keyStr {
-	case "a":
-		return hotkey.KeyA
-	case "b":
-		return hotkey.KeyB
-	case "c":
-		return hotkey.KeyC
-	case "d":
-		return hotkey.KeyD
-	case "e":
-		return hotkey.KeyE
-	case "f":
-		return hotkey.KeyF
-	case "g":
-		return hotkey.KeyG
-	case "h":
-		return hotkey.KeyH
-	case "i":
-		return hotkey.KeyI
-	case "j":
-		return hotkey.KeyJ
-	case "k":
-		return hotkey.KeyK
-	case "l":
-		return hotkey.KeyL
-	case "m":
-		return hotkey.KeyM
-	case "n":
-		return hotkey.KeyN
-	case "o":
-		return hotkey.KeyO
-	case "p":
-		return hotkey.KeyP
-	case "q":
-		return hotkey.KeyQ
-	case "r":
-		return hotkey.KeyR
-	case "s":
-		return hotkey.KeyS
-	case "t":
-		return hotkey.KeyT
-	case "u":
-		return hotkey.KeyU
-	case "v":
-		return hotkey.KeyV
-	case "w":
-		return hotkey.KeyW
-	case "x":
-		return hotkey.KeyX
-	case "y":
-		return hotkey.KeyY
-	case "z":
-		return hotkey.KeyZ
-	case "0":
-		return hotkey.Key0
-	case "1":
-		return hotkey.Key1
-	case "2":
-		return hotkey.Key2
-	case "3":
-		return hotkey.Key3
-	case "4":
-		return hotkey.Key4
-	case "5":
-		return hotkey.Key5
-	case "6":
-		return hotkey.Key6
-	case "7":
-		return hotkey.Key7
-	case "8":
-		return hotkey.Key8
-	case "9":
-		return hotkey.Key9
-	case "space":
-		return hotkey.KeySpace
-	case "enter", "return":
-		return hotkey.KeyReturn
-	case "tab":
-		return hotkey.KeyTab
-	case "escape", "esc":
-		return hotkey.KeyEscape
-	case "delete", "del":
-		return hotkey.KeyDelete
-	case "up":
-		return hotkey.KeyUp
-	case "down":
-		return hotkey.KeyDown
-	case "left":
-		return hotkey.KeyLeft
-	case "right":
-		return hotkey.KeyRight
-	case "f1":
-		return hotkey.KeyF1
-	case "f2":
-		return hotkey.KeyF2
-	case "f3":
-		return hotkey.KeyF3
-	case "f4":
-		return hotkey.KeyF4
-	case "f5":
-		return hotkey.KeyF5
-	case "f6":
-		return hotkey.KeyF6
-	case "f7":
-		return hotkey.KeyF7
-	case "f8":
-		return hotkey.KeyF8
-	case "f9":
-		return hotkey.KeyF9
-	case "f10":
-		return hotkey.KeyF10
-	case "f11":
-		return hotkey.KeyF11
-	case "f12":
-		return hotkey.KeyF12
-	default:
-		return 0
+// RegisterHotkey 注册全局快捷键，id 相同的绑定会先取消注册（不同 id 的绑定互不影响）
+// 两步组合键（例如 "Ctrl+K Ctrl+V"）只注册第一步，按下后再临时注册第二步
+func RegisterHotkey(id string, hotkeyStr string, callback HotkeyCallback) error {
+	// 先取消之前注册的同一绑定
+	UnregisterHotkey(id)
+
+	// 解析快捷键字符串
+	seq, err := parseHotkeySequence(hotkeyStr)
+	if err != nil {
+		return fmt.Errorf("解析快捷键失败: %v", err)
+	}
+	if len(seq) > 1 {
+		return registerChord(id, seq, hotkeyStr, callback)
 	}
+	return registerHotkeyCombo(id, seq[0], hotkeyStr, callback)
 }
 
-// RegisterHotkey 注册全局快捷键
-func RegisterHotkey(hotkeyStr string, callback HotkeyCallback) error {
+// registerHotkeyCombo 向系统注册一组按键，等待注册结果
+func registerHotkeyCombo(id string, combo hotkeyCombo, hotkeyStr string, callback HotkeyCallback) (err error) {
 	// 添加沙盒环境检测和错误处理
 	defer func() {
 		if r := recover(); r != nil {
 			log.Printf("热键注册崩溃，但继续运行: %v", r)
+			err = fmt.Errorf("注册快捷键失败: %v", r)
 		}
 	}()
 
-	// 先取消之前注册的热键
-	UnregisterHotkey()
+	unregisterHotkeyEntry(id)
 
-	// 解析快捷键字符串
-	mods, key, err := parseHotkeyString(hotkeyStr)
+	mods, key, err := comboToHotkey(combo)
 	if err != nil {
 		return fmt.Errorf("解析快捷键失败: %v", err)
 	}
 
 	// 创建新的上下文用于取消
 	ctx, cancel := context.WithCancel(context.Background())
+	entry := &registeredHotkey{hk: hotkey.New(mods, key), cancel: cancel, hotkey: hotkeyStr, combo: combo.id()}
 
-	hotkeyCancel = cancel
-
+	registered := make(chan error, 1)
 	go func() {
 		defer func() {
 			if r := recover(); r != nil {
@@ -201,22 +143,38 @@ func RegisterHotkey(hotkeyStr string, callback HotkeyCallback) error {
 			}
 		}()
 
-		err := listenHotkeyWithContext(ctx, key, mods, callback)
+		err := listenHotkeyWithContext(ctx, entry.hk, callback, registered)
 		if err != nil {
-			log.Printf("热键监听失败: %v", err)
+			log.Printf("热键监听失败 (%s): %v", id, err)
 		}
 	}()
 
-	log.Printf("成功注册快捷键: %s", hotkeyStr)
+	// 等待系统注册结果（快捷键被其他应用占用等），超时则认为注册仍在进行
+	select {
+	case err := <-registered:
+		if err != nil {
+			cancel()
+			return fmt.Errorf("注册快捷键 %s 失败: %v", hotkeyStr, err)
+		}
+	case <-time.After(hotkeyRegisterTimeout):
+		log.Printf("⚠️ 等待快捷键注册结果超时: %s", hotkeyStr)
+	}
+
+	hotkeysMu.Lock()
+	hotkeys[id] = entry
+	hotkeysMu.Unlock()
+
+	if id != hotkeyProbeID {
+		log.Printf("成功注册快捷键: %s -> %s", id, hotkeyStr)
+	}
 	return nil
 }
 
-func listenHotkeyWithContext(ctx context.Context, key hotkey.Key, mods []hotkey.Modifier, callback HotkeyCallback) (err error) {
-	hk = hotkey.New(mods, key)
-
-	err = hk.Register()
+func listenHotkeyWithContext(ctx context.Context, hk *hotkey.Hotkey, callback HotkeyCallback, registered chan<- error) error {
+	err := hk.Register()
+	registered <- err
 	if err != nil {
-		return
+		return err
 	}
 
 	// 持续监听热键事件
@@ -225,39 +183,95 @@ func listenHotkeyWithContext(ctx context.Context, key hotkey.Key, mods []hotkey.
 		case <-ctx.Done():
 			log.Println("热键监听已取消")
 			return nil
-		default:
+		case <-hk.Keydown():
 			select {
 			case <-ctx.Done():
 				log.Println("热键监听已取消")
 				return nil
-			case <-hk.Keydown():
-				log.Printf("热键被按下")
-				select {
-				case <-ctx.Done():
-					log.Println("热键监听已取消")
-					return nil
-				case <-hk.Keyup():
-					log.Printf("热键被松开")
-					if callback != nil {
-						callback()
-					}
+			case <-hk.Keyup():
+				if callback != nil {
+					callback()
 				}
 			}
 		}
 	}
 }
 
+// probeHotkeyRegistration 逐步临时注册快捷键后立即取消，返回系统拒绝注册的原因
+// 已由本应用注册的按键跳过（再次注册必然失败）
+func probeHotkeyRegistration(seq hotkeySequence) error {
+	for _, combo := range seq {
+		if hotkeyComboRegistered(combo) {
+			continue
+		}
+		err := registerHotkeyCombo(hotkeyProbeID, combo, combo.String(), nil)
+		unregisterHotkeyEntry(hotkeyProbeID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hotkeyComboRegistered 这组按键是否已由本应用注册
+func hotkeyComboRegistered(combo hotkeyCombo) bool {
+	hotkeysMu.Lock()
+	defer hotkeysMu.Unlock()
+	for _, entry := range hotkeys {
+		if entry.combo == combo.id() {
+			return true
+		}
+	}
+	return false
+}
+
 // UnregisterHotkey 取消注册快捷键
-func UnregisterHotkey() {
-	if hotkeyCancel != nil {
-		hotkeyCancel()
-		hotkeyCancel = nil
-		log.Println("✅ 快捷键已取消注册")
+func UnregisterHotkey(id string) {
+	unregisterChord(id)
+	unregisterHotkeyEntry(id)
+}
+
+// unregisterHotkeyEntry 取消注册一组按键
+func unregisterHotkeyEntry(id string) {
+	hotkeysMu.Lock()
+	entry := hotkeys[id]
+	delete(hotkeys, id)
+	hotkeysMu.Unlock()
+
+	if entry != nil {
+		entry.cancel()
+		entry.hk.Unregister()
+		if id != hotkeyProbeID {
+			log.Printf("✅ 快捷键已取消注册: %s", id)
+		}
 	}
+}
+
+// UnregisterAllHotkeys 取消注册所有快捷键
+func UnregisterAllHotkeys() {
+	unregisterAllChords()
 
-	// 确保热键对象也被清理
-	if hk != nil {
-		hk.Unregister()
-		hk = nil
+	hotkeysMu.Lock()
+	ids := make([]string, 0, len(hotkeys))
+	for id := range hotkeys {
+		ids = append(ids, id)
+	}
+	hotkeysMu.Unlock()
+
+	for _, id := range ids {
+		unregisterHotkeyEntry(id)
+	}
+}
+
+// RegisteredHotkeys 当前已注册的快捷键（绑定 ID -> 快捷键），不包括组合键内部使用的注册
+func RegisteredHotkeys() map[string]string {
+	result := registeredChords()
+	hotkeysMu.Lock()
+	defer hotkeysMu.Unlock()
+	for id, entry := range hotkeys {
+		if !isInternalHotkeyID(id) {
+			result[id] = entry.hotkey
+		}
 	}
+	return result
 }
@@ -0,0 +1,144 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// 全局快捷键绑定：每个绑定把一个快捷键映射到一个动作，分别注册和取消注册
+// 显示窗口的快捷键沿用设置中的 hotkey 字段（绑定 ID 为 show_window），其他绑定保存在 hotkeys 字段
+
+// 快捷键动作
+const (
+	HotkeyActionShowWindow    = "show_window"        // 显示窗口
+	HotkeyActionPasteLatest   = "paste_latest_plain" // 以纯文本粘贴最近一条
+	HotkeyActionPasteNth      = "paste_nth"          // 粘贴第 N 条
+	HotkeyActionOpenFavorites = "open_favorites"     // 打开收藏列表
+	HotkeyActionToggleCapture = "toggle_capture"     // 暂停 / 恢复剪贴板捕获
+	HotkeyActionRunScript     = "run_script"         // 对最近一条运行指定脚本
+)
+
+// ShowWindowHotkeyID 设置中 hotkey 字段对应的绑定 ID
+const ShowWindowHotkeyID = "show_window"
+
+// maxPasteNthIndex paste_nth 最大可以粘贴第几条
+const maxPasteNthIndex = 50
+
+// HotkeyBinding 快捷键绑定
+type HotkeyBinding struct {
+	ID       string `json:"id"`
+	Action   string `json:"action"`
+	Hotkey   string `json:"hotkey"`
+	Index    int    `json:"index,omitempty"`    // paste_nth：第几条（从 1 开始）
+	ScriptID string `json:"scriptId,omitempty"` // run_script：脚本 ID
+	Enabled  bool   `json:"enabled"`
+}
+
+// isHotkeyAction 是否是支持的快捷键动作
+func isHotkeyAction(action string) bool {
+	switch action {
+	case HotkeyActionShowWindow, HotkeyActionPasteLatest, HotkeyActionPasteNth,
+		HotkeyActionOpenFavorites, HotkeyActionToggleCapture, HotkeyActionRunScript:
+		return true
+	}
+	return false
+}
+
+// validate 校验单个绑定（不检查快捷键冲突）
+func (b *HotkeyBinding) validate() error {
+	if strings.TrimSpace(b.ID) == "" {
+		return fmt.Errorf("绑定 ID 不能为空")
+	}
+	if b.ID == ShowWindowHotkeyID {
+		return fmt.Errorf("绑定 ID %s 已被显示窗口快捷键使用", b.ID)
+	}
+	if !isHotkeyAction(b.Action) {
+		return fmt.Errorf("%s: 不支持的动作 %s", b.ID, b.Action)
+	}
+	if strings.TrimSpace(b.Hotkey) == "" {
+		return fmt.Errorf("%s: 快捷键不能为空", b.ID)
+	}
+	if err := checkHotkeyString(b.Hotkey); err != nil {
+		return fmt.Errorf("%s: %v", b.ID, err)
+	}
+	switch b.Action {
+	case HotkeyActionPasteNth:
+		if b.Index < 1 || b.Index > maxPasteNthIndex {
+			return fmt.Errorf("%s: 条目序号需要在 1 到 %d 之间", b.ID, maxPasteNthIndex)
+		}
+	case HotkeyActionRunScript:
+		if b.ScriptID == "" {
+			return fmt.Errorf("%s: 没有选择脚本", b.ID)
+		}
+	}
+	return nil
+}
+
+// validateHotkeyBindings 校验所有绑定：ID 不重复、启用的绑定之间以及和显示窗口快捷键之间没有冲突
+// 组合键的第一步和单独的快捷键相同也算冲突
+func validateHotkeyBindings(showWindowHotkey string, bindings []HotkeyBinding) error {
+	ids := make(map[string]bool, len(bindings))
+	if showWindowHotkey == "" {
+		showWindowHotkey = DefaultSettings().Hotkey
+	}
+	used := []HotkeyBinding{{ID: ShowWindowHotkeyID, Hotkey: showWindowHotkey}}
+	for i := range bindings {
+		binding := &bindings[i]
+		if err := binding.validate(); err != nil {
+			return err
+		}
+		if ids[binding.ID] {
+			return fmt.Errorf("绑定 ID %s 重复", binding.ID)
+		}
+		ids[binding.ID] = true
+		if !binding.Enabled {
+			continue
+		}
+		for _, other := range used {
+			if hotkeysOverlap(binding.Hotkey, other.Hotkey) {
+				return fmt.Errorf("%s: 快捷键 %s 与 %s 的 %s 冲突", binding.ID, binding.Hotkey, other.ID, other.Hotkey)
+			}
+		}
+		used = append(used, *binding)
+	}
+	return nil
+}
+
+// EffectiveHotkeyBindings 需要注册的快捷键绑定：显示窗口（hotkey 为空时使用默认快捷键）和启用的 hotkeys
+func EffectiveHotkeyBindings(settings *Settings) []HotkeyBinding {
+	showWindow := settings.Hotkey
+	if showWindow == "" {
+		showWindow = DefaultSettings().Hotkey
+	}
+	bindings := []HotkeyBinding{{ID: ShowWindowHotkeyID, Action: HotkeyActionShowWindow, Hotkey: showWindow, Enabled: true}}
+	for _, binding := range settings.Hotkeys {
+		if binding.Enabled {
+			bindings = append(bindings, binding)
+		}
+	}
+	return bindings
+}
+
+// HotkeyPlainText 项目的纯文本形式：文本和片段使用内容（片段展开占位符），文件使用路径，图片使用 OCR 文字
+// 快捷键没有地方输入值，需要 {{input:Name}} 的片段返回错误
+func HotkeyPlainText(item *ClipboardItem) (string, error) {
+	switch item.ContentType {
+	case SnippetContentType:
+		expansion, err := ExpandSnippetForCopy(item.Content, nil)
+		if err != nil {
+			return "", fmt.Errorf("%v，请在窗口中复制", err)
+		}
+		return expansion.Text, nil
+	case "File":
+		var paths []string
+		if err := json.Unmarshal([]byte(item.FilePaths), &paths); err == nil {
+			return strings.Join(paths, "\n"), nil
+		}
+		return item.FilePaths, nil
+	case "Image":
+		return item.OCRText, nil
+	default:
+		return item.Content, nil
+	}
+}
@@ -0,0 +1,123 @@
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateHotkeyBindings(t *testing.T) {
+	fav := func(id, hotkey string) HotkeyBinding {
+		return HotkeyBinding{ID: id, Action: HotkeyActionOpenFavorites, Hotkey: hotkey, Enabled: true}
+	}
+	tests := []struct {
+		name       string
+		showWindow string
+		bindings   []HotkeyBinding
+		wantErr    string // 错误信息中应包含的内容，空表示通过
+	}{
+		{name: "没有绑定", showWindow: "ctrl+shift+v"},
+		{name: "不同的快捷键", showWindow: "ctrl+shift+v", bindings: []HotkeyBinding{fav("a", "ctrl+shift+f"), fav("b", "alt+1")}},
+		{name: "与显示窗口相同", showWindow: "ctrl+shift+v", bindings: []HotkeyBinding{fav("a", "Shift+Control+V")}, wantErr: "show_window"},
+		{name: "显示窗口为空时使用默认快捷键", showWindow: "", bindings: []HotkeyBinding{fav("a", "cmd+alt+c")}, wantErr: "show_window"},
+		{name: "两个绑定相同", showWindow: "ctrl+shift+v", bindings: []HotkeyBinding{fav("a", "ctrl+k"), fav("b", "control+K")}, wantErr: "b: 快捷键"},
+		{name: "组合键的第一步与单独的快捷键相同", showWindow: "ctrl+shift+v",
+			bindings: []HotkeyBinding{fav("a", "ctrl+k"), fav("b", "ctrl+k ctrl+v")}, wantErr: "冲突"},
+		{name: "组合键第一步相同第二步不同", showWindow: "ctrl+shift+v",
+			bindings: []HotkeyBinding{fav("a", "ctrl+k ctrl+v"), fav("b", "ctrl+k ctrl+c")}},
+		{name: "组合键与显示窗口的第一步冲突", showWindow: "ctrl+k ctrl+w", bindings: []HotkeyBinding{fav("a", "ctrl+k")}, wantErr: "show_window"},
+		{name: "禁用的绑定不检查冲突", showWindow: "ctrl+shift+v",
+			bindings: []HotkeyBinding{{ID: "a", Action: HotkeyActionOpenFavorites, Hotkey: "ctrl+shift+v"}}},
+		{name: "ID 重复", showWindow: "ctrl+shift+v", bindings: []HotkeyBinding{fav("a", "ctrl+1"), fav("a", "ctrl+2")}, wantErr: "重复"},
+		{name: "ID 与显示窗口相同", showWindow: "ctrl+shift+v", bindings: []HotkeyBinding{fav(ShowWindowHotkeyID, "ctrl+1")}, wantErr: "已被显示窗口"},
+		{name: "不支持的动作", showWindow: "ctrl+shift+v",
+			bindings: []HotkeyBinding{{ID: "a", Action: "launch_rocket", Hotkey: "ctrl+1", Enabled: true}}, wantErr: "不支持的动作"},
+		{name: "paste_nth 序号超出", showWindow: "ctrl+shift+v",
+			bindings: []HotkeyBinding{{ID: "a", Action: HotkeyActionPasteNth, Hotkey: "ctrl+1", Index: maxPasteNthIndex + 1, Enabled: true}}, wantErr: "条目序号"},
+		{name: "run_script 没有脚本", showWindow: "ctrl+shift+v",
+			bindings: []HotkeyBinding{{ID: "a", Action: HotkeyActionRunScript, Hotkey: "ctrl+1", Enabled: true}}, wantErr: "没有选择脚本"},
+		{name: "快捷键无法解析", showWindow: "ctrl+shift+v", bindings: []HotkeyBinding{fav("a", "ctrl+nokey")}, wantErr: "不支持的按键"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateHotkeyBindings(tt.showWindow, tt.bindings)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateHotkeyBindings = %v，期望通过", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("validateHotkeyBindings = %v，期望包含 %q 的错误", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateHotkeyConflicts(t *testing.T) {
+	openTestDB(t, 0)
+	if err := initSettings(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UpdateSettings(func(s *Settings) error {
+		s.Hotkey = "ctrl+shift+v"
+		s.Hotkeys = []HotkeyBinding{
+			{ID: "fav", Action: HotkeyActionOpenFavorites, Hotkey: "ctrl+k ctrl+f", Enabled: true},
+			{ID: "off", Action: HotkeyActionToggleCapture, Hotkey: "ctrl+p"},
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		hotkey        string
+		wantError     bool
+		wantConflicts []string
+	}{
+		{hotkey: "ctrl+shift+v", wantConflicts: []string{ShowWindowHotkeyID}},
+		{hotkey: "ctrl+k", wantConflicts: []string{"fav"}},
+		{hotkey: "ctrl+k ctrl+g"},
+		{hotkey: "ctrl+p"}, // 禁用的绑定不算冲突
+		{hotkey: "ctrl+", wantError: true},
+	}
+	for _, tt := range tests {
+		result := ValidateHotkey(tt.hotkey)
+		if (result.Error != "") != tt.wantError {
+			t.Errorf("ValidateHotkey(%q).Error = %q，期望出错 %v", tt.hotkey, result.Error, tt.wantError)
+			continue
+		}
+		if !equalStrings(result.Conflicts, tt.wantConflicts) {
+			t.Errorf("ValidateHotkey(%q).Conflicts = %v，期望 %v", tt.hotkey, result.Conflicts, tt.wantConflicts)
+		}
+		if !tt.wantError && result.ChordTimeoutMs != hotkeyChordTimeout.Milliseconds() {
+			t.Errorf("ChordTimeoutMs = %d", result.ChordTimeoutMs)
+		}
+	}
+}
+
+func TestHotkeyPlainText(t *testing.T) {
+	tests := []struct {
+		name    string
+		item    ClipboardItem
+		want    string
+		wantErr bool
+	}{
+		{name: "文本", item: ClipboardItem{ContentType: "Text", Content: "hello"}, want: "hello"},
+		{name: "文件使用路径", item: ClipboardItem{ContentType: "File", FilePaths: `["/a/b.txt","/c.txt"]`}, want: "/a/b.txt\n/c.txt"},
+		{name: "图片使用 OCR 文字", item: ClipboardItem{ContentType: "Image", Content: "图片 4x4", OCRText: "识别结果"}, want: "识别结果"},
+		{name: "没有 OCR 的图片", item: ClipboardItem{ContentType: "Image", Content: "图片 4x4"}, want: ""},
+		{name: "片段展开占位符", item: ClipboardItem{ContentType: SnippetContentType, Content: "a{{cursor}}b"}, want: "ab"},
+		{name: "需要输入的片段", item: ClipboardItem{ContentType: SnippetContentType, Content: "{{input:Name}}"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := HotkeyPlainText(&tt.item)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("HotkeyPlainText 错误 = %v，期望出错 %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("HotkeyPlainText = %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+//go:build darwin || windows
+// +build darwin windows
+
+package common
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 两步组合键（例如 "Ctrl+K Ctrl+V"）的状态机：
+//   - 空闲：只注册每个组合键的第一步，第一步相同的组合键共用一个注册
+//   - 等待第二步：按下第一步后，临时注册所有以它开头的组合键的第二步，并开始计时
+//   - 按下其中一个第二步则触发对应绑定；超时或再次按下第一步都会回到空闲并取消临时注册
+
+// 组合键内部使用的注册 ID 前缀
+const (
+	chordPrefixIDPrefix = "chord:"      // 第一步
+	chordStepIDPrefix   = "chord-step:" // 等待中的第二步
+)
+
+// chordBinding 已注册的两步组合键
+type chordBinding struct {
+	first    hotkeyCombo
+	second   hotkeyCombo
+	hotkey   string
+	callback HotkeyCallback
+}
+
+var (
+	chordMu      sync.Mutex
+	chords       = make(map[string]*chordBinding) // 绑定 ID -> 组合键
+	chordPending string                           // 正在等待第二步的第一步（hotkeyCombo.id()），为空表示空闲
+	chordSteps   []string                         // 等待中临时注册的第二步
+	chordTimer   *time.Timer
+	chordGen     int // 每次进入等待状态加一，用来忽略过期的超时回调
+)
+
+// isInternalHotkeyID 是否是组合键或检查快捷键时内部使用的注册
+func isInternalHotkeyID(id string) bool {
+	return id == hotkeyProbeID || strings.HasPrefix(id, chordPrefixIDPrefix) || strings.HasPrefix(id, chordStepIDPrefix)
+}
+
+// registerChord 注册两步组合键：第一步尚未注册时向系统注册
+func registerChord(id string, seq hotkeySequence, hotkeyStr string, callback HotkeyCallback) error {
+	chordMu.Lock()
+	defer chordMu.Unlock()
+
+	first := seq[0]
+	if !chordPrefixInUseLocked(first.id()) {
+		firstID := first.id()
+		err := registerHotkeyCombo(chordPrefixIDPrefix+firstID, first, first.String(), func() {
+			go startChord(firstID)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	chords[id] = &chordBinding{first: first, second: seq[1], hotkey: hotkeyStr, callback: callback}
+	log.Printf("成功注册组合键: %s -> %s", id, hotkeyStr)
+	return nil
+}
+
+// unregisterChord 取消注册组合键，没有其他组合键使用同一个第一步时取消第一步的注册
+func unregisterChord(id string) {
+	chordMu.Lock()
+	defer chordMu.Unlock()
+
+	chord, ok := chords[id]
+	if !ok {
+		return
+	}
+	delete(chords, id)
+	firstID := chord.first.id()
+	if chordPending == firstID {
+		endChordLocked()
+	}
+	if !chordPrefixInUseLocked(firstID) {
+		unregisterHotkeyEntry(chordPrefixIDPrefix + firstID)
+	}
+}
+
+// unregisterAllChords 取消注册所有组合键
+func unregisterAllChords() {
+	chordMu.Lock()
+	defer chordMu.Unlock()
+
+	endChordLocked()
+	prefixes := make(map[string]bool)
+	for _, chord := range chords {
+		prefixes[chord.first.id()] = true
+	}
+	chords = make(map[string]*chordBinding)
+	for firstID := range prefixes {
+		unregisterHotkeyEntry(chordPrefixIDPrefix + firstID)
+	}
+}
+
+// registeredChords 已注册的组合键（绑定 ID -> 快捷键）
+func registeredChords() map[string]string {
+	chordMu.Lock()
+	defer chordMu.Unlock()
+	result := make(map[string]string, len(chords))
+	for id, chord := range chords {
+		result[id] = chord.hotkey
+	}
+	return result
+}
+
+func chordPrefixInUseLocked(firstID string) bool {
+	for _, chord := range chords {
+		if chord.first.id() == firstID {
+			return true
+		}
+	}
+	return false
+}
+
+// startChord 按下了第一步：临时注册所有以它开头的组合键的第二步，超时后取消
+func startChord(firstID string) {
+	chordMu.Lock()
+	defer chordMu.Unlock()
+
+	endChordLocked()
+
+	registered := make(map[string]bool)
+	for _, chord := range chords {
+		if chord.first.id() != firstID {
+			continue
+		}
+		secondID := chord.second.id()
+		if registered[secondID] {
+			continue
+		}
+		registered[secondID] = true
+		stepID := chordStepIDPrefix + secondID
+		err := registerHotkeyCombo(stepID, chord.second, chord.second.String(), func() {
+			go finishChord(firstID, secondID)
+		})
+		if err != nil {
+			log.Printf("⚠️ 注册组合键第二步失败 (%s): %v", chord.hotkey, err)
+			continue
+		}
+		chordSteps = append(chordSteps, stepID)
+	}
+	if len(chordSteps) == 0 {
+		return
+	}
+
+	chordPending = firstID
+	chordGen++
+	gen := chordGen
+	chordTimer = time.AfterFunc(hotkeyChordTimeout, func() {
+		chordMu.Lock()
+		defer chordMu.Unlock()
+		if chordGen == gen && chordPending != "" {
+			endChordLocked()
+		}
+	})
+}
+
+// finishChord 等待中按下了第二步：触发对应的绑定并回到空闲
+func finishChord(firstID, secondID string) {
+	chordMu.Lock()
+	if chordPending != firstID {
+		chordMu.Unlock()
+		return
+	}
+	var callbacks []HotkeyCallback
+	for _, chord := range chords {
+		if chord.first.id() == firstID && chord.second.id() == secondID && chord.callback != nil {
+			callbacks = append(callbacks, chord.callback)
+		}
+	}
+	endChordLocked()
+	chordMu.Unlock()
+
+	for _, callback := range callbacks {
+		callback()
+	}
+}
+
+// endChordLocked 回到空闲：停止计时并取消临时注册的第二步
+func endChordLocked() {
+	if chordTimer != nil {
+		chordTimer.Stop()
+		chordTimer = nil
+	}
+	for _, stepID := range chordSteps {
+		unregisterHotkeyEntry(stepID)
+	}
+	chordSteps = nil
+	chordPending = ""
+}
@@ -5,18 +5,53 @@ package common
 
 import "golang.design/x/hotkey"
 
-// mapModifier 将字符串修饰键映射为 macOS 下的热键修饰常量
+// mapModifier 将修饰键（内部名称）映射为 macOS 下的热键修饰常量
 func mapModifier(part string) (hotkey.Modifier, bool) {
 	switch part {
-	case "ctrl", "control":
+	case hotkeyModCtrl:
 		return hotkey.ModCtrl, true
-	case "shift":
+	case hotkeyModShift:
 		return hotkey.ModShift, true
-	case "alt", "option":
+	case hotkeyModAlt:
 		return hotkey.ModOption, true
-	case "cmd", "command", "meta":
+	case hotkeyModCmd:
 		return hotkey.ModCmd, true
 	default:
 		return 0, false
 	}
 }
+
+// platformKeyCodes golang.design/x/hotkey 没有命名的按键，使用 macOS 虚拟键码（kVK_*）
+var platformKeyCodes = map[string]hotkey.Key{
+	"home":        0x73,
+	"end":         0x77,
+	"pageup":      0x74,
+	"pagedown":    0x79,
+	"-":           0x1B,
+	"=":           0x18,
+	"[":           0x21,
+	"]":           0x1E,
+	"\\":          0x2A,
+	";":           0x29,
+	"'":           0x27,
+	",":           0x2B,
+	".":           0x2F,
+	"/":           0x2C,
+	"`":           0x32,
+	"num0":        0x52,
+	"num1":        0x53,
+	"num2":        0x54,
+	"num3":        0x55,
+	"num4":        0x56,
+	"num5":        0x57,
+	"num6":        0x58,
+	"num7":        0x59,
+	"num8":        0x5B,
+	"num9":        0x5C,
+	"numadd":      0x45,
+	"numsubtract": 0x4E,
+	"nummultiply": 0x43,
+	"numdivide":   0x4B,
+	"numdecimal":  0x41,
+	"numenter":    0x4C,
+}
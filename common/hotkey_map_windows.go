@@ -5,18 +5,53 @@ package common
 
 import "golang.design/x/hotkey"
 
-// mapModifier 将字符串修饰键映射为 Windows 下的热键修饰常量
+// mapModifier 将修饰键（内部名称）映射为 Windows 下的热键修饰常量
 func mapModifier(part string) (hotkey.Modifier, bool) {
 	switch part {
-	case "ctrl", "control":
+	case hotkeyModCtrl:
 		return hotkey.ModCtrl, true
-	case "shift":
+	case hotkeyModShift:
 		return hotkey.ModShift, true
-	case "alt", "option":
+	case hotkeyModAlt:
 		return hotkey.ModAlt, true
-	case "cmd", "command", "meta":
+	case hotkeyModCmd:
 		return hotkey.ModWin, true
 	default:
 		return 0, false
 	}
 }
+
+// platformKeyCodes golang.design/x/hotkey 没有命名的按键，使用 Windows 虚拟键码（VK_*）
+// 小键盘回车和主键盘回车是同一个虚拟键码，所以没有 numenter
+var platformKeyCodes = map[string]hotkey.Key{
+	"home":        0x24,
+	"end":         0x23,
+	"pageup":      0x21,
+	"pagedown":    0x22,
+	"-":           0xBD,
+	"=":           0xBB,
+	"[":           0xDB,
+	"]":           0xDD,
+	"\\":          0xDC,
+	";":           0xBA,
+	"'":           0xDE,
+	",":           0xBC,
+	".":           0xBE,
+	"/":           0xBF,
+	"`":           0xC0,
+	"num0":        0x60,
+	"num1":        0x61,
+	"num2":        0x62,
+	"num3":        0x63,
+	"num4":        0x64,
+	"num5":        0x65,
+	"num6":        0x66,
+	"num7":        0x67,
+	"num8":        0x68,
+	"num9":        0x69,
+	"numadd":      0x6B,
+	"numsubtract": 0x6D,
+	"nummultiply": 0x6A,
+	"numdivide":   0x6F,
+	"numdecimal":  0x6E,
+}
@@ -0,0 +1,360 @@
+package common
+
+import (
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// 快捷键字符串解析（与平台无关）："修饰键+...+主键"，不区分大小写，修饰键顺序任意
+// 两步组合键用空格分隔两组按键，例如 "Ctrl+K Ctrl+V"：按下第一组后在限定时间内按第二组才会触发
+// 解析后得到规范形式：修饰键按固定顺序排列，并使用当前系统的名称（macOS: Control/Option/Shift/Command，其他: Ctrl/Alt/Shift/Win）
+
+// 修饰键（内部名称）
+const (
+	hotkeyModCtrl  = "ctrl"
+	hotkeyModAlt   = "alt"
+	hotkeyModShift = "shift"
+	hotkeyModCmd   = "cmd"
+)
+
+// hotkeyModifierOrder 规范形式中修饰键的顺序
+var hotkeyModifierOrder = []string{hotkeyModCtrl, hotkeyModAlt, hotkeyModShift, hotkeyModCmd}
+
+// hotkeyModifierAliases 修饰键的写法 -> 内部名称
+var hotkeyModifierAliases = map[string]string{
+	"ctrl":    hotkeyModCtrl,
+	"control": hotkeyModCtrl,
+	"alt":     hotkeyModAlt,
+	"opt":     hotkeyModAlt,
+	"option":  hotkeyModAlt,
+	"shift":   hotkeyModShift,
+	"cmd":     hotkeyModCmd,
+	"command": hotkeyModCmd,
+	"meta":    hotkeyModCmd,
+	"super":   hotkeyModCmd,
+	"win":     hotkeyModCmd,
+}
+
+// maxHotkeySteps 组合键最多几步
+const maxHotkeySteps = 2
+
+// hotkeyChordTimeout 按下组合键第一步后等待第二步的时间
+const hotkeyChordTimeout = 1500 * time.Millisecond
+
+// hotkeyKeyDef 主键：内部名称和显示名称
+type hotkeyKeyDef struct {
+	name    string
+	display string
+}
+
+// hotkeyKeys 支持的主键（语法上），各平台实际能注册的按键见 hotkeyKeySupported
+var hotkeyKeys = func() []hotkeyKeyDef {
+	var keys []hotkeyKeyDef
+	for c := 'a'; c <= 'z'; c++ {
+		keys = append(keys, hotkeyKeyDef{string(c), strings.ToUpper(string(c))})
+	}
+	for c := '0'; c <= '9'; c++ {
+		keys = append(keys, hotkeyKeyDef{string(c), string(c)})
+	}
+	keys = append(keys,
+		hotkeyKeyDef{"space", "Space"},
+		hotkeyKeyDef{"enter", "Enter"},
+		hotkeyKeyDef{"tab", "Tab"},
+		hotkeyKeyDef{"escape", "Escape"},
+		hotkeyKeyDef{"delete", "Delete"},
+		hotkeyKeyDef{"up", "Up"},
+		hotkeyKeyDef{"down", "Down"},
+		hotkeyKeyDef{"left", "Left"},
+		hotkeyKeyDef{"right", "Right"},
+		hotkeyKeyDef{"home", "Home"},
+		hotkeyKeyDef{"end", "End"},
+		hotkeyKeyDef{"pageup", "PageUp"},
+		hotkeyKeyDef{"pagedown", "PageDown"},
+	)
+	for i := 1; i <= 20; i++ {
+		name := fmt.Sprintf("f%d", i)
+		keys = append(keys, hotkeyKeyDef{name, strings.ToUpper(name)})
+	}
+	for _, c := range []string{"-", "=", "[", "]", "\\", ";", "'", ",", ".", "/", "`"} {
+		keys = append(keys, hotkeyKeyDef{c, c})
+	}
+	for c := '0'; c <= '9'; c++ {
+		keys = append(keys, hotkeyKeyDef{"num" + string(c), "Num" + string(c)})
+	}
+	keys = append(keys,
+		hotkeyKeyDef{"numadd", "NumAdd"},
+		hotkeyKeyDef{"numsubtract", "NumSubtract"},
+		hotkeyKeyDef{"nummultiply", "NumMultiply"},
+		hotkeyKeyDef{"numdivide", "NumDivide"},
+		hotkeyKeyDef{"numdecimal", "NumDecimal"},
+		hotkeyKeyDef{"numenter", "NumEnter"},
+	)
+	return keys
+}()
+
+// hotkeyKeyAliases 主键的其他写法 -> 内部名称（包括浏览器 KeyboardEvent.code 的写法）
+var hotkeyKeyAliases = func() map[string]string {
+	aliases := map[string]string{
+		"return":         "enter",
+		"esc":            "escape",
+		"del":            "delete",
+		"backspace":      "delete",
+		"spacebar":       "space",
+		"arrowup":        "up",
+		"arrowdown":      "down",
+		"arrowleft":      "left",
+		"arrowright":     "right",
+		"pgup":           "pageup",
+		"pgdn":           "pagedown",
+		"minus":          "-",
+		"equal":          "=",
+		"bracketleft":    "[",
+		"bracketright":   "]",
+		"backslash":      "\\",
+		"semicolon":      ";",
+		"quote":          "'",
+		"comma":          ",",
+		"period":         ".",
+		"slash":          "/",
+		"backquote":      "`",
+		"grave":          "`",
+		"numpadadd":      "numadd",
+		"numpadsubtract": "numsubtract",
+		"numpadmultiply": "nummultiply",
+		"numpaddivide":   "numdivide",
+		"numpaddecimal":  "numdecimal",
+		"numpadenter":    "numenter",
+	}
+	for c := '0'; c <= '9'; c++ {
+		aliases["numpad"+string(c)] = "num" + string(c)
+	}
+	return aliases
+}()
+
+var hotkeyKeyDisplay = func() map[string]string {
+	display := make(map[string]string, len(hotkeyKeys))
+	for _, key := range hotkeyKeys {
+		display[key.name] = key.display
+	}
+	return display
+}()
+
+// hotkeyCombo 解析后的一组按键
+type hotkeyCombo struct {
+	Modifiers []string // 内部名称，按 hotkeyModifierOrder 排列
+	Key       string   // 内部名称
+}
+
+// hotkeySequence 解析后的快捷键：一组按键，或两步组合键
+type hotkeySequence []hotkeyCombo
+
+// hotkeyStepSeparator 匹配 "+" 两侧的空白，解析前去掉，剩下的空白用来分隔组合键的每一步
+var hotkeyStepSeparator = regexp.MustCompile(`\s*\+\s*`)
+
+// parseHotkeySequence 解析快捷键字符串（可以是两步组合键）
+func parseHotkeySequence(hotkeyStr string) (hotkeySequence, error) {
+	normalized := hotkeyStepSeparator.ReplaceAllString(strings.ToLower(strings.TrimSpace(hotkeyStr)), "+")
+	steps := strings.Fields(normalized)
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("快捷键不能为空")
+	}
+	if len(steps) > maxHotkeySteps {
+		return nil, fmt.Errorf("组合键最多支持 %d 步，例如: ctrl+k ctrl+v", maxHotkeySteps)
+	}
+	seq := make(hotkeySequence, 0, len(steps))
+	for _, step := range steps {
+		combo, err := parseHotkeyCombo(step)
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, combo)
+	}
+	return seq, nil
+}
+
+// parseHotkeyCombo 解析一组按键
+func parseHotkeyCombo(hotkeyStr string) (hotkeyCombo, error) {
+	var combo hotkeyCombo
+	parts := strings.Split(strings.ToLower(hotkeyStr), "+")
+	if len(parts) < 2 {
+		return combo, fmt.Errorf("快捷键格式错误，至少需要两个键，例如: ctrl+shift+k")
+	}
+
+	seen := make(map[string]bool)
+	for _, part := range parts[:len(parts)-1] {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return combo, fmt.Errorf("快捷键格式错误: %s", hotkeyStr)
+		}
+		mod, ok := hotkeyModifierAliases[part]
+		if !ok {
+			return combo, fmt.Errorf("不支持的修饰键: %s", part)
+		}
+		if seen[mod] {
+			return combo, fmt.Errorf("修饰键重复: %s", part)
+		}
+		seen[mod] = true
+	}
+	for _, mod := range hotkeyModifierOrder {
+		if seen[mod] {
+			combo.Modifiers = append(combo.Modifiers, mod)
+		}
+	}
+
+	key := strings.TrimSpace(parts[len(parts)-1])
+	if alias, ok := hotkeyKeyAliases[key]; ok {
+		key = alias
+	}
+	if _, ok := hotkeyKeyDisplay[key]; !ok {
+		return combo, fmt.Errorf("不支持的按键: %s", key)
+	}
+	if !hotkeyKeySupported(key) {
+		return combo, fmt.Errorf("当前系统不支持按键: %s", hotkeyKeyDisplay[key])
+	}
+	combo.Key = key
+	return combo, nil
+}
+
+// id 用于比较是否是同一组按键
+func (c hotkeyCombo) id() string {
+	return strings.Join(append(append([]string{}, c.Modifiers...), c.Key), "+")
+}
+
+// String 规范形式
+func (c hotkeyCombo) String() string {
+	parts := make([]string, 0, len(c.Modifiers)+1)
+	for _, mod := range c.Modifiers {
+		parts = append(parts, hotkeyModifierName(mod))
+	}
+	return strings.Join(append(parts, hotkeyKeyDisplay[c.Key]), "+")
+}
+
+// id 用于比较是否是同一个快捷键
+func (s hotkeySequence) id() string {
+	ids := make([]string, len(s))
+	for i, combo := range s {
+		ids[i] = combo.id()
+	}
+	return strings.Join(ids, " ")
+}
+
+// String 规范形式
+func (s hotkeySequence) String() string {
+	parts := make([]string, len(s))
+	for i, combo := range s {
+		parts[i] = combo.String()
+	}
+	return strings.Join(parts, " ")
+}
+
+// overlaps 两个快捷键是否冲突：完全相同，或者一个是另一个组合键的第一步（按下后无法区分）
+func (s hotkeySequence) overlaps(other hotkeySequence) bool {
+	if s.id() == other.id() {
+		return true
+	}
+	if len(s) != len(other) && len(s) > 0 && len(other) > 0 {
+		return s[0].id() == other[0].id()
+	}
+	return false
+}
+
+// hotkeyModifierName 修饰键在当前系统上的名称
+func hotkeyModifierName(mod string) string {
+	mac := runtime.GOOS == "darwin"
+	switch mod {
+	case hotkeyModCtrl:
+		if mac {
+			return "Control"
+		}
+		return "Ctrl"
+	case hotkeyModAlt:
+		if mac {
+			return "Option"
+		}
+		return "Alt"
+	case hotkeyModShift:
+		return "Shift"
+	case hotkeyModCmd:
+		if mac {
+			return "Command"
+		}
+		return "Win"
+	}
+	return mod
+}
+
+// checkHotkeyString 检查快捷键字符串能否解析（不注册）
+func checkHotkeyString(hotkeyStr string) error {
+	_, err := parseHotkeySequence(hotkeyStr)
+	return err
+}
+
+// hotkeysOverlap 两个快捷键字符串是否冲突，无法解析时比较原字符串
+func hotkeysOverlap(a, b string) bool {
+	seqA, errA := parseHotkeySequence(a)
+	seqB, errB := parseHotkeySequence(b)
+	if errA != nil || errB != nil {
+		return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+	}
+	return seqA.overlaps(seqB)
+}
+
+// HotkeyValidation 快捷键检查结果
+type HotkeyValidation struct {
+	Valid         bool     `json:"valid"`         // 格式正确且系统允许注册
+	Canonical     string   `json:"canonical"`     // 规范形式，保存设置时应使用这个值
+	Error         string   `json:"error"`         // 格式错误或不支持的按键
+	Conflicts     []string `json:"conflicts"`     // 已经使用这个快捷键的绑定 ID（show_window 为显示窗口快捷键）
+	RegisterError string   `json:"registerError"` // 系统拒绝注册的原因（例如已被其他应用占用）
+
+	GlobalHotkeys      bool     `json:"globalHotkeys"`      // 当前系统是否支持全局快捷键
+	SupportedModifiers []string `json:"supportedModifiers"` // 当前系统上的修饰键名称
+	SupportedKeys      []string `json:"supportedKeys"`      // 支持的主键
+	ChordTimeoutMs     int64    `json:"chordTimeoutMs"`     // 两步组合键第二步的等待时间（毫秒）
+}
+
+// ValidateHotkey 检查快捷键：解析为规范形式、查找已保存的绑定中的冲突，并尝试向系统注册一次（组合键逐步注册）
+// 已经被本应用注册的按键不会重复注册，只报告冲突
+func ValidateHotkey(hotkeyStr string) *HotkeyValidation {
+	result := &HotkeyValidation{
+		GlobalHotkeys:  globalHotkeysSupported,
+		SupportedKeys:  make([]string, 0, len(hotkeyKeys)),
+		Conflicts:      []string{},
+		ChordTimeoutMs: hotkeyChordTimeout.Milliseconds(),
+	}
+	for _, mod := range hotkeyModifierOrder {
+		result.SupportedModifiers = append(result.SupportedModifiers, hotkeyModifierName(mod))
+	}
+	for _, key := range hotkeyKeys {
+		if hotkeyKeySupported(key.name) {
+			result.SupportedKeys = append(result.SupportedKeys, key.display)
+		}
+	}
+
+	seq, err := parseHotkeySequence(hotkeyStr)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Canonical = seq.String()
+
+	if DB != nil {
+		if settings, err := LoadSettings(); err == nil {
+			for _, binding := range EffectiveHotkeyBindings(settings) {
+				if hotkeysOverlap(binding.Hotkey, result.Canonical) {
+					result.Conflicts = append(result.Conflicts, binding.ID)
+				}
+			}
+		}
+	}
+
+	if err := probeHotkeyRegistration(seq); err != nil {
+		result.RegisterError = err.Error()
+	}
+
+	result.Valid = result.RegisterError == ""
+	return result
+}
@@ -0,0 +1,113 @@
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseHotkeySequence(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantID  string // 内部名称形式（与平台无关）
+		wantErr string // 错误信息中应包含的内容，非空表示应该解析失败
+	}{
+		// 修饰键别名和顺序
+		{input: "ctrl+shift+k", wantID: "ctrl+shift+k"},
+		{input: "Shift+Control+K", wantID: "ctrl+shift+k"},
+		{input: "command+option+c", wantID: "alt+cmd+c"},
+		{input: "meta+opt+c", wantID: "alt+cmd+c"},
+		{input: "super+alt+c", wantID: "alt+cmd+c"},
+		{input: "win+c", wantID: "cmd+c"},
+		{input: " ctrl + shift + k ", wantID: "ctrl+shift+k"},
+		// 主键别名（包括 KeyboardEvent.code 的写法）
+		{input: "ctrl+return", wantID: "ctrl+enter"},
+		{input: "ctrl+esc", wantID: "ctrl+escape"},
+		{input: "ctrl+backspace", wantID: "ctrl+delete"},
+		{input: "alt+arrowup", wantID: "alt+up"},
+		{input: "ctrl+pgdn", wantID: "ctrl+pagedown"},
+		{input: "ctrl+Slash", wantID: "ctrl+/"},
+		{input: "ctrl+backquote", wantID: "ctrl+`"},
+		{input: "ctrl+numpad7", wantID: "ctrl+num7"},
+		{input: "ctrl+numpadenter", wantID: "ctrl+numenter"},
+		{input: "alt+F12", wantID: "alt+f12"},
+		// 两步组合键
+		{input: "ctrl+k ctrl+v", wantID: "ctrl+k ctrl+v"},
+		{input: "Control+K   Shift+Control+V", wantID: "ctrl+k ctrl+shift+v"},
+		{input: "ctrl + k ctrl + v", wantID: "ctrl+k ctrl+v"},
+		// 错误
+		{input: "", wantErr: "不能为空"},
+		{input: "k", wantErr: "至少需要两个键"},
+		{input: "ctrl+k ctrl+v ctrl+c", wantErr: "最多支持"},
+		{input: "ctrl+k v", wantErr: "至少需要两个键"},
+		{input: "hyper+k", wantErr: "不支持的修饰键"},
+		{input: "ctrl+control+k", wantErr: "修饰键重复"},
+		{input: "ctrl+", wantErr: "不支持的按键"},
+		{input: "ctrl++k", wantErr: "格式错误"},
+		{input: "ctrl+f21", wantErr: "不支持的按键"},
+	}
+	for _, tt := range tests {
+		seq, err := parseHotkeySequence(tt.input)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseHotkeySequence(%q) 错误 = %v，期望包含 %q", tt.input, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseHotkeySequence(%q) 失败: %v", tt.input, err)
+			continue
+		}
+		if got := seq.id(); got != tt.wantID {
+			t.Errorf("parseHotkeySequence(%q) = %s，期望 %s", tt.input, got, tt.wantID)
+		}
+	}
+}
+
+func TestHotkeySequenceString(t *testing.T) {
+	ctrl, shift, cmd := hotkeyModifierName(hotkeyModCtrl), hotkeyModifierName(hotkeyModShift), hotkeyModifierName(hotkeyModCmd)
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"shift+ctrl+k", ctrl + "+" + shift + "+K"},
+		{"meta+f5", cmd + "+F5"},
+		{"ctrl+numpadadd", ctrl + "+NumAdd"},
+		{"ctrl+k ctrl+pgup", ctrl + "+K " + ctrl + "+PageUp"},
+	}
+	for _, tt := range tests {
+		seq, err := parseHotkeySequence(tt.input)
+		if err != nil {
+			t.Fatalf("parseHotkeySequence(%q) 失败: %v", tt.input, err)
+		}
+		if got := seq.String(); got != tt.want {
+			t.Errorf("%q 的规范形式 = %q，期望 %q", tt.input, got, tt.want)
+		}
+		// 规范形式再次解析得到相同的快捷键
+		again, err := parseHotkeySequence(seq.String())
+		if err != nil || again.id() != seq.id() {
+			t.Errorf("规范形式 %q 重新解析 = %v, %v", seq.String(), again, err)
+		}
+	}
+}
+
+func TestHotkeysOverlap(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"ctrl+k", "Control+K", true},
+		{"ctrl+k", "ctrl+shift+k", false},
+		{"ctrl+k", "ctrl+k ctrl+v", true}, // 第一步相同，按下 ctrl+k 后无法区分
+		{"ctrl+k ctrl+v", "ctrl+k", true},
+		{"ctrl+k ctrl+v", "ctrl+k ctrl+c", false},
+		{"ctrl+k ctrl+v", "control+K control+V", true},
+		{"ctrl+j ctrl+v", "ctrl+k ctrl+v", false},
+		{"bogus", "BOGUS", true}, // 无法解析时比较原字符串
+		{"bogus", "ctrl+k", false},
+	}
+	for _, tt := range tests {
+		if got := hotkeysOverlap(tt.a, tt.b); got != tt.want {
+			t.Errorf("hotkeysOverlap(%q, %q) = %v，期望 %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -3,13 +3,34 @@
 
 package common
 
+// globalHotkeysSupported 非 macOS/Windows 平台不支持全局快捷键
+const globalHotkeysSupported = false
+
 // HotkeyCallback 快捷键回调函数类型
 type HotkeyCallback func()
 
-// RegisterHotkey 非 macOS/Windows 平台暂不支持，返回错误
-func RegisterHotkey(hotkeyStr string, callback HotkeyCallback) error {
+// RegisterHotkey 非 macOS/Windows 平台暂不支持，不注册
+func RegisterHotkey(id string, hotkeyStr string, callback HotkeyCallback) error {
 	return nil
 }
 
 // UnregisterHotkey 非 macOS/Windows 平台无操作
-func UnregisterHotkey() {}
+func UnregisterHotkey(id string) {}
+
+// UnregisterAllHotkeys 非 macOS/Windows 平台无操作
+func UnregisterAllHotkeys() {}
+
+// RegisteredHotkeys 非 macOS/Windows 平台没有注册的快捷键
+func RegisteredHotkeys() map[string]string {
+	return map[string]string{}
+}
+
+// probeHotkeyRegistration 非 macOS/Windows 平台不注册，始终成功
+func probeHotkeyRegistration(seq hotkeySequence) error {
+	return nil
+}
+
+// hotkeyKeySupported 非 macOS/Windows 平台只检查语法，所有按键都接受
+func hotkeyKeySupported(name string) bool {
+	return true
+}
@@ -1,8 +1,8 @@
 package common
 
 import (
-	"encoding/json"
 	"fmt"
+	"sync"
 )
 
 const AppVersion = "2.3.4"
@@ -31,6 +31,9 @@ type LanguagePack struct {
 var languagePacks = make(map[string]LanguagePack)
 var currentLanguage = DefaultLanguage
 
+// languageMu 保护 currentLanguage（设置订阅在保存设置的 goroutine 中切换语言，T 可能同时在其他 goroutine 中调用）
+var languageMu sync.RWMutex
+
 // 初始化国际化
 func InitI18n() error {
 	// 加载中文语言包
@@ -148,14 +151,8 @@ func InitI18n() error {
 	languagePacks[LangArabic] = arPack
 
 	// 尝试从数据库加载用户的语言设置
-	settingsJSON, err := GetSetting("app_settings")
-	if err == nil && settingsJSON != "" {
-		var settings map[string]interface{}
-		if json.Unmarshal([]byte(settingsJSON), &settings) == nil {
-			if lang, ok := settings["language"].(string); ok && (lang == LangChinese || lang == LangEnglish || lang == LangFrench || lang == LangArabic) {
-				currentLanguage = lang
-			}
-		}
+	if settings, err := LoadSettings(); err == nil && settings.Language != "" {
+		setCurrentLanguage(settings.Language)
 	}
 
 	return nil
@@ -166,31 +163,44 @@ func SetLanguage(lang string) error {
 	if _, exists := languagePacks[lang]; !exists {
 		return fmt.Errorf("unsupported language: %s", lang)
 	}
-	currentLanguage = lang
 
-	// 保存语言设置到数据库
-	settingsJSON, err := GetSetting("app_settings")
-	var settings map[string]interface{}
-	if err != nil || settingsJSON == "" {
-		settings = make(map[string]interface{})
-	} else {
-		json.Unmarshal([]byte(settingsJSON), &settings)
-	}
-	settings["language"] = lang
-	newSettingsJSON, _ := json.Marshal(settings)
-	SaveSetting("app_settings", string(newSettingsJSON))
+	// 保存语言设置到数据库（只修改 language 字段，切换由设置订阅完成）
+	_, err := UpdateSettings(func(s *Settings) error {
+		s.Language = lang
+		return nil
+	})
+	return err
+}
 
-	return nil
+// 设置中的语言变化时切换当前语言
+func init() {
+	SubscribeSettings("i18n", []string{"language"}, func(change SettingsChange) error {
+		lang := change.Settings.Language
+		if lang == "" {
+			lang = DefaultLanguage
+		}
+		setCurrentLanguage(lang)
+		return nil
+	})
+}
+
+// setCurrentLanguage 切换当前语言
+func setCurrentLanguage(lang string) {
+	languageMu.Lock()
+	defer languageMu.Unlock()
+	currentLanguage = lang
 }
 
 // 获取当前语言
 func GetCurrentLanguage() string {
+	languageMu.RLock()
+	defer languageMu.RUnlock()
 	return currentLanguage
 }
 
 // 获取翻译文本
 func T(key string, args ...interface{}) string {
-	pack, exists := languagePacks[currentLanguage]
+	pack, exists := languagePacks[GetCurrentLanguage()]
 	if !exists {
 		pack = languagePacks[LangChinese] // 回退到中文
 	}
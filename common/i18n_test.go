@@ -0,0 +1,62 @@
+package common
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestTranslate(t *testing.T) {
+	if err := InitI18n(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { setCurrentLanguage(DefaultLanguage) })
+
+	tests := []struct {
+		lang string
+		key  string
+		want string
+	}{
+		{LangChinese, "menu.search", "查找"},
+		{LangEnglish, "menu.search", "Search"},
+		{LangEnglish, "no.such.key", "no.such.key"},
+		{"xx-XX", "menu.search", "查找"}, // 未知语言回退到中文
+	}
+	for _, tt := range tests {
+		setCurrentLanguage(tt.lang)
+		if got := T(tt.key); got != tt.want {
+			t.Errorf("[%s] T(%q) = %q，期望 %q", tt.lang, tt.key, got, tt.want)
+		}
+	}
+}
+
+// TestLanguageSwitchConcurrent 设置订阅切换语言时其他 goroutine 仍在翻译（配合 -race 检查）
+func TestLanguageSwitchConcurrent(t *testing.T) {
+	if err := InitI18n(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { setCurrentLanguage(DefaultLanguage) })
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			lang := LangEnglish
+			if i%2 == 0 {
+				lang = LangFrench
+			}
+			setCurrentLanguage(lang)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			if T("app.version") != AppVersion {
+				t.Error("翻译结果错误")
+				return
+			}
+			GetCurrentLanguage()
+		}
+	}()
+	wg.Wait()
+}
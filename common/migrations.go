@@ -0,0 +1,341 @@
+package common
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ErrSchemaTooNew 数据库由更新版本的应用创建，当前版本不认识其中的结构
+var ErrSchemaTooNew = errors.New("数据库版本高于当前应用支持的版本")
+
+// migration 一次数据库结构变更
+// up 在事务中执行，必须能在老用户的数据库上安全运行（字段/表可能已由旧的检查逻辑创建）
+type migration struct {
+	version int
+	name    string
+	up      func(tx *sql.Tx) error
+	vacuum  bool // 执行后需要 VACUUM 回收空间（VACUUM 不能在事务中执行）
+}
+
+// migrations 按版本号升序排列的迁移列表
+// 新增字段或表时在末尾追加一条迁移，不要修改已经发布的迁移
+var migrations = []migration{
+	{version: 1, name: "create_base_tables", up: migrateCreateBaseTables},
+	{version: 2, name: "add_content_hash", up: migrateAddContentHash},
+	{version: 3, name: "add_ocr_text", up: migrateAddOCRText},
+	{version: 4, name: "add_is_favorite", up: migrateAddIsFavorite},
+	{version: 5, name: "create_user_scripts", up: migrateCreateUserScripts},
+	{version: 6, name: "add_script_plugin_fields", up: migrateAddScriptPluginFields},
+	{version: 7, name: "extract_image_blobs", up: migrateExtractImageBlobs, vacuum: true},
+	{version: 8, name: "add_thumbnail", up: migrateAddThumbnail},
+	{version: 9, name: "create_tags", up: migrateCreateTags},
+	{version: 10, name: "add_sensitive_fields", up: migrateAddSensitiveFields},
+	{version: 11, name: "create_capture_rules", up: migrateCreateCaptureRules},
+	{version: 12, name: "add_paste_limit", up: migrateAddPasteLimit},
+	{version: 13, name: "create_script_storage", up: migrateCreateScriptStorage},
+	{version: 14, name: "create_script_runs", up: migrateCreateScriptRuns},
+}
+
+// MigrationInfo 迁移记录
+type MigrationInfo struct {
+	Version    int       `json:"version"`
+	Name       string    `json:"name"`
+	Applied    bool      `json:"applied"`
+	AppliedAt  time.Time `json:"appliedAt"`
+	AppVersion string    `json:"appVersion"` // 执行迁移时的应用版本
+}
+
+// LatestSchemaVersion 当前应用支持的最新数据库版本
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// ensureMigrationTable 创建迁移记录表
+func ensureMigrationTable() error {
+	_, err := DB.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		app_version TEXT,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	return err
+}
+
+// CurrentSchemaVersion 获取数据库当前的结构版本（没有执行过迁移时为 0）
+func CurrentSchemaVersion() (int, error) {
+	if DB == nil {
+		return 0, fmt.Errorf("数据库未初始化")
+	}
+	// 只读检查，试运行时不能创建迁移记录表
+	var tableCount int
+	if err := DB.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='schema_migrations'`).Scan(&tableCount); err != nil {
+		return 0, fmt.Errorf("检查迁移记录表失败: %v", err)
+	}
+	if tableCount == 0 {
+		return 0, nil
+	}
+	var version int
+	if err := DB.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("查询数据库版本失败: %v", err)
+	}
+	return version, nil
+}
+
+// MigrateDB 执行所有未执行的迁移，返回本次执行（或将要执行）的迁移
+// dryRun 为 true 时在事务中试运行全部待执行迁移后回滚，用于提前发现问题，不修改数据库
+// 数据库版本高于当前应用时返回 ErrSchemaTooNew，不做任何修改
+func MigrateDB(dryRun bool) ([]MigrationInfo, error) {
+	current, err := CurrentSchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	if latest := LatestSchemaVersion(); current > latest {
+		return nil, fmt.Errorf("%w（数据库版本 %d，应用支持 %d），请升级 ClipSave", ErrSchemaTooNew, current, latest)
+	}
+
+	var pending []migration
+	for _, m := range migrations {
+		if m.version > current {
+			pending = append(pending, m)
+		}
+	}
+	if len(pending) == 0 {
+		log.Printf("✅ 数据库已是最新版本 (v%d)", current)
+		return nil, nil
+	}
+
+	if dryRun {
+		return dryRunMigrations(pending)
+	}
+
+	if err := ensureMigrationTable(); err != nil {
+		return nil, fmt.Errorf("创建迁移记录表失败: %v", err)
+	}
+
+	var applied []MigrationInfo
+	needVacuum := false
+	for _, m := range pending {
+		info, err := applyMigration(m)
+		if err != nil {
+			return applied, err
+		}
+		applied = append(applied, info)
+		needVacuum = needVacuum || m.vacuum
+	}
+
+	if needVacuum {
+		log.Printf("🔧 正在压缩数据库...")
+		if _, err := DB.Exec(`VACUUM`); err != nil {
+			// 压缩失败不影响使用
+			log.Printf("⚠️ 压缩数据库失败: %v", err)
+		}
+	}
+	return applied, nil
+}
+
+// applyMigration 在单独的事务中执行一条迁移并记录
+func applyMigration(m migration) (MigrationInfo, error) {
+	log.Printf("🔧 执行数据库迁移 v%d: %s", m.version, m.name)
+	tx, err := DB.Begin()
+	if err != nil {
+		return MigrationInfo{}, fmt.Errorf("开启事务失败: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := m.up(tx); err != nil {
+		return MigrationInfo{}, fmt.Errorf("迁移 v%d (%s) 失败: %v", m.version, m.name, err)
+	}
+	now := time.Now()
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version, name, app_version, applied_at) VALUES (?, ?, ?, ?)`,
+		m.version, m.name, AppVersion, now); err != nil {
+		return MigrationInfo{}, fmt.Errorf("记录迁移 v%d 失败: %v", m.version, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return MigrationInfo{}, fmt.Errorf("提交迁移 v%d 失败: %v", m.version, err)
+	}
+	log.Printf("✅ 数据库迁移 v%d 完成", m.version)
+	return MigrationInfo{Version: m.version, Name: m.name, Applied: true, AppliedAt: now, AppVersion: AppVersion}, nil
+}
+
+// migrationDryRun 正在试运行迁移；迁移中数据库以外的修改（例如写文件）需要检查它，试运行时跳过
+var migrationDryRun bool
+
+// dryRunMigrations 在一个事务中依次执行待执行迁移后回滚
+func dryRunMigrations(pending []migration) ([]MigrationInfo, error) {
+	migrationDryRun = true
+	defer func() { migrationDryRun = false }()
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败: %v", err)
+	}
+	defer tx.Rollback()
+
+	var planned []MigrationInfo
+	for _, m := range pending {
+		if err := m.up(tx); err != nil {
+			return planned, fmt.Errorf("试运行迁移 v%d (%s) 失败: %v", m.version, m.name, err)
+		}
+		planned = append(planned, MigrationInfo{Version: m.version, Name: m.name})
+		log.Printf("🔍 [dry-run] 迁移 v%d (%s) 可以执行", m.version, m.name)
+	}
+	return planned, nil
+}
+
+// GetSchemaMigrations 获取全部迁移及其执行状态
+func GetSchemaMigrations() ([]MigrationInfo, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	if err := ensureMigrationTable(); err != nil {
+		return nil, fmt.Errorf("创建迁移记录表失败: %v", err)
+	}
+
+	rows, err := DB.Query(`SELECT version, name, COALESCE(app_version, ''), applied_at FROM schema_migrations ORDER BY version ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("查询迁移记录失败: %v", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]MigrationInfo)
+	var unknown []MigrationInfo
+	for rows.Next() {
+		var info MigrationInfo
+		if err := rows.Scan(&info.Version, &info.Name, &info.AppVersion, &info.AppliedAt); err != nil {
+			log.Printf("扫描迁移记录失败: %v", err)
+			continue
+		}
+		info.Applied = true
+		applied[info.Version] = info
+		if info.Version > LatestSchemaVersion() {
+			// 更新版本的应用执行过的迁移，也一并列出
+			unknown = append(unknown, info)
+		}
+	}
+
+	result := make([]MigrationInfo, 0, len(migrations)+len(unknown))
+	for _, m := range migrations {
+		if info, ok := applied[m.version]; ok {
+			result = append(result, info)
+			continue
+		}
+		result = append(result, MigrationInfo{Version: m.version, Name: m.name})
+	}
+	return append(result, unknown...), nil
+}
+
+// columnExists 检查表中是否存在指定字段
+func columnExists(tx *sql.Tx, table, column string) (bool, error) {
+	var count int
+	err := tx.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("检查%s.%s字段是否存在失败: %v", table, column, err)
+	}
+	return count > 0, nil
+}
+
+// addColumnIfMissing 字段不存在时添加（老用户的数据库可能已经由旧版本添加过）
+func addColumnIfMissing(tx *sql.Tx, table, column, definition string) error {
+	exists, err := columnExists(tx, table, column)
+	if err != nil || exists {
+		return err
+	}
+	if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("添加%s字段失败: %v", column, err)
+	}
+	log.Printf("✅ 已添加%s字段", column)
+	return nil
+}
+
+// migrateCreateBaseTables v1: 剪贴板记录表和设置表
+func migrateCreateBaseTables(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+	CREATE TABLE IF NOT EXISTS clipboard_items (
+		id TEXT PRIMARY KEY,
+		content TEXT NOT NULL,
+		content_type TEXT NOT NULL,
+		image_data BLOB,
+		file_paths TEXT,
+		file_info TEXT,
+		timestamp DATETIME NOT NULL,
+		source TEXT,
+		char_count INTEGER,
+		word_count INTEGER,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_timestamp ON clipboard_items(timestamp DESC);
+	CREATE INDEX IF NOT EXISTS idx_content_type ON clipboard_items(content_type);
+
+	CREATE TABLE IF NOT EXISTS app_settings (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`)
+	return err
+}
+
+// migrateAddContentHash v2: 内容哈希（用于去重）
+func migrateAddContentHash(tx *sql.Tx) error {
+	if err := addColumnIfMissing(tx, "clipboard_items", "content_hash", "TEXT"); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_content_hash ON clipboard_items(content_hash, content_type)`)
+	return err
+}
+
+// migrateAddOCRText v3: 图片 OCR 文字
+// 搜索使用前后通配符的 LIKE 或全文索引，普通索引用不上，所以不建索引
+func migrateAddOCRText(tx *sql.Tx) error {
+	return addColumnIfMissing(tx, "clipboard_items", "ocr_text", "TEXT")
+}
+
+// migrateAddIsFavorite v4: 收藏
+func migrateAddIsFavorite(tx *sql.Tx) error {
+	if err := addColumnIfMissing(tx, "clipboard_items", "is_favorite", "INTEGER DEFAULT 0"); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_is_favorite ON clipboard_items(is_favorite)`)
+	return err
+}
+
+// migrateCreateUserScripts v5: 用户脚本表
+func migrateCreateUserScripts(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+	CREATE TABLE IF NOT EXISTS user_scripts (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		enabled INTEGER DEFAULT 1,
+		trigger TEXT NOT NULL,
+		content_types TEXT, -- JSON 数组
+		keywords TEXT, -- JSON 数组
+		script TEXT NOT NULL,
+		description TEXT,
+		sort_order INTEGER DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_script_trigger ON user_scripts(trigger);
+	CREATE INDEX IF NOT EXISTS idx_script_enabled ON user_scripts(enabled);
+	CREATE INDEX IF NOT EXISTS idx_script_sort_order ON user_scripts(sort_order);
+	`)
+	return err
+}
+
+// migrateAddScriptPluginFields v6: 在线插件的 ID 和版本号
+func migrateAddScriptPluginFields(tx *sql.Tx) error {
+	if err := addColumnIfMissing(tx, "user_scripts", "plugin_id", "TEXT"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(tx, "user_scripts", "plugin_version", "TEXT"); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_script_plugin_id ON user_scripts(plugin_id)`)
+	return err
+}
@@ -0,0 +1,102 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// schemaSnapshot 返回数据库结构和迁移记录，用于比较试运行前后是否有变化
+func schemaSnapshot(t *testing.T) string {
+	t.Helper()
+	rows, err := DB.Query(`SELECT type, name, COALESCE(sql, '') FROM sqlite_master ORDER BY type, name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var snapshot string
+	for rows.Next() {
+		var typ, name, sql string
+		if err := rows.Scan(&typ, &name, &sql); err != nil {
+			t.Fatal(err)
+		}
+		snapshot += fmt.Sprintf("%s %s: %s\n", typ, name, sql)
+	}
+	var versions string
+	if err := DB.QueryRow(`SELECT COALESCE(GROUP_CONCAT(version), '') FROM schema_migrations`).Scan(&versions); err != nil {
+		t.Fatal(err)
+	}
+	return snapshot + "migrations: " + versions
+}
+
+func TestMigrateDBDryRun(t *testing.T) {
+	latest := LatestSchemaVersion()
+	for _, upTo := range []int{1, 6, latest - 1} {
+		t.Run(fmt.Sprintf("从v%d开始", upTo), func(t *testing.T) {
+			openTestDB(t, upTo)
+			before := schemaSnapshot(t)
+
+			planned, err := MigrateDB(true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(planned) != latest-upTo || planned[0].Version != upTo+1 {
+				t.Fatalf("试运行计划 = %+v，期望 v%d 到 v%d", planned, upTo+1, latest)
+			}
+			for _, info := range planned {
+				if info.Applied {
+					t.Errorf("试运行的迁移 v%d 标记为已执行", info.Version)
+				}
+			}
+			if after := schemaSnapshot(t); after != before {
+				t.Errorf("试运行修改了数据库:\n之前 %s\n之后 %s", before, after)
+			}
+			if current, _ := CurrentSchemaVersion(); current != upTo {
+				t.Errorf("试运行后版本 = %d，期望 %d", current, upTo)
+			}
+
+			// 试运行之后正式执行的是同一批迁移
+			applied, err := MigrateDB(false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(applied) != len(planned) {
+				t.Errorf("执行了 %d 个迁移，试运行计划 %d 个", len(applied), len(planned))
+			}
+			if planned, err := MigrateDB(true); err != nil || len(planned) != 0 {
+				t.Errorf("已是最新版本时试运行 = %+v, %v", planned, err)
+			}
+		})
+	}
+}
+
+func TestMigrateDBSchemaTooNew(t *testing.T) {
+	openTestDB(t, 0)
+	newer := LatestSchemaVersion() + 1
+	if _, err := DB.Exec(`INSERT INTO schema_migrations (version, name, app_version, applied_at) VALUES (?, 'from_the_future', '99.0.0', CURRENT_TIMESTAMP)`, newer); err != nil {
+		t.Fatal(err)
+	}
+	before := schemaSnapshot(t)
+
+	for _, dryRun := range []bool{false, true} {
+		applied, err := MigrateDB(dryRun)
+		if !errors.Is(err, ErrSchemaTooNew) {
+			t.Errorf("MigrateDB(%v) 错误 = %v，期望 ErrSchemaTooNew", dryRun, err)
+		}
+		if len(applied) != 0 {
+			t.Errorf("MigrateDB(%v) 返回了 %d 个迁移", dryRun, len(applied))
+		}
+	}
+	if after := schemaSnapshot(t); after != before {
+		t.Errorf("版本过高时修改了数据库:\n之前 %s\n之后 %s", before, after)
+	}
+
+	// 更新版本执行过的迁移也出现在列表末尾
+	infos, err := GetSchemaMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := infos[len(infos)-1]; last.Version != newer || !last.Applied || last.AppVersion != "99.0.0" {
+		t.Errorf("最后一条迁移记录 = %+v，期望 v%d", last, newer)
+	}
+}
@@ -0,0 +1,341 @@
+package common
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// monitorPollInterval 轮询间隔，缩短到 50ms 以便更及时地捕获剪贴板变化
+const monitorPollInterval = 50 * time.Millisecond
+
+// ownWriteTTL 应用自己写入的文本在多长时间内被捕获时跳过（超时后按普通复制处理）
+const ownWriteTTL = 5 * time.Second
+
+// MonitorStatus 捕获器状态
+type MonitorStatus struct {
+	Running     bool      `json:"running"`
+	Paused      bool      `json:"paused"`
+	PausedUntil time.Time `json:"pausedUntil"` // 暂停截止时间，零值表示无限期暂停
+}
+
+// MonitorStatusCallback 捕获器状态变化回调
+type MonitorStatusCallback func(status MonitorStatus)
+
+// Monitor 剪贴板捕获器：从 ClipboardBackend 读取变化，按 图片 > 文件 > 文本 的优先级去重后写入 ClipboardStore
+type Monitor struct {
+	backend ClipboardBackend
+	store   ClipboardStore
+
+	// 以下字段只在轮询协程中访问
+	lastTextContent           string
+	lastImageHash             string
+	lastFileHash              string
+	lastPasteboardChangeCount int
+
+	mu             sync.Mutex
+	cancel         context.CancelFunc
+	done           chan struct{}
+	paused         bool
+	pausedUntil    time.Time
+	resync         bool // 恢复后需要先同步变化计数，跳过暂停期间复制的内容
+	statusCallback MonitorStatusCallback
+
+	// 应用自己写入剪贴板的文本（复制历史项目时 on_copy 脚本或片段展开的结果），捕获时跳过一次
+	ownWriteHash string
+	ownWriteAt   time.Time
+}
+
+// NewMonitor 使用指定的剪贴板后端和存储创建捕获器
+func NewMonitor(backend ClipboardBackend, store ClipboardStore) *Monitor {
+	return &Monitor{
+		backend: backend,
+		store:   store,
+	}
+}
+
+// SetStatusCallback 设置状态变化回调（暂停、恢复、启动、停止时触发）
+func (m *Monitor) SetStatusCallback(callback MonitorStatusCallback) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statusCallback = callback
+}
+
+// Start 启动捕获循环，ctx 取消或调用 Stop 时退出
+func (m *Monitor) Start(ctx context.Context) error {
+	m.mu.Lock()
+	if m.cancel != nil {
+		m.mu.Unlock()
+		return fmt.Errorf("剪贴板捕获已在运行")
+	}
+	loopCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	m.cancel = cancel
+	m.done = done
+	m.mu.Unlock()
+
+	go m.loop(loopCtx, done)
+
+	log.Println("✅ 剪贴板捕获已启动")
+	m.notifyStatus()
+	return nil
+}
+
+// Stop 停止捕获循环并等待其退出
+func (m *Monitor) Stop() {
+	m.mu.Lock()
+	cancel, done := m.cancel, m.done
+	m.cancel = nil
+	m.done = nil
+	m.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+
+	log.Println("✅ 剪贴板捕获已停止")
+	m.notifyStatus()
+}
+
+// Pause 暂停捕获（隐私模式），duration <= 0 表示无限期暂停直到调用 Resume
+// 暂停期间复制的内容在恢复后也不会被记录
+func (m *Monitor) Pause(duration time.Duration) {
+	m.mu.Lock()
+	m.paused = true
+	if duration > 0 {
+		m.pausedUntil = time.Now().Add(duration)
+	} else {
+		m.pausedUntil = time.Time{}
+	}
+	m.mu.Unlock()
+
+	if duration > 0 {
+		log.Printf("⏸️ 剪贴板捕获已暂停 %v", duration)
+	} else {
+		log.Println("⏸️ 剪贴板捕获已暂停")
+	}
+	m.notifyStatus()
+}
+
+// Resume 恢复捕获
+func (m *Monitor) Resume() {
+	m.mu.Lock()
+	wasPaused := m.paused
+	m.paused = false
+	m.pausedUntil = time.Time{}
+	m.resync = true
+	m.mu.Unlock()
+
+	if wasPaused {
+		log.Println("▶️ 剪贴板捕获已恢复")
+		m.notifyStatus()
+	}
+}
+
+// IgnoreOwnWrite 记录应用即将写入剪贴板的文本，下次捕获到相同文本时跳过，避免复制历史项目时产生重复记录
+// 需要在写入剪贴板之前调用
+func (m *Monitor) IgnoreOwnWrite(text string) {
+	h := sha256.Sum256([]byte(text))
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ownWriteHash = hex.EncodeToString(h[:])
+	m.ownWriteAt = time.Now()
+}
+
+// takeOwnWrite 判断文本是否是应用自己写入的，是则清除记录（每次写入只跳过一次）
+func (m *Monitor) takeOwnWrite(text string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ownWriteHash == "" {
+		return false
+	}
+	if time.Since(m.ownWriteAt) > ownWriteTTL {
+		m.ownWriteHash = ""
+		return false
+	}
+	h := sha256.Sum256([]byte(text))
+	if hex.EncodeToString(h[:]) != m.ownWriteHash {
+		return false
+	}
+	m.ownWriteHash = ""
+	return true
+}
+
+// Status 获取当前状态
+func (m *Monitor) Status() MonitorStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return MonitorStatus{
+		Running:     m.cancel != nil,
+		Paused:      m.paused,
+		PausedUntil: m.pausedUntil,
+	}
+}
+
+// notifyStatus 将当前状态通知给回调
+func (m *Monitor) notifyStatus() {
+	m.mu.Lock()
+	callback := m.statusCallback
+	m.mu.Unlock()
+
+	if callback != nil {
+		callback(m.Status())
+	}
+}
+
+// checkPaused 检查是否处于暂停状态，到期时自动恢复
+// 返回 paused 表示本次应跳过捕获，resync 表示需要先同步变化计数
+func (m *Monitor) checkPaused() (paused bool, resync bool) {
+	m.mu.Lock()
+	expired := m.paused && !m.pausedUntil.IsZero() && time.Now().After(m.pausedUntil)
+	if expired {
+		m.paused = false
+		m.pausedUntil = time.Time{}
+		m.resync = true
+	}
+	paused = m.paused
+	resync = m.resync
+	m.resync = false
+	m.mu.Unlock()
+
+	if expired {
+		log.Println("▶️ 暂停时间已到，剪贴板捕获已自动恢复")
+		m.notifyStatus()
+	}
+	return paused, resync
+}
+
+func (m *Monitor) loop(ctx context.Context, done chan struct{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("剪贴板捕获循环崩溃: %v", r)
+		}
+		// 外部 ctx 被取消时也要清理运行状态
+		m.mu.Lock()
+		if m.done == done {
+			m.cancel = nil
+			m.done = nil
+		}
+		m.mu.Unlock()
+		close(done)
+	}()
+
+	ticker := time.NewTicker(monitorPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			paused, resync := m.checkPaused()
+			if paused || resync {
+				// 暂停期间只跟踪变化计数，不读取内容
+				m.lastPasteboardChangeCount = m.backend.ChangeCount()
+				continue
+			}
+			m.Poll()
+		}
+	}
+}
+
+// Poll 检查一次剪贴板，有变化时完成捕获、保存与 after_save 脚本分发
+// 返回本次是否检测到剪贴板变化。捕获器运行中（Start 之后）由内部循环调用，不要并发调用
+func (m *Monitor) Poll() bool {
+	// 使用 changeCount 精确检测剪贴板是否变化
+	currentChangeCount := m.backend.ChangeCount()
+	if currentChangeCount == m.lastPasteboardChangeCount {
+		// 剪贴板没有变化，继续下一次循环
+		return false
+	}
+	m.lastPasteboardChangeCount = currentChangeCount
+
+	// 获取当前活动应用
+	sourceAppName := m.backend.FrontmostAppName()
+
+	// 捕获规则在解码图片之前执行，被忽略的内容不做任何处理
+	rules, err := m.store.GetEnabledCaptureRules()
+	if err != nil {
+		log.Printf("⚠️ 读取捕获规则失败: %v", err)
+	}
+	applyRule := func(contentType string, size func() int64) (captureOptions, bool) {
+		rule := matchCaptureRule(rules, captureSubject{appName: sourceAppName, contentType: contentType, size: size})
+		if rule != nil && rule.Action == CaptureActionIgnore {
+			log.Printf("⏭️ 捕获规则「%s」忽略来自 %s 的%s内容", rule.Name, sourceAppName, contentType)
+			return captureOptions{}, false
+		}
+		return captureOptionsFor(rule), true
+	}
+
+	// 优先级1: 先检测图片（截图场景最常见）
+	imgData := m.backend.ReadImage()
+	if len(imgData) > 0 {
+		opts, capture := applyRule("Image", func() int64 { return int64(len(imgData)) })
+		if !capture {
+			return true
+		}
+		// 统一转换为PNG格式后计算哈希，确保相同图片内容产生相同哈希值
+		pngData, err := convertToPNG(imgData)
+		if err != nil {
+			log.Printf("❌ 转换图片为PNG失败: %v", err)
+			return true
+		}
+		// 对PNG数据计算哈希值来判断是否是新图片
+		h := sha256.Sum256(pngData)
+		imageHash := hex.EncodeToString(h[:])
+		if imageHash != m.lastImageHash {
+			m.lastImageHash = imageHash
+			m.lastTextContent = ""
+			m.lastFileHash = ""
+
+			m.handleImageClipboard(imgData, sourceAppName, imageHash, opts)
+		}
+		return true
+	}
+
+	// 优先级2: 不是图片，再检测文件
+	fileJSON, fileCount := m.backend.ReadFileURLs()
+	if fileCount > 0 && fileJSON != "" {
+		opts, capture := applyRule("File", func() int64 { return filePathsTotalSize(fileJSON) })
+		if !capture {
+			return true
+		}
+		// 使用完整路径集合的稳定哈希，避免前缀相同导致的误判
+		fileHash := calculateFilePathsHash(fileJSON)
+		if fileHash != m.lastFileHash {
+			m.lastFileHash = fileHash
+			m.lastTextContent = ""
+			m.lastImageHash = ""
+			m.handleFileClipboard(fileJSON, fileCount, sourceAppName, fileHash, opts)
+		}
+		return true
+	}
+
+	// 优先级3: 没有图片和文件，检查文本
+	content := m.backend.ReadText()
+	if content != m.lastTextContent && content != "" {
+		if m.takeOwnWrite(content) {
+			// 应用自己写入的内容不再记录，但仍作为上次的内容，避免之后重复检测
+			m.lastTextContent = content
+			m.lastImageHash = ""
+			m.lastFileHash = ""
+			log.Printf("⏭️ 跳过应用自己写入剪贴板的文本")
+			return true
+		}
+		opts, capture := applyRule(detectContentType(content), func() int64 { return int64(len(content)) })
+		if !capture {
+			return true
+		}
+		m.lastTextContent = content
+		m.lastImageHash = ""
+		m.lastFileHash = ""
+		m.handleTextClipboard(content, sourceAppName, opts)
+	}
+	return true
+}
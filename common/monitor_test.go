@@ -0,0 +1,351 @@
+package common
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memClipboardStore 内存中的 ClipboardStore，按 content_hash 去重（和 SaveClipboardItem 一致）
+type memClipboardStore struct {
+	mu      sync.Mutex
+	items   []ClipboardItem
+	byHash  map[string]string
+	saves   int
+	scripts map[string][]UserScript
+	tags    map[string][]string
+	rules   []CaptureRule
+}
+
+func newMemClipboardStore() *memClipboardStore {
+	return &memClipboardStore{
+		byHash:  make(map[string]string),
+		scripts: make(map[string][]UserScript),
+		tags:    make(map[string][]string),
+	}
+}
+
+func (s *memClipboardStore) SaveClipboardItem(item *ClipboardItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saves++
+	if id, ok := s.byHash[item.ContentHash]; ok {
+		item.ID = id
+		return nil
+	}
+	s.byHash[item.ContentHash] = item.ID
+	s.items = append(s.items, *item)
+	return nil
+}
+
+func (s *memClipboardStore) UpdateOCRText(id string, ocrText string) error { return nil }
+
+func (s *memClipboardStore) FindOCRTextByHash(contentHash string) (string, string, error) {
+	return "", "", nil
+}
+
+func (s *memClipboardStore) GetEnabledUserScripts(trigger string) ([]UserScript, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]UserScript(nil), s.scripts[trigger]...), nil
+}
+
+func (s *memClipboardStore) LoadSensitivePolicy() (SensitivePolicy, error) {
+	return SensitivePolicy{Enabled: false}, nil
+}
+
+func (s *memClipboardStore) GetEnabledCaptureRules() ([]CaptureRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rules, nil
+}
+
+func (s *memClipboardStore) AddTagNamesToItem(itemID string, names []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tags[itemID] = append(s.tags[itemID], names...)
+	return nil
+}
+
+func (s *memClipboardStore) snapshot() []ClipboardItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ClipboardItem(nil), s.items...)
+}
+
+func newTestMonitor(t *testing.T) (*Monitor, *FakeClipboardBackend, *memClipboardStore) {
+	t.Helper()
+	backend := NewFakeClipboardBackend()
+	store := newMemClipboardStore()
+	return NewMonitor(backend, store), backend, store
+}
+
+func testPNG(t *testing.T, c color.Color) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 4; y++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestMonitorPollNoChange(t *testing.T) {
+	m, backend, store := newTestMonitor(t)
+	backend.SetText("hello")
+	if !m.Poll() {
+		t.Fatal("第一次 Poll 应该检测到变化")
+	}
+	if m.Poll() {
+		t.Fatal("变化计数没变时 Poll 应该返回 false")
+	}
+	if got := len(store.snapshot()); got != 1 {
+		t.Fatalf("保存了 %d 个项目，期望 1", got)
+	}
+}
+
+func TestMonitorPriority(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(file, []byte("abc"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		set  func(f *FakeClipboardBackend)
+		want string
+	}{
+		{"图片优先于文件和文本", func(f *FakeClipboardBackend) {
+			f.SetImage(testPNG(t, color.White))
+			f.mu.Lock()
+			f.text = "also text"
+			f.fileJSON, f.fileCount = `["`+filepath.ToSlash(file)+`"]`, 1
+			f.mu.Unlock()
+		}, "Image"},
+		{"文件优先于文本", func(f *FakeClipboardBackend) {
+			if err := f.SetFiles([]string{file}); err != nil {
+				t.Fatal(err)
+			}
+			f.mu.Lock()
+			f.text = file
+			f.mu.Unlock()
+		}, "File"},
+		{"只有文本", func(f *FakeClipboardBackend) { f.SetText("https://example.com") }, "URL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, backend, store := newTestMonitor(t)
+			backend.SetFrontmostApp("Editor")
+			tt.set(backend)
+			m.Poll()
+
+			items := store.snapshot()
+			if len(items) != 1 {
+				t.Fatalf("保存了 %d 个项目，期望 1", len(items))
+			}
+			if items[0].ContentType != tt.want {
+				t.Errorf("ContentType = %s，期望 %s", items[0].ContentType, tt.want)
+			}
+			if items[0].Source != "Editor" {
+				t.Errorf("Source = %s，期望 Editor", items[0].Source)
+			}
+		})
+	}
+}
+
+func TestMonitorDedup(t *testing.T) {
+	m, backend, store := newTestMonitor(t)
+
+	backend.SetText("same")
+	m.Poll()
+	// 其他应用重新写入相同内容：变化计数增加，但和上次捕获的内容相同
+	backend.Touch()
+	m.Poll()
+	if store.saves != 1 {
+		t.Fatalf("相同内容重复写入后保存了 %d 次，期望 1", store.saves)
+	}
+
+	// 中间复制过其他内容后再复制回来：Monitor 会再次保存，由存储按哈希去重
+	backend.SetText("other")
+	m.Poll()
+	backend.SetText("same")
+	m.Poll()
+	if store.saves != 3 {
+		t.Fatalf("保存了 %d 次，期望 3", store.saves)
+	}
+	if got := len(store.snapshot()); got != 2 {
+		t.Fatalf("去重后有 %d 个项目，期望 2", got)
+	}
+
+	// 相同图片只保存一次
+	img := testPNG(t, color.Black)
+	backend.SetImage(img)
+	m.Poll()
+	backend.SetImage(img)
+	m.Poll()
+	if store.saves != 4 {
+		t.Fatalf("相同图片再次复制后保存了 %d 次，期望 4", store.saves)
+	}
+}
+
+func TestMonitorIgnoreOwnWrite(t *testing.T) {
+	m, backend, store := newTestMonitor(t)
+	backend.SetText("original")
+	m.Poll()
+	expansion, err := ExpandSnippetForCopy("Hi {{input:Name}},", map[string]string{"Name": "Ann"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 每一步：应用记录的自己写入的文本 → 剪贴板中的文本 → 是否保存为新项目
+	steps := []struct {
+		name     string
+		own      string
+		text     string
+		wantSave bool
+	}{
+		{name: "on_copy 脚本改写后的文本", own: "ORIGINAL", text: "ORIGINAL", wantSave: false},
+		{name: "之后其他应用复制的内容", text: "from editor", wantSave: true},
+		{name: "相同文本再次由用户复制", text: "ORIGINAL", wantSave: true}, // 每次写入只跳过一次
+		{name: "片段展开后的文本", own: expansion.Text, text: "Hi Ann,", wantSave: false},
+		{name: "剪贴板被其他应用抢先改写", own: "expanded", text: "from another app", wantSave: true},
+	}
+	for _, step := range steps {
+		saves := store.saves
+		if step.own != "" {
+			m.IgnoreOwnWrite(step.own)
+		}
+		backend.SetText(step.text)
+		m.Poll()
+		if saved := store.saves != saves; saved != step.wantSave {
+			t.Errorf("%s: 保存 = %v，期望 %v", step.name, saved, step.wantSave)
+		}
+	}
+
+	// 超过有效期的记录不再生效
+	m.IgnoreOwnWrite("stale")
+	m.mu.Lock()
+	m.ownWriteAt = time.Now().Add(-ownWriteTTL - time.Second)
+	m.mu.Unlock()
+	backend.SetText("stale")
+	m.Poll()
+	if got := len(store.snapshot()); got != 5 {
+		t.Errorf("有 %d 个项目，期望 5", got)
+	}
+}
+
+func TestMonitorCaptureRules(t *testing.T) {
+	m, backend, store := newTestMonitor(t)
+	store.rules = compileTestRules(t,
+		CaptureRule{Name: "skip-vault", AppPattern: "vault*", Action: CaptureActionIgnore},
+		CaptureRule{Name: "expire-chat", AppPattern: "chat", Action: CaptureActionExpire, ExpireMinutes: 30},
+	)
+
+	tests := []struct {
+		app        string
+		text       string
+		wantSaved  bool
+		wantExpiry bool
+	}{
+		{app: "Vault Desktop", text: "hunter2"},
+		{app: "Editor", text: "notes", wantSaved: true},
+		{app: "Chat", text: "see you at 5", wantSaved: true, wantExpiry: true},
+		{app: "Vault Desktop", text: "notes"}, // 已保存过的内容从被忽略的应用复制时同样不保存
+	}
+	for _, tt := range tests {
+		before := store.saves
+		backend.SetFrontmostApp(tt.app)
+		backend.SetText(tt.text)
+		m.Poll()
+		if saved := store.saves != before; saved != tt.wantSaved {
+			t.Fatalf("%s 的 %q: 保存 = %v，期望 %v", tt.app, tt.text, saved, tt.wantSaved)
+		}
+		if !tt.wantSaved {
+			continue
+		}
+		items := store.snapshot()
+		if item := items[len(items)-1]; (item.ExpiresAt != nil) != tt.wantExpiry {
+			t.Errorf("%q 的 ExpiresAt = %v，期望设置 %v", tt.text, item.ExpiresAt, tt.wantExpiry)
+		}
+	}
+	if got := len(store.snapshot()); got != 2 {
+		t.Errorf("保存了 %d 个项目，期望 2", got)
+	}
+}
+
+func TestMonitorBeforeSave(t *testing.T) {
+	m, backend, store := newTestMonitor(t)
+	store.scripts["before_save"] = []UserScript{
+		{ID: "veto", Name: "veto", Trigger: "before_save", Keywords: []string{"secret"}, Script: "return false"},
+		{ID: "upper", Name: "upper", Trigger: "before_save", ContentType: []string{"Text"},
+			Script: "return { content: item.Content.toUpperCase(), tags: ['shout'] }"},
+	}
+
+	backend.SetText("my secret")
+	m.Poll()
+	if got := len(store.snapshot()); got != 0 {
+		t.Fatalf("被拒绝的内容保存了 %d 个项目", got)
+	}
+
+	backend.SetText("hello")
+	m.Poll()
+	items := store.snapshot()
+	if len(items) != 1 || items[0].Content != "HELLO" {
+		t.Fatalf("保存的项目 = %+v，期望内容为 HELLO", items)
+	}
+	if items[0].ContentHash != calculateContentHash(&ClipboardItem{Content: "HELLO", ContentType: "Text"}) {
+		t.Errorf("哈希应该按修改后的内容计算")
+	}
+	if tags := store.tags[items[0].ID]; len(tags) != 1 || tags[0] != "shout" {
+		t.Errorf("标签 = %v，期望 [shout]", tags)
+	}
+}
+
+func TestMonitorAfterSaveDispatch(t *testing.T) {
+	m, backend, store := newTestMonitor(t)
+	store.scripts["after_save"] = []UserScript{
+		{ID: "notify", Name: "notify", Trigger: "after_save", ContentType: []string{"Text"},
+			Script: "csNotify('saved', item.Content)"},
+	}
+
+	notified := make(chan string, 4)
+	previous := globalScriptEventCallback
+	globalScriptEventCallback = func(eventName string, data interface{}) {
+		if eventName == "script.notify" {
+			notified <- data.(map[string]interface{})["message"].(string)
+		}
+	}
+	defer func() { globalScriptEventCallback = previous }()
+
+	// 不匹配的内容类型不会执行
+	backend.SetText("https://example.com")
+	m.Poll()
+	backend.SetText("plain text")
+	m.Poll()
+
+	select {
+	case message := <-notified:
+		if message != "plain text" {
+			t.Fatalf("after_save 脚本收到 %q，期望 plain text", message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("after_save 脚本没有执行")
+	}
+	select {
+	case message := <-notified:
+		t.Fatalf("不匹配的项目也执行了 after_save 脚本: %q", message)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
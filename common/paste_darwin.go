@@ -102,6 +102,24 @@ static void sendCmdV() {
     }
 }
 
+// 发送 n 次左方向键（用于片段 {{cursor}} 定位）
+static void sendLeftArrow(int n) {
+    const CGKeyCode keyLeft = (CGKeyCode)123;
+    for (int i = 0; i < n; i++) {
+        CGEventRef down = CGEventCreateKeyboardEvent(NULL, keyLeft, true);
+        if (down != NULL) {
+            CGEventPost(kCGHIDEventTap, down);
+            CFRelease(down);
+        }
+        CGEventRef up = CGEventCreateKeyboardEvent(NULL, keyLeft, false);
+        if (up != NULL) {
+            CGEventPost(kCGHIDEventTap, up);
+            CFRelease(up);
+        }
+        usleep(2000); // 2ms
+    }
+}
+
 // 获取之前的前台应用的进程 ID
 static pid_t getPreviousAppPID() {
     // 验证记录的 PID 是否有效
@@ -168,6 +186,14 @@ func PasteCmdV() {
 	C.sendCmdV()
 }
 
+// MoveCursorLeft 把光标向左移动 n 个字符
+func MoveCursorLeft(n int) {
+	if n <= 0 {
+		return
+	}
+	C.sendLeftArrow(C.int(n))
+}
+
 func ActivatePreviousApp() {
 	// 获取之前的前台应用的进程 ID（优先使用记录的 PID）
 	pid := C.getPreviousAppPID()
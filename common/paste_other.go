@@ -20,6 +20,10 @@ func ActivateAppByPID(pid int) bool {
 func PasteCmdV() {
 }
 
+// MoveCursorLeft 其他平台空实现
+func MoveCursorLeft(n int) {
+}
+
 // ActivatePreviousApp 其他平台空实现
 func ActivatePreviousApp() {
 }
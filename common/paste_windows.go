@@ -30,6 +30,7 @@ const (
 	KEYEVENTF_KEYUP_PASTE = 0x0002
 	VK_CONTROL_PASTE      = 0x11
 	VK_V_PASTE            = 0x56
+	VK_LEFT_PASTE         = 0x25
 	SW_RESTORE_PASTE      = 9
 	SW_SHOW_PASTE         = 5
 )
@@ -318,6 +319,25 @@ func sendCtrlVWithKeybdEvent_paste() {
 	procKeybdEvent.Call(VK_CONTROL_PASTE, 0, KEYEVENTF_KEYUP_PASTE, 0)
 }
 
+// MoveCursorLeft 把光标向左移动 n 个字符
+func MoveCursorLeft(n int) {
+	if n <= 0 {
+		return
+	}
+	inputs := make([]INPUT_PASTE, 0, n*2)
+	for i := 0; i < n; i++ {
+		inputs = append(inputs,
+			INPUT_PASTE{Type: INPUT_KEYBOARD_PASTE, Ki: KEYBDINPUT_PASTE{Wvk: VK_LEFT_PASTE}},
+			INPUT_PASTE{Type: INPUT_KEYBOARD_PASTE, Ki: KEYBDINPUT_PASTE{Wvk: VK_LEFT_PASTE, Dwflags: KEYEVENTF_KEYUP_PASTE}},
+		)
+	}
+	procSendInput_paste.Call(
+		uintptr(len(inputs)),
+		uintptr(unsafe.Pointer(&inputs[0])),
+		uintptr(unsafe.Sizeof(inputs[0])),
+	)
+}
+
 // ActivatePreviousApp 激活之前的应用
 func ActivatePreviousApp() {
 	windowMutex.Lock()
@@ -0,0 +1,245 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// 历史记录保留策略在 Go 中按计划执行，窗口不打开（后台模式）时也会生效
+// 收藏和带有受保护标签的项目（protectedItemsClause）不会被任何策略删除
+
+const (
+	// retentionInterval 两次自动清理的间隔
+	retentionInterval = time.Hour
+	// retentionStartupDelay 启动后第一次清理的延迟，避免和启动时的其他工作抢资源
+	retentionStartupDelay = 30 * time.Second
+	// retentionSettingsDelay 保留策略修改后重新清理的延迟（连续修改时只执行一次）
+	retentionSettingsDelay = 5 * time.Second
+	// retentionStatusKey 上次清理结果在 app_settings 中的 key
+	retentionStatusKey = "retention_last_run"
+)
+
+// RetentionPolicy 保留策略（来自 app_settings）
+type RetentionPolicy struct {
+	AutoClean       bool           `json:"autoClean"`             // 总开关
+	RetentionDays   int            `json:"retentionDays"`         // 最长保留天数，0 表示不限制
+	MaxItems        int            `json:"retentionMaxItems"`     // 最多保留的项目数，0 表示不限制
+	MaxStorageMB    int            `json:"retentionMaxStorageMB"` // 历史记录最多占用的空间（MB），0 表示不限制
+	TypeLimits      map[string]int `json:"retentionTypeLimits"`   // 每种内容类型最多保留的项目数，如 {"Image": 200}
+	maxStorageBytes int64
+}
+
+// RetentionResult 一次清理的结果
+type RetentionResult struct {
+	RanAt    time.Time
+	Duration int64          // 耗时（毫秒）
+	Removed  int            // 删除的项目总数
+	ByPolicy map[string]int // 各策略删除的项目数：age / type:<类型> / count / storage
+	Skipped  bool           // 自动清理已关闭，本次未执行
+	Error    string
+}
+
+// loadRetentionPolicy 从应用设置读取保留策略
+func loadRetentionPolicy() (RetentionPolicy, error) {
+	settings, err := LoadSettings()
+	policy := RetentionPolicy{
+		AutoClean:     settings.AutoClean,
+		RetentionDays: settings.RetentionDays,
+		MaxItems:      settings.RetentionMaxItems,
+		MaxStorageMB:  settings.RetentionMaxStorageMB,
+		TypeLimits:    settings.RetentionTypeLimits,
+	}
+	policy.maxStorageBytes = int64(policy.MaxStorageMB) * 1024 * 1024
+	return policy, err
+}
+
+// itemStorageSizeSQL 估算单个项目占用的空间（图片的 char_count 即 PNG 字节数）
+const itemStorageSizeSQL = `COALESCE(length(CAST(content AS BLOB)), 0) + COALESCE(length(ocr_text), 0) +
+	COALESCE(length(file_paths), 0) + COALESCE(length(file_info), 0) + COALESCE(length(thumbnail), 0) +
+	CASE WHEN content_type = 'Image' THEN COALESCE(char_count, 0) ELSE 0 END`
+
+// RunRetention 按当前设置立即执行一次清理
+func RunRetention() (*RetentionResult, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	start := time.Now()
+	result := &RetentionResult{RanAt: start, ByPolicy: make(map[string]int)}
+	err := runRetention(result)
+	result.Duration = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+	}
+	saveRetentionResult(result)
+
+	if result.Removed > 0 {
+		log.Printf("🧹 自动清理删除了 %d 条项目 %v", result.Removed, result.ByPolicy)
+		collectImageBlobs()
+		notifyListeners()
+	}
+	return result, err
+}
+
+// runRetention 依次执行各项策略：最长保留时间、按类型数量、总数量、总空间
+func runRetention(result *RetentionResult) error {
+	policy, err := loadRetentionPolicy()
+	if err != nil {
+		return err
+	}
+	if !policy.AutoClean {
+		result.Skipped = true
+		return nil
+	}
+
+	remove := func(policyName string, query string, args ...interface{}) error {
+		res, err := DB.Exec(query, args...)
+		if err != nil {
+			return fmt.Errorf("执行清理策略 %s 失败: %v", policyName, err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			result.ByPolicy[policyName] += int(n)
+			result.Removed += int(n)
+		}
+		return nil
+	}
+
+	if policy.RetentionDays > 0 {
+		cutoff := time.Now().AddDate(0, 0, -policy.RetentionDays)
+		if err := remove("age", `DELETE FROM clipboard_items WHERE `+protectedItemsClause+` AND timestamp < ?`,
+			cutoff.Format("2006-01-02 15:04:05")); err != nil {
+			return err
+		}
+	}
+
+	// 数量限制只统计可删除的项目：受保护的项目始终保留，不占用名额
+	for contentType, limit := range policy.TypeLimits {
+		if limit <= 0 {
+			continue
+		}
+		if err := remove("type:"+contentType, `DELETE FROM clipboard_items WHERE id IN (
+			SELECT id FROM clipboard_items WHERE content_type = ? AND `+protectedItemsClause+`
+			ORDER BY timestamp DESC LIMIT -1 OFFSET ?)`, contentType, limit); err != nil {
+			return err
+		}
+	}
+
+	if policy.MaxItems > 0 {
+		if err := remove("count", `DELETE FROM clipboard_items WHERE id IN (
+			SELECT id FROM clipboard_items WHERE `+protectedItemsClause+`
+			ORDER BY timestamp DESC LIMIT -1 OFFSET ?)`, policy.MaxItems); err != nil {
+			return err
+		}
+	}
+
+	// 空间限制包含受保护项目占用的空间，超出部分从最旧的可删除项目开始删除
+	if policy.maxStorageBytes > 0 {
+		var protectedBytes int64
+		if err := DB.QueryRow(`SELECT COALESCE(SUM(` + itemStorageSizeSQL + `), 0) FROM clipboard_items
+			WHERE NOT (` + protectedItemsClause + `)`).Scan(&protectedBytes); err != nil {
+			return fmt.Errorf("统计受保护项目空间失败: %v", err)
+		}
+		budget := policy.maxStorageBytes - protectedBytes
+		if err := remove("storage", `DELETE FROM clipboard_items WHERE id IN (
+			SELECT id FROM (
+				SELECT id, SUM(`+itemStorageSizeSQL+`) OVER (ORDER BY timestamp DESC, id DESC) AS running
+				FROM clipboard_items WHERE `+protectedItemsClause+`
+			) WHERE running > ?)`, budget); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveRetentionResult 持久化最近一次清理结果
+func saveRetentionResult(result *RetentionResult) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+	if err := SaveSetting(retentionStatusKey, string(data)); err != nil {
+		log.Printf("⚠️ 保存清理结果失败: %v", err)
+	}
+}
+
+// GetLastRetentionResult 获取最近一次清理结果，从未执行过时返回 nil
+func GetLastRetentionResult() (*RetentionResult, error) {
+	value, err := GetSetting(retentionStatusKey)
+	if err != nil || value == "" {
+		return nil, err
+	}
+	var result RetentionResult
+	if err := json.Unmarshal([]byte(value), &result); err != nil {
+		return nil, fmt.Errorf("解析清理结果失败: %v", err)
+	}
+	return &result, nil
+}
+
+var (
+	retentionMu     sync.Mutex
+	retentionCancel context.CancelFunc
+)
+
+// StartRetentionScheduler 启动定时清理（重复调用会先停止之前的计划）
+func StartRetentionScheduler() {
+	startRetentionScheduler(retentionStartupDelay)
+	log.Printf("✅ 自动清理计划已启动（每 %v 执行一次）", retentionInterval)
+}
+
+// startRetentionScheduler 启动定时清理，第一次清理在 delay 之后执行
+func startRetentionScheduler(delay time.Duration) {
+	StopRetentionScheduler()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	retentionMu.Lock()
+	retentionCancel = cancel
+	retentionMu.Unlock()
+
+	go func() {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+				if _, err := RunRetention(); err != nil {
+					log.Printf("⚠️ 自动清理失败: %v", err)
+				}
+				timer.Reset(retentionInterval)
+			}
+		}
+	}()
+}
+
+// retentionSchedulerRunning 定时清理是否已启动
+func retentionSchedulerRunning() bool {
+	retentionMu.Lock()
+	defer retentionMu.Unlock()
+	return retentionCancel != nil
+}
+
+// 保留策略修改后按新策略重新安排清理（清理时读取已保存的设置，所以延迟执行）
+func init() {
+	keys := []string{"autoClean", "retentionDays", "retentionMaxItems", "retentionMaxStorageMB", "retentionTypeLimits"}
+	SubscribeSettings("retention", keys, func(change SettingsChange) error {
+		if retentionSchedulerRunning() {
+			startRetentionScheduler(retentionSettingsDelay)
+		}
+		return nil
+	})
+}
+
+// StopRetentionScheduler 停止定时清理
+func StopRetentionScheduler() {
+	retentionMu.Lock()
+	defer retentionMu.Unlock()
+	if retentionCancel != nil {
+		retentionCancel()
+		retentionCancel = nil
+	}
+}
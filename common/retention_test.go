@@ -0,0 +1,133 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// retentionItemSize 每个测试项目的内容大小，4 个正好是 2MB
+const retentionItemSize = 512 * 1024
+
+// seedRetentionItems 保存一组从旧到新的项目：最旧的两个分别是收藏和带受保护标签的项目
+func seedRetentionItems(t *testing.T) {
+	t.Helper()
+	tag, err := CreateTag("归档", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SetTagProtected(tag.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	items := []struct {
+		id          string
+		contentType string
+		age         time.Duration
+	}{
+		{id: "fav", contentType: "Text", age: 10 * 24 * time.Hour},
+		{id: "tagged", contentType: "URL", age: 9 * 24 * time.Hour},
+		{id: "text-old", contentType: "Text", age: 8 * 24 * time.Hour},
+		{id: "url-old", contentType: "URL", age: 6 * 24 * time.Hour},
+		{id: "text-mid", contentType: "Text", age: 3 * 24 * time.Hour},
+		{id: "url-new", contentType: "URL", age: 2 * time.Hour},
+		{id: "text-new", contentType: "Text", age: time.Hour},
+	}
+	for _, it := range items {
+		item := ClipboardItem{
+			ID:          it.id,
+			Content:     it.id + strings.Repeat("x", retentionItemSize-len(it.id)),
+			ContentType: it.contentType,
+			Timestamp:   time.Now().Add(-it.age),
+		}
+		item.ContentHash = calculateContentHash(&item)
+		if err := SaveClipboardItem(&item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := DB.Exec(`UPDATE clipboard_items SET is_favorite = 1 WHERE id = 'fav'`); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddTagsToItems([]string{"tagged"}, []string{tag.ID}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// remainingItemIDs 按时间从旧到新返回剩余项目的 ID
+func remainingItemIDs(t *testing.T) []string {
+	t.Helper()
+	rows, err := DB.Query(`SELECT id FROM clipboard_items ORDER BY timestamp`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func TestRunRetention(t *testing.T) {
+	tests := []struct {
+		name         string
+		settings     string // 保存到 app_settings 的保留策略
+		wantIDs      []string
+		wantByPolicy map[string]int
+		wantSkipped  bool
+	}{
+		{name: "自动清理关闭", settings: `{"autoClean":false,"retentionDays":1}`, wantSkipped: true,
+			wantIDs: []string{"fav", "tagged", "text-old", "url-old", "text-mid", "url-new", "text-new"}},
+		{name: "最长保留天数", settings: `{"autoClean":true,"retentionDays":5}`,
+			wantIDs:      []string{"fav", "tagged", "text-mid", "url-new", "text-new"},
+			wantByPolicy: map[string]int{"age": 2}},
+		{name: "按类型限制数量", settings: `{"autoClean":true,"retentionTypeLimits":{"URL":1,"Text":0}}`,
+			wantIDs:      []string{"fav", "tagged", "text-old", "text-mid", "url-new", "text-new"},
+			wantByPolicy: map[string]int{"type:URL": 1}},
+		{name: "总数量不计受保护的项目", settings: `{"autoClean":true,"retentionMaxItems":2}`,
+			wantIDs:      []string{"fav", "tagged", "url-new", "text-new"},
+			wantByPolicy: map[string]int{"count": 3}},
+		{name: "总空间包含受保护项目", settings: `{"autoClean":true,"retentionMaxStorageMB":2}`,
+			wantIDs:      []string{"fav", "tagged", "url-new", "text-new"}, // 受保护的 1MB + 最新的 1MB
+			wantByPolicy: map[string]int{"storage": 3}},
+		{name: "受保护项目占满空间", settings: `{"autoClean":true,"retentionMaxStorageMB":1}`,
+			wantIDs:      []string{"fav", "tagged"},
+			wantByPolicy: map[string]int{"storage": 5}},
+		{name: "多个策略依次执行", settings: `{"autoClean":true,"retentionDays":7,"retentionTypeLimits":{"URL":0},"retentionMaxItems":2}`,
+			wantIDs:      []string{"fav", "tagged", "url-new", "text-new"}, // 限制为 0 的类型不清理
+			wantByPolicy: map[string]int{"age": 1, "count": 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			openTestDB(t, 0)
+			seedRetentionItems(t)
+			if err := SaveSetting(settingsKey, tt.settings); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := RunRetention()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Skipped != tt.wantSkipped {
+				t.Errorf("Skipped = %v，期望 %v", result.Skipped, tt.wantSkipped)
+			}
+			if got, want := fmt.Sprint(result.ByPolicy), fmt.Sprint(tt.wantByPolicy); got != want {
+				t.Errorf("ByPolicy = %v，期望 %v", got, want)
+			}
+			if got := remainingItemIDs(t); !equalStrings(got, tt.wantIDs) {
+				t.Errorf("剩余项目 = %v，期望 %v", got, tt.wantIDs)
+			}
+
+			last, err := GetLastRetentionResult()
+			if err != nil || last == nil || last.Removed != result.Removed {
+				t.Errorf("GetLastRetentionResult = %+v, %v，期望删除 %d 条", last, err, result.Removed)
+			}
+		})
+	}
+}
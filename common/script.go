@@ -37,92 +37,6 @@ func SetScriptEventCallback(callback ScriptEventCallback) {
 	globalScriptEventCallback = callback
 }
 
-// checkAndAddScriptTable 检查并添加脚本表
-func checkAndAddScriptTable() error {
-	if DB == nil {
-		return fmt.Errorf("数据库未初始化")
-	}
-
-	// 检查表是否存在
-	checkSQL := `SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='user_scripts'`
-	var count int
-	err := DB.QueryRow(checkSQL).Scan(&count)
-	if err != nil {
-		return fmt.Errorf("检查脚本表失败: %v", err)
-	}
-
-	if count == 0 {
-		log.Printf("🔧 正在创建 user_scripts 表...")
-		createTableSQL := `
-		CREATE TABLE IF NOT EXISTS user_scripts (
-			id TEXT PRIMARY KEY,
-			name TEXT NOT NULL,
-			enabled INTEGER DEFAULT 1,
-			trigger TEXT NOT NULL,
-			content_types TEXT, -- JSON 数组
-			keywords TEXT, -- JSON 数组
-			script TEXT NOT NULL,
-			description TEXT,
-			sort_order INTEGER DEFAULT 0,
-			plugin_id TEXT, -- 在线插件的 ID
-			plugin_version TEXT, -- 在线插件的版本号
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		);
-		
-		CREATE INDEX IF NOT EXISTS idx_script_trigger ON user_scripts(trigger);
-		CREATE INDEX IF NOT EXISTS idx_script_enabled ON user_scripts(enabled);
-		CREATE INDEX IF NOT EXISTS idx_script_sort_order ON user_scripts(sort_order);
-		CREATE INDEX IF NOT EXISTS idx_script_plugin_id ON user_scripts(plugin_id);
-		`
-
-		_, err := DB.Exec(createTableSQL)
-		if err != nil {
-			return fmt.Errorf("创建脚本表失败: %v", err)
-		}
-		log.Printf("✅ 已创建 user_scripts 表")
-	}
-
-	// 检查并添加 plugin_id 字段（兼容老用户）
-	checkPluginIDSQL := `SELECT COUNT(*) FROM pragma_table_info('user_scripts') WHERE name = 'plugin_id'`
-	var pluginIDCount int
-	err = DB.QueryRow(checkPluginIDSQL).Scan(&pluginIDCount)
-	if err != nil {
-		log.Printf("⚠️ 检查 plugin_id 字段失败: %v", err)
-	} else if pluginIDCount == 0 {
-		log.Printf("🔧 正在添加 plugin_id 字段...")
-		_, err = DB.Exec("ALTER TABLE user_scripts ADD COLUMN plugin_id TEXT")
-		if err != nil {
-			log.Printf("⚠️ 添加 plugin_id 字段失败: %v", err)
-		} else {
-			log.Printf("✅ 已添加 plugin_id 字段")
-			// 添加索引
-			_, err = DB.Exec("CREATE INDEX IF NOT EXISTS idx_script_plugin_id ON user_scripts(plugin_id)")
-			if err != nil {
-				log.Printf("⚠️ 创建 plugin_id 索引失败: %v", err)
-			}
-		}
-	}
-
-	// 检查并添加 plugin_version 字段（兼容老用户）
-	checkPluginVersionSQL := `SELECT COUNT(*) FROM pragma_table_info('user_scripts') WHERE name = 'plugin_version'`
-	var pluginVersionCount int
-	err = DB.QueryRow(checkPluginVersionSQL).Scan(&pluginVersionCount)
-	if err != nil {
-		log.Printf("⚠️ 检查 plugin_version 字段失败: %v", err)
-	} else if pluginVersionCount == 0 {
-		log.Printf("🔧 正在添加 plugin_version 字段...")
-		_, err = DB.Exec("ALTER TABLE user_scripts ADD COLUMN plugin_version TEXT")
-		if err != nil {
-			log.Printf("⚠️ 添加 plugin_version 字段失败: %v", err)
-		} else {
-			log.Printf("✅ 已添加 plugin_version 字段")
-		}
-	}
-
-	return nil
-}
-
 // GetAllUserScripts 获取所有用户脚本
 func GetAllUserScripts() ([]UserScript, error) {
 	if DB == nil {
@@ -300,6 +214,15 @@ func DeleteUserScript(id string) error {
 		return fmt.Errorf("未找到要删除的脚本")
 	}
 
+	// 删除脚本的存储
+	if _, err := DB.Exec(`DELETE FROM script_storage WHERE script_id = ?`, id); err != nil {
+		log.Printf("⚠️ 删除脚本存储失败: %v", err)
+	}
+	// 删除脚本的执行记录
+	if _, err := DB.Exec(`DELETE FROM script_runs WHERE script_id = ?`, id); err != nil {
+		log.Printf("⚠️ 删除脚本执行记录失败: %v", err)
+	}
+
 	log.Printf("✅ 已删除脚本: %s", id)
 	return nil
 }
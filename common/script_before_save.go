@@ -0,0 +1,173 @@
+package common
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// before_save 脚本：在项目写入数据库之前按 sort_order 依次执行，可以改写内容、添加标签或拒绝保存
+//
+// 脚本的返回值：
+//   - undefined / null / true：不做修改
+//   - false：拒绝保存
+//   - 字符串：替换 Content
+//   - 对象：{ content?: string, contentType?: string, tags?: string[], veto?: boolean, reason?: string }
+//
+// 图片和文件的 Content 只是描述，只能添加标签或拒绝保存。脚本执行失败或超时不影响保存
+
+// beforeSavePipelineTimeout 所有 before_save 脚本的总时间，超过后剩下的脚本不再执行
+const beforeSavePipelineTimeout = 5 * time.Second
+
+// beforeSaveContentTypes before_save 脚本可以设置的内容类型
+var beforeSaveContentTypes = map[string]bool{"Text": true, "URL": true, "JSON": true, "Color": true}
+
+// BeforeSaveDecision before_save 脚本返回值解析后的结果
+type BeforeSaveDecision struct {
+	Veto        bool
+	Reason      string
+	Content     *string // nil 表示不修改
+	ContentType string  // 为空表示不修改（修改了内容时重新检测）
+	Tags        []string
+}
+
+// parseBeforeSaveReturn 解析 before_save 脚本的返回值
+func parseBeforeSaveReturn(value interface{}) (BeforeSaveDecision, error) {
+	var decision BeforeSaveDecision
+	switch v := value.(type) {
+	case nil:
+		return decision, nil
+	case bool:
+		decision.Veto = !v
+		return decision, nil
+	case string:
+		decision.Content = &v
+		return decision, nil
+	case map[string]interface{}:
+		return parseBeforeSaveObject(v)
+	default:
+		return decision, fmt.Errorf("不支持的返回值类型: %T", value)
+	}
+}
+
+// parseBeforeSaveObject 解析对象形式的返回值
+func parseBeforeSaveObject(obj map[string]interface{}) (BeforeSaveDecision, error) {
+	var decision BeforeSaveDecision
+	if raw, ok := obj["veto"]; ok && raw != nil {
+		veto, ok := raw.(bool)
+		if !ok {
+			return decision, fmt.Errorf("veto 必须是布尔值")
+		}
+		decision.Veto = veto
+	}
+	if raw, ok := obj["reason"]; ok && raw != nil {
+		decision.Reason = fmt.Sprint(raw)
+	}
+	if raw, ok := obj["content"]; ok && raw != nil {
+		content, ok := raw.(string)
+		if !ok {
+			return decision, fmt.Errorf("content 必须是字符串")
+		}
+		decision.Content = &content
+	}
+	if raw, ok := obj["contentType"]; ok && raw != nil {
+		contentType, ok := raw.(string)
+		if !ok || !beforeSaveContentTypes[contentType] {
+			return decision, fmt.Errorf("不支持的 contentType: %v", raw)
+		}
+		decision.ContentType = contentType
+	}
+	if raw, ok := obj["tags"]; ok && raw != nil {
+		switch tags := raw.(type) {
+		case string:
+			decision.Tags = []string{tags}
+		case []interface{}:
+			for _, tag := range tags {
+				name, ok := tag.(string)
+				if !ok {
+					return decision, fmt.Errorf("tags 必须是字符串数组")
+				}
+				decision.Tags = append(decision.Tags, name)
+			}
+		default:
+			return decision, fmt.Errorf("tags 必须是字符串数组")
+		}
+		for _, name := range decision.Tags {
+			if _, err := normalizeTagName(name); err != nil {
+				return decision, err
+			}
+		}
+	}
+	return decision, nil
+}
+
+// applyBeforeSaveDecision 把脚本的修改应用到项目上
+func applyBeforeSaveDecision(item *ClipboardItem, decision BeforeSaveDecision) error {
+	if decision.Content == nil && decision.ContentType == "" {
+		return nil
+	}
+	if !beforeSaveContentTypes[item.ContentType] {
+		return fmt.Errorf("%s 类型的内容不能修改", item.ContentType)
+	}
+	if decision.Content != nil {
+		if strings.TrimSpace(*decision.Content) == "" {
+			return fmt.Errorf("修改后的内容不能为空，如需丢弃请返回 false")
+		}
+		item.Content = *decision.Content
+		item.CharCount = len([]rune(item.Content))
+		item.WordCount = countWords(item.Content)
+		item.ContentType = detectContentType(item.Content)
+	}
+	if decision.ContentType != "" {
+		item.ContentType = decision.ContentType
+	}
+	return nil
+}
+
+// runBeforeSaveScripts 依次执行匹配的 before_save 脚本，返回需要添加的标签；第二个返回值为 false 表示有脚本拒绝保存
+func (m *Monitor) runBeforeSaveScripts(item *ClipboardItem) ([]string, bool) {
+	scripts, err := m.store.GetEnabledUserScripts("before_save")
+	if err != nil {
+		log.Printf("❌ 获取 before_save 脚本失败: %v", err)
+		return nil, true
+	}
+	if len(scripts) == 0 {
+		return nil, true
+	}
+
+	var tags []string
+	vetoed := false
+	runScriptPipeline(scripts, item, beforeSavePipelineTimeout, func(script *UserScript, value interface{}) bool {
+		decision, err := parseBeforeSaveReturn(value)
+		if err != nil {
+			log.Printf("⚠️ before_save 脚本「%s」返回值无效，已忽略: %v", script.Name, err)
+			return true
+		}
+		if decision.Veto {
+			log.Printf("⛔ before_save 脚本「%s」拒绝保存: %s", script.Name, decision.Reason)
+			vetoed = true
+			return false
+		}
+		if err := applyBeforeSaveDecision(item, decision); err != nil {
+			log.Printf("⚠️ before_save 脚本「%s」的修改无效，已忽略: %v", script.Name, err)
+			return true
+		}
+		tags = append(tags, decision.Tags...)
+		return true
+	})
+	if vetoed {
+		return nil, false
+	}
+	return tags, true
+}
+
+// addBeforeSaveTags 保存后添加 before_save 脚本要求的标签
+func (m *Monitor) addBeforeSaveTags(item *ClipboardItem, tags []string) {
+	if len(tags) == 0 {
+		return
+	}
+	if err := m.store.AddTagNamesToItem(item.ID, tags); err != nil {
+		log.Printf("⚠️ 添加 before_save 脚本标签失败: %v", err)
+	}
+}
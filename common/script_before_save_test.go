@@ -0,0 +1,133 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+// shortTransformTimeout 缩短单个转换脚本的超时时间，避免超时用例等待 scriptTransformTimeout
+func shortTransformTimeout(t *testing.T, limit time.Duration) {
+	previous := transformScriptRunner
+	transformScriptRunner = func(script *UserScript, item *ClipboardItem, timeout time.Duration) ScriptResult {
+		if timeout > limit {
+			timeout = limit
+		}
+		return previous(script, item, timeout)
+	}
+	t.Cleanup(func() { transformScriptRunner = previous })
+}
+
+func TestRunBeforeSaveScripts(t *testing.T) {
+	shortTransformTimeout(t, 200*time.Millisecond)
+	script := func(name, body string) UserScript {
+		return UserScript{ID: name, Name: name, Trigger: "before_save", Script: body}
+	}
+	text := ClipboardItem{ID: "t", Content: "hello world", ContentType: "Text"}
+	image := ClipboardItem{ID: "i", Content: "图片 4x4 (png)", ContentType: "Image"}
+
+	tests := []struct {
+		name        string
+		item        ClipboardItem
+		scripts     []UserScript
+		wantSave    bool
+		wantContent string
+		wantType    string
+		wantTags    []string
+	}{
+		{name: "返回 false 拒绝保存", item: text, scripts: []UserScript{script("veto", "return false")}},
+		{name: "对象形式拒绝保存", item: text, scripts: []UserScript{script("veto", "return { veto: true, reason: 'otp' }")}},
+		{name: "拒绝后不再执行后面的脚本", item: text, scripts: []UserScript{
+			script("veto", "return false"),
+			script("tag", "return { tags: ['late'] }"),
+		}},
+		{name: "返回字符串替换内容并重新检测类型", item: text, wantSave: true,
+			scripts:     []UserScript{script("url", "return 'https://example.com/' + item.Content.length")},
+			wantContent: "https://example.com/11", wantType: "URL"},
+		{name: "对象修改内容、类型和标签", item: text, wantSave: true,
+			scripts:     []UserScript{script("obj", "return { content: '{\"a\":1}', contentType: 'Text', tags: ['json', 'cleaned'] }")},
+			wantContent: `{"a":1}`, wantType: "Text", wantTags: []string{"json", "cleaned"}},
+		{name: "后面的脚本看到修改后的内容", item: text, wantSave: true, scripts: []UserScript{
+			script("upper", "return item.Content.toUpperCase()"),
+			script("suffix", "return item.Content + '!'"),
+		}, wantContent: "HELLO WORLD!", wantType: "Text"},
+		{name: "脚本抛出异常时保存原内容", item: text, wantSave: true, scripts: []UserScript{
+			script("throw", "throw new Error('boom')"),
+			script("tag", "return { tags: ['after-error'] }"),
+		}, wantContent: "hello world", wantType: "Text", wantTags: []string{"after-error"}},
+		{name: "脚本超时时保存原内容", item: text, wantSave: true,
+			scripts:     []UserScript{script("loop", "while (true) {}")},
+			wantContent: "hello world", wantType: "Text"},
+		{name: "无效的返回值被忽略", item: text, wantSave: true, scripts: []UserScript{
+			script("number", "return 42"),
+			script("blank", "return '   '"),
+			script("badtype", "return { content: 'x', contentType: 'Image' }"),
+		}, wantContent: "hello world", wantType: "Text"},
+		{name: "图片只能添加标签", item: image, wantSave: true, scripts: []UserScript{
+			script("rewrite", "return 'text'"),
+			script("tag", "return { tags: 'screenshot' }"),
+		}, wantContent: "图片 4x4 (png)", wantType: "Image", wantTags: []string{"screenshot"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _, store := newTestMonitor(t)
+			store.scripts["before_save"] = tt.scripts
+			item := tt.item
+
+			tags, save := m.runBeforeSaveScripts(&item)
+			if save != tt.wantSave {
+				t.Fatalf("保存 = %v，期望 %v", save, tt.wantSave)
+			}
+			if !save {
+				return
+			}
+			if item.Content != tt.wantContent || item.ContentType != tt.wantType {
+				t.Errorf("内容 = %q (%s)，期望 %q (%s)", item.Content, item.ContentType, tt.wantContent, tt.wantType)
+			}
+			if !equalStrings(tags, tt.wantTags) {
+				t.Errorf("标签 = %v，期望 %v", tags, tt.wantTags)
+			}
+		})
+	}
+}
+
+func TestBeforeSavePipelineTimeout(t *testing.T) {
+	// 第一个脚本用完了总时间，剩下的脚本不再执行
+	var ran []string
+	previous := transformScriptRunner
+	transformScriptRunner = func(script *UserScript, item *ClipboardItem, timeout time.Duration) ScriptResult {
+		ran = append(ran, script.ID)
+		time.Sleep(timeout)
+		return ScriptResult{Status: ScriptRunTimeout, Error: "timeout"}
+	}
+	t.Cleanup(func() { transformScriptRunner = previous })
+
+	scripts := []UserScript{
+		{ID: "slow", Name: "slow", Trigger: "before_save"},
+		{ID: "skipped", Name: "skipped", Trigger: "before_save"},
+	}
+	handled := 0
+	runScriptPipeline(scripts, &ClipboardItem{Content: "x", ContentType: "Text"}, 50*time.Millisecond,
+		func(*UserScript, interface{}) bool { handled++; return true })
+	if !equalStrings(ran, []string{"slow"}) || handled != 0 {
+		t.Errorf("执行了 %v，处理了 %d 个返回值，期望只执行 slow 且不处理返回值", ran, handled)
+	}
+}
+
+func TestMonitorBeforeSaveFailureSavesOriginal(t *testing.T) {
+	shortTransformTimeout(t, 200*time.Millisecond)
+	m, backend, store := newTestMonitor(t)
+	store.scripts["before_save"] = []UserScript{
+		{ID: "throw", Name: "throw", Trigger: "before_save", Script: "throw new Error('boom')"},
+		{ID: "loop", Name: "loop", Trigger: "before_save", Script: "while (true) {}"},
+	}
+
+	backend.SetText("keep me")
+	m.Poll()
+	items := store.snapshot()
+	if len(items) != 1 || items[0].Content != "keep me" {
+		t.Fatalf("保存的项目 = %+v，期望原内容", items)
+	}
+	if items[0].ContentHash != calculateContentHash(&ClipboardItem{Content: "keep me", ContentType: "Text"}) {
+		t.Error("哈希应该按原内容计算")
+	}
+}
@@ -0,0 +1,137 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// 脚本可以调用的宿主功能（Go 运行时直接调用，前端通过 App 的绑定调用）
+
+// scriptHTTPClient 脚本发送 HTTP 请求使用的客户端
+var scriptHTTPClient = &http.Client{
+	Timeout: 30 * time.Second,
+}
+
+// scriptFetchMaxBody 脚本 HTTP 请求的响应体最多读取的字节数，超过时请求失败（避免一个大响应耗尽内存）
+const scriptFetchMaxBody = 10 << 20
+
+// ScriptHTTPRequest 代理脚本的 HTTP 请求（用于绕过 CORS 限制），ctx 取消时请求立即结束
+// method: HTTP 方法（GET, POST, PUT, DELETE 等）
+// headersJSON: 请求头 JSON 字符串，格式如 {"Content-Type": "application/json", "Authorization": "Bearer token"}
+// bodyJSON: 请求体 JSON 字符串（GET 请求可为空字符串）
+// 返回 {status, statusText, headers, body} 的 JSON 字符串，body 是 JSON 时已解析
+func ScriptHTTPRequest(ctx context.Context, method string, requestURL string, headersJSON string, bodyJSON string) (string, error) {
+	// 解析请求头
+	var headers map[string]string
+	if headersJSON != "" {
+		if err := json.Unmarshal([]byte(headersJSON), &headers); err != nil {
+			return "", fmt.Errorf("解析请求头失败: %v", err)
+		}
+	} else {
+		headers = make(map[string]string)
+	}
+
+	resp, err := scriptFetch(ctx, method, requestURL, headers, bodyJSON)
+	if err != nil {
+		return "", err
+	}
+
+	// 构建响应对象
+	response := map[string]interface{}{
+		"status":     resp.Status,
+		"statusText": resp.StatusText,
+		"headers":    resp.Header,
+		"body":       resp.Body,
+	}
+
+	// 如果响应是 JSON，尝试解析
+	var bodyObj interface{}
+	if err := json.Unmarshal([]byte(resp.Body), &bodyObj); err == nil {
+		response["body"] = bodyObj
+	}
+
+	// 返回 JSON 格式的响应
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		return "", fmt.Errorf("序列化响应失败: %v", err)
+	}
+
+	return string(responseJSON), nil
+}
+
+// scriptFetchResponse 脚本 HTTP 请求的响应
+type scriptFetchResponse struct {
+	Status     int
+	StatusText string // 例如 "200 OK"
+	Header     http.Header
+	Body       string
+}
+
+// scriptFetch 发送脚本的 HTTP 请求并读取整个响应（POST、PUT、PATCH 才发送请求体），
+// 响应体超过 scriptFetchMaxBody 时返回错误
+func scriptFetch(ctx context.Context, method string, requestURL string, headers map[string]string, body string) (*scriptFetchResponse, error) {
+	// 创建请求体
+	var bodyReader io.Reader
+	if body != "" && (method == "POST" || method == "PUT" || method == "PATCH") {
+		bodyReader = bytes.NewBufferString(body)
+	}
+
+	// 创建 HTTP 请求
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %v", err)
+	}
+
+	// 设置请求头
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	// 如果没有设置 Content-Type 且有请求体，默认设置为 application/json
+	if bodyReader != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	// 发送请求
+	resp, err := scriptHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("发送请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// 读取响应体（多读一个字节用来判断是否超过上限）
+	data, err := io.ReadAll(io.LimitReader(resp.Body, scriptFetchMaxBody+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %v", err)
+	}
+	if len(data) > scriptFetchMaxBody {
+		return nil, fmt.Errorf("响应超过 %d MB", scriptFetchMaxBody>>20)
+	}
+	return &scriptFetchResponse{Status: resp.StatusCode, StatusText: resp.Status, Header: resp.Header, Body: string(data)}, nil
+}
+
+// scriptCopyText 脚本写入剪贴板文本
+func scriptCopyText(text string) {
+	if err := WriteClipboardText(text); err != nil {
+		log.Printf("⚠️ 脚本写入剪贴板失败: %v", err)
+		return
+	}
+	log.Printf("已复制文本到剪贴板: %s", truncateString(text, 50))
+}
+
+// scriptNotify 显示脚本的通知（发送事件到前端显示，窗口未加载时只记录日志）
+func scriptNotify(title string, message string) {
+	log.Printf("🔔 脚本通知: %s %s", title, message)
+	if globalScriptEventCallback != nil {
+		globalScriptEventCallback("script.notify", map[string]interface{}{
+			"title":   title,
+			"message": message,
+		})
+	}
+}
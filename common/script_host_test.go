@@ -0,0 +1,54 @@
+package common
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestScriptFetchBodyLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+		// 不设置 Content-Length，按分块传输，和不声明长度的服务器一样
+		chunk := strings.Repeat("x", 64*1024)
+		for size > 0 {
+			n := min(size, len(chunk))
+			io.WriteString(w, chunk[:n])
+			size -= n
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		size    int
+		wantErr bool
+	}{
+		{name: "空响应", size: 0},
+		{name: "普通响应", size: 1234},
+		{name: "正好等于上限", size: scriptFetchMaxBody},
+		{name: "超过上限", size: scriptFetchMaxBody + 1, wantErr: true},
+		{name: "远超上限", size: 3 * scriptFetchMaxBody, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := scriptFetch(context.Background(), "GET", server.URL+"?size="+strconv.Itoa(tt.size), nil, "")
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "响应超过") {
+					t.Fatalf("scriptFetch 错误 = %v，期望响应超过上限", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Body) != tt.size {
+				t.Errorf("响应长度 = %d，期望 %d", len(resp.Body), tt.size)
+			}
+		})
+	}
+}
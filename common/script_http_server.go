@@ -6,6 +6,7 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -13,23 +14,12 @@ import (
 
 var (
 	httpServer          *http.Server
+	httpServerAddr      string
 	httpServerMutex     sync.RWMutex
 	enabledScripts      = make(map[string]*UserScript) // identifier -> script
 	enabledScriptsMutex sync.RWMutex
-	scriptResults       = make(map[string]chan ScriptHTTPResult) // requestID -> result channel
-	scriptResultsMutex  sync.RWMutex
-	requestCounter      int64
-	requestCounterMutex sync.Mutex
-	cleanupTicker       *time.Ticker
-	cleanupStopChan     chan struct{}
 )
 
-// ScriptHTTPResult 脚本执行结果
-type ScriptHTTPResult struct {
-	ReturnValue interface{} `json:"returnValue,omitempty"`
-	Error       string      `json:"error,omitempty"`
-}
-
 // GetScriptIdentifier 获取脚本的 HTTP 服务标识符
 func GetScriptIdentifier(script *UserScript) string {
 	// 优先使用 plugin_id
@@ -51,7 +41,7 @@ func GetScriptIdentifier(script *UserScript) string {
 	return script.ID
 }
 
-// StartScriptHTTPServer 启动脚本 HTTP 服务器
+// StartScriptHTTPServer 启动脚本 HTTP 服务器（监听地址来自设置 scriptHTTPBind / scriptHTTPPort）
 func StartScriptHTTPServer() error {
 	httpServerMutex.Lock()
 	defer httpServerMutex.Unlock()
@@ -60,96 +50,97 @@ func StartScriptHTTPServer() error {
 		return fmt.Errorf("HTTP 服务器已在运行")
 	}
 
+	settings, err := LoadSettings()
+	if err != nil {
+		log.Printf("⚠️ 读取设置失败，脚本 HTTP 服务器使用默认地址: %v", err)
+	}
+	addr := scriptHTTPAddr(settings)
+	if err := serveScriptHTTPLocked(addr); err != nil {
+		return err
+	}
+
+	log.Printf("✅ 脚本 HTTP 服务器已启动，地址: %s", addr)
+	return nil
+}
+
+// scriptHTTPAddr 脚本 HTTP 服务器的监听地址
+func scriptHTTPAddr(settings *Settings) string {
+	return net.JoinHostPort(settings.ScriptHTTPBind, strconv.Itoa(settings.ScriptHTTPPort))
+}
+
+// serveScriptHTTPLocked 在 addr 上监听并启动服务，监听失败时直接返回错误（调用方需持有 httpServerMutex）
+func serveScriptHTTPLocked(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("监听 %s 失败: %v", addr, err)
+	}
+
 	mux := http.NewServeMux()
 	mux.HandleFunc("/clip-save/", handleScriptHTTPRequest)
-
-	httpServer = &http.Server{
-		Addr:    ":6527",
+	server := &http.Server{
+		Addr:    addr,
 		Handler: mux,
 	}
 
-	// 启动定期清理任务（每 5 分钟清理一次超时的结果通道）
-	cleanupStopChan = make(chan struct{})
-	cleanupTicker = time.NewTicker(5 * time.Minute)
-	go cleanupExpiredResults()
-
 	go func() {
-		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Printf("❌ 脚本 HTTP 服务器启动失败: %v", err)
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("❌ 脚本 HTTP 服务器异常退出: %v", err)
 		}
 	}()
 
-	log.Printf("✅ 脚本 HTTP 服务器已启动，端口: 6527")
+	httpServer = server
+	httpServerAddr = addr
 	return nil
 }
 
-// StopScriptHTTPServer 停止脚本 HTTP 服务器
-func StopScriptHTTPServer() error {
+// restartScriptHTTPServer 在新地址上重新启动正在运行的服务器（未运行时不处理），新地址监听失败时恢复原地址
+func restartScriptHTTPServer(addr string) error {
 	httpServerMutex.Lock()
 	defer httpServerMutex.Unlock()
 
-	if httpServer == nil {
+	if httpServer == nil || httpServerAddr == addr {
 		return nil
 	}
 
-	// 停止清理任务
-	if cleanupTicker != nil {
-		cleanupTicker.Stop()
-		cleanupTicker = nil
+	oldAddr := httpServerAddr
+	if err := httpServer.Close(); err != nil {
+		return fmt.Errorf("停止 HTTP 服务器失败: %v", err)
 	}
-	if cleanupStopChan != nil {
-		close(cleanupStopChan)
-		cleanupStopChan = nil
+	httpServer = nil
+
+	if err := serveScriptHTTPLocked(addr); err != nil {
+		if restoreErr := serveScriptHTTPLocked(oldAddr); restoreErr != nil {
+			log.Printf("❌ 恢复脚本 HTTP 服务器失败: %v", restoreErr)
+		}
+		return err
 	}
+	log.Printf("✅ 脚本 HTTP 服务器已切换到: %s", addr)
+	return nil
+}
 
-	if err := httpServer.Close(); err != nil {
-		return fmt.Errorf("停止 HTTP 服务器失败: %v", err)
+// StopScriptHTTPServer 停止脚本 HTTP 服务器
+func StopScriptHTTPServer() error {
+	httpServerMutex.Lock()
+	defer httpServerMutex.Unlock()
+
+	if httpServer == nil {
+		return nil
 	}
 
-	// 清理所有结果通道
-	scriptResultsMutex.Lock()
-	for requestID, resultChan := range scriptResults {
-		close(resultChan)
-		delete(scriptResults, requestID)
+	if err := httpServer.Close(); err != nil {
+		return fmt.Errorf("停止 HTTP 服务器失败: %v", err)
 	}
-	scriptResultsMutex.Unlock()
 
 	httpServer = nil
 	log.Printf("✅ 脚本 HTTP 服务器已停止")
 	return nil
 }
 
-// cleanupExpiredResults 定期清理超时的结果通道（防止内存泄漏）
-func cleanupExpiredResults() {
-	for {
-		select {
-		case <-cleanupStopChan:
-			return
-		case <-cleanupTicker.C:
-			scriptResultsMutex.Lock()
-			now := time.Now().Unix()
-			expiredCount := 0
-			for requestID, resultChan := range scriptResults {
-				// 解析 requestID 中的时间戳（格式：req_<timestamp>_<counter>）
-				// 如果超过 60 秒，认为是过期请求
-				parts := strings.Split(requestID, "_")
-				if len(parts) >= 2 {
-					var timestamp int64
-					if _, err := fmt.Sscanf(parts[1], "%d", &timestamp); err == nil {
-						if now-timestamp > 60 {
-							close(resultChan)
-							delete(scriptResults, requestID)
-							expiredCount++
-						}
-					}
-				}
-			}
-			scriptResultsMutex.Unlock()
-			if expiredCount > 0 {
-				log.Printf("🧹 清理了 %d 个过期的脚本执行结果通道", expiredCount)
-			}
-		}
-	}
+// 监听地址修改后在新地址上重新启动，端口被占用等错误会使设置修改失败
+func init() {
+	SubscribeSettings("script_http", []string{"scriptHTTPBind", "scriptHTTPPort"}, func(change SettingsChange) error {
+		return restartScriptHTTPServer(scriptHTTPAddr(change.Settings))
+	})
 }
 
 // EnableScriptHTTPService 启用脚本的 HTTP 服务
@@ -224,13 +215,21 @@ func GetScriptHTTPURL(scriptID string) (string, error) {
 
 	identifier := GetScriptIdentifier(script)
 
-	// 获取本机局域网 IP
-	ip, err := getLocalIP()
+	settings, err := LoadSettings()
 	if err != nil {
-		return "", fmt.Errorf("获取本机 IP 失败: %v", err)
+		return "", fmt.Errorf("读取设置失败: %v", err)
+	}
+
+	// 监听所有地址时使用本机局域网 IP
+	host := settings.ScriptHTTPBind
+	if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+		host, err = getLocalIP()
+		if err != nil {
+			return "", fmt.Errorf("获取本机 IP 失败: %v", err)
+		}
 	}
 
-	return fmt.Sprintf("http://%s:6527/clip-save/%s?content=xx", ip, identifier), nil
+	return fmt.Sprintf("http://%s/clip-save/%s?content=xx", net.JoinHostPort(host, strconv.Itoa(settings.ScriptHTTPPort)), identifier), nil
 }
 
 // getLocalIP 获取本机局域网 IP
@@ -303,71 +302,30 @@ func handleScriptHTTPRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 生成请求 ID
-	requestCounterMutex.Lock()
-	requestCounter++
-	requestID := fmt.Sprintf("req_%d_%d", time.Now().Unix(), requestCounter)
-	requestCounterMutex.Unlock()
-
-	// 创建结果通道
-	resultChan := make(chan ScriptHTTPResult, 1)
-	scriptResultsMutex.Lock()
-	scriptResults[requestID] = resultChan
-	scriptResultsMutex.Unlock()
-
-	// 通过事件触发脚本执行
-	if globalScriptEventCallback != nil {
-		globalScriptEventCallback("script.http.execute", map[string]interface{}{
-			"requestID": requestID,
-			"scriptID":  script.ID,
-			"content":   content,
+	// 在 Go 运行时中执行脚本（不依赖前端窗口），客户端断开时中断脚本
+	now := time.Now()
+	item := &ClipboardItem{
+		ID:          fmt.Sprintf("http-%d", now.UnixMilli()),
+		Content:     content,
+		ContentType: "Text",
+		Timestamp:   now,
+		Source:      "HTTP",
+		CharCount:   len([]rune(content)),
+		WordCount:   countWords(content),
+	}
+	result := runAndRecordScript(r.Context(), "http", script, item, DefaultScriptLimits)
+	logScriptResult("http", script, result)
+
+	// 返回结果
+	w.Header().Set("Content-Type", "application/json")
+	if result.Error != "" {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": result.Error,
 		})
 	} else {
-		http.Error(w, "脚本执行器未初始化", http.StatusInternalServerError)
-		return
-	}
-
-	// 等待脚本执行结果（超时 30 秒）
-	select {
-	case result := <-resultChan:
-		// 清理结果通道
-		scriptResultsMutex.Lock()
-		delete(scriptResults, requestID)
-		scriptResultsMutex.Unlock()
-
-		// 返回结果
-		w.Header().Set("Content-Type", "application/json")
-		if result.Error != "" {
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"error": result.Error,
-			})
-		} else {
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"code": 0,
-				"data": result.ReturnValue,
-			})
-		}
-	case <-time.After(30 * time.Second):
-		// 超时
-		scriptResultsMutex.Lock()
-		delete(scriptResults, requestID)
-		scriptResultsMutex.Unlock()
-
-		http.Error(w, "脚本执行超时", http.StatusRequestTimeout)
-	}
-}
-
-// SetScriptHTTPResult 设置脚本执行结果（由前端调用）
-func SetScriptHTTPResult(requestID string, result ScriptHTTPResult) {
-	scriptResultsMutex.RLock()
-	resultChan, exists := scriptResults[requestID]
-	scriptResultsMutex.RUnlock()
-
-	if exists {
-		select {
-		case resultChan <- result:
-		default:
-			// 通道已满，忽略
-		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": result.ReturnValue,
+		})
 	}
 }
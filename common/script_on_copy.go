@@ -0,0 +1,75 @@
+package common
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// on_copy 脚本：从历史记录复制（或粘贴）项目时按 sort_order 依次执行，只修改这次复制出去的文本，不修改保存的项目
+//
+// 脚本的返回值：
+//   - undefined / null / true / false：不做修改
+//   - 字符串：替换复制的文本
+//   - 对象：{ content?: string }
+//
+// 图片和文件按原样复制，不执行 on_copy 脚本
+
+// onCopyPipelineTimeout 所有 on_copy 脚本的总时间（复制时用户在等待，比 before_save 短）
+const onCopyPipelineTimeout = 3 * time.Second
+
+// parseOnCopyReturn 解析 on_copy 脚本的返回值，返回 nil 表示不修改
+func parseOnCopyReturn(value interface{}) (*string, error) {
+	switch v := value.(type) {
+	case nil, bool:
+		return nil, nil
+	case string:
+		return &v, nil
+	case map[string]interface{}:
+		raw, ok := v["content"]
+		if !ok || raw == nil {
+			return nil, nil
+		}
+		content, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("content 必须是字符串")
+		}
+		return &content, nil
+	default:
+		return nil, fmt.Errorf("不支持的返回值类型: %T", value)
+	}
+}
+
+// RunOnCopyScripts 对即将复制的文本 text 执行匹配的 on_copy 脚本，返回转换后的文本
+// 脚本看到的项目是 item 的副本，Content 为 text（片段为展开后的文本），item 本身不会被修改
+func RunOnCopyScripts(item *ClipboardItem, text string) string {
+	scripts, err := GetEnabledUserScripts("on_copy")
+	if err != nil {
+		log.Printf("❌ 获取 on_copy 脚本失败: %v", err)
+		return text
+	}
+	if len(scripts) == 0 {
+		return text
+	}
+
+	outgoing := *item
+	outgoing.Content = text
+	runScriptPipeline(scripts, &outgoing, onCopyPipelineTimeout, func(script *UserScript, value interface{}) bool {
+		content, err := parseOnCopyReturn(value)
+		if err != nil {
+			log.Printf("⚠️ on_copy 脚本「%s」返回值无效，已忽略: %v", script.Name, err)
+			return true
+		}
+		if content == nil {
+			return true
+		}
+		if strings.TrimSpace(*content) == "" {
+			log.Printf("⚠️ on_copy 脚本「%s」返回了空内容，已忽略", script.Name)
+			return true
+		}
+		outgoing.Content = *content
+		return true
+	})
+	return outgoing.Content
+}
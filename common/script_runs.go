@@ -0,0 +1,388 @@
+package common
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// 脚本执行记录：Go 运行时执行的每个脚本（保存前后、复制时、HTTP 服务、快捷键）都会记录一条，
+// 用于排查后台执行失败的脚本。只保留最近 scriptRunMaxRows 条，删除脚本时一起删除
+
+const (
+	// scriptRunMaxRows 最多保留的执行记录数
+	scriptRunMaxRows = 5000
+	// scriptRunPruneEvery 每写入多少条记录检查一次是否超过 scriptRunMaxRows
+	scriptRunPruneEvery = 100
+	// scriptRunValueLimit 返回值 JSON 最多保存的字节数（console 输出在执行时已限制）
+	scriptRunValueLimit = 64 * 1024
+	// scriptRunDefaultLimit 查询执行记录时默认返回的条数
+	scriptRunDefaultLimit = 100
+)
+
+// ScriptRun 一次脚本执行的记录
+type ScriptRun struct {
+	ID            int64
+	ScriptID      string
+	ScriptName    string // 执行时的脚本名称（脚本改名或删除后仍然可以看到）
+	ScriptVersion string // 在线插件的版本号，本地脚本为空
+	ScriptHash    string // 执行时脚本代码的 SHA-256 前 12 位，用于区分脚本的不同版本
+	Trigger       string // after_save / before_save / on_copy / http / hotkey
+	ItemID        string
+	StartedAt     time.Time
+	DurationMs    int64
+	Status        string // success / error / timeout / cancelled
+	Console       string
+	ReturnValue   string // 返回值的 JSON，超过 scriptRunValueLimit 时截断
+	Error         string
+}
+
+// ScriptRunFilter 查询或清除执行记录的条件，空值表示不限制
+type ScriptRunFilter struct {
+	ScriptID string
+	Trigger  string
+	Status   string
+	ItemID   string
+	Since    time.Time // 开始时间不早于
+	Before   time.Time // 开始时间早于
+	Limit    int       // 只用于查询，0 表示 scriptRunDefaultLimit
+	Offset   int
+}
+
+// ScriptRunSummary 一个脚本的执行统计
+type ScriptRunSummary struct {
+	ScriptID      string
+	ScriptName    string // 最近一次执行时的名称
+	Total         int
+	Succeeded     int
+	Failed        int // error、timeout 和 cancelled
+	AvgDurationMs int64
+	LastRunAt     time.Time
+	LastStatus    string
+	LastError     string
+}
+
+// scriptRunInserts 写入的执行记录数，用于定期清理
+var scriptRunInserts atomic.Int64
+
+// runAndRecordScript 执行脚本并保存执行记录
+func runAndRecordScript(ctx context.Context, trigger string, script *UserScript, item *ClipboardItem, limits ScriptLimits) ScriptResult {
+	start := time.Now()
+	result := RunUserScript(ctx, script, item, limits)
+	if err := recordScriptRun(trigger, script, item.ID, start, result); err != nil {
+		log.Printf("⚠️ 保存脚本执行记录失败: %v", err)
+	}
+	return result
+}
+
+// recordScriptRun 保存一条执行记录
+func recordScriptRun(trigger string, script *UserScript, itemID string, start time.Time, result ScriptResult) error {
+	if DB == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	returnValue := ""
+	if result.Status == ScriptRunSuccess && result.ReturnValue != nil {
+		data, err := json.Marshal(result.ReturnValue)
+		if err != nil {
+			return fmt.Errorf("序列化返回值失败: %v", err)
+		}
+		returnValue = truncateBytes(string(data), scriptRunValueLimit)
+	}
+
+	// console 和返回值可能包含剪贴板内容，启用加密存储时用数据密钥加密；
+	// 存储已锁定时（例如锁定期间触发的 before_save 脚本）无法加密，不保存这两项
+	console, returnValue, err := encryptScriptRunOutput(result.Console, returnValue)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256([]byte(script.Script))
+	_, err = DB.Exec(`
+	INSERT INTO script_runs (script_id, script_name, script_version, script_hash, trigger, item_id,
+		started_at, duration_ms, status, console, return_value, error)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		script.ID, script.Name, script.PluginVersion, hex.EncodeToString(hash[:])[:12], trigger, itemID,
+		start.UTC(), result.Duration.Milliseconds(), result.Status, console, returnValue, result.Error)
+	if err != nil {
+		return fmt.Errorf("保存脚本执行记录失败: %v", err)
+	}
+
+	if scriptRunInserts.Add(1)%scriptRunPruneEvery == 0 {
+		pruneScriptRuns()
+	}
+	return nil
+}
+
+// encryptScriptRunOutput 加密执行记录的 console 和返回值；存储已锁定时返回空字符串
+func encryptScriptRunOutput(console, returnValue string) (string, string, error) {
+	encryptedConsole, err := encryptText(console)
+	if errors.Is(err, ErrStoreLocked) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("加密脚本输出失败: %v", err)
+	}
+	encryptedValue, err := encryptText(returnValue)
+	if err != nil {
+		return "", "", fmt.Errorf("加密脚本返回值失败: %v", err)
+	}
+	return encryptedConsole, encryptedValue, nil
+}
+
+// migrateScriptRunOutputs 启用或关闭加密存储时加密或解密已有执行记录的 console 和返回值，返回修改的记录数
+// 记录数受 scriptRunMaxRows 限制，一次读出后在同一个事务中按原值条件更新
+func migrateScriptRunOutputs(encrypt bool) (int, error) {
+	transform := decryptText
+	if encrypt {
+		transform = encryptText
+	}
+
+	rows, err := DB.Query(`SELECT id, console, return_value FROM script_runs`)
+	if err != nil {
+		return 0, fmt.Errorf("查询脚本执行记录失败: %v", err)
+	}
+	type pendingUpdate struct {
+		id                         int64
+		console, returnValue       string
+		oldConsole, oldReturnValue string
+	}
+	var updates []pendingUpdate
+	for rows.Next() {
+		var u pendingUpdate
+		if err := rows.Scan(&u.id, &u.oldConsole, &u.oldReturnValue); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("扫描脚本执行记录失败: %v", err)
+		}
+		if u.console, err = transform(u.oldConsole); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("迁移脚本执行记录失败: ID=%d, %v", u.id, err)
+		}
+		if u.returnValue, err = transform(u.oldReturnValue); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("迁移脚本执行记录失败: ID=%d, %v", u.id, err)
+		}
+		if u.console != u.oldConsole || u.returnValue != u.oldReturnValue {
+			updates = append(updates, u)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("查询脚本执行记录失败: %v", err)
+	}
+	if len(updates) == 0 {
+		return 0, nil
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("开启事务失败: %v", err)
+	}
+	changed := 0
+	for _, u := range updates {
+		result, err := tx.Exec(`UPDATE script_runs SET console = ?, return_value = ? WHERE id = ? AND console = ? AND return_value = ?`,
+			u.console, u.returnValue, u.id, u.oldConsole, u.oldReturnValue)
+		if err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("更新脚本执行记录失败: %v", err)
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			changed++
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("提交迁移失败: %v", err)
+	}
+	return changed, nil
+}
+
+// pruneScriptRuns 删除超过 scriptRunMaxRows 的旧记录
+func pruneScriptRuns() {
+	result, err := DB.Exec(`
+	DELETE FROM script_runs WHERE id NOT IN (
+		SELECT id FROM script_runs ORDER BY id DESC LIMIT ?)`, scriptRunMaxRows)
+	if err != nil {
+		log.Printf("⚠️ 清理脚本执行记录失败: %v", err)
+		return
+	}
+	if removed, _ := result.RowsAffected(); removed > 0 {
+		log.Printf("🧹 已清理 %d 条旧的脚本执行记录", removed)
+	}
+}
+
+// truncateBytes 把字符串截断到最多 limit 字节（不截断 UTF-8 字符）
+func truncateBytes(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	return strings.ToValidUTF8(s[:limit], "")
+}
+
+// scriptRunWhere 把过滤条件转换为 WHERE 子句和参数
+func scriptRunWhere(filter ScriptRunFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, value interface{}) {
+		conditions = append(conditions, condition)
+		args = append(args, value)
+	}
+	if filter.ScriptID != "" {
+		add("script_id = ?", filter.ScriptID)
+	}
+	if filter.Trigger != "" {
+		add("trigger = ?", filter.Trigger)
+	}
+	if filter.Status != "" {
+		add("status = ?", filter.Status)
+	}
+	if filter.ItemID != "" {
+		add("item_id = ?", filter.ItemID)
+	}
+	if !filter.Since.IsZero() {
+		add("started_at >= ?", filter.Since.UTC())
+	}
+	if !filter.Before.IsZero() {
+		add("started_at < ?", filter.Before.UTC())
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// ListScriptRuns 按条件查询执行记录（最新的在前）
+func ListScriptRuns(filter ScriptRunFilter) ([]ScriptRun, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = scriptRunDefaultLimit
+	}
+	offset := filter.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	if err := checkStoreUnlocked(); err != nil {
+		return nil, err
+	}
+
+	where, args := scriptRunWhere(filter)
+	rows, err := DB.Query(`
+	SELECT id, script_id, script_name, script_version, script_hash, trigger, item_id,
+		started_at, duration_ms, status, console, return_value, error
+	FROM script_runs`+where+`
+	ORDER BY started_at DESC, id DESC LIMIT ? OFFSET ?`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, fmt.Errorf("查询脚本执行记录失败: %v", err)
+	}
+	defer rows.Close()
+
+	runs := []ScriptRun{}
+	for rows.Next() {
+		var run ScriptRun
+		if err := rows.Scan(&run.ID, &run.ScriptID, &run.ScriptName, &run.ScriptVersion, &run.ScriptHash,
+			&run.Trigger, &run.ItemID, &run.StartedAt, &run.DurationMs, &run.Status,
+			&run.Console, &run.ReturnValue, &run.Error); err != nil {
+			log.Printf("扫描脚本执行记录失败: %v", err)
+			continue
+		}
+		if run.Console, err = decryptText(run.Console); err != nil {
+			log.Printf("解密脚本执行记录失败: ID=%d, %v", run.ID, err)
+			continue
+		}
+		if run.ReturnValue, err = decryptText(run.ReturnValue); err != nil {
+			log.Printf("解密脚本执行记录失败: ID=%d, %v", run.ID, err)
+			continue
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// PurgeScriptRuns 删除符合条件的执行记录（忽略 Limit 和 Offset），条件为空时删除全部，返回删除的条数
+func PurgeScriptRuns(filter ScriptRunFilter) (int64, error) {
+	if DB == nil {
+		return 0, fmt.Errorf("数据库未初始化")
+	}
+	where, args := scriptRunWhere(filter)
+	result, err := DB.Exec(`DELETE FROM script_runs`+where, args...)
+	if err != nil {
+		return 0, fmt.Errorf("删除脚本执行记录失败: %v", err)
+	}
+	removed, _ := result.RowsAffected()
+	log.Printf("✅ 已删除 %d 条脚本执行记录", removed)
+	return removed, nil
+}
+
+// GetScriptRunSummaries 每个脚本的执行统计（最近执行的脚本在前）
+func GetScriptRunSummaries() ([]ScriptRunSummary, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	rows, err := DB.Query(`
+	SELECT s.script_id, last.script_name, s.total, s.succeeded, s.avg_duration,
+		last.started_at, last.status, last.error
+	FROM (
+		SELECT script_id, COUNT(*) AS total,
+			SUM(CASE WHEN status = 'success' THEN 1 ELSE 0 END) AS succeeded,
+			CAST(AVG(duration_ms) AS INTEGER) AS avg_duration,
+			MAX(id) AS last_id
+		FROM script_runs GROUP BY script_id
+	) s JOIN script_runs last ON last.id = s.last_id
+	ORDER BY last.started_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("查询脚本执行统计失败: %v", err)
+	}
+	defer rows.Close()
+
+	summaries := []ScriptRunSummary{}
+	for rows.Next() {
+		var summary ScriptRunSummary
+		if err := rows.Scan(&summary.ScriptID, &summary.ScriptName, &summary.Total, &summary.Succeeded,
+			&summary.AvgDurationMs, &summary.LastRunAt, &summary.LastStatus, &summary.LastError); err != nil {
+			log.Printf("扫描脚本执行统计失败: %v", err)
+			continue
+		}
+		summary.Failed = summary.Total - summary.Succeeded
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// migrateCreateScriptRuns v14: 脚本执行记录
+func migrateCreateScriptRuns(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+	CREATE TABLE IF NOT EXISTS script_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		script_id TEXT NOT NULL,
+		script_name TEXT NOT NULL DEFAULT '',
+		script_version TEXT NOT NULL DEFAULT '',
+		script_hash TEXT NOT NULL DEFAULT '',
+		trigger TEXT NOT NULL,
+		item_id TEXT NOT NULL DEFAULT '',
+		started_at DATETIME NOT NULL,
+		duration_ms INTEGER NOT NULL DEFAULT 0,
+		status TEXT NOT NULL,
+		console TEXT NOT NULL DEFAULT '',
+		return_value TEXT NOT NULL DEFAULT '',
+		error TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_script_runs_script ON script_runs(script_id, started_at);
+	CREATE INDEX IF NOT EXISTS idx_script_runs_started_at ON script_runs(started_at);
+	`)
+	if err != nil {
+		return fmt.Errorf("创建 script_runs 表失败: %v", err)
+	}
+	return nil
+}
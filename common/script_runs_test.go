@@ -0,0 +1,119 @@
+package common
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// storedScriptRunOutput 直接读取数据库中的 console 和 return_value（不解密）
+func storedScriptRunOutput(t *testing.T, scriptID string) (string, string) {
+	t.Helper()
+	var console, returnValue string
+	if err := DB.QueryRow(`SELECT console, return_value FROM script_runs WHERE script_id = ?`, scriptID).Scan(&console, &returnValue); err != nil {
+		t.Fatal(err)
+	}
+	return console, returnValue
+}
+
+func TestRecordScriptRunEncryption(t *testing.T) {
+	openTestDB(t, 0)
+	resetEncryptionState(t)
+	if err := EnableEncryption("test-pw"); err != nil {
+		t.Fatal(err)
+	}
+	encryptionMu.RLock()
+	aead := dataAEAD
+	encryptionMu.RUnlock()
+	setLocked := func(locked bool) {
+		encryptionMu.Lock()
+		defer encryptionMu.Unlock()
+		if locked {
+			dataAEAD = nil
+		} else {
+			dataAEAD = aead
+		}
+	}
+
+	result := ScriptResult{Status: ScriptRunSuccess, Console: "token=s3cr3t", ReturnValue: map[string]string{"otp": "424242"}}
+	tests := []struct {
+		name        string
+		locked      bool
+		result      ScriptResult
+		wantConsole string // ListScriptRuns 返回的明文
+		wantValue   string
+	}{
+		{name: "解锁时加密保存", result: result, wantConsole: "token=s3cr3t", wantValue: `{"otp":"424242"}`},
+		{name: "锁定时不保存输出", locked: true, result: result},
+		{name: "空输出", result: ScriptResult{Status: ScriptRunError, Error: "boom"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := &UserScript{ID: "run-" + tt.name, Name: tt.name, Script: "return 1"}
+			setLocked(tt.locked)
+			err := recordScriptRun("before_save", script, "item", time.Now(), tt.result)
+			setLocked(false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			console, returnValue := storedScriptRunOutput(t, script.ID)
+			for _, stored := range []string{console, returnValue} {
+				if stored != "" && !strings.HasPrefix(stored, encryptedTextPrefix) {
+					t.Errorf("数据库中是明文: %q", stored)
+				}
+			}
+
+			runs, err := ListScriptRuns(ScriptRunFilter{ScriptID: script.ID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(runs) != 1 {
+				t.Fatalf("ListScriptRuns 返回 %d 条，期望 1", len(runs))
+			}
+			if runs[0].Console != tt.wantConsole || runs[0].ReturnValue != tt.wantValue {
+				t.Errorf("console=%q returnValue=%q，期望 %q 和 %q", runs[0].Console, runs[0].ReturnValue, tt.wantConsole, tt.wantValue)
+			}
+		})
+	}
+
+	setLocked(true)
+	if _, err := ListScriptRuns(ScriptRunFilter{}); err != ErrStoreLocked {
+		t.Errorf("锁定时 ListScriptRuns 错误 = %v，期望 ErrStoreLocked", err)
+	}
+	setLocked(false)
+}
+
+func TestScriptRunOutputMigration(t *testing.T) {
+	openTestDB(t, 0)
+	resetEncryptionState(t)
+	setTestPassword(t, "test-pw")
+
+	script := &UserScript{ID: "migrate", Name: "migrate", Script: "return 1"}
+	result := ScriptResult{Status: ScriptRunSuccess, Console: "hello", ReturnValue: "world"}
+	if err := recordScriptRun("on_copy", script, "", time.Now(), result); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name      string
+		apply     func() error
+		encrypted bool
+	}{
+		{name: "启用加密后加密已有记录", apply: func() error { return EnableEncryption("test-pw") }, encrypted: true},
+		{name: "关闭加密后恢复明文", apply: func() error { return DisableEncryption("test-pw") }},
+	}
+	for _, step := range steps {
+		if err := step.apply(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		console, returnValue := storedScriptRunOutput(t, script.ID)
+		if got := strings.HasPrefix(console, encryptedTextPrefix) && strings.HasPrefix(returnValue, encryptedTextPrefix); got != step.encrypted {
+			t.Errorf("%s: console=%q return_value=%q", step.name, console, returnValue)
+		}
+		runs, err := ListScriptRuns(ScriptRunFilter{ScriptID: script.ID})
+		if err != nil || len(runs) != 1 || runs[0].Console != "hello" || runs[0].ReturnValue != `"world"` {
+			t.Errorf("%s: ListScriptRuns = %+v, %v", step.name, runs, err)
+		}
+	}
+}
@@ -0,0 +1,429 @@
+package common
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+// 脚本运行时：在 Go 中用 goja 执行用户脚本，不依赖前端窗口
+//
+// 脚本代码和在前端执行时相同：包装在 async 函数中，可以使用 item、return 和 await，
+// import { csRequest, csCopyText, csNotify, csStorage } from '@clipsave/api' 会被移除（这些 API 始终可用）
+// 浏览器 API 只有 fetch、btoa、TextEncoder 等常用的兼容实现，没有 setTimeout、DOM 等，alert 显示为通知
+
+// ScriptLimits 脚本的执行限制
+type ScriptLimits struct {
+	Timeout time.Duration // 总时间，包括等待 HTTP 请求等宿主调用
+	CPUTime time.Duration // 执行 JavaScript 的时间，不包括宿主调用
+}
+
+// DefaultScriptLimits 保存后、HTTP 服务和快捷键触发的脚本使用的执行限制
+var DefaultScriptLimits = ScriptLimits{Timeout: 20 * time.Second, CPUTime: 5 * time.Second}
+
+// 脚本执行的状态
+const (
+	ScriptRunSuccess   = "success"
+	ScriptRunError     = "error"     // 脚本抛出异常、语法错误等
+	ScriptRunTimeout   = "timeout"   // 超过总时间或 CPU 时间限制
+	ScriptRunCancelled = "cancelled" // ctx 被取消，例如 HTTP 客户端断开
+)
+
+// ScriptResult 脚本执行结果
+type ScriptResult struct {
+	Status      string        `json:"status"`
+	ReturnValue interface{}   `json:"returnValue,omitempty"` // 经过 JSON 转换，和前端执行时一致
+	Error       string        `json:"error,omitempty"`
+	Console     string        `json:"console,omitempty"` // console.log 等的输出
+	Duration    time.Duration `json:"duration"`
+}
+
+const (
+	scriptConsoleLimit       = 64 * 1024             // console 输出最多保留的字节数
+	scriptMaxCallStackSize   = 10000                 // 最大调用深度，防止无限递归耗尽内存
+	scriptCPUCheckInterval   = 20 * time.Millisecond // 检查 CPU 时间的间隔
+	scriptUnfinishedErrorMsg = "脚本没有执行完成（不支持 setTimeout 等异步等待）"
+)
+
+// scriptImportPattern 脚本中的 '@clipsave/api' 导入语句
+var scriptImportPattern = regexp.MustCompile(`import\s*\{[^}]+\}\s*from\s*['"]@clipsave/api['"];?\s*\n?`)
+
+// scriptRun 一次脚本执行的状态（goja.Runtime 不能并发使用，每次执行创建新的）
+type scriptRun struct {
+	ctx    context.Context
+	script *UserScript
+	limits ScriptLimits
+	vm     *goja.Runtime
+
+	hostNanos atomic.Int64 // 已完成的宿主调用用时
+	hostStart atomic.Int64 // 正在进行的宿主调用的开始时间，0 表示没有
+
+	console          strings.Builder
+	consoleTruncated bool
+}
+
+// RunUserScript 在 Go 运行时中执行脚本，ctx 取消或超过 limits 时中断脚本
+func RunUserScript(ctx context.Context, script *UserScript, item *ClipboardItem, limits ScriptLimits) ScriptResult {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, limits.Timeout)
+	defer cancel()
+
+	run := &scriptRun{ctx: ctx, script: script, limits: limits, vm: goja.New()}
+	value, err := run.execute(item)
+
+	result := ScriptResult{Status: ScriptRunSuccess, Console: run.console.String(), Duration: time.Since(start)}
+	if err != nil {
+		result.Status = ScriptRunError
+		var interrupt *scriptInterrupt
+		if errors.As(err, &interrupt) {
+			result.Status = interrupt.status
+		} else if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			// 等待 HTTP 请求时超时，脚本收到的是请求失败的异常
+			result.Status = ScriptRunTimeout
+		} else if ctx.Err() != nil {
+			result.Status = ScriptRunCancelled
+		}
+		result.Error = err.Error()
+	} else {
+		result.ReturnValue = value
+	}
+	return result
+}
+
+// scriptInterrupt 中断脚本的原因（作为 vm.Interrupt 的值）
+type scriptInterrupt struct {
+	status  string
+	message string
+}
+
+func (e *scriptInterrupt) Error() string {
+	return e.message
+}
+
+// logScriptResult 记录脚本的执行结果
+func logScriptResult(trigger string, script *UserScript, result ScriptResult) {
+	if result.Error != "" {
+		log.Printf("⚠️ %s 脚本「%s」执行失败 (%v): %s", trigger, script.Name, result.Duration, result.Error)
+		return
+	}
+	log.Printf("✅ %s 脚本「%s」执行完成 (%v)", trigger, script.Name, result.Duration)
+}
+
+// runScriptsInBackground 在后台依次对 item 执行脚本
+func runScriptsInBackground(item *ClipboardItem, trigger string, scripts []UserScript) {
+	itemCopy := *item
+	go func() {
+		for i := range scripts {
+			result := runAndRecordScript(context.Background(), trigger, &scripts[i], &itemCopy, DefaultScriptLimits)
+			logScriptResult(trigger, &scripts[i], result)
+		}
+	}()
+}
+
+// execute 执行脚本，返回 async 函数的结果
+func (r *scriptRun) execute(item *ClipboardItem) (interface{}, error) {
+	r.vm.SetMaxCallStackSize(scriptMaxCallStackSize)
+	if err := r.setupGlobals(item); err != nil {
+		return nil, fmt.Errorf("初始化脚本环境失败: %v", err)
+	}
+	if err := r.setupCompat(); err != nil {
+		return nil, fmt.Errorf("初始化脚本环境失败: %v", err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go r.watch(done)
+
+	code := "(async function() {\n" + scriptImportPattern.ReplaceAllString(r.script.Script, "") + "\n})()"
+	value, err := r.vm.RunString(code)
+	if err != nil {
+		return nil, r.scriptError(err)
+	}
+
+	// async 函数返回 Promise；宿主调用都是同步的，RunString 返回前会执行完所有 await
+	promise, ok := value.Export().(*goja.Promise)
+	if !ok {
+		return r.exportValue(value)
+	}
+	switch promise.State() {
+	case goja.PromiseStateFulfilled:
+		return r.exportValue(promise.Result())
+	case goja.PromiseStateRejected:
+		return nil, errors.New(r.errorMessage(promise.Result()))
+	default:
+		return nil, errors.New(scriptUnfinishedErrorMsg)
+	}
+}
+
+// watch 超过时间限制或 ctx 取消时中断脚本
+func (r *scriptRun) watch(done <-chan struct{}) {
+	start := time.Now()
+	ticker := time.NewTicker(scriptCPUCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-r.ctx.Done():
+			if errors.Is(r.ctx.Err(), context.DeadlineExceeded) {
+				r.vm.Interrupt(&scriptInterrupt{ScriptRunTimeout, fmt.Sprintf("脚本执行超时（超过 %v）", r.limits.Timeout)})
+			} else {
+				r.vm.Interrupt(&scriptInterrupt{ScriptRunCancelled, "脚本执行已取消"})
+			}
+			return
+		case now := <-ticker.C:
+			cpu := now.Sub(start) - time.Duration(r.hostNanos.Load())
+			if hostStart := r.hostStart.Load(); hostStart != 0 {
+				cpu -= now.Sub(time.Unix(0, hostStart))
+			}
+			if cpu > r.limits.CPUTime {
+				r.vm.Interrupt(&scriptInterrupt{ScriptRunTimeout, fmt.Sprintf("脚本 CPU 时间超过 %v", r.limits.CPUTime)})
+				return
+			}
+		}
+	}
+}
+
+// enterHost 开始一次宿主调用（不计入 CPU 时间），返回结束调用的函数
+func (r *scriptRun) enterHost() func() {
+	start := time.Now()
+	r.hostStart.Store(start.UnixNano())
+	return func() {
+		r.hostNanos.Add(int64(time.Since(start)))
+		r.hostStart.Store(0)
+	}
+}
+
+// setupGlobals 设置脚本可以使用的 item 和 API
+func (r *scriptRun) setupGlobals(item *ClipboardItem) error {
+	vm := r.vm
+
+	itemObj := vm.NewObject()
+	for key, value := range scriptItemData(item) {
+		if err := itemObj.Set(key, value); err != nil {
+			return err
+		}
+	}
+	// ImageData（base64）数据较大，用到时才读取
+	var imageData *string
+	getImageData := vm.ToValue(func() string {
+		if imageData == nil {
+			data := scriptImageData(item)
+			imageData = &data
+		}
+		return *imageData
+	})
+	if err := itemObj.DefineAccessorProperty("ImageData", getImageData, nil, goja.FLAG_TRUE, goja.FLAG_TRUE); err != nil {
+		return err
+	}
+
+	console := vm.NewObject()
+	for _, level := range []string{"log", "info", "warn", "error", "debug"} {
+		if err := console.Set(level, r.consoleFunc(level)); err != nil {
+			return err
+		}
+	}
+
+	storage := vm.NewObject()
+	if err := storage.Set("get", r.storageGet); err != nil {
+		return err
+	}
+	if err := storage.Set("set", r.storageSet); err != nil {
+		return err
+	}
+	if err := storage.Set("remove", r.storageRemove); err != nil {
+		return err
+	}
+
+	globals := map[string]interface{}{
+		"item":    itemObj,
+		"console": console,
+		"alert": func(message string) {
+			defer r.enterHost()()
+			scriptNotify(r.script.Name, message)
+		},
+		"csRequest": func(method string, requestURL string, headersJSON string, bodyJSON string) (string, error) {
+			defer r.enterHost()()
+			return ScriptHTTPRequest(r.ctx, method, requestURL, headersJSON, bodyJSON)
+		},
+		"csCopyText": func(text string) {
+			defer r.enterHost()()
+			scriptCopyText(text)
+		},
+		"csNotify": func(title string, message string) {
+			defer r.enterHost()()
+			scriptNotify(title, message)
+		},
+		"csStorage": storage,
+	}
+	for name, value := range globals {
+		if err := vm.Set(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// consoleFunc console.log 等：记录到执行结果中
+func (r *scriptRun) consoleFunc(level string) func(call goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		parts := make([]string, 0, len(call.Arguments))
+		for _, arg := range call.Arguments {
+			parts = append(parts, r.formatConsoleArg(arg))
+		}
+		line := strings.Join(parts, " ")
+		if level != "log" {
+			line = "[" + level + "] " + line
+		}
+		r.appendConsole(line)
+		return goja.Undefined()
+	}
+}
+
+// formatConsoleArg 字符串原样输出，对象输出 JSON
+func (r *scriptRun) formatConsoleArg(arg goja.Value) string {
+	if _, ok := arg.(*goja.Object); !ok {
+		return arg.String()
+	}
+	text, ok, err := r.stringify(arg)
+	if err != nil || !ok {
+		return arg.String()
+	}
+	return text
+}
+
+func (r *scriptRun) appendConsole(line string) {
+	if r.consoleTruncated {
+		return
+	}
+	if r.console.Len()+len(line)+1 > scriptConsoleLimit {
+		r.console.WriteString("...（输出过长，已截断）\n")
+		r.consoleTruncated = true
+		return
+	}
+	r.console.WriteString(line)
+	r.console.WriteString("\n")
+}
+
+// storageGet csStorage.get(key)：不存在时返回 null
+func (r *scriptRun) storageGet(key string) (goja.Value, error) {
+	defer r.enterHost()()
+	valueJSON, err := GetScriptStorage(r.script.ID, key)
+	if err != nil {
+		return nil, err
+	}
+	if valueJSON == "" {
+		return goja.Null(), nil
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(valueJSON), &value); err != nil {
+		return nil, fmt.Errorf("解析存储的值失败: %v", err)
+	}
+	return r.vm.ToValue(value), nil
+}
+
+// storageSet csStorage.set(key, value)：value 为 undefined 时删除
+func (r *scriptRun) storageSet(key string, value goja.Value) error {
+	valueJSON, ok, err := r.stringify(value)
+	if err != nil {
+		return err
+	}
+	defer r.enterHost()()
+	if !ok {
+		return DeleteScriptStorage(r.script.ID, key)
+	}
+	return SetScriptStorage(r.script.ID, key, valueJSON)
+}
+
+// storageRemove csStorage.remove(key)
+func (r *scriptRun) storageRemove(key string) error {
+	defer r.enterHost()()
+	return DeleteScriptStorage(r.script.ID, key)
+}
+
+// stringify 调用 JSON.stringify，第二个返回值为 false 表示结果是 undefined（函数、undefined 等）
+func (r *scriptRun) stringify(value goja.Value) (string, bool, error) {
+	jsonObj := r.vm.Get("JSON").ToObject(r.vm)
+	stringify, ok := goja.AssertFunction(jsonObj.Get("stringify"))
+	if !ok {
+		return "", false, fmt.Errorf("JSON.stringify 不可用")
+	}
+	out, err := stringify(jsonObj, value)
+	if err != nil {
+		return "", false, err
+	}
+	if goja.IsUndefined(out) {
+		return "", false, nil
+	}
+	return out.String(), true, nil
+}
+
+// exportValue 把返回值转换为 JSON 兼容的 Go 值（和前端把结果 JSON 序列化后传给后端一致）
+func (r *scriptRun) exportValue(value goja.Value) (interface{}, error) {
+	text, ok, err := r.stringify(value)
+	if err != nil {
+		return nil, fmt.Errorf("返回值无法转换为 JSON: %v", r.scriptError(err))
+	}
+	if !ok {
+		return nil, nil
+	}
+	var result interface{}
+	if err := json.Unmarshal([]byte(text), &result); err != nil {
+		return nil, fmt.Errorf("返回值无法转换为 JSON: %v", err)
+	}
+	return result, nil
+}
+
+// scriptError 把 goja 的错误转换为错误信息
+func (r *scriptRun) scriptError(err error) error {
+	var interrupted *goja.InterruptedError
+	if errors.As(err, &interrupted) {
+		if interrupt, ok := interrupted.Value().(*scriptInterrupt); ok {
+			return interrupt
+		}
+		return fmt.Errorf("%v", interrupted.Value())
+	}
+	var stackOverflow *goja.StackOverflowError
+	if errors.As(err, &stackOverflow) {
+		return fmt.Errorf("调用层数超过 %d（可能是无限递归）", scriptMaxCallStackSize)
+	}
+	var exception *goja.Exception
+	if errors.As(err, &exception) {
+		return errors.New(r.errorMessage(exception.Value()))
+	}
+	return err
+}
+
+// errorMessage 抛出的值的错误信息（Error 对象使用 message）
+func (r *scriptRun) errorMessage(value goja.Value) string {
+	if obj, ok := value.(*goja.Object); ok {
+		if message := obj.Get("message"); message != nil && !goja.IsUndefined(message) {
+			return message.String()
+		}
+	}
+	return value.String()
+}
+
+// scriptImageData 图片的 base64 数据，内存中没有时从数据库读取
+func scriptImageData(item *ClipboardItem) string {
+	data := item.ImageData
+	if len(data) == 0 && item.ContentType == "Image" && item.ID != "" {
+		full, err := GetClipboardItemByID(item.ID)
+		if err != nil {
+			log.Printf("⚠️ 读取脚本使用的图片失败: %v", err)
+			return ""
+		}
+		data = full.ImageData
+	}
+	return base64.StdEncoding.EncodeToString(data)
+}
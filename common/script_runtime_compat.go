@@ -0,0 +1,184 @@
+package common
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/dop251/goja"
+)
+
+// 常用浏览器 API 的兼容实现，让为前端编写的脚本可以在 Go 运行时中执行：
+// fetch（请求完成后才返回，不支持流式读取）、btoa、atob、TextEncoder、TextDecoder
+
+// scriptCompatPrelude 在用户脚本之前执行，使用 __clipsave 中的 Go 函数定义浏览器 API
+const scriptCompatPrelude = `(function(native) {
+  function Headers(init) {
+    this._map = {};
+    if (init) {
+      for (var key in init) {
+        this._map[key.toLowerCase()] = String(init[key]);
+      }
+    }
+  }
+  Headers.prototype.get = function(name) {
+    var value = this._map[String(name).toLowerCase()];
+    return value === undefined ? null : value;
+  };
+  Headers.prototype.has = function(name) {
+    return this._map[String(name).toLowerCase()] !== undefined;
+  };
+  Headers.prototype.forEach = function(callback) {
+    for (var key in this._map) {
+      callback(this._map[key], key, this);
+    }
+  };
+
+  function Response(raw) {
+    this.status = raw.status;
+    this.statusText = raw.statusText;
+    this.ok = raw.status >= 200 && raw.status < 300;
+    this.url = raw.url;
+    this.headers = new Headers(raw.headers);
+    this._body = raw.body;
+  }
+  Response.prototype.text = function() {
+    return Promise.resolve(this._body);
+  };
+  Response.prototype.json = function() {
+    try {
+      return Promise.resolve(JSON.parse(this._body));
+    } catch (e) {
+      return Promise.reject(e);
+    }
+  };
+  Response.prototype.arrayBuffer = function() {
+    return Promise.resolve(native.utf8Encode(this._body));
+  };
+
+  globalThis.Headers = Headers;
+  globalThis.Response = Response;
+  globalThis.fetch = function(input, init) {
+    init = init || {};
+    var headers = {};
+    if (init.headers instanceof Headers) {
+      init.headers.forEach(function(value, key) { headers[key] = value; });
+    } else if (init.headers) {
+      for (var key in init.headers) {
+        headers[key] = String(init.headers[key]);
+      }
+    }
+    try {
+      var raw = native.fetch(String(input), String(init.method || 'GET').toUpperCase(), headers,
+        init.body == null ? '' : String(init.body));
+      return Promise.resolve(new Response(raw));
+    } catch (e) {
+      return Promise.reject(e);
+    }
+  };
+
+  globalThis.btoa = native.btoa;
+  globalThis.atob = native.atob;
+
+  function TextEncoder() {}
+  TextEncoder.prototype.encoding = 'utf-8';
+  TextEncoder.prototype.encode = function(text) {
+    return new Uint8Array(native.utf8Encode(text === undefined ? '' : String(text)));
+  };
+  globalThis.TextEncoder = TextEncoder;
+
+  function TextDecoder() {}
+  TextDecoder.prototype.encoding = 'utf-8';
+  TextDecoder.prototype.decode = function(input) {
+    if (input === undefined) {
+      return '';
+    }
+    if (ArrayBuffer.isView(input)) {
+      input = input.buffer.slice(input.byteOffset, input.byteOffset + input.byteLength);
+    }
+    return native.utf8Decode(input);
+  };
+  globalThis.TextDecoder = TextDecoder;
+})(__clipsave);
+delete globalThis.__clipsave;
+`
+
+// setupCompat 定义浏览器 API 的兼容实现
+func (r *scriptRun) setupCompat() error {
+	vm := r.vm
+	native := vm.NewObject()
+	funcs := map[string]interface{}{
+		"fetch":      r.compatFetch,
+		"btoa":       compatBtoa,
+		"atob":       compatAtob,
+		"utf8Encode": func(text string) goja.ArrayBuffer { return vm.NewArrayBuffer([]byte(text)) },
+		"utf8Decode": func(buf goja.ArrayBuffer) string { return strings.ToValidUTF8(string(buf.Bytes()), "�") },
+	}
+	for name, fn := range funcs {
+		if err := native.Set(name, fn); err != nil {
+			return err
+		}
+	}
+	if err := vm.Set("__clipsave", native); err != nil {
+		return err
+	}
+	_, err := vm.RunString(scriptCompatPrelude)
+	return err
+}
+
+// compatFetch fetch 使用的 Go 函数：发送请求并返回 {status, statusText, url, headers, body}
+func (r *scriptRun) compatFetch(requestURL string, method string, headers map[string]string, body string) (map[string]interface{}, error) {
+	defer r.enterHost()()
+	resp, err := scriptFetch(r.ctx, method, requestURL, headers, body)
+	if err != nil {
+		return nil, err
+	}
+	header := make(map[string]interface{}, len(resp.Header))
+	for key := range resp.Header {
+		header[key] = resp.Header.Get(key)
+	}
+	return map[string]interface{}{
+		"status":     resp.Status,
+		"statusText": http.StatusText(resp.Status),
+		"url":        requestURL,
+		"headers":    header,
+		"body":       resp.Body,
+	}, nil
+}
+
+// compatBtoa btoa：每个字符作为一个字节（Latin1）编码为 base64
+func compatBtoa(call goja.FunctionCall, vm *goja.Runtime) goja.Value {
+	units := utf16.Encode([]rune(call.Argument(0).String()))
+	data := make([]byte, len(units))
+	for i, unit := range units {
+		if unit > 0xFF {
+			panic(vm.NewTypeError("btoa: 字符串包含 Latin1 范围以外的字符"))
+		}
+		data[i] = byte(unit)
+	}
+	return vm.ToValue(base64.StdEncoding.EncodeToString(data))
+}
+
+// compatAtob atob：解码 base64，每个字节作为一个字符（Latin1）
+func compatAtob(call goja.FunctionCall, vm *goja.Runtime) goja.Value {
+	text := strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\f' || r == '\r' {
+			return -1
+		}
+		return r
+	}, call.Argument(0).String())
+	data, err := base64.StdEncoding.DecodeString(text)
+	if err != nil {
+		data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(text, "="))
+	}
+	if err != nil {
+		panic(vm.NewTypeError(fmt.Sprintf("atob: 不是有效的 base64 字符串: %v", err)))
+	}
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return vm.ToValue(string(runes))
+}
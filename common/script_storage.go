@@ -0,0 +1,79 @@
+package common
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// 脚本存储：每个脚本独立的键值存储，值保存为 JSON，删除脚本时一起删除
+
+// scriptStorageValueLimit 单个值 JSON 的最大长度
+const scriptStorageValueLimit = 1 << 20
+
+// GetScriptStorage 读取脚本存储的值（JSON），不存在时返回空字符串
+func GetScriptStorage(scriptID string, key string) (string, error) {
+	if DB == nil {
+		return "", fmt.Errorf("数据库未初始化")
+	}
+	var value string
+	err := DB.QueryRow(`SELECT value FROM script_storage WHERE script_id = ? AND key = ?`, scriptID, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("读取脚本存储失败: %v", err)
+	}
+	return value, nil
+}
+
+// SetScriptStorage 保存脚本存储的值，valueJSON 必须是有效的 JSON
+func SetScriptStorage(scriptID string, key string, valueJSON string) error {
+	if DB == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	if key == "" {
+		return fmt.Errorf("存储的键不能为空")
+	}
+	if len(valueJSON) > scriptStorageValueLimit {
+		return fmt.Errorf("存储的值不能超过 %d 字节", scriptStorageValueLimit)
+	}
+	if !json.Valid([]byte(valueJSON)) {
+		return fmt.Errorf("存储的值不是有效的 JSON")
+	}
+	_, err := DB.Exec(`
+	INSERT INTO script_storage (script_id, key, value, updated_at) VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+	ON CONFLICT(script_id, key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+		scriptID, key, valueJSON)
+	if err != nil {
+		return fmt.Errorf("保存脚本存储失败: %v", err)
+	}
+	return nil
+}
+
+// DeleteScriptStorage 删除脚本存储的值
+func DeleteScriptStorage(scriptID string, key string) error {
+	if DB == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	if _, err := DB.Exec(`DELETE FROM script_storage WHERE script_id = ? AND key = ?`, scriptID, key); err != nil {
+		return fmt.Errorf("删除脚本存储失败: %v", err)
+	}
+	return nil
+}
+
+// migrateCreateScriptStorage v13: 脚本的键值存储
+func migrateCreateScriptStorage(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+	CREATE TABLE IF NOT EXISTS script_storage (
+		script_id TEXT NOT NULL,
+		key TEXT NOT NULL,
+		value TEXT NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (script_id, key)
+	)`)
+	if err != nil {
+		return fmt.Errorf("创建 script_storage 表失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package common
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// 转换类脚本（before_save、on_copy）：同步执行并使用返回值，按 sort_order 依次执行，
+// 每个脚本看到前面脚本修改后的内容。脚本执行失败、超时或返回值无效时跳过该脚本
+
+// scriptTransformTimeout 单个转换脚本的最长执行时间
+const scriptTransformTimeout = 2 * time.Second
+
+// ScriptRunner 执行脚本，超过 timeout 时中断
+type ScriptRunner func(script *UserScript, item *ClipboardItem, timeout time.Duration) ScriptResult
+
+// transformScriptRunner 执行转换脚本
+var transformScriptRunner ScriptRunner = runTransformScript
+
+// runTransformScript 在 Go 运行时中执行转换脚本（转换脚本同步执行，CPU 时间和总时间使用同一个限制）
+func runTransformScript(script *UserScript, item *ClipboardItem, timeout time.Duration) ScriptResult {
+	return runAndRecordScript(context.Background(), script.Trigger, script, item, ScriptLimits{Timeout: timeout, CPUTime: timeout})
+}
+
+// runScriptPipeline 依次执行匹配 item 的脚本，把返回值交给 handle；handle 返回 false 时停止执行剩下的脚本
+// 所有脚本的总时间超过 pipelineTimeout 后剩下的脚本不再执行
+func runScriptPipeline(scripts []UserScript, item *ClipboardItem, pipelineTimeout time.Duration, handle func(script *UserScript, value interface{}) bool) {
+	deadline := time.Now().Add(pipelineTimeout)
+	for i := range scripts {
+		script := &scripts[i]
+		// 匹配条件使用前面脚本修改后的内容
+		if !shouldTriggerScript(script, item) {
+			continue
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			log.Printf("⚠️ %s 脚本总执行时间超过 %v，跳过剩下的脚本", script.Trigger, pipelineTimeout)
+			return
+		}
+
+		timeout := scriptTransformTimeout
+		if remaining < timeout {
+			timeout = remaining
+		}
+		result := transformScriptRunner(script, item, timeout)
+		if result.Error != "" {
+			log.Printf("⚠️ %s 脚本「%s」执行失败，已跳过: %s", script.Trigger, script.Name, result.Error)
+			continue
+		}
+		if !handle(script, result.ReturnValue) {
+			return
+		}
+	}
+}
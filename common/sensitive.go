@@ -0,0 +1,453 @@
+package common
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// 敏感内容检测：文本在写入数据库之前经过检测器注册表，
+// 命中的类别按设置中的动作处理（丢弃 / 打码后保存 / 保存并定时过期），处理结果记录在项目上供界面显示标记
+
+// 敏感内容类别
+const (
+	SensitiveCategoryPrivateKey = "private_key" // PEM 私钥
+	SensitiveCategoryCloudKey   = "cloud_key"   // 云服务访问密钥（AWS 等）
+	SensitiveCategoryToken      = "token"       // JWT、GitHub / Slack 令牌
+	SensitiveCategoryCreditCard = "credit_card" // 银行卡号（Luhn 校验）
+	SensitiveCategoryPassword   = "password"    // 密码赋值、高熵字符串
+)
+
+// 敏感内容处理动作
+const (
+	SensitiveActionAllow  = "allow"  // 原样保存（只记录类别）
+	SensitiveActionExpire = "expire" // 原样保存，到期后自动删除
+	SensitiveActionRedact = "redact" // 打码后保存
+	SensitiveActionDrop   = "drop"   // 不保存
+)
+
+// sensitiveActionRank 多个类别同时命中时取最严格的动作
+var sensitiveActionRank = map[string]int{
+	SensitiveActionAllow:  0,
+	SensitiveActionExpire: 1,
+	SensitiveActionRedact: 2,
+	SensitiveActionDrop:   3,
+}
+
+// defaultSensitiveActions 各类别的默认动作
+var defaultSensitiveActions = map[string]string{
+	SensitiveCategoryPrivateKey: SensitiveActionDrop,
+	SensitiveCategoryCloudKey:   SensitiveActionRedact,
+	SensitiveCategoryToken:      SensitiveActionRedact,
+	SensitiveCategoryCreditCard: SensitiveActionRedact,
+	SensitiveCategoryPassword:   SensitiveActionExpire,
+}
+
+// defaultSensitiveExpireMinutes 过期动作的默认保留时间
+const defaultSensitiveExpireMinutes = 10
+
+// sensitiveMask 打码后显示的前缀，保留原文最后 4 个字符便于辨认
+const sensitiveMask = "••••••"
+
+// SensitiveDetector 敏感内容检测器，Detect 返回命中的字节区间 [start, end)
+type SensitiveDetector struct {
+	Name     string
+	Category string
+	Detect   func(content string) [][2]int
+}
+
+var (
+	sensitiveDetectorsMu sync.RWMutex
+	sensitiveDetectors   []SensitiveDetector
+)
+
+// RegisterSensitiveDetector 注册敏感内容检测器（同名检测器会被替换）
+func RegisterSensitiveDetector(detector SensitiveDetector) {
+	sensitiveDetectorsMu.Lock()
+	defer sensitiveDetectorsMu.Unlock()
+	for i := range sensitiveDetectors {
+		if sensitiveDetectors[i].Name == detector.Name {
+			sensitiveDetectors[i] = detector
+			return
+		}
+	}
+	sensitiveDetectors = append(sensitiveDetectors, detector)
+}
+
+// regexDetector 基于正则的检测器；正则带分组时只把第一个分组视为敏感内容，validate 为空表示不额外校验
+func regexDetector(name, category, pattern string, validate func(match string) bool) SensitiveDetector {
+	re := regexp.MustCompile(pattern)
+	return SensitiveDetector{
+		Name:     name,
+		Category: category,
+		Detect: func(content string) [][2]int {
+			var spans [][2]int
+			for _, m := range re.FindAllStringSubmatchIndex(content, -1) {
+				start, end := m[0], m[1]
+				if len(m) >= 4 && m[2] >= 0 {
+					start, end = m[2], m[3]
+				}
+				if validate != nil && !validate(content[start:end]) {
+					continue
+				}
+				spans = append(spans, [2]int{start, end})
+			}
+			return spans
+		},
+	}
+}
+
+// luhnValid 银行卡号 Luhn 校验，并检查常见卡组织号段（减少误报）
+func luhnValid(number string) bool {
+	var digits []int
+	for _, r := range number {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, int(r-'0'))
+		}
+	}
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	// Visa 4、万事达 51-55 / 22-27、运通 34 / 37、大来 30 / 36 / 38、JCB 35、Discover / 银联 6
+	switch first, second := digits[0], digits[1]; {
+	case first == 4, first == 6:
+	case first == 5 && second >= 1 && second <= 5:
+	case first == 2 && second >= 2 && second <= 7:
+	case first == 3 && (second == 0 || second == 4 || second == 5 || second == 6 || second == 7 || second == 8):
+	default:
+		return false
+	}
+
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := digits[i]
+		if (len(digits)-1-i)%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// shannonEntropy 每个字符的香农熵（比特）
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	entropy := 0.0
+	for _, c := range counts {
+		p := float64(c) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// highEntropySymbols 随机密码中常见、代码标识符和路径中不常见的符号
+const highEntropySymbols = "!@#$%^&*+=?~|"
+
+// highEntropyEmailPattern 邮箱地址（包含 @，但不是密码）
+var highEntropyEmailPattern = regexp.MustCompile(`^[^@\s]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}$`)
+
+// highEntropyDetector 整段内容是单个随机字符串（如密码管理器复制的密码）时命中
+// 要求同时包含大写、小写、数字和 highEntropySymbols 中的符号，
+// 避免把 UUID、哈希值、普通单词和 ClipboardItemV2、getUserById42 这样的标识符识别为密码
+func highEntropyDetector() SensitiveDetector {
+	return SensitiveDetector{
+		Name:     "high_entropy",
+		Category: SensitiveCategoryPassword,
+		Detect: func(content string) [][2]int {
+			token := strings.TrimSpace(content)
+			if len(token) < 12 || len(token) > 128 || strings.Contains(token, "://") {
+				return nil
+			}
+			var hasUpper, hasLower, hasDigit, hasSymbol bool
+			for _, r := range token {
+				switch {
+				case unicode.IsSpace(r) || r > unicode.MaxASCII:
+					return nil
+				case unicode.IsUpper(r):
+					hasUpper = true
+				case unicode.IsLower(r):
+					hasLower = true
+				case unicode.IsDigit(r):
+					hasDigit = true
+				case strings.ContainsRune(highEntropySymbols, r):
+					hasSymbol = true
+				}
+			}
+			if !hasUpper || !hasLower || !hasDigit || !hasSymbol || shannonEntropy(token) < 3.5 {
+				return nil
+			}
+			if highEntropyEmailPattern.MatchString(token) {
+				return nil
+			}
+			start := strings.Index(content, token)
+			return [][2]int{{start, start + len(token)}}
+		},
+	}
+}
+
+func init() {
+	// 设置变化时更新捕获使用的敏感内容设置
+	SubscribeSettings("sensitive", sensitivePolicyKeys, func(change SettingsChange) error {
+		policy := sensitivePolicyFromSettings(change.Settings)
+		sensitivePolicyMu.Lock()
+		sensitivePolicyCache = &policy
+		sensitivePolicyMu.Unlock()
+		return nil
+	})
+
+	RegisterSensitiveDetector(regexDetector("pem_private_key", SensitiveCategoryPrivateKey,
+		`(?s)-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----.*?(?:-----END [A-Z0-9 ]*PRIVATE KEY-----|\z)`, nil))
+	RegisterSensitiveDetector(regexDetector("aws_access_key_id", SensitiveCategoryCloudKey,
+		`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`, nil))
+	RegisterSensitiveDetector(regexDetector("aws_secret_access_key", SensitiveCategoryCloudKey,
+		`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`, nil))
+	RegisterSensitiveDetector(regexDetector("jwt", SensitiveCategoryToken,
+		`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{10,}`, nil))
+	RegisterSensitiveDetector(regexDetector("github_token", SensitiveCategoryToken,
+		`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`, nil))
+	RegisterSensitiveDetector(regexDetector("slack_token", SensitiveCategoryToken,
+		`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`, nil))
+	RegisterSensitiveDetector(regexDetector("password_assignment", SensitiveCategoryPassword,
+		`(?i)\b(?:password|passwd|pwd|secret|api_?key)["']?\s*[:=]\s*["']?([^\s"']{6,})`, nil))
+	RegisterSensitiveDetector(regexDetector("credit_card", SensitiveCategoryCreditCard,
+		`\b\d(?:[ -]?\d){12,18}\b`, luhnValid))
+	RegisterSensitiveDetector(highEntropyDetector())
+}
+
+// SensitivePolicy 敏感内容处理设置（来自 app_settings）
+type SensitivePolicy struct {
+	Enabled       bool              `json:"sensitiveDetection"`     // 总开关
+	Actions       map[string]string `json:"sensitiveActions"`       // 类别 -> 动作，未设置的类别使用默认动作
+	ExpireMinutes int               `json:"sensitiveExpireMinutes"` // 过期动作的保留时间（分钟）
+}
+
+// actionFor 获取类别对应的动作
+func (p SensitivePolicy) actionFor(category string) string {
+	if action, ok := p.Actions[category]; ok {
+		if _, valid := sensitiveActionRank[action]; valid {
+			return action
+		}
+	}
+	if action, ok := defaultSensitiveActions[category]; ok {
+		return action
+	}
+	return SensitiveActionRedact
+}
+
+// sensitivePolicyCache 捕获时使用的敏感内容设置，设置变化时由订阅更新
+var (
+	sensitivePolicyMu    sync.RWMutex
+	sensitivePolicyCache *SensitivePolicy
+)
+
+// sensitivePolicyKeys 敏感内容设置对应的设置字段
+var sensitivePolicyKeys = []string{"sensitiveDetection", "sensitiveActions", "sensitiveExpireMinutes"}
+
+// sensitivePolicyFromSettings 从应用设置提取敏感内容处理设置
+func sensitivePolicyFromSettings(settings *Settings) SensitivePolicy {
+	return SensitivePolicy{
+		Enabled:       settings.SensitiveDetection,
+		Actions:       settings.SensitiveActions,
+		ExpireMinutes: settings.SensitiveExpireMinutes,
+	}
+}
+
+// LoadSensitivePolicy 从应用设置读取敏感内容处理设置（首次读取后缓存）
+func LoadSensitivePolicy() (SensitivePolicy, error) {
+	policy := SensitivePolicy{Enabled: true, ExpireMinutes: defaultSensitiveExpireMinutes}
+	if DB == nil {
+		return policy, nil
+	}
+
+	sensitivePolicyMu.RLock()
+	cached := sensitivePolicyCache
+	sensitivePolicyMu.RUnlock()
+	if cached != nil {
+		return *cached, nil
+	}
+
+	settings, err := LoadSettings()
+	if err != nil {
+		return policy, err
+	}
+	policy = sensitivePolicyFromSettings(settings)
+	sensitivePolicyMu.Lock()
+	sensitivePolicyCache = &policy
+	sensitivePolicyMu.Unlock()
+	return policy, nil
+}
+
+// SensitiveCategoryInfo 敏感内容类别及当前动作（供设置界面使用）
+type SensitiveCategoryInfo struct {
+	Category      string
+	Action        string
+	DefaultAction string
+	Detectors     []string
+}
+
+// GetSensitiveCategories 获取所有类别、检测器和当前动作
+func GetSensitiveCategories() ([]SensitiveCategoryInfo, error) {
+	policy, err := LoadSensitivePolicy()
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]int)
+	var infos []SensitiveCategoryInfo
+	sensitiveDetectorsMu.RLock()
+	for _, detector := range sensitiveDetectors {
+		i, ok := index[detector.Category]
+		if !ok {
+			i = len(infos)
+			index[detector.Category] = i
+			infos = append(infos, SensitiveCategoryInfo{
+				Category:      detector.Category,
+				Action:        policy.actionFor(detector.Category),
+				DefaultAction: defaultSensitiveActions[detector.Category],
+			})
+		}
+		infos[i].Detectors = append(infos[i].Detectors, detector.Name)
+	}
+	sensitiveDetectorsMu.RUnlock()
+	return infos, nil
+}
+
+// SensitiveDecision 一次检测的处理结果
+type SensitiveDecision struct {
+	Categories []string // 命中的类别（已排序）
+	Action     string
+	Content    string     // 处理后的内容（打码时为打码后的文本）
+	ExpiresAt  *time.Time // 过期动作的到期时间
+}
+
+// DetectSensitive 检测文本中的敏感内容，未命中时返回 nil
+func DetectSensitive(content string, policy SensitivePolicy) *SensitiveDecision {
+	if !policy.Enabled || strings.TrimSpace(content) == "" {
+		return nil
+	}
+
+	spansByCategory := make(map[string][][2]int)
+	sensitiveDetectorsMu.RLock()
+	for _, detector := range sensitiveDetectors {
+		if spans := detector.Detect(content); len(spans) > 0 {
+			spansByCategory[detector.Category] = append(spansByCategory[detector.Category], spans...)
+		}
+	}
+	sensitiveDetectorsMu.RUnlock()
+	if len(spansByCategory) == 0 {
+		return nil
+	}
+
+	decision := &SensitiveDecision{Action: SensitiveActionAllow, Content: content}
+	var redactSpans [][2]int
+	for category, spans := range spansByCategory {
+		decision.Categories = append(decision.Categories, category)
+		action := policy.actionFor(category)
+		if sensitiveActionRank[action] > sensitiveActionRank[decision.Action] {
+			decision.Action = action
+		}
+		if action == SensitiveActionRedact {
+			redactSpans = append(redactSpans, spans...)
+		}
+	}
+	sort.Strings(decision.Categories)
+
+	switch decision.Action {
+	case SensitiveActionRedact:
+		decision.Content = redactContent(content, redactSpans)
+	case SensitiveActionExpire:
+		expiresAt := time.Now().Add(time.Duration(policy.ExpireMinutes) * time.Minute)
+		decision.ExpiresAt = &expiresAt
+	}
+	return decision
+}
+
+// redactContent 把命中区间替换为打码文本（重叠区间会先合并）
+func redactContent(content string, spans [][2]int) string {
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+	var b strings.Builder
+	pos := 0
+	for i := 0; i < len(spans); i++ {
+		start, end := spans[i][0], spans[i][1]
+		for i+1 < len(spans) && spans[i+1][0] < end {
+			i++
+			end = max(end, spans[i][1])
+		}
+		if start < pos {
+			start = pos
+		}
+		b.WriteString(content[pos:start])
+		b.WriteString(maskSecret(content[start:end]))
+		pos = end
+	}
+	b.WriteString(content[pos:])
+	return b.String()
+}
+
+// maskSecret 打码：较长的内容保留最后 4 个字符，私钥等多行内容整段隐藏
+func maskSecret(secret string) string {
+	runes := []rune(strings.TrimSpace(secret))
+	if len(runes) < 12 || strings.Contains(secret, "\n") {
+		return sensitiveMask
+	}
+	return sensitiveMask + string(runes[len(runes)-4:])
+}
+
+// applySensitivePolicy 对待保存的文本项目执行敏感内容检测，返回 false 表示项目应被丢弃
+// 打码时重新计算字数统计，内容哈希由调用方基于处理后的内容计算（数据库中不保留原文的哈希）
+func applySensitivePolicy(item *ClipboardItem, policy SensitivePolicy) bool {
+	decision := DetectSensitive(item.Content, policy)
+	if decision == nil {
+		return true
+	}
+
+	categories := strings.Join(decision.Categories, ",")
+	if decision.Action == SensitiveActionDrop {
+		log.Printf("🔒 检测到敏感内容（%s），已丢弃", categories)
+		return false
+	}
+
+	item.SensitiveCategory = categories
+	item.SensitiveAction = decision.Action
+	item.ExpiresAt = decision.ExpiresAt
+	if decision.Content != item.Content {
+		item.Content = decision.Content
+		item.ContentType = detectContentType(item.Content)
+		item.CharCount = len([]rune(item.Content))
+		item.WordCount = countWords(item.Content)
+	}
+	log.Printf("🔒 检测到敏感内容（%s），动作: %s", categories, decision.Action)
+	return true
+}
+
+// migrateAddSensitiveFields v10: 敏感内容检测结果和过期时间
+func migrateAddSensitiveFields(tx *sql.Tx) error {
+	if err := addColumnIfMissing(tx, "clipboard_items", "sensitive_category", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(tx, "clipboard_items", "sensitive_action", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(tx, "clipboard_items", "expires_at", "DATETIME"); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_clipboard_items_expires_at ON clipboard_items(expires_at) WHERE expires_at IS NOT NULL`)
+	if err != nil {
+		return fmt.Errorf("创建过期时间索引失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,601 @@
+package common
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 应用设置：以 JSON 保存在 app_settings 表的 app_settings 键中
+// 读取时补齐默认值、按版本升级并把无效值恢复为默认值；写入前校验，校验失败时不保存
+// 修改统一通过 UpdateSettings 在事务中读-改-写，订阅者（见 SubscribeSettings）应用成功后才提交，
+// 提交后通知 settings.changed
+// 不认识的字段（例如老版本的 password）原样保留，不会被覆盖
+
+// settingsKey 设置在 app_settings 表中的键
+const settingsKey = "app_settings"
+
+// 设置取值范围
+const (
+	minPageSize       = 10
+	maxPageSize       = 200
+	maxRetentionDays  = 3650
+	maxExpireMinutes  = 1440
+	minScriptHTTPPort = 1024
+	maxScriptHTTPPort = 65535
+)
+
+// Settings 应用设置
+type Settings struct {
+	Version int `json:"version"` // 设置格式版本，见 settingsUpgrades
+
+	// 历史记录保留策略（见 RetentionPolicy）
+	AutoClean             bool           `json:"autoClean"`
+	RetentionDays         int            `json:"retentionDays"`
+	RetentionMaxItems     int            `json:"retentionMaxItems"`
+	RetentionMaxStorageMB int            `json:"retentionMaxStorageMB"`
+	RetentionTypeLimits   map[string]int `json:"retentionTypeLimits"`
+
+	PageSize         int             `json:"pageSize"`         // 列表每页加载的项目数
+	Hotkey           string          `json:"hotkey"`           // 显示窗口的全局快捷键，空字符串表示使用默认快捷键
+	Hotkeys          []HotkeyBinding `json:"hotkeys"`          // 其他动作的全局快捷键（见 HotkeyBinding）
+	BackgroundMode   bool            `json:"backgroundMode"`   // 后台运行模式（仅 macOS，隐藏 Dock 图标）
+	DoubleClickPaste bool            `json:"doubleClickPaste"` // 双击自动粘贴
+	AutoStart        bool            `json:"autoStart"`        // 开机自启（仅 Windows）
+	Language         string          `json:"language"`         // 界面语言，空字符串表示使用默认语言
+	AutoLockMinutes  int             `json:"autoLockMinutes"`  // 空闲自动锁定（分钟，0 表示不自动锁定）
+
+	// 敏感内容检测（见 SensitivePolicy）
+	SensitiveDetection     bool              `json:"sensitiveDetection"`
+	SensitiveActions       map[string]string `json:"sensitiveActions"`
+	SensitiveExpireMinutes int               `json:"sensitiveExpireMinutes"`
+
+	// 脚本 HTTP 服务
+	ScriptHTTPPort int    `json:"scriptHTTPPort"`
+	ScriptHTTPBind string `json:"scriptHTTPBind"` // 监听地址，默认 127.0.0.1 只允许本机；0.0.0.0 允许局域网访问（服务没有认证）
+
+	// extra 不认识的字段，保存时原样写回
+	extra map[string]json.RawMessage
+}
+
+// SettingsChange 设置变化通知
+type SettingsChange struct {
+	Keys     []string  `json:"keys"` // 变化的字段（JSON 键名）
+	Settings *Settings `json:"settings"`
+	previous *Settings
+}
+
+// Changed 判断某个字段是否变化
+func (c SettingsChange) Changed(key string) bool {
+	for _, k := range c.Keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Previous 修改前的设置
+func (c SettingsChange) Previous() *Settings {
+	return c.previous
+}
+
+// SettingsValidationError 设置校验失败
+type SettingsValidationError struct {
+	Key     string
+	Message string
+}
+
+func (e *SettingsValidationError) Error() string {
+	return fmt.Sprintf("设置 %s 无效: %s", e.Key, e.Message)
+}
+
+// settingsUpgrade 设置格式升级（在原始 JSON 字段上操作，Version 递增且不可修改）
+type settingsUpgrade struct {
+	Version int
+	Name    string
+	Upgrade func(raw map[string]json.RawMessage) error
+}
+
+// settingsUpgrades 设置格式升级列表，只能在末尾追加
+var settingsUpgrades = []settingsUpgrade{
+	{Version: 1, Name: "drop_empty_password", Upgrade: upgradeSettingsDropEmptyPassword},
+	{Version: 2, Name: "script_http_loopback", Upgrade: upgradeSettingsScriptHTTPLoopback},
+}
+
+var (
+	// settingsMu 串行化设置的读-改-写
+	settingsMu sync.Mutex
+
+	settingsChangedMu       sync.Mutex
+	settingsChangedCallback func(SettingsChange)
+)
+
+// DefaultSettings 返回默认设置
+func DefaultSettings() *Settings {
+	return &Settings{
+		Version:                currentSettingsVersion(),
+		AutoClean:              true,
+		RetentionDays:          30,
+		RetentionTypeLimits:    map[string]int{},
+		PageSize:               50,
+		Hotkey:                 "Command+Option+c",
+		Hotkeys:                []HotkeyBinding{},
+		DoubleClickPaste:       true,
+		SensitiveDetection:     true,
+		SensitiveActions:       map[string]string{},
+		SensitiveExpireMinutes: defaultSensitiveExpireMinutes,
+		ScriptHTTPPort:         6527,
+		ScriptHTTPBind:         "127.0.0.1",
+	}
+}
+
+// currentSettingsVersion 当前设置格式版本
+func currentSettingsVersion() int {
+	return settingsUpgrades[len(settingsUpgrades)-1].Version
+}
+
+// settingsAlias 去掉自定义 JSON 方法，避免递归
+type settingsAlias Settings
+
+// MarshalJSON 输出所有字段和保留的未知字段
+func (s Settings) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(settingsAlias(s))
+	if err != nil || len(s.extra) == 0 {
+		return data, err
+	}
+	var merged map[string]json.RawMessage
+	if err := json.Unmarshal(data, &merged); err != nil {
+		return nil, err
+	}
+	for key, value := range s.extra {
+		if _, known := merged[key]; !known {
+			merged[key] = value
+		}
+	}
+	return json.Marshal(merged)
+}
+
+// UnmarshalJSON 在现有值（通常是默认值）上覆盖 JSON 中出现的字段，并保留未知字段
+func (s *Settings) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*settingsAlias)(s)); err != nil {
+		return err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	fields := settingsFields()
+	for key := range raw {
+		if _, known := fields[key]; known {
+			delete(raw, key)
+		}
+	}
+	s.extra = raw
+	return nil
+}
+
+// Clone 深拷贝设置
+func (s *Settings) Clone() *Settings {
+	clone := *s
+	clone.RetentionTypeLimits = make(map[string]int, len(s.RetentionTypeLimits))
+	for k, v := range s.RetentionTypeLimits {
+		clone.RetentionTypeLimits[k] = v
+	}
+	clone.SensitiveActions = make(map[string]string, len(s.SensitiveActions))
+	for k, v := range s.SensitiveActions {
+		clone.SensitiveActions[k] = v
+	}
+	clone.Hotkeys = append([]HotkeyBinding{}, s.Hotkeys...)
+	clone.extra = make(map[string]json.RawMessage, len(s.extra))
+	for k, v := range s.extra {
+		clone.extra[k] = v
+	}
+	return &clone
+}
+
+// legacyPassword 老版本保存在设置中的密码哈希（升级到 credential 后删除）
+func (s *Settings) legacyPassword() string {
+	var password string
+	if value, ok := s.extra["password"]; ok {
+		json.Unmarshal(value, &password)
+	}
+	return password
+}
+
+// Validate 校验设置，返回第一个无效字段的 *SettingsValidationError
+func (s *Settings) Validate() error {
+	invalid := func(key string, format string, args ...interface{}) error {
+		return &SettingsValidationError{Key: key, Message: fmt.Sprintf(format, args...)}
+	}
+
+	if s.RetentionDays < 0 || s.RetentionDays > maxRetentionDays {
+		return invalid("retentionDays", "需要在 0 到 %d 之间", maxRetentionDays)
+	}
+	if s.RetentionMaxItems < 0 {
+		return invalid("retentionMaxItems", "不能小于 0")
+	}
+	if s.RetentionMaxStorageMB < 0 {
+		return invalid("retentionMaxStorageMB", "不能小于 0")
+	}
+	for contentType, limit := range s.RetentionTypeLimits {
+		if limit < 0 {
+			return invalid("retentionTypeLimits", "%s 的数量不能小于 0", contentType)
+		}
+	}
+	if s.PageSize < minPageSize || s.PageSize > maxPageSize {
+		return invalid("pageSize", "需要在 %d 到 %d 之间", minPageSize, maxPageSize)
+	}
+	if s.Hotkey != "" {
+		if err := checkHotkeyString(s.Hotkey); err != nil {
+			return invalid("hotkey", "%v", err)
+		}
+	}
+	if err := validateHotkeyBindings(s.Hotkey, s.Hotkeys); err != nil {
+		return invalid("hotkeys", "%v", err)
+	}
+	if s.Language != "" {
+		if !isKnownLanguage(s.Language) {
+			return invalid("language", "不支持的语言 %s", s.Language)
+		}
+	}
+	if s.AutoLockMinutes < 0 || s.AutoLockMinutes > maxExpireMinutes {
+		return invalid("autoLockMinutes", "需要在 0 到 %d 之间", maxExpireMinutes)
+	}
+	for category, action := range s.SensitiveActions {
+		if !isSensitiveAction(action) {
+			return invalid("sensitiveActions", "%s 的动作 %s 无效", category, action)
+		}
+	}
+	if s.SensitiveExpireMinutes < 1 || s.SensitiveExpireMinutes > maxExpireMinutes {
+		return invalid("sensitiveExpireMinutes", "需要在 1 到 %d 之间", maxExpireMinutes)
+	}
+	if s.ScriptHTTPPort < minScriptHTTPPort || s.ScriptHTTPPort > maxScriptHTTPPort {
+		return invalid("scriptHTTPPort", "需要在 %d 到 %d 之间", minScriptHTTPPort, maxScriptHTTPPort)
+	}
+	if s.ScriptHTTPBind != "localhost" && net.ParseIP(s.ScriptHTTPBind) == nil {
+		return invalid("scriptHTTPBind", "不是有效的 IP 地址: %s", s.ScriptHTTPBind)
+	}
+	return nil
+}
+
+// isKnownLanguage 是否是支持的界面语言
+func isKnownLanguage(lang string) bool {
+	switch lang {
+	case LangChinese, LangEnglish, LangFrench, LangArabic:
+		return true
+	}
+	return false
+}
+
+// isSensitiveAction 是否是有效的敏感内容处理动作
+func isSensitiveAction(action string) bool {
+	switch action {
+	case SensitiveActionAllow, SensitiveActionExpire, SensitiveActionRedact, SensitiveActionDrop:
+		return true
+	}
+	return false
+}
+
+// settingsFieldIndex JSON 键名 -> Settings 字段下标
+var (
+	settingsFieldIndex     map[string]int
+	settingsFieldIndexOnce sync.Once
+)
+
+func settingsFields() map[string]int {
+	settingsFieldIndexOnce.Do(func() {
+		settingsFieldIndex = make(map[string]int)
+		t := reflect.TypeOf(Settings{})
+		for i := 0; i < t.NumField(); i++ {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			if name != "" && name != "-" {
+				settingsFieldIndex[name] = i
+			}
+		}
+	})
+	return settingsFieldIndex
+}
+
+// sanitize 把无效字段恢复为默认值（读取老数据或手动修改过的数据时使用，保证读取不会失败）
+func (s *Settings) sanitize() {
+	defaults := DefaultSettings()
+	fields := settingsFields()
+	for range fields {
+		err := s.Validate()
+		if err == nil {
+			return
+		}
+		validationErr, ok := err.(*SettingsValidationError)
+		if !ok {
+			return
+		}
+		index, ok := fields[validationErr.Key]
+		if !ok {
+			return
+		}
+		log.Printf("⚠️ %v，已恢复默认值", validationErr)
+		reflect.ValueOf(s).Elem().Field(index).Set(reflect.ValueOf(defaults).Elem().Field(index))
+	}
+}
+
+// parseSettings 解析保存的设置 JSON：执行格式升级、补齐默认值并修正无效值
+// 返回的 upgraded 表示执行了升级，需要写回数据库
+func parseSettings(settingsJSON string) (settings *Settings, upgraded bool, err error) {
+	settings = DefaultSettings()
+	if settingsJSON == "" {
+		return settings, false, nil
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(settingsJSON), &raw); err != nil {
+		return nil, false, fmt.Errorf("解析设置失败: %v", err)
+	}
+	var version int
+	if value, ok := raw["version"]; ok {
+		json.Unmarshal(value, &version)
+	}
+	if version > currentSettingsVersion() {
+		log.Printf("⚠️ 设置来自更新的版本 (v%d)，按当前版本读取", version)
+	}
+	for _, upgrade := range settingsUpgrades {
+		if upgrade.Version <= version {
+			continue
+		}
+		if err := upgrade.Upgrade(raw); err != nil {
+			return nil, false, fmt.Errorf("升级设置 v%d (%s) 失败: %v", upgrade.Version, upgrade.Name, err)
+		}
+		log.Printf("🔧 已升级设置 v%d: %s", upgrade.Version, upgrade.Name)
+		upgraded = true
+	}
+	delete(raw, "version")
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, false, fmt.Errorf("序列化设置失败: %v", err)
+	}
+	// 逐个字段解析，类型不对的字段保留默认值，不影响其他字段
+	if err := json.Unmarshal(data, settings); err != nil {
+		for key, value := range raw {
+			if err := settings.setField(key, value); err != nil {
+				log.Printf("⚠️ 忽略无效的设置 %s: %v", key, err)
+			}
+		}
+	}
+	settings.Version = max(version, currentSettingsVersion())
+	settings.sanitize()
+	return settings, upgraded, nil
+}
+
+// setField 按 JSON 键名设置单个字段，未知字段写入 extra
+func (s *Settings) setField(key string, value json.RawMessage) error {
+	index, known := settingsFields()[key]
+	if !known {
+		if s.extra == nil {
+			s.extra = make(map[string]json.RawMessage)
+		}
+		s.extra[key] = value
+		return nil
+	}
+	field := reflect.ValueOf(s).Elem().Field(index)
+	// 先解析到新值再替换，避免 map 字段与旧值合并
+	target := reflect.New(field.Type())
+	if err := json.Unmarshal(value, target.Interface()); err != nil {
+		return err
+	}
+	field.Set(target.Elem())
+	return nil
+}
+
+// LoadSettings 读取应用设置
+func LoadSettings() (*Settings, error) {
+	settingsJSON, err := GetSetting(settingsKey)
+	if err != nil {
+		return DefaultSettings(), err
+	}
+	settings, _, err := parseSettings(settingsJSON)
+	if err != nil {
+		return DefaultSettings(), err
+	}
+	return settings, nil
+}
+
+// loadSettingsTx 在事务中读取应用设置
+func loadSettingsTx(tx *sql.Tx) (*Settings, error) {
+	var settingsJSON string
+	err := tx.QueryRow(`SELECT value FROM app_settings WHERE key = ?`, settingsKey).Scan(&settingsJSON)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("读取设置失败: %v", err)
+	}
+	settings, _, err := parseSettings(settingsJSON)
+	return settings, err
+}
+
+// saveSettingsTx 在事务中保存应用设置
+func saveSettingsTx(tx *sql.Tx, settings *Settings) error {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("序列化设置失败: %v", err)
+	}
+	return saveSettingTx(tx, settingsKey, string(data))
+}
+
+// UpdateSettings 在事务中读取设置、调用 update 修改并校验后保存，返回修改后的设置
+// 有字段变化时通知 settings.changed
+func UpdateSettings(update func(s *Settings) error) (*Settings, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败: %v", err)
+	}
+	defer tx.Rollback()
+
+	current, err := loadSettingsTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	next := current.Clone()
+	if err := update(next); err != nil {
+		return nil, err
+	}
+	next.Version = current.Version
+	if err := next.Validate(); err != nil {
+		return nil, err
+	}
+
+	changed := changedSettingsKeys(current, next)
+	if len(changed) == 0 {
+		return next, nil
+	}
+	if err := saveSettingsTx(tx, next); err != nil {
+		return nil, err
+	}
+
+	// 先让订阅者应用新设置，任何一个失败都不保存（已应用的订阅者会回滚）
+	change := SettingsChange{Keys: changed, Settings: next.Clone(), previous: current}
+	applied, err := applySettingsChange(change)
+	if err != nil {
+		log.Printf("⚠️ %v，设置未保存", err)
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		rollbackSettingsChange(applied)
+		return nil, fmt.Errorf("保存设置失败: %v", err)
+	}
+
+	log.Printf("已保存设置: %s", strings.Join(changed, ", "))
+	notifySettingsChanged(change)
+	return next, nil
+}
+
+// PatchSettings 只修改 patch 中出现的字段（JSON 键名 -> 新值），其余字段保持不变
+// password 只能通过 SetPassword / ClearPassword 修改，version 由升级维护，两者在 patch 中被忽略
+func PatchSettings(patch map[string]json.RawMessage) (*Settings, error) {
+	return UpdateSettings(func(s *Settings) error {
+		for key, value := range patch {
+			if key == "password" || key == "version" {
+				continue
+			}
+			if err := s.setField(key, value); err != nil {
+				return &SettingsValidationError{Key: key, Message: err.Error()}
+			}
+		}
+		return nil
+	})
+}
+
+// PatchSettingsJSON 以 JSON 对象形式修改部分设置
+func PatchSettingsJSON(patchJSON string) (*Settings, error) {
+	var patch map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(patchJSON), &patch); err != nil {
+		return nil, fmt.Errorf("解析设置失败: %v", err)
+	}
+	return PatchSettings(patch)
+}
+
+// changedSettingsKeys 比较两份设置，返回变化的字段（按键名排序）
+func changedSettingsKeys(before, after *Settings) []string {
+	beforeFields := settingsRawFields(before)
+	afterFields := settingsRawFields(after)
+	var changed []string
+	for key, value := range afterFields {
+		if !bytes.Equal(beforeFields[key], value) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range beforeFields {
+		if _, ok := afterFields[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func settingsRawFields(s *Settings) map[string]json.RawMessage {
+	fields := make(map[string]json.RawMessage)
+	if data, err := json.Marshal(s); err == nil {
+		json.Unmarshal(data, &fields)
+	}
+	return fields
+}
+
+// SetSettingsChangedCallback 设置 settings.changed 回调（用于通知前端）
+func SetSettingsChangedCallback(callback func(SettingsChange)) {
+	settingsChangedMu.Lock()
+	defer settingsChangedMu.Unlock()
+	settingsChangedCallback = callback
+}
+
+// notifySettingsChanged 通知设置变化
+func notifySettingsChanged(change SettingsChange) {
+	settingsChangedMu.Lock()
+	callback := settingsChangedCallback
+	settingsChangedMu.Unlock()
+	if callback != nil {
+		callback(change)
+	}
+}
+
+// initSettings 首次启动时写入默认设置，老版本的设置升级后写回
+func initSettings() error {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+
+	settingsJSON, err := GetSetting(settingsKey)
+	if err != nil {
+		return err
+	}
+	settings, upgraded, err := parseSettings(settingsJSON)
+	if err != nil {
+		return err
+	}
+	if settingsJSON != "" && !upgraded {
+		return nil
+	}
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("序列化设置失败: %v", err)
+	}
+	if err := SaveSetting(settingsKey, string(data)); err != nil {
+		return err
+	}
+	if settingsJSON == "" {
+		log.Printf("✅ 已初始化默认设置")
+	}
+	return nil
+}
+
+// upgradeSettingsDropEmptyPassword v1: 老版本默认设置中的空 password 字段（密码已改由 credential 保存）
+func upgradeSettingsDropEmptyPassword(raw map[string]json.RawMessage) error {
+	if value, ok := raw["password"]; ok {
+		var password string
+		if json.Unmarshal(value, &password) == nil && password == "" {
+			delete(raw, "password")
+		}
+	}
+	return nil
+}
+
+// upgradeSettingsScriptHTTPLoopback v2: 脚本 HTTP 服务的默认监听地址从 0.0.0.0 改为 127.0.0.1
+// 老版本会把默认值写入设置，无法区分是否是用户选择的，统一改为只允许本机访问，需要局域网访问时在设置中重新开启
+func upgradeSettingsScriptHTTPLoopback(raw map[string]json.RawMessage) error {
+	var bind string
+	if value, ok := raw["scriptHTTPBind"]; ok && json.Unmarshal(value, &bind) == nil && bind == "0.0.0.0" {
+		raw["scriptHTTPBind"] = json.RawMessage(`"127.0.0.1"`)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package common
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+// 设置订阅：后台模块按设置字段订阅变化，设置修改时自动应用，不需要前端再调用单独的接口或重启
+// 订阅者在设置提交前按订阅顺序调用，任何一个返回错误时本次修改不保存，
+// 已经应用的订阅者和失败的订阅者（可能已应用了一部分）都会收到反向的变化（Settings 与 Previous 互换）以恢复原状态
+// 订阅者调用时持有 settingsMu 且设置的写事务尚未提交：只能使用 change.Settings，
+// 调用 LoadSettings 会等待事务（SQLite 写锁），调用 UpdateSettings 会等待 settingsMu，两者都会死锁
+
+// SettingsApplyFunc 应用设置变化，change.Keys 只包含订阅的字段
+type SettingsApplyFunc func(change SettingsChange) error
+
+// SettingsApplyError 订阅者应用设置失败
+type SettingsApplyError struct {
+	Subscriber string   `json:"subscriber"`
+	Keys       []string `json:"keys"`
+	Err        error    `json:"-"`
+}
+
+func (e *SettingsApplyError) Error() string {
+	return fmt.Sprintf("应用设置 %s 失败 (%s): %v", strings.Join(e.Keys, ", "), e.Subscriber, e.Err)
+}
+
+func (e *SettingsApplyError) Unwrap() error {
+	return e.Err
+}
+
+type settingsSubscriber struct {
+	id    int
+	name  string
+	keys  map[string]bool
+	apply SettingsApplyFunc
+}
+
+// appliedSettingsChange 已经应用的订阅者及其收到的变化（用于回滚）
+type appliedSettingsChange struct {
+	subscriber *settingsSubscriber
+	change     SettingsChange
+}
+
+var (
+	settingsSubscribersMu sync.RWMutex
+	settingsSubscribers   []*settingsSubscriber
+	nextSettingsSubID     int
+)
+
+// SubscribeSettings 订阅设置字段（JSON 键名）的变化，返回取消订阅的函数
+// apply 在 UpdateSettings 持有设置锁和写事务时同步调用，不能再读取或修改设置；
+// 返回错误后还会收到一次恢复旧设置的调用，需要能处理只应用了一部分的状态
+func SubscribeSettings(name string, keys []string, apply SettingsApplyFunc) func() {
+	subscriber := &settingsSubscriber{name: name, keys: make(map[string]bool, len(keys)), apply: apply}
+	for _, key := range keys {
+		subscriber.keys[key] = true
+	}
+
+	settingsSubscribersMu.Lock()
+	nextSettingsSubID++
+	subscriber.id = nextSettingsSubID
+	settingsSubscribers = append(settingsSubscribers, subscriber)
+	settingsSubscribersMu.Unlock()
+
+	return func() {
+		settingsSubscribersMu.Lock()
+		defer settingsSubscribersMu.Unlock()
+		for i, s := range settingsSubscribers {
+			if s.id == subscriber.id {
+				settingsSubscribers = append(settingsSubscribers[:i:i], settingsSubscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// applySettingsChange 按订阅顺序应用设置变化，失败时回滚失败的订阅者和已应用的订阅者
+func applySettingsChange(change SettingsChange) ([]appliedSettingsChange, error) {
+	settingsSubscribersMu.RLock()
+	subscribers := append([]*settingsSubscriber(nil), settingsSubscribers...)
+	settingsSubscribersMu.RUnlock()
+
+	var applied []appliedSettingsChange
+	for _, subscriber := range subscribers {
+		var keys []string
+		for _, key := range change.Keys {
+			if subscriber.keys[key] {
+				keys = append(keys, key)
+			}
+		}
+		if len(keys) == 0 {
+			continue
+		}
+
+		subChange := SettingsChange{Keys: keys, Settings: change.Settings, previous: change.previous}
+		if err := callSettingsSubscriber(subscriber, subChange); err != nil {
+			// 失败的订阅者可能已经应用了一部分，先恢复它，再按相反顺序恢复之前的订阅者
+			rollbackSettingsChange(append(applied, appliedSettingsChange{subscriber: subscriber, change: subChange}))
+			return nil, &SettingsApplyError{Subscriber: subscriber.name, Keys: keys, Err: err}
+		}
+		applied = append(applied, appliedSettingsChange{subscriber: subscriber, change: subChange})
+	}
+	return applied, nil
+}
+
+// rollbackSettingsChange 按相反顺序恢复已应用的订阅者
+func rollbackSettingsChange(applied []appliedSettingsChange) {
+	for i := len(applied) - 1; i >= 0; i-- {
+		subscriber := applied[i].subscriber
+		change := applied[i].change
+		revert := SettingsChange{Keys: change.Keys, Settings: change.previous, previous: change.Settings}
+		if err := callSettingsSubscriber(subscriber, revert); err != nil {
+			log.Printf("⚠️ 回滚设置 %s 失败 (%s): %v", strings.Join(change.Keys, ", "), subscriber.name, err)
+		}
+	}
+}
+
+// callSettingsSubscriber 调用订阅者，panic 视为失败
+func callSettingsSubscriber(subscriber *settingsSubscriber, change SettingsChange) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("崩溃: %v", r)
+		}
+	}()
+	return subscriber.apply(change)
+}
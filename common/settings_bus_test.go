@@ -0,0 +1,99 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestApplySettingsChangeRollback(t *testing.T) {
+	// 只使用测试专用的字段名，不会触发其他模块注册的订阅者
+	tests := []struct {
+		name      string
+		failing   string // 返回错误的订阅者，空表示全部成功
+		panicking string // 崩溃的订阅者
+		wantCalls []string
+		wantErr   string
+	}{
+		{name: "全部成功", wantCalls: []string{"a:new", "b:new", "c:new"}},
+		{name: "第一个失败时恢复自己", failing: "a",
+			wantCalls: []string{"a:new", "a:old"}, wantErr: "a"},
+		{name: "中间失败时按相反顺序恢复", failing: "b",
+			wantCalls: []string{"a:new", "b:new", "b:old", "a:old"}, wantErr: "b"},
+		{name: "最后一个失败", failing: "c",
+			wantCalls: []string{"a:new", "b:new", "c:new", "c:old", "b:old", "a:old"}, wantErr: "c"},
+		{name: "崩溃视为失败", panicking: "b",
+			wantCalls: []string{"a:new", "b:new", "b:old", "a:old"}, wantErr: "b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			for _, name := range []string{"a", "b", "c"} {
+				name := name
+				unsubscribe := SubscribeSettings(name, []string{"testBus" + strings.ToUpper(name)}, func(change SettingsChange) error {
+					state := "new"
+					if change.Settings.Language == "old" {
+						state = "old"
+					}
+					calls = append(calls, name+":"+state)
+					if state == "new" && name == tt.failing {
+						return fmt.Errorf("apply failed")
+					}
+					if state == "new" && name == tt.panicking {
+						panic("boom")
+					}
+					return nil
+				})
+				t.Cleanup(unsubscribe)
+			}
+
+			change := SettingsChange{
+				Keys:     []string{"testBusA", "testBusB", "testBusC"},
+				Settings: &Settings{Language: "new"},
+				previous: &Settings{Language: "old"},
+			}
+			applied, err := applySettingsChange(change)
+			if strings.Join(calls, ",") != strings.Join(tt.wantCalls, ",") {
+				t.Errorf("调用顺序 = %v，期望 %v", calls, tt.wantCalls)
+			}
+			if tt.wantErr == "" {
+				if err != nil || len(applied) != 3 {
+					t.Fatalf("applied = %d, err = %v，期望 3 个订阅者全部应用", len(applied), err)
+				}
+				return
+			}
+			var applyErr *SettingsApplyError
+			if !errors.As(err, &applyErr) || applyErr.Subscriber != tt.wantErr {
+				t.Fatalf("err = %v，期望订阅者 %s 的 *SettingsApplyError", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplySettingsChangeFiltersKeys(t *testing.T) {
+	var got []string
+	unsubscribe := SubscribeSettings("filter", []string{"testBusX", "testBusY"}, func(change SettingsChange) error {
+		got = change.Keys
+		return nil
+	})
+	defer unsubscribe()
+
+	tests := []struct {
+		keys []string
+		want []string
+	}{
+		{keys: []string{"testBusOther"}, want: nil},
+		{keys: []string{"testBusY", "testBusOther"}, want: []string{"testBusY"}},
+		{keys: []string{"testBusX", "testBusY"}, want: []string{"testBusX", "testBusY"}},
+	}
+	for _, tt := range tests {
+		got = nil
+		if _, err := applySettingsChange(SettingsChange{Keys: tt.keys, Settings: DefaultSettings(), previous: DefaultSettings()}); err != nil {
+			t.Fatal(err)
+		}
+		if !equalStrings(got, tt.want) {
+			t.Errorf("变化 %v: 订阅者收到 %v，期望 %v", tt.keys, got, tt.want)
+		}
+	}
+}
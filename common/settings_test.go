@@ -0,0 +1,171 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestSettingsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(s *Settings)
+		wantKey string // 为空表示校验通过
+	}{
+		{name: "默认设置", modify: func(s *Settings) {}},
+		{name: "保留天数上限", modify: func(s *Settings) { s.RetentionDays = maxRetentionDays }},
+		{name: "保留天数超出", modify: func(s *Settings) { s.RetentionDays = maxRetentionDays + 1 }, wantKey: "retentionDays"},
+		{name: "最大条数为负", modify: func(s *Settings) { s.RetentionMaxItems = -1 }, wantKey: "retentionMaxItems"},
+		{name: "存储上限为负", modify: func(s *Settings) { s.RetentionMaxStorageMB = -5 }, wantKey: "retentionMaxStorageMB"},
+		{name: "类型数量为负", modify: func(s *Settings) { s.RetentionTypeLimits = map[string]int{"Image": -1} }, wantKey: "retentionTypeLimits"},
+		{name: "每页数量太小", modify: func(s *Settings) { s.PageSize = minPageSize - 1 }, wantKey: "pageSize"},
+		{name: "每页数量太大", modify: func(s *Settings) { s.PageSize = maxPageSize + 1 }, wantKey: "pageSize"},
+		{name: "空快捷键使用默认值", modify: func(s *Settings) { s.Hotkey = "" }},
+		{name: "无法解析的快捷键", modify: func(s *Settings) { s.Hotkey = "Hyper+K" }, wantKey: "hotkey"},
+		{name: "绑定与显示窗口冲突", modify: func(s *Settings) {
+			s.Hotkeys = []HotkeyBinding{{ID: "fav", Action: HotkeyActionOpenFavorites, Hotkey: "cmd+alt+c", Enabled: true}}
+		}, wantKey: "hotkeys"},
+		{name: "未知语言", modify: func(s *Settings) { s.Language = "xx-XX" }, wantKey: "language"},
+		{name: "支持的语言", modify: func(s *Settings) { s.Language = LangArabic }},
+		{name: "自动锁定超出", modify: func(s *Settings) { s.AutoLockMinutes = maxExpireMinutes + 1 }, wantKey: "autoLockMinutes"},
+		{name: "无效的敏感动作", modify: func(s *Settings) { s.SensitiveActions = map[string]string{"jwt": "shred"} }, wantKey: "sensitiveActions"},
+		{name: "敏感过期时间为 0", modify: func(s *Settings) { s.SensitiveExpireMinutes = 0 }, wantKey: "sensitiveExpireMinutes"},
+		{name: "端口低于 1024", modify: func(s *Settings) { s.ScriptHTTPPort = 80 }, wantKey: "scriptHTTPPort"},
+		{name: "监听地址为主机名", modify: func(s *Settings) { s.ScriptHTTPBind = "example.com" }, wantKey: "scriptHTTPBind"},
+		{name: "监听地址为 localhost", modify: func(s *Settings) { s.ScriptHTTPBind = "localhost" }},
+		{name: "监听地址为 IPv6", modify: func(s *Settings) { s.ScriptHTTPBind = "::1" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := DefaultSettings()
+			tt.modify(s)
+			err := s.Validate()
+			if tt.wantKey == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v，期望通过", err)
+				}
+				return
+			}
+			var validationErr *SettingsValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Validate() = %v，期望 %s 的 *SettingsValidationError", err, tt.wantKey)
+			}
+			if validationErr.Key != tt.wantKey {
+				t.Errorf("无效字段 = %s，期望 %s", validationErr.Key, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestSettingsUpgradesOrdered(t *testing.T) {
+	for i, upgrade := range settingsUpgrades {
+		if upgrade.Version != i+1 {
+			t.Errorf("settingsUpgrades[%d].Version = %d，期望 %d（版本从 1 开始连续递增）", i, upgrade.Version, i+1)
+		}
+		if upgrade.Name == "" || upgrade.Upgrade == nil {
+			t.Errorf("settingsUpgrades[%d] 缺少名称或升级函数", i)
+		}
+	}
+}
+
+func TestParseSettings(t *testing.T) {
+	defaults := DefaultSettings()
+	tests := []struct {
+		name         string
+		json         string
+		wantUpgraded bool
+		wantVersion  int
+		check        func(t *testing.T, s *Settings)
+	}{
+		{name: "空设置使用默认值", json: "", wantVersion: currentSettingsVersion(), check: func(t *testing.T, s *Settings) {
+			if s.PageSize != defaults.PageSize || s.ScriptHTTPBind != defaults.ScriptHTTPBind {
+				t.Errorf("设置 = %+v，期望默认值", s)
+			}
+		}},
+		{name: "老版本删除空密码", json: `{"pageSize":20,"password":""}`, wantUpgraded: true, wantVersion: currentSettingsVersion(),
+			check: func(t *testing.T, s *Settings) {
+				if _, ok := s.extra["password"]; ok {
+					t.Error("空的 password 字段应该被删除")
+				}
+				if s.PageSize != 20 {
+					t.Errorf("PageSize = %d，期望 20", s.PageSize)
+				}
+			}},
+		{name: "老版本保留非空密码", json: `{"password":"abc"}`, wantUpgraded: true, wantVersion: currentSettingsVersion(),
+			check: func(t *testing.T, s *Settings) {
+				if s.legacyPassword() != "abc" {
+					t.Errorf("legacyPassword = %q，期望 abc", s.legacyPassword())
+				}
+			}},
+		{name: "只执行更新的升级", json: `{"version":1,"password":"","scriptHTTPBind":"0.0.0.0"}`, wantUpgraded: true, wantVersion: 2,
+			check: func(t *testing.T, s *Settings) {
+				if _, ok := s.extra["password"]; !ok {
+					t.Error("已是 v1 的设置不应该再执行 v1 升级")
+				}
+				if s.ScriptHTTPBind != "127.0.0.1" {
+					t.Errorf("ScriptHTTPBind = %s，期望升级为 127.0.0.1", s.ScriptHTTPBind)
+				}
+			}},
+		{name: "升级保留自定义的监听地址", json: `{"version":1,"scriptHTTPBind":"192.168.1.5"}`, wantUpgraded: true, wantVersion: 2,
+			check: func(t *testing.T, s *Settings) {
+				if s.ScriptHTTPBind != "192.168.1.5" {
+					t.Errorf("ScriptHTTPBind = %s，期望保持 192.168.1.5", s.ScriptHTTPBind)
+				}
+			}},
+		{name: "升级后重新开启局域网访问", json: `{"version":2,"scriptHTTPBind":"0.0.0.0"}`, wantVersion: 2,
+			check: func(t *testing.T, s *Settings) {
+				if s.ScriptHTTPBind != "0.0.0.0" {
+					t.Errorf("ScriptHTTPBind = %s，期望保持 0.0.0.0", s.ScriptHTTPBind)
+				}
+			}},
+		{name: "更新版本的设置保留版本号", json: `{"version":99,"futureOption":true}`, wantVersion: 99, check: func(t *testing.T, s *Settings) {
+			if _, ok := s.extra["futureOption"]; !ok {
+				t.Error("不认识的字段应该保留")
+			}
+		}},
+		{name: "无效值恢复默认", json: `{"version":2,"pageSize":5,"retentionDays":-1,"language":"xx"}`, wantVersion: 2,
+			check: func(t *testing.T, s *Settings) {
+				if s.PageSize != defaults.PageSize || s.RetentionDays != defaults.RetentionDays || s.Language != defaults.Language {
+					t.Errorf("无效字段没有恢复默认值: pageSize=%d retentionDays=%d language=%q", s.PageSize, s.RetentionDays, s.Language)
+				}
+			}},
+		{name: "类型错误的字段不影响其他字段", json: `{"version":2,"pageSize":"many","autoClean":false}`, wantVersion: 2,
+			check: func(t *testing.T, s *Settings) {
+				if s.PageSize != defaults.PageSize || s.AutoClean {
+					t.Errorf("pageSize=%d autoClean=%v，期望默认 pageSize 且 autoClean=false", s.PageSize, s.AutoClean)
+				}
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, upgraded, err := parseSettings(tt.json)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if upgraded != tt.wantUpgraded {
+				t.Errorf("upgraded = %v，期望 %v", upgraded, tt.wantUpgraded)
+			}
+			if s.Version != tt.wantVersion {
+				t.Errorf("Version = %d，期望 %d", s.Version, tt.wantVersion)
+			}
+			tt.check(t, s)
+
+			// 再次序列化和解析后结果不变，不会重复升级
+			data, err := json.Marshal(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			again, upgradedAgain, err := parseSettings(string(data))
+			if err != nil || upgradedAgain {
+				t.Fatalf("重新解析: upgraded=%v, err=%v", upgradedAgain, err)
+			}
+			if keys := changedSettingsKeys(s, again); len(keys) > 0 {
+				t.Errorf("重新解析后字段变化: %v", keys)
+			}
+		})
+	}
+
+	if _, _, err := parseSettings("{not json"); err == nil {
+		t.Error("无法解析的 JSON 应该返回错误")
+	}
+}
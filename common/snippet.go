@@ -0,0 +1,193 @@
+package common
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// SnippetContentType 片段类型：内容中可以包含占位符，复制/粘贴时展开
+const SnippetContentType = "Snippet"
+
+// 支持的占位符：
+//
+//	{{date}} / {{date:2006-01-02}}  当前日期（Go 时间格式）
+//	{{time}} / {{time:15:04}}       当前时间（Go 时间格式）
+//	{{uuid}}                        随机 UUID
+//	{{clipboard}}                   历史记录中最新的文本项目
+//	{{input:Name}}                  由用户输入的值
+//	{{cursor}}                      粘贴后光标停留的位置
+//
+// 占位符用正则逐个替换，而不是 text/template：片段内容由用户输入或从剪贴板保存，
+// 模板引擎会执行其中的任意管道和函数调用（{{clipboard}} 的值也可能包含 {{...}}），
+// 并且内容中出现不完整的 {{ 时整个片段都会解析失败。正则替换只认识上面这些占位符，其余文本原样保留
+var snippetPlaceholderPattern = regexp.MustCompile(`\{\{\s*([a-zA-Z]+)\s*(?::([^{}]*))?\}\}`)
+
+// SnippetExpansion 片段展开结果
+type SnippetExpansion struct {
+	Text          string
+	CursorOffset  int      // 粘贴后需要把光标向左移动的字符数（没有 {{cursor}} 时为 0）
+	Inputs        []string // 片段需要的输入项（按出现顺序去重）
+	MissingInputs []string // 未提供值的输入项（预览中保留占位符原文）
+}
+
+// snippetInputNames 提取片段中的输入项名称
+func snippetInputNames(content string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, m := range snippetPlaceholderPattern.FindAllStringSubmatch(content, -1) {
+		if strings.ToLower(m[1]) != "input" {
+			continue
+		}
+		name := strings.TrimSpace(m[2])
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// latestClipboardText 获取历史记录中最新的文本项目（片段、图片、文件除外）
+func latestClipboardText() string {
+	if DB == nil {
+		return ""
+	}
+	var content string
+	err := DB.QueryRow(`SELECT content FROM clipboard_items
+	WHERE content_type NOT IN ('Image', 'File', ?)
+	ORDER BY timestamp DESC LIMIT 1`, SnippetContentType).Scan(&content)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("⚠️ 查询最新剪贴板文本失败: %v", err)
+	}
+	if content, err = decryptText(content); err != nil {
+		log.Printf("⚠️ 解密最新剪贴板文本失败: %v", err)
+		return ""
+	}
+	return content
+}
+
+// ExpandSnippet 展开片段内容中的占位符
+// inputs 为 {{input:Name}} 的值；未知的占位符原样保留
+func ExpandSnippet(content string, inputs map[string]string) *SnippetExpansion {
+	now := time.Now()
+	result := &SnippetExpansion{Inputs: snippetInputNames(content)}
+
+	const cursorMarker = "\x00cursor\x00"
+	hasCursor := false
+	var clipboardText *string
+
+	text := snippetPlaceholderPattern.ReplaceAllStringFunc(content, func(placeholder string) string {
+		m := snippetPlaceholderPattern.FindStringSubmatch(placeholder)
+		name, arg := strings.ToLower(m[1]), strings.TrimSpace(m[2])
+		switch name {
+		case "date":
+			if arg == "" {
+				arg = "2006-01-02"
+			}
+			return now.Format(arg)
+		case "time":
+			if arg == "" {
+				arg = "15:04:05"
+			}
+			return now.Format(arg)
+		case "uuid":
+			return uuid.NewString()
+		case "clipboard":
+			if clipboardText == nil {
+				text := latestClipboardText()
+				clipboardText = &text
+			}
+			return *clipboardText
+		case "input":
+			if value, ok := inputs[arg]; ok {
+				return value
+			}
+			for _, missing := range result.MissingInputs {
+				if missing == arg {
+					return placeholder
+				}
+			}
+			result.MissingInputs = append(result.MissingInputs, arg)
+			return placeholder
+		case "cursor":
+			// 只有第一个 {{cursor}} 生效
+			if hasCursor {
+				return ""
+			}
+			hasCursor = true
+			return cursorMarker
+		default:
+			return placeholder
+		}
+	})
+
+	if hasCursor {
+		idx := strings.Index(text, cursorMarker)
+		result.CursorOffset = utf8.RuneCountInString(text[idx+len(cursorMarker):])
+		text = text[:idx] + text[idx+len(cursorMarker):]
+	}
+	result.Text = text
+	return result
+}
+
+// ExpandSnippetForCopy 展开片段用于复制，缺少输入值时返回错误
+func ExpandSnippetForCopy(content string, inputs map[string]string) (*SnippetExpansion, error) {
+	result := ExpandSnippet(content, inputs)
+	if len(result.MissingInputs) > 0 {
+		return nil, fmt.Errorf("片段缺少输入: %s", strings.Join(result.MissingInputs, ", "))
+	}
+	return result, nil
+}
+
+// SaveSnippet 保存片段（id 为空时新建），片段默认收藏，不会被自动清理
+func SaveSnippet(id string, content string) (string, error) {
+	if DB == nil {
+		return "", fmt.Errorf("数据库未初始化")
+	}
+	if strings.TrimSpace(content) == "" {
+		return "", fmt.Errorf("片段内容不能为空")
+	}
+	contentHash := calculateContentHash(&ClipboardItem{Content: content, ContentType: SnippetContentType})
+
+	if id != "" {
+		storedContent, err := encryptText(content)
+		if err != nil {
+			return "", err
+		}
+		result, err := DB.Exec(`UPDATE clipboard_items SET content = ?, content_hash = ?, char_count = ?, word_count = ?
+		WHERE id = ? AND content_type = ?`,
+			storedContent, contentHash, utf8.RuneCountInString(content), countWords(content), id, SnippetContentType)
+		if err != nil {
+			return "", fmt.Errorf("更新片段失败: %v", err)
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+			return "", fmt.Errorf("未找到片段")
+		}
+		return id, nil
+	}
+
+	item := ClipboardItem{
+		ID:          fmt.Sprintf("%d", time.Now().UnixNano()),
+		Content:     content,
+		ContentType: SnippetContentType,
+		ContentHash: contentHash,
+		Timestamp:   time.Now(),
+		Source:      "ClipSave",
+		CharCount:   utf8.RuneCountInString(content),
+		WordCount:   countWords(content),
+	}
+	if err := SaveClipboardItem(&item); err != nil {
+		return "", err
+	}
+	if _, err := DB.Exec(`UPDATE clipboard_items SET is_favorite = 1 WHERE id = ?`, item.ID); err != nil {
+		return "", fmt.Errorf("收藏片段失败: %v", err)
+	}
+	return item.ID, nil
+}
@@ -0,0 +1,85 @@
+package common
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestExpandSnippet(t *testing.T) {
+	today := time.Now().Format("2006-01-02")
+	tests := []struct {
+		name         string
+		content      string
+		inputs       map[string]string
+		want         string
+		wantCursor   int
+		wantMissing  []string
+		wantInputIDs []string
+	}{
+		{name: "没有占位符", content: "plain text", want: "plain text"},
+		{name: "默认日期格式", content: "on {{date}}", want: "on " + today},
+		{name: "自定义日期格式", content: "{{ date:2006 }}", want: time.Now().Format("2006")},
+		{name: "占位符名称不区分大小写", content: "{{DATE}}", want: today},
+		{name: "输入", content: "Hi {{input:Name}}, {{input:Name}}!", inputs: map[string]string{"Name": "Ann"},
+			want: "Hi Ann, Ann!", wantInputIDs: []string{"Name"}},
+		{name: "缺少输入时保留原文且只报告一次", content: "{{input:A}} {{input:B}} {{input:A}}", inputs: map[string]string{"B": "b"},
+			want: "{{input:A}} b {{input:A}}", wantMissing: []string{"A"}, wantInputIDs: []string{"A", "B"}},
+		{name: "光标位置按字符计算", content: "<p>{{cursor}}</p>中文", want: "<p></p>中文", wantCursor: 6},
+		{name: "只有第一个光标生效", content: "a{{cursor}}b{{cursor}}c", want: "abc", wantCursor: 2},
+		{name: "未知占位符原样保留", content: "{{unknown}} {{ if .X }}", want: "{{unknown}} {{ if .X }}"},
+		{name: "不完整的括号", content: "{{date", want: "{{date"},
+		{name: "输入值中的占位符不再展开", content: "{{input:X}}", inputs: map[string]string{"X": "{{date}}"},
+			want: "{{date}}", wantInputIDs: []string{"X"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExpandSnippet(tt.content, tt.inputs)
+			if got.Text != tt.want {
+				t.Errorf("Text = %q，期望 %q", got.Text, tt.want)
+			}
+			if got.CursorOffset != tt.wantCursor {
+				t.Errorf("CursorOffset = %d，期望 %d", got.CursorOffset, tt.wantCursor)
+			}
+			if !equalStrings(got.MissingInputs, tt.wantMissing) {
+				t.Errorf("MissingInputs = %v，期望 %v", got.MissingInputs, tt.wantMissing)
+			}
+			if !equalStrings(got.Inputs, tt.wantInputIDs) {
+				t.Errorf("Inputs = %v，期望 %v", got.Inputs, tt.wantInputIDs)
+			}
+		})
+	}
+}
+
+func TestExpandSnippetUUID(t *testing.T) {
+	got := ExpandSnippet("{{uuid}} {{uuid}}", nil).Text
+	uuidPattern := regexp.MustCompile(`^[0-9a-f-]{36} [0-9a-f-]{36}$`)
+	if !uuidPattern.MatchString(got) {
+		t.Fatalf("展开结果 %q 不是两个 UUID", got)
+	}
+	if got[:36] == got[37:] {
+		t.Fatal("每个 {{uuid}} 应该生成不同的值")
+	}
+}
+
+func TestExpandSnippetForCopy(t *testing.T) {
+	if _, err := ExpandSnippetForCopy("{{input:Name}}", nil); err == nil {
+		t.Fatal("缺少输入时应该返回错误")
+	}
+	got, err := ExpandSnippetForCopy("{{input:Name}}", map[string]string{"Name": "x"})
+	if err != nil || got.Text != "x" {
+		t.Fatalf("ExpandSnippetForCopy = %+v, %v", got, err)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
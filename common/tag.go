@@ -0,0 +1,391 @@
+package common
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Tag 标签（与剪贴板项目多对多关联）
+type Tag struct {
+	ID        string
+	Name      string
+	Color     string // #RRGGBB
+	Protected bool   // 带有受保护标签的项目不会被自动清理或"清空全部"删除
+	ItemCount int    // 关联的项目数量
+	CreatedAt time.Time
+}
+
+// DefaultTagColor 未指定颜色时使用的标签颜色
+const DefaultTagColor = "#909399"
+
+var tagColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// protectedItemsClause 排除受保护项目（收藏或带有受保护标签）的条件，用于批量清理
+const protectedItemsClause = `is_favorite != 1 AND id NOT IN (
+	SELECT it.item_id FROM item_tags it JOIN tags t ON t.id = it.tag_id WHERE t.protected = 1
+)`
+
+// tagFilterClause 按标签过滤项目的条件
+const tagFilterClause = `id IN (SELECT item_id FROM item_tags WHERE tag_id = ?)`
+
+// normalizeTagName 清理标签名称
+func normalizeTagName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("标签名称不能为空")
+	}
+	if len([]rune(name)) > 32 {
+		return "", fmt.Errorf("标签名称不能超过 32 个字符")
+	}
+	return name, nil
+}
+
+// normalizeTagColor 校验标签颜色，为空时使用默认颜色
+func normalizeTagColor(color string) (string, error) {
+	color = strings.TrimSpace(color)
+	if color == "" {
+		return DefaultTagColor, nil
+	}
+	if !tagColorPattern.MatchString(color) {
+		return "", fmt.Errorf("无效的标签颜色: %s（格式应为 #RRGGBB）", color)
+	}
+	return strings.ToUpper(color), nil
+}
+
+// GetAllTags 获取所有标签（按名称排序）
+func GetAllTags() ([]Tag, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	rows, err := DB.Query(`
+	SELECT t.id, t.name, t.color, t.protected, COUNT(it.item_id), t.created_at
+	FROM tags t LEFT JOIN item_tags it ON it.tag_id = t.id
+	GROUP BY t.id
+	ORDER BY t.name COLLATE NOCASE ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("查询标签失败: %v", err)
+	}
+	defer rows.Close()
+
+	tags := []Tag{}
+	for rows.Next() {
+		var tag Tag
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Color, &tag.Protected, &tag.ItemCount, &tag.CreatedAt); err != nil {
+			log.Printf("扫描标签行失败: %v", err)
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// CreateTag 创建标签，同名标签（不区分大小写）已存在时返回错误
+func CreateTag(name string, color string) (*Tag, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	name, err := normalizeTagName(name)
+	if err != nil {
+		return nil, err
+	}
+	color, err = normalizeTagColor(color)
+	if err != nil {
+		return nil, err
+	}
+	if id, _ := findTagIDByName(name); id != "" {
+		return nil, fmt.Errorf("标签已存在: %s", name)
+	}
+
+	tag := &Tag{
+		ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+		Name:      name,
+		Color:     color,
+		CreatedAt: time.Now(),
+	}
+	_, err = DB.Exec(`INSERT INTO tags (id, name, color, protected, created_at) VALUES (?, ?, ?, 0, ?)`,
+		tag.ID, tag.Name, tag.Color, tag.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("创建标签失败: %v", err)
+	}
+	log.Printf("✅ 已创建标签: %s", tag.Name)
+	return tag, nil
+}
+
+// findTagIDByName 按名称查找标签 ID（不区分大小写），不存在时返回空字符串
+func findTagIDByName(name string) (string, error) {
+	var id string
+	err := DB.QueryRow(`SELECT id FROM tags WHERE name = ? COLLATE NOCASE`, name).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return id, err
+}
+
+// updateTagField 更新标签的单个字段
+func updateTagField(id string, column string, value interface{}) error {
+	result, err := DB.Exec(fmt.Sprintf(`UPDATE tags SET %s = ?, updated_at = datetime('now') WHERE id = ?`, column), value, id)
+	if err != nil {
+		return fmt.Errorf("更新标签失败: %v", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return fmt.Errorf("未找到标签")
+	}
+	return nil
+}
+
+// RenameTag 重命名标签
+func RenameTag(id string, name string) error {
+	if DB == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	name, err := normalizeTagName(name)
+	if err != nil {
+		return err
+	}
+	if existingID, _ := findTagIDByName(name); existingID != "" && existingID != id {
+		return fmt.Errorf("标签已存在: %s（如需合并请使用合并标签）", name)
+	}
+	return updateTagField(id, "name", name)
+}
+
+// SetTagColor 设置标签颜色
+func SetTagColor(id string, color string) error {
+	if DB == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	color, err := normalizeTagColor(color)
+	if err != nil {
+		return err
+	}
+	return updateTagField(id, "color", color)
+}
+
+// SetTagProtected 设置标签是否保护其项目不被清理
+func SetTagProtected(id string, protected bool) error {
+	if DB == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	value := 0
+	if protected {
+		value = 1
+	}
+	return updateTagField(id, "protected", value)
+}
+
+// MergeTags 把 sourceIDs 中的标签合并到 targetID：关联项目转移到目标标签，然后删除源标签
+// 任一源标签受保护时目标标签也会变为受保护，避免合并后项目失去保护
+func MergeTags(sourceIDs []string, targetID string) error {
+	if DB == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %v", err)
+	}
+	defer tx.Rollback()
+
+	var targetCount int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM tags WHERE id = ?`, targetID).Scan(&targetCount); err != nil || targetCount == 0 {
+		return fmt.Errorf("未找到目标标签")
+	}
+
+	merged := 0
+	for _, sourceID := range sourceIDs {
+		if sourceID == targetID {
+			continue
+		}
+		var protected int
+		err := tx.QueryRow(`SELECT protected FROM tags WHERE id = ?`, sourceID).Scan(&protected)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("未找到要合并的标签: %s", sourceID)
+		}
+		if err != nil {
+			return fmt.Errorf("查询标签失败: %v", err)
+		}
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO item_tags (item_id, tag_id) SELECT item_id, ? FROM item_tags WHERE tag_id = ?`, targetID, sourceID); err != nil {
+			return fmt.Errorf("转移标签关联失败: %v", err)
+		}
+		if protected == 1 {
+			if _, err := tx.Exec(`UPDATE tags SET protected = 1, updated_at = datetime('now') WHERE id = ?`, targetID); err != nil {
+				return fmt.Errorf("更新标签失败: %v", err)
+			}
+		}
+		if _, err := tx.Exec(`DELETE FROM tags WHERE id = ?`, sourceID); err != nil {
+			return fmt.Errorf("删除标签失败: %v", err)
+		}
+		merged++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("合并标签失败: %v", err)
+	}
+	log.Printf("✅ 已将 %d 个标签合并到 %s", merged, targetID)
+	return nil
+}
+
+// DeleteTag 删除标签（项目本身不会被删除）
+func DeleteTag(id string) error {
+	if DB == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	result, err := DB.Exec(`DELETE FROM tags WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("删除标签失败: %v", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return fmt.Errorf("未找到要删除的标签")
+	}
+	log.Printf("✅ 已删除标签: %s", id)
+	return nil
+}
+
+// AddTagsToItems 批量为项目添加标签（已有的关联会被忽略）
+func AddTagsToItems(itemIDs []string, tagIDs []string) error {
+	return updateItemTags(itemIDs, tagIDs,
+		`INSERT OR IGNORE INTO item_tags (item_id, tag_id)
+		SELECT c.id, t.id FROM clipboard_items c, tags t WHERE c.id = ? AND t.id = ?`)
+}
+
+// AddTagNamesToItem 按名称为项目添加标签，不存在的标签使用默认颜色创建（供脚本使用）
+func AddTagNamesToItem(itemID string, names []string) error {
+	if DB == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	tagIDs := make([]string, 0, len(names))
+	for _, name := range names {
+		name, err := normalizeTagName(name)
+		if err != nil {
+			return err
+		}
+		id, err := findTagIDByName(name)
+		if err != nil {
+			return fmt.Errorf("查询标签失败: %v", err)
+		}
+		if id == "" {
+			tag, err := CreateTag(name, "")
+			if err != nil {
+				return err
+			}
+			id = tag.ID
+		}
+		tagIDs = append(tagIDs, id)
+	}
+	return AddTagsToItems([]string{itemID}, tagIDs)
+}
+
+// RemoveTagsFromItems 批量移除项目的标签
+func RemoveTagsFromItems(itemIDs []string, tagIDs []string) error {
+	return updateItemTags(itemIDs, tagIDs, `DELETE FROM item_tags WHERE item_id = ? AND tag_id = ?`)
+}
+
+// updateItemTags 在一个事务中对每个 (项目, 标签) 组合执行 stmtSQL
+func updateItemTags(itemIDs []string, tagIDs []string, stmtSQL string) error {
+	if DB == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	if len(itemIDs) == 0 || len(tagIDs) == 0 {
+		return nil
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %v", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(stmtSQL)
+	if err != nil {
+		return fmt.Errorf("更新项目标签失败: %v", err)
+	}
+	defer stmt.Close()
+
+	for _, itemID := range itemIDs {
+		for _, tagID := range tagIDs {
+			if _, err := stmt.Exec(itemID, tagID); err != nil {
+				return fmt.Errorf("更新项目标签失败: %v", err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("更新项目标签失败: %v", err)
+	}
+	return nil
+}
+
+// GetTagsForItems 批量获取项目的标签，返回 项目 ID -> 标签列表
+func GetTagsForItems(itemIDs []string) (map[string][]Tag, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	result := make(map[string][]Tag, len(itemIDs))
+	if len(itemIDs) == 0 {
+		return result, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(itemIDs)), ",")
+	args := make([]interface{}, len(itemIDs))
+	for i, id := range itemIDs {
+		args[i] = id
+	}
+	query := fmt.Sprintf(`
+	SELECT it.item_id, t.id, t.name, t.color, t.protected, t.created_at
+	FROM item_tags it JOIN tags t ON t.id = it.tag_id
+	WHERE it.item_id IN (%s)
+	ORDER BY t.name COLLATE NOCASE ASC`, placeholders)
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询项目标签失败: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var itemID string
+		var tag Tag
+		if err := rows.Scan(&itemID, &tag.ID, &tag.Name, &tag.Color, &tag.Protected, &tag.CreatedAt); err != nil {
+			log.Printf("扫描项目标签失败: %v", err)
+			continue
+		}
+		result[itemID] = append(result[itemID], tag)
+	}
+	return result, nil
+}
+
+// migrateCreateTags v9: 标签表和项目-标签关联表
+func migrateCreateTags(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+	CREATE TABLE IF NOT EXISTS tags (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		color TEXT NOT NULL DEFAULT '` + DefaultTagColor + `',
+		protected INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS item_tags (
+		item_id TEXT NOT NULL,
+		tag_id TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (item_id, tag_id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_item_tags_tag_id ON item_tags(tag_id);
+
+	-- 删除项目或标签时清理关联（数据库未开启外键约束）
+	CREATE TRIGGER IF NOT EXISTS item_tags_item_ad AFTER DELETE ON clipboard_items BEGIN
+		DELETE FROM item_tags WHERE item_id = OLD.id;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS item_tags_tag_ad AFTER DELETE ON tags BEGIN
+		DELETE FROM item_tags WHERE tag_id = OLD.id;
+	END;
+	`)
+	return err
+}
@@ -0,0 +1,170 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+// saveTaggedItem 保存一个指定时间的文本项目并加上标签
+func saveTaggedItem(t *testing.T, id string, age time.Duration, tagIDs ...string) {
+	t.Helper()
+	item := ClipboardItem{ID: id, Content: "item " + id, ContentType: "Text", Timestamp: time.Now().Add(-age)}
+	item.ContentHash = calculateContentHash(&item)
+	if err := SaveClipboardItem(&item); err != nil {
+		t.Fatal(err)
+	}
+	if len(tagIDs) > 0 {
+		if err := AddTagsToItems([]string{id}, tagIDs); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// tagsByName 按名称索引当前所有标签
+func tagsByName(t *testing.T) map[string]Tag {
+	t.Helper()
+	tags, err := GetAllTags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]Tag, len(tags))
+	for _, tag := range tags {
+		byName[tag.Name] = tag
+	}
+	return byName
+}
+
+func TestMergeTags(t *testing.T) {
+	tests := []struct {
+		name          string
+		sources       map[string]bool // 源标签名 → 是否受保护
+		targetProtect bool
+		wantProtected bool
+	}{
+		{name: "普通标签合并", sources: map[string]bool{"a": false, "b": false}},
+		{name: "源标签受保护", sources: map[string]bool{"a": true, "b": false}, wantProtected: true},
+		{name: "目标标签受保护", sources: map[string]bool{"a": false}, targetProtect: true, wantProtected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			openTestDB(t, 0)
+			target, err := CreateTag("target", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := SetTagProtected(target.ID, tt.targetProtect); err != nil {
+				t.Fatal(err)
+			}
+			// shared 同时带有目标标签和所有源标签，合并后只保留一条关联
+			saveTaggedItem(t, "shared", time.Hour, target.ID)
+			sourceIDs := []string{target.ID} // 目标标签出现在源列表中时跳过
+			for name, protected := range tt.sources {
+				tag, err := CreateTag(name, "")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := SetTagProtected(tag.ID, protected); err != nil {
+					t.Fatal(err)
+				}
+				saveTaggedItem(t, "only-"+name, time.Hour, tag.ID)
+				if err := AddTagsToItems([]string{"shared"}, []string{tag.ID}); err != nil {
+					t.Fatal(err)
+				}
+				sourceIDs = append(sourceIDs, tag.ID)
+			}
+
+			if err := MergeTags(sourceIDs, target.ID); err != nil {
+				t.Fatal(err)
+			}
+
+			tags := tagsByName(t)
+			if len(tags) != 1 {
+				t.Fatalf("合并后还有 %d 个标签，期望只剩目标标签", len(tags))
+			}
+			merged := tags["target"]
+			if merged.Protected != tt.wantProtected {
+				t.Errorf("Protected = %v，期望 %v", merged.Protected, tt.wantProtected)
+			}
+			if want := len(tt.sources) + 1; merged.ItemCount != want {
+				t.Errorf("ItemCount = %d，期望 %d", merged.ItemCount, want)
+			}
+			itemIDs := []string{"shared"}
+			for name := range tt.sources {
+				itemIDs = append(itemIDs, "only-"+name)
+			}
+			itemTags, err := GetTagsForItems(itemIDs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, id := range itemIDs {
+				if got := itemTags[id]; len(got) != 1 || got[0].ID != target.ID {
+					t.Errorf("%s 的标签 = %+v，期望只有目标标签", id, got)
+				}
+			}
+		})
+	}
+
+	t.Run("源标签不存在时不修改", func(t *testing.T) {
+		openTestDB(t, 0)
+		target, _ := CreateTag("target", "")
+		source, _ := CreateTag("source", "")
+		saveTaggedItem(t, "item", time.Hour, source.ID)
+		if err := MergeTags([]string{source.ID, "missing"}, target.ID); err == nil {
+			t.Fatal("合并不存在的标签应该返回错误")
+		}
+		if tags := tagsByName(t); len(tags) != 2 || tags["source"].ItemCount != 1 {
+			t.Errorf("失败后标签 = %+v，期望保持不变", tags)
+		}
+	})
+}
+
+func TestClearSkipsProtectedItems(t *testing.T) {
+	tests := []struct {
+		name    string
+		clear   func() error
+		wantIDs []string
+	}{
+		{name: "清除超过 7 天的项目", clear: func() error { return ClearItemsOlderThanDays(7) },
+			wantIDs: []string{"favorite", "protected", "merged", "plain-new"}},
+		{name: "清空全部", clear: ClearAllItems,
+			wantIDs: []string{"favorite", "protected", "merged"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			openTestDB(t, 0)
+			old := 30 * 24 * time.Hour
+			protected, _ := CreateTag("keep", "")
+			if err := SetTagProtected(protected.ID, true); err != nil {
+				t.Fatal(err)
+			}
+			unprotected, _ := CreateTag("work", "")
+			// 从受保护标签合并过来的项目同样受保护
+			formerlyProtected, _ := CreateTag("vault", "")
+			if err := SetTagProtected(formerlyProtected.ID, true); err != nil {
+				t.Fatal(err)
+			}
+			mergeTarget, _ := CreateTag("archive", "")
+
+			saveTaggedItem(t, "favorite", old)
+			if _, err := DB.Exec(`UPDATE clipboard_items SET is_favorite = 1 WHERE id = 'favorite'`); err != nil {
+				t.Fatal(err)
+			}
+			saveTaggedItem(t, "protected", old, protected.ID)
+			saveTaggedItem(t, "merged", old, formerlyProtected.ID)
+			saveTaggedItem(t, "tagged", old, unprotected.ID)
+			saveTaggedItem(t, "plain-old", old)
+			saveTaggedItem(t, "plain-new", time.Hour)
+			if err := MergeTags([]string{formerlyProtected.ID}, mergeTarget.ID); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tt.clear(); err != nil {
+				t.Fatal(err)
+			}
+			got := remainingItemIDs(t)
+			if !equalStrings(sortedStrings(got), sortedStrings(tt.wantIDs)) {
+				t.Errorf("剩余项目 = %v，期望 %v", got, tt.wantIDs)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package common
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// thumbnailMaxSize 缩略图最长边（像素），保持原图宽高比
+const thumbnailMaxSize = 240
+
+// thumbnailBackfillBatch 回填缩略图时每批处理的图片数
+const thumbnailBackfillBatch = 20
+
+// makeThumbnail 把图片缩放到 thumbnailMaxSize 以内并编码为 PNG（保留透明通道）
+func makeThumbnail(img image.Image) ([]byte, error) {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= 0 || h <= 0 {
+		return nil, fmt.Errorf("图片尺寸无效: %dx%d", w, h)
+	}
+
+	tw, th := w, h
+	if w > thumbnailMaxSize || h > thumbnailMaxSize {
+		if w >= h {
+			tw, th = thumbnailMaxSize, max(1, h*thumbnailMaxSize/w)
+		} else {
+			tw, th = max(1, w*thumbnailMaxSize/h), thumbnailMaxSize
+		}
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, tw, th))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, fmt.Errorf("编码缩略图失败: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// GetThumbnails 批量获取图片缩略图，返回 ID -> PNG 数据（没有缩略图的记录不在结果中）
+func GetThumbnails(ids []string) (map[string][]byte, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	result := make(map[string][]byte, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	query := fmt.Sprintf(`SELECT id, thumbnail FROM clipboard_items WHERE id IN (%s) AND length(thumbnail) > 0`, placeholders)
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询缩略图失败: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var thumb []byte
+		if err := rows.Scan(&id, &thumb); err != nil {
+			log.Printf("扫描缩略图失败: %v", err)
+			continue
+		}
+		if thumb, err = decryptBytes(thumb); err != nil {
+			return nil, err
+		}
+		result[id] = thumb
+	}
+	return result, nil
+}
+
+// backfillThumbnails 为没有缩略图的老图片生成缩略图（后台执行）
+// 无法生成的图片写入空值，避免每次启动重复尝试；加密存储锁定时跳过，解锁后再执行
+func backfillThumbnails() {
+	if DB == nil || checkStoreUnlocked() != nil {
+		return
+	}
+	total := 0
+	for {
+		rows, err := DB.Query(`
+		SELECT id, COALESCE(content_hash, ''), image_data FROM clipboard_items
+		WHERE content_type = 'Image' AND thumbnail IS NULL
+		LIMIT ?`, thumbnailBackfillBatch)
+		if err != nil {
+			log.Printf("⚠️ 查询待生成缩略图的图片失败: %v", err)
+			return
+		}
+		var batch []ClipboardItem
+		for rows.Next() {
+			var item ClipboardItem
+			item.ContentType = "Image"
+			if err := rows.Scan(&item.ID, &item.ContentHash, &item.ImageData); err != nil {
+				log.Printf("扫描图片失败: %v", err)
+				continue
+			}
+			batch = append(batch, item)
+		}
+		rows.Close()
+		if len(batch) == 0 {
+			break
+		}
+
+		for i := range batch {
+			item := &batch[i]
+			fillImageData(item)
+			thumb := []byte{}
+			if img, _, err := image.Decode(bytes.NewReader(item.ImageData)); err == nil {
+				if t, err := makeThumbnail(img); err == nil {
+					thumb = t
+				}
+			}
+			if len(thumb) == 0 {
+				log.Printf("⚠️ 无法为图片生成缩略图: ID=%s", item.ID)
+			}
+			if err := updateThumbnail(item.ID, thumb); err != nil {
+				log.Printf("⚠️ 保存缩略图失败: %v", err)
+				return
+			}
+		}
+		total += len(batch)
+	}
+
+	if total > 0 {
+		log.Printf("✅ 已为 %d 张图片生成缩略图", total)
+		notifyListeners()
+	}
+}
+
+// updateThumbnail 更新图片的缩略图
+func updateThumbnail(id string, thumb []byte) error {
+	thumb, err := encryptBytes(thumb)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(`UPDATE clipboard_items SET thumbnail = ? WHERE id = ?`, thumb, id)
+	if err != nil {
+		return fmt.Errorf("更新缩略图失败: %v", err)
+	}
+	return nil
+}
+
+// migrateAddThumbnail v8: 图片缩略图（列表渲染只读取缩略图，老图片由 backfillThumbnails 回填）
+func migrateAddThumbnail(tx *sql.Tx) error {
+	return addColumnIfMissing(tx, "clipboard_items", "thumbnail", "BLOB")
+}
@@ -1,11 +1,15 @@
 import { ref, computed } from 'vue';
 import { ElMessage } from 'element-plus';
+import { useI18n } from 'vue-i18n';
+import { ValidateHotkey } from '../../wailsjs/go/main/App';
 
 export interface HotkeySettings {
   hotkey: string;
 }
 
 export function useHotkey(settings: { value: HotkeySettings }) {
+  const { t } = useI18n();
+
   // 快捷键录制状态
   const isRecording = ref(false);
   
@@ -15,11 +19,18 @@ export function useHotkey(settings: { value: HotkeySettings }) {
   // 录制超时定时器
   let recordingTimeout: any = null;
 
+  // 两步组合键：已录制的第一组按键，等待第二组
+  let pendingStep = "";
+
+  // 等待组合键第二组按键的时间（与后端 hotkeyChordTimeout 一致）
+  const CHORD_TIMEOUT_MS = 1500;
+
   // 开始录制快捷键
   function startRecording() {
     recordingTimeout && clearTimeout(recordingTimeout);
     isRecording.value = true;
     currentRecordingHotkey.value = "";
+    pendingStep = "";
 
     // 添加全局键盘事件监听
     document.addEventListener("keydown", handleKeyDown, true);
@@ -38,9 +49,22 @@ export function useHotkey(settings: { value: HotkeySettings }) {
   function stopRecording() {
     isRecording.value = false;
     currentRecordingHotkey.value = "";
+    pendingStep = "";
     document.removeEventListener("keydown", handleKeyDown, true);
   }
 
+  // 完成录制，由后端检查后保存规范形式
+  function finishRecording(hotkey: string) {
+    recordingTimeout && clearTimeout(recordingTimeout);
+    recordingTimeout = null;
+    stopRecording();
+    validateWithSystem(hotkey, "show_window").then((canonical) => {
+      if (canonical) {
+        settings.value.hotkey = canonical;
+      }
+    });
+  }
+
   // 处理键盘按下事件
   function handleKeyDown(event: KeyboardEvent) {
     if (!isRecording.value) return;
@@ -71,7 +95,7 @@ export function useHotkey(settings: { value: HotkeySettings }) {
     if (keyMap[key]) {
       // 只显示修饰键
       if (modifiers.length > 0) {
-        currentRecordingHotkey.value = modifiers.join("+");
+        currentRecordingHotkey.value = [pendingStep, modifiers.join("+")].filter(Boolean).join(" ");
         console.log("currentRecordingHotkey", currentRecordingHotkey.value);
       }
       return;
@@ -89,6 +113,9 @@ export function useHotkey(settings: { value: HotkeySettings }) {
     } else if (physicalKey.startsWith("Digit")) {
       // 数字键：Digit1 -> 1
       displayKey = physicalKey.replace("Digit", "");
+    } else if (physicalKey.startsWith("Numpad")) {
+      // 小键盘：Numpad1 -> Num1，NumpadAdd -> NumAdd
+      displayKey = physicalKey.replace("Numpad", "Num");
     } else {
       // 其他特殊键的映射
       const specialKeyMap: { [key: string]: string } = {
@@ -103,23 +130,26 @@ export function useHotkey(settings: { value: HotkeySettings }) {
         ArrowDown: "Down",
         ArrowLeft: "Left",
         ArrowRight: "Right",
-        F1: "F1",
-        F2: "F2",
-        F3: "F3",
-        F4: "F4",
-        F5: "F5",
-        F6: "F6",
-        F7: "F7",
-        F8: "F8",
-        F9: "F9",
-        F10: "F10",
-        F11: "F11",
-        F12: "F12",
+        Home: "Home",
+        End: "End",
+        PageUp: "PageUp",
+        PageDown: "PageDown",
+        Minus: "-",
+        Equal: "=",
+        BracketLeft: "[",
+        BracketRight: "]",
+        Backslash: "\\",
+        Semicolon: ";",
+        Quote: "'",
+        Comma: ",",
+        Period: ".",
+        Slash: "/",
+        Backquote: "`",
       };
 
       if (specialKeyMap[physicalKey]) {
         displayKey = specialKeyMap[physicalKey];
-      } else if (physicalKey.startsWith("F")) {
+      } else if (/^F\d+$/.test(physicalKey)) {
         // F键：F1, F2, etc.
         displayKey = physicalKey;
       }
@@ -130,35 +160,41 @@ export function useHotkey(settings: { value: HotkeySettings }) {
       displayKey = displayKey.toUpperCase();
     }
 
-    // 构建快捷键字符串并实时显示
-    if (modifiers.length > 0) {
-      const newHotkey = [...modifiers, displayKey].join("+");
-      currentRecordingHotkey.value = newHotkey;
+    if (modifiers.length === 0) {
+      // 如果没有修饰键，只显示主键
+      currentRecordingHotkey.value = [pendingStep, displayKey].filter(Boolean).join(" ");
+      return;
+    }
 
-      // 验证快捷键
-      const validation = validateHotkey(newHotkey);
-      if (!validation.valid) {
-        ElMessage.warning(validation.message);
-        stopRecording();
-        return;
-      }
+    const step = [...modifiers, displayKey].join("+");
 
-      // 检查冲突
-      if (checkHotkeyConflict(newHotkey)) {
-        ElMessage.warning("此快捷键与系统快捷键冲突，请选择其他组合");
-        stopRecording();
-        return;
-      }
+    // 第二组按键：组成两步组合键
+    if (pendingStep) {
+      finishRecording(`${pendingStep} ${step}`);
+      return;
+    }
 
-      // 设置快捷键并停止录制
-      settings.value.hotkey = newHotkey;
+    currentRecordingHotkey.value = step;
 
-      // 停止录制
+    // 验证快捷键
+    const validation = validateHotkey(step);
+    if (!validation.valid) {
+      ElMessage.warning(validation.message);
       stopRecording();
-    } else {
-      // 如果没有修饰键，只显示主键
-      currentRecordingHotkey.value = displayKey;
+      return;
+    }
+
+    // 检查冲突
+    if (checkHotkeyConflict(step)) {
+      ElMessage.warning("此快捷键与系统快捷键冲突，请选择其他组合");
+      stopRecording();
+      return;
     }
+
+    // 第一组按键：短时间内再按一组则录制为两步组合键，否则只使用这一组
+    pendingStep = step;
+    recordingTimeout && clearTimeout(recordingTimeout);
+    recordingTimeout = setTimeout(() => finishRecording(step), CHORD_TIMEOUT_MS);
   }
 
   // 验证快捷键格式
@@ -195,16 +231,39 @@ export function useHotkey(settings: { value: HotkeySettings }) {
       };
     }
 
-    // 检查主键是否有效
-    const validKeys =
-      /^[A-Z0-9]$|^(F[1-9]|F1[0-2])$|^(Space|Enter|Return|Tab|Escape|Delete|Up|Down|Left|Right)$/i;
-    if (!validKeys.test(mainKey)) {
+    // 主键是否支持由后端检查（ValidateHotkey）
+    if (!mainKey) {
       return { valid: false, message: "不支持的主键" };
     }
 
     return { valid: true };
   }
 
+  // 由后端检查快捷键：格式、与其他绑定的冲突、系统能否注册；通过时返回规范形式
+  // ownId 为正在编辑的绑定 ID，和自己相同不算冲突
+  async function validateWithSystem(hotkey: string, ownId: string): Promise<string | null> {
+    try {
+      const result = await ValidateHotkey(hotkey);
+      if (result.error) {
+        ElMessage.warning(t("settings.hotkeyInvalid", { error: result.error }));
+        return null;
+      }
+      const conflicts = (result.conflicts || []).filter((id) => id !== ownId);
+      if (conflicts.length > 0) {
+        ElMessage.warning(t("settings.hotkeyConflict", { ids: conflicts.join(", ") }));
+        return null;
+      }
+      if (result.registerError) {
+        ElMessage.warning(t("settings.hotkeyRegisterFailed", { error: result.registerError }));
+        return null;
+      }
+      return result.canonical;
+    } catch (error) {
+      console.error("检查快捷键失败:", error);
+      return null;
+    }
+  }
+
   // 检查快捷键冲突
   function checkHotkeyConflict(hotkey: string): boolean {
     // 常见的系统快捷键
@@ -276,6 +335,7 @@ export function useHotkey(settings: { value: HotkeySettings }) {
     
     // 工具函数
     validateHotkey,
+    validateWithSystem,
     checkHotkeyConflict,
   };
 }
@@ -39,6 +39,17 @@ const zhCN = {
     removePassword: "移除密码",
     removePasswordDesc: "移除密码后可直接打开应用",
     lock: "锁定",
+    encryption: "加密存储",
+    encryptionDesc: "使用应用密码加密剪贴板内容、OCR 文字和图片，锁定后需输入密码才能查看",
+    encryptionPasswordPrompt: "请输入应用密码",
+    encryptionEnabled: "已启用加密存储",
+    encryptionDisabled: "已关闭加密存储",
+    encryptionError: "操作失败: {0}",
+    encryptionPasswordLocked: "已启用加密存储，请先关闭加密再移除密码",
+    autoLock: "空闲自动锁定",
+    autoLockDesc: "一段时间没有操作后自动锁定应用，需要重新输入密码",
+    autoLockNever: "从不",
+    autoLockMinutes: "{0} 分钟",
     general: "通用设置",
     autoClean: "自动清理历史",
     autoCleanDesc: "自动删除超过指定天数的剪贴板历史",
@@ -50,12 +61,45 @@ const zhCN = {
     record: "录制",
     recordingPlaceholder: "请按下快捷键组合...",
     recordPlaceholder: "点击录制快捷键",
+    hotkeyBindings: "更多快捷键",
+    hotkeyBindingsDesc: "为粘贴、收藏、暂停记录和脚本等动作设置全局快捷键，例如 Ctrl+Shift+V，或两步组合键 Ctrl+K Ctrl+V",
+    hotkeyBindingAdd: "添加",
+    hotkeyBindingPlaceholder: "快捷键",
+    hotkeyBindingScript: "选择脚本",
+    hotkeyInvalid: "快捷键无效：{error}",
+    hotkeyConflict: "快捷键已被使用：{ids}",
+    hotkeyRegisterFailed: "系统无法注册该快捷键：{error}",
+    hotkeyActions: {
+      show_window: "显示窗口",
+      paste_latest_plain: "纯文本粘贴最近一条",
+      paste_nth: "粘贴第 N 条",
+      open_favorites: "打开收藏",
+      toggle_capture: "暂停/恢复记录",
+      run_script: "运行脚本",
+    },
     clearAll: "全部清除",
     clearAllDesc: "清除所有剪贴板历史记录，此操作不可恢复",
     clearAllButton: "清除全部",
     doubleClickPaste: "双击自动粘贴",
     doubleClickPasteDesc:
       "开启后，双击剪贴板历史记录项将自动粘贴到当前活动窗口",
+    sensitiveDetection: "敏感内容检测",
+    sensitiveDetectionDesc: "复制密码、密钥、令牌、银行卡号时按类别丢弃、打码或定时删除",
+    sensitiveExpireMinutes: "敏感内容保留时间",
+    sensitiveExpireMinutesDesc: "动作为“定时删除”的内容保留的分钟数",
+    sensitiveCategories: {
+      private_key: "私钥",
+      cloud_key: "云服务密钥",
+      token: "访问令牌",
+      credit_card: "银行卡号",
+      password: "密码",
+    },
+    sensitiveActions: {
+      allow: "正常保存",
+      expire: "定时删除",
+      redact: "打码保存",
+      drop: "不保存",
+    },
     interface: "界面设置",
     pageSize: "每页显示数量",
     pageSizeDesc: "列表中每次加载的记录数量",
@@ -99,6 +143,10 @@ const zhCN = {
       triggerPlaceholder: "选择触发时机",
       triggerAfterSave: "保存后",
       triggerAfterSaveDesc: "（复制内容记录到数据库后触发）",
+      triggerBeforeSave: "保存前",
+      triggerBeforeSaveDesc: "（保存到数据库之前触发，可以修改内容、添加标签或返回 false 丢弃）",
+      triggerOnCopy: "复制时",
+      triggerOnCopyDesc: "（从历史记录复制或粘贴时触发，返回的文本替换复制的内容，不修改保存的记录）",
       triggerManual: "手动执行",
       contentTypes: "内容类型",
       contentTypesPlaceholder: "选择触发的内容类型（留空表示所有类型）",
@@ -164,6 +212,8 @@ const zhCN = {
     newPlaceholder: "请输入新密码",
     confirmPassword: "确认密码",
     confirmPlaceholder: "请再次输入密码",
+    currentPassword: "当前密码",
+    currentPlaceholder: "请输入当前密码",
     cancel: "取消",
     confirm: "确定",
     passwordRequired: "请输入密码",
@@ -197,6 +247,7 @@ const zhCN = {
     createTime: "创建时间:",
     copy: "复制",
     delete: "删除",
+    sensitive: "敏感内容：{0}",
   },
 
   // 组件相关文本
@@ -382,6 +433,17 @@ const enUS = {
     removePassword: "Remove Password",
     removePasswordDesc: "Remove password to open app directly",
     lock: "Lock",
+    encryption: "Encrypted Storage",
+    encryptionDesc: "Encrypt clipboard content, OCR text and images with the app password; locked history requires the password to view",
+    encryptionPasswordPrompt: "Enter the app password",
+    encryptionEnabled: "Encrypted storage enabled",
+    encryptionDisabled: "Encrypted storage disabled",
+    encryptionError: "Operation failed: {0}",
+    encryptionPasswordLocked: "Encrypted storage is enabled. Disable it before removing the password",
+    autoLock: "Auto Lock When Idle",
+    autoLockDesc: "Lock the app after a period of inactivity; the password is required again",
+    autoLockNever: "Never",
+    autoLockMinutes: "{0} min",
     general: "General Settings",
     autoClean: "Auto Clean History",
     autoCleanDesc:
@@ -394,6 +456,22 @@ const enUS = {
     record: "Record",
     recordingPlaceholder: "Please press hotkey combination...",
     recordPlaceholder: "Click to record hotkey",
+    hotkeyBindings: "More Hotkeys",
+    hotkeyBindingsDesc: "Global hotkeys for pasting, favorites, pausing capture and scripts, e.g. Ctrl+Shift+V or a two-step chord like Ctrl+K Ctrl+V",
+    hotkeyBindingAdd: "Add",
+    hotkeyBindingPlaceholder: "Hotkey",
+    hotkeyBindingScript: "Select script",
+    hotkeyInvalid: "Invalid hotkey: {error}",
+    hotkeyConflict: "Hotkey already in use: {ids}",
+    hotkeyRegisterFailed: "The system refused this hotkey: {error}",
+    hotkeyActions: {
+      show_window: "Show window",
+      paste_latest_plain: "Paste latest as plain text",
+      paste_nth: "Paste Nth item",
+      open_favorites: "Open favorites",
+      toggle_capture: "Pause/resume capture",
+      run_script: "Run script",
+    },
     clearAll: "Clear All",
     clearAllDesc:
       "Clear all clipboard history records, this operation cannot be undone",
@@ -401,6 +479,23 @@ const enUS = {
     doubleClickPaste: "Double Click to Paste",
     doubleClickPasteDesc:
       "When enabled, double-clicking a clipboard history item will automatically paste it to the currently active window",
+    sensitiveDetection: "Sensitive Content Detection",
+    sensitiveDetectionDesc: "Drop, redact or auto-expire copied passwords, keys, tokens and card numbers by category",
+    sensitiveExpireMinutes: "Sensitive Content Lifetime",
+    sensitiveExpireMinutesDesc: "Minutes to keep items whose action is \"Auto-expire\"",
+    sensitiveCategories: {
+      private_key: "Private Key",
+      cloud_key: "Cloud Access Key",
+      token: "Access Token",
+      credit_card: "Card Number",
+      password: "Password",
+    },
+    sensitiveActions: {
+      allow: "Store",
+      expire: "Auto-expire",
+      redact: "Redact",
+      drop: "Don't Store",
+    },
     scripts: {
       title: "Custom Scripts",
       manage: "Script Management",
@@ -417,6 +512,10 @@ const enUS = {
       triggerPlaceholder: "Select trigger",
       triggerAfterSave: "After Save",
       triggerAfterSaveDesc: "(Triggered after content is saved to database)",
+      triggerBeforeSave: "Before Save",
+      triggerBeforeSaveDesc: "(Runs before saving; can rewrite the content, add tags, or return false to discard)",
+      triggerOnCopy: "On Copy",
+      triggerOnCopyDesc: "(Runs when copying or pasting from history; the returned text replaces what is copied, the saved item is unchanged)",
       triggerManual: "Manual Execution",
       contentTypes: "Content Types",
       contentTypesPlaceholder: "Select content types (empty for all types)",
@@ -510,6 +609,8 @@ const enUS = {
     newPlaceholder: "Please enter new password",
     confirmPassword: "Confirm Password",
     confirmPlaceholder: "Please enter password again",
+    currentPassword: "Current Password",
+    currentPlaceholder: "Please enter current password",
     cancel: "Cancel",
     confirm: "Confirm",
     passwordRequired: "Please enter password",
@@ -545,6 +646,7 @@ const enUS = {
     createTime: "Created:",
     copy: "Copy",
     delete: "Delete",
+    sensitive: "Sensitive content: {0}",
   },
 
   // 组件相关文本
@@ -735,6 +837,17 @@ const frFR = {
     removePasswordDesc:
       "Supprimer le mot de passe pour ouvrir l'application directement",
     lock: "Verrouiller",
+    encryption: "Stockage Chiffré",
+    encryptionDesc: "Chiffrer le contenu du presse-papiers, le texte OCR et les images avec le mot de passe de l'application",
+    encryptionPasswordPrompt: "Saisissez le mot de passe de l'application",
+    encryptionEnabled: "Stockage chiffré activé",
+    encryptionDisabled: "Stockage chiffré désactivé",
+    encryptionError: "Échec de l'opération : {0}",
+    encryptionPasswordLocked: "Le stockage chiffré est activé. Désactivez-le avant de supprimer le mot de passe",
+    autoLock: "Verrouillage Automatique",
+    autoLockDesc: "Verrouiller l'application après une période d'inactivité",
+    autoLockNever: "Jamais",
+    autoLockMinutes: "{0} min",
     general: "Paramètres Généraux",
     autoClean: "Nettoyage Automatique de l'Historique",
     autoCleanDesc:
@@ -749,6 +862,22 @@ const frFR = {
     record: "Enregistrer",
     recordingPlaceholder: "Veuillez appuyer sur la combinaison de touches...",
     recordPlaceholder: "Cliquez pour enregistrer le raccourci",
+    hotkeyBindings: "Autres raccourcis",
+    hotkeyBindingsDesc: "Raccourcis globaux pour coller, les favoris, la pause de la capture et les scripts, ex. Ctrl+Shift+V ou une séquence en deux temps comme Ctrl+K Ctrl+V",
+    hotkeyBindingAdd: "Ajouter",
+    hotkeyBindingPlaceholder: "Raccourci",
+    hotkeyBindingScript: "Choisir un script",
+    hotkeyInvalid: "Raccourci invalide : {error}",
+    hotkeyConflict: "Raccourci déjà utilisé : {ids}",
+    hotkeyRegisterFailed: "Le système refuse ce raccourci : {error}",
+    hotkeyActions: {
+      show_window: "Afficher la fenêtre",
+      paste_latest_plain: "Coller le dernier en texte brut",
+      paste_nth: "Coller le N-ième élément",
+      open_favorites: "Ouvrir les favoris",
+      toggle_capture: "Suspendre/reprendre la capture",
+      run_script: "Exécuter un script",
+    },
     clearAll: "Tout Effacer",
     clearAllDesc:
       "Effacer tous les enregistrements d'historique du presse-papiers, cette opération est irréversible",
@@ -756,6 +885,23 @@ const frFR = {
     doubleClickPaste: "Double-clic pour Coller",
     doubleClickPasteDesc:
       "Lorsqu'il est activé, double-cliquer sur un élément de l'historique du presse-papiers le collera automatiquement dans la fenêtre actuellement active",
+    sensitiveDetection: "Détection de Contenu Sensible",
+    sensitiveDetectionDesc: "Ignorer, masquer ou faire expirer les mots de passe, clés, jetons et numéros de carte copiés selon la catégorie",
+    sensitiveExpireMinutes: "Durée de Conservation du Contenu Sensible",
+    sensitiveExpireMinutesDesc: "Minutes de conservation des éléments dont l'action est « Expiration automatique »",
+    sensitiveCategories: {
+      private_key: "Clé Privée",
+      cloud_key: "Clé d'Accès Cloud",
+      token: "Jeton d'Accès",
+      credit_card: "Numéro de Carte",
+      password: "Mot de Passe",
+    },
+    sensitiveActions: {
+      allow: "Enregistrer",
+      expire: "Expiration automatique",
+      redact: "Masquer",
+      drop: "Ne pas enregistrer",
+    },
     interface: "Paramètres de l'Interface",
     pageSize: "Éléments par Page",
     pageSizeDesc: "Nombre d'enregistrements à charger à la fois dans la liste",
@@ -801,6 +947,12 @@ const frFR = {
       triggerAfterSave: "Après Sauvegarde",
       triggerAfterSaveDesc:
         "(Déclenché après l'enregistrement du contenu dans la base de données)",
+      triggerBeforeSave: "Avant Sauvegarde",
+      triggerBeforeSaveDesc:
+        "(Déclenché avant l'enregistrement ; peut modifier le contenu, ajouter des tags ou renvoyer false pour l'ignorer)",
+      triggerOnCopy: "À la Copie",
+      triggerOnCopyDesc:
+        "(Déclenché lors de la copie ou du collage depuis l'historique ; le texte renvoyé remplace le contenu copié sans modifier l'élément enregistré)",
       triggerManual: "Exécution Manuelle",
       contentTypes: "Types de Contenu",
       contentTypesPlaceholder:
@@ -863,6 +1015,8 @@ const frFR = {
     newPlaceholder: "Veuillez entrer le nouveau mot de passe",
     confirmPassword: "Confirmer le Mot de Passe",
     confirmPlaceholder: "Veuillez entrer le mot de passe à nouveau",
+    currentPassword: "Mot de Passe Actuel",
+    currentPlaceholder: "Veuillez entrer le mot de passe actuel",
     cancel: "Annuler",
     confirm: "Confirmer",
     passwordRequired: "Veuillez entrer le mot de passe",
@@ -898,6 +1052,7 @@ const frFR = {
     createTime: "Créé :",
     copy: "Copier",
     delete: "Supprimer",
+    sensitive: "Contenu sensible : {0}",
   },
 
   // 组件相关文本
@@ -1083,6 +1238,17 @@ const arSA = {
     removePassword: "إزالة كلمة المرور",
     removePasswordDesc: "إزالة كلمة المرور لفتح التطبيق مباشرة",
     lock: "قفل",
+    encryption: "التخزين المشفر",
+    encryptionDesc: "تشفير محتوى الحافظة ونص OCR والصور باستخدام كلمة مرور التطبيق",
+    encryptionPasswordPrompt: "أدخل كلمة مرور التطبيق",
+    encryptionEnabled: "تم تفعيل التخزين المشفر",
+    encryptionDisabled: "تم إيقاف التخزين المشفر",
+    encryptionError: "فشلت العملية: {0}",
+    encryptionPasswordLocked: "التخزين المشفر مفعّل. أوقفه قبل إزالة كلمة المرور",
+    autoLock: "القفل التلقائي عند الخمول",
+    autoLockDesc: "قفل التطبيق بعد فترة من عدم النشاط",
+    autoLockNever: "أبداً",
+    autoLockMinutes: "{0} دقيقة",
     general: "الإعدادات العامة",
     autoClean: "تنظيف السجل التلقائي",
     autoCleanDesc: "حذف سجل الحافظة تلقائياً الأقدم من الأيام المحددة",
@@ -1094,6 +1260,22 @@ const arSA = {
     record: "تسجيل",
     recordingPlaceholder: "يرجى الضغط على مجموعة المفاتيح...",
     recordPlaceholder: "انقر لتسجيل الاختصار",
+    hotkeyBindings: "اختصارات إضافية",
+    hotkeyBindingsDesc: "اختصارات عامة للصق والمفضلة وإيقاف الالتقاط والسكريبتات، مثل Ctrl+Shift+V أو اختصار من خطوتين مثل Ctrl+K Ctrl+V",
+    hotkeyBindingAdd: "إضافة",
+    hotkeyBindingPlaceholder: "الاختصار",
+    hotkeyBindingScript: "اختر سكريبت",
+    hotkeyInvalid: "اختصار غير صالح: {error}",
+    hotkeyConflict: "الاختصار مستخدم بالفعل: {ids}",
+    hotkeyRegisterFailed: "رفض النظام هذا الاختصار: {error}",
+    hotkeyActions: {
+      show_window: "إظهار النافذة",
+      paste_latest_plain: "لصق الأحدث كنص عادي",
+      paste_nth: "لصق العنصر رقم N",
+      open_favorites: "فتح المفضلة",
+      toggle_capture: "إيقاف/استئناف الالتقاط",
+      run_script: "تشغيل سكريبت",
+    },
     clearAll: "مسح الكل",
     clearAllDesc:
       "مسح جميع سجلات سجل الحافظة، هذه العملية لا يمكن التراجع عنها",
@@ -1101,6 +1283,23 @@ const arSA = {
     doubleClickPaste: "النقر المزدوج للصق",
     doubleClickPasteDesc:
       "عند التمكين، سيؤدي النقر المزدوج على عنصر سجل الحافظة إلى لصقه تلقائياً في النافذة النشطة حالياً",
+    sensitiveDetection: "اكتشاف المحتوى الحساس",
+    sensitiveDetectionDesc: "تجاهل كلمات المرور والمفاتيح والرموز وأرقام البطاقات المنسوخة أو إخفاؤها أو حذفها تلقائياً حسب الفئة",
+    sensitiveExpireMinutes: "مدة الاحتفاظ بالمحتوى الحساس",
+    sensitiveExpireMinutesDesc: "عدد الدقائق للاحتفاظ بالعناصر التي إجراؤها \"حذف تلقائي\"",
+    sensitiveCategories: {
+      private_key: "مفتاح خاص",
+      cloud_key: "مفتاح وصول سحابي",
+      token: "رمز وصول",
+      credit_card: "رقم البطاقة",
+      password: "كلمة المرور",
+    },
+    sensitiveActions: {
+      allow: "حفظ",
+      expire: "حذف تلقائي",
+      redact: "إخفاء",
+      drop: "عدم الحفظ",
+    },
     interface: "إعدادات الواجهة",
     pageSize: "العناصر في الصفحة",
     pageSizeDesc: "عدد السجلات المراد تحميلها في كل مرة في القائمة",
@@ -1145,6 +1344,10 @@ const arSA = {
       triggerPlaceholder: "اختر المشغل",
       triggerAfterSave: "بعد الحفظ",
       triggerAfterSaveDesc: "(يتم التشغيل بعد حفظ المحتوى في قاعدة البيانات)",
+      triggerBeforeSave: "قبل الحفظ",
+      triggerBeforeSaveDesc: "(يتم التشغيل قبل الحفظ؛ يمكنه تعديل المحتوى أو إضافة وسوم أو إرجاع false للتجاهل)",
+      triggerOnCopy: "عند النسخ",
+      triggerOnCopyDesc: "(يتم التشغيل عند النسخ أو اللصق من السجل؛ يحل النص المُرجع محل المحتوى المنسوخ دون تعديل العنصر المحفوظ)",
       triggerManual: "تنفيذ يدوي",
       contentTypes: "أنواع المحتوى",
       contentTypesPlaceholder: "اختر أنواع المحتوى (فارغ لجميع الأنواع)",
@@ -1204,6 +1407,8 @@ const arSA = {
     newPlaceholder: "يرجى إدخال كلمة المرور الجديدة",
     confirmPassword: "تأكيد كلمة المرور",
     confirmPlaceholder: "يرجى إدخال كلمة المرور مرة أخرى",
+    currentPassword: "كلمة المرور الحالية",
+    currentPlaceholder: "يرجى إدخال كلمة المرور الحالية",
     cancel: "إلغاء",
     confirm: "تأكيد",
     passwordRequired: "يرجى إدخال كلمة المرور",
@@ -1238,6 +1443,7 @@ const arSA = {
     createTime: "تاريخ الإنشاء:",
     copy: "نسخ",
     delete: "حذف",
+    sensitive: "محتوى حساس: {0}",
   },
 
   // 组件相关文本
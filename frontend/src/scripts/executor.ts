@@ -1,11 +1,12 @@
 /**
- * 脚本执行器 - 在浏览器环境中执行用户脚本
+ * 脚本执行器 - 在浏览器环境中执行手动运行的用户脚本
+ * 保存前后、复制时、HTTP 服务和快捷键触发的脚本由后端的 Go 运行时执行
  */
 
 import { EventsOn } from '../../wailsjs/runtime/runtime'
-import { GetEnabledUserScriptsByTrigger, GetClipboardItemByID, HttpRequest, GetUserScriptsByIDs, CopyTextToClipboard, GetUserScriptByID, SetScriptHTTPResult } from '../../wailsjs/go/main/App'
+import { HttpRequest, CopyTextToClipboard, GetScriptStorage, SetScriptStorage, DeleteScriptStorage } from '../../wailsjs/go/main/App'
 import { common } from '../../wailsjs/go/models'
-import { ElMessageBox } from 'element-plus'
+import { ElMessageBox, ElNotification } from 'element-plus'
 
 // 使用 Wails 生成的类型
 export type ClipboardItem = common.ClipboardItem
@@ -121,6 +122,26 @@ function removeImports(scriptCode: string): string {
   return cleanCode
 }
 
+/**
+ * 脚本的键值存储（和后端执行脚本时的 csStorage 相同，值保存为 JSON）
+ */
+function createScriptStorage(scriptID: string) {
+  return {
+    get: async (key: string) => {
+      const value = await GetScriptStorage(scriptID, key)
+      return value ? JSON.parse(value) : null
+    },
+    set: async (key: string, value: any) => {
+      if (value === undefined) {
+        await DeleteScriptStorage(scriptID, key)
+        return
+      }
+      await SetScriptStorage(scriptID, key, JSON.stringify(value))
+    },
+    remove: (key: string) => DeleteScriptStorage(scriptID, key),
+  }
+}
+
 /**
  * 在浏览器环境中执行脚本
  * 导出供外部使用（如脚本编辑器测试功能）
@@ -176,6 +197,16 @@ export async function executeScriptInBrowser(
         name: 'csCopyText',
         func: CopyTextToClipboard,
       },
+      {
+        name: 'csNotify',
+        func: (title: string, message: string) => {
+          ElNotification({ title, message, type: 'info' })
+        },
+      },
+      {
+        name: 'csStorage',
+        func: createScriptStorage(script.ID),
+      },
     ]
     
     // 根据导入语句注入函数
@@ -277,149 +308,17 @@ export async function executeScriptInBrowser(
   return result
 }
 
-/**
- * 处理脚本执行事件
- */
-async function handleScriptExecution(data: {
-  itemId: string
-  trigger: string
-  scriptIds?: string[]  // 可选的脚本ID列表（后端已匹配）
-  item?: ClipboardItem  // 可选的 item 数据（后端已传递，避免重复查询）
-}) {
-  const { itemId, trigger, scriptIds, item: itemFromEvent } = data
-
-  try {
-    // 如果后端已经传递了 item 数据，直接使用；否则查询数据库
-    let item: ClipboardItem
-    if (itemFromEvent) {
-      console.log(`使用后端传递的 item 数据`)
-      // 使用 ClipboardItem.createFrom 转换数据
-      item = common.ClipboardItem.createFrom(itemFromEvent)
-      
-      // 如果 item 是图片类型且没有 ImageData，延迟加载
-      if (item.ContentType === 'Image' && (!item.ImageData || item.ImageData.length === 0)) {
-        console.log(`延迟加载图片数据...`)
-        const fullItem = await GetClipboardItemByID(itemId)
-        if (fullItem && fullItem.ImageData) {
-          item.ImageData = fullItem.ImageData
-        }
-      }
-    } else {
-      // 兼容旧逻辑：如果没有传递 item，查询数据库
-      console.log(`查询 item 数据...`)
-      const queriedItem = await GetClipboardItemByID(itemId)
-      if (!queriedItem) {
-      console.error(`未找到剪贴板项: ${itemId}`)
-      return
-    }
-      item = queriedItem
-    }
-
-    let scriptsToExecute: UserScript[] = []
-
-    // 如果后端已经提供了匹配的脚本ID列表，直接使用
-    if (scriptIds && scriptIds.length > 0) {
-      console.log(`后端已匹配 ${scriptIds.length} 个脚本，批量获取执行...`)
-      
-      // 使用批量查询接口获取脚本
-      try {
-        scriptsToExecute = await GetUserScriptsByIDs(scriptIds)
-        
-        if (scriptsToExecute.length === 0) {
-          console.log(`无法获取匹配的脚本`)
-          return
-        }
-      } catch (error) {
-        console.error(`批量获取脚本失败:`, error)
-        return
-      }
-    } else {
-      // 兼容旧逻辑：如果没有提供脚本ID，使用原有方式（用于其他trigger类型）
-      console.log(`使用原有方式获取 ${trigger} 脚本...`)
-    const scripts = await GetEnabledUserScriptsByTrigger(trigger)
-      scriptsToExecute = scripts.filter(
-      (script) => shouldTriggerScript(script, item)
-    )
-    }
-
-    if (scriptsToExecute.length === 0) {
-      console.log(`没有匹配的 ${trigger} 脚本`)
-      return
-    }
-
-    console.log(`找到 ${scriptsToExecute.length} 个匹配的脚本，开始执行...`)
-
-    // 执行每个脚本（静默执行，不显示执行中状态）
-    for (const script of scriptsToExecute) {
-      console.log(`执行脚本: ${script.Name}`)
-      await executeScriptInBrowser(script, item)
-    }
-  } catch (error) {
-    console.error('处理脚本执行事件失败:', error)
-  }
-}
-
-/**
- * 处理 HTTP 请求的脚本执行
- */
-async function handleHTTPScriptExecution(data: {
-  requestID: string
-  scriptID: string
-  content: string
-}) {
-  const { requestID, scriptID, content } = data
-
-  try {
-    // 获取脚本
-    const script = await GetUserScriptByID(scriptID)
-    if (!script) {
-      await SetScriptHTTPResult(requestID, JSON.stringify({
-        error: '脚本不存在'
-      }))
-      return
-    }
-
-    // 构造模拟的 ClipboardItem
-    const item = common.ClipboardItem.createFrom({
-      ID: `http-${Date.now()}`,
-      Content: content,
-      ContentType: 'Text',
-      ContentHash: '',
-      ImageData: [], // number[] 类型
-      FilePaths: '', // string 类型
-      FileInfo: '',
-      Timestamp: new Date().toISOString(),
-      Source: 'HTTP',
-      CharCount: content.length,
-      WordCount: 0,
-      IsFavorite: 0, // number 类型，0 表示 false
-      OCRText: '',
-    })
-
-    // 执行脚本
-    const result = await executeScriptInBrowser(script, item)
-
-    // 返回结果给后端
-    await SetScriptHTTPResult(requestID, JSON.stringify({
-      returnValue: result.returnValue,
-      error: result.error,
-    }))
-  } catch (error: any) {
-    console.error('处理 HTTP 脚本执行失败:', error)
-    await SetScriptHTTPResult(requestID, JSON.stringify({
-      error: error.message || String(error)
-    }))
-  }
-}
-
 /**
  * 初始化脚本执行器
  */
 export function initScriptExecutor() {
-  // 监听脚本执行事件
-  EventsOn('clipboard.script.execute', handleScriptExecution)
-  // 监听 HTTP 脚本执行事件
-  EventsOn('script.http.execute', handleHTTPScriptExecution)
+  // 脚本由后端执行，前端只负责显示脚本的通知（csNotify、alert）
+  EventsOn('script.notify', (data: { title: string; message: string }) => {
+    ElNotification({
+      title: data.title,
+      message: data.message,
+      type: 'info',
+    })
+  })
   console.log('✅ 脚本执行器已初始化')
 }
-
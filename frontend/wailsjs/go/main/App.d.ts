@@ -4,6 +4,8 @@ import {common} from '../models';
 
 export function ActivatePreviousApp():Promise<void>;
 
+export function AddTagsToItems(arg1:Array<string>,arg2:Array<string>):Promise<void>;
+
 export function AutoPasteCurrentItem():Promise<void>;
 
 export function AutoPasteCurrentItemToPreviousApp():Promise<void>;
@@ -12,38 +14,64 @@ export function ClearAllItems():Promise<void>;
 
 export function ClearItemsOlderThanDays(arg1:number):Promise<void>;
 
+export function ClearPassword(arg1:string):Promise<void>;
+
 export function CollectCurrentItem():Promise<void>;
 
 export function CopyCurrentItem():Promise<void>;
 
 export function CopyImageToClipboard(arg1:string):Promise<void>;
 
+export function CopySnippetToClipboard(arg1:string,arg2:Record<string, string>):Promise<void>;
+
 export function CopyTextToClipboard(arg1:string):Promise<void>;
 
 export function CopyToClipboard(arg1:string):Promise<void>;
 
+export function CreateTag(arg1:string,arg2:string):Promise<common.Tag>;
+
+export function DeleteCaptureRule(arg1:string):Promise<void>;
+
 export function DeleteClipboardItem(arg1:string):Promise<void>;
 
 export function DeleteCurrentItem():Promise<void>;
 
+export function DeleteScriptStorage(arg1:string,arg2:string):Promise<void>;
+
+export function DeleteTag(arg1:string):Promise<void>;
+
 export function DeleteUserScript(arg1:string):Promise<void>;
 
 export function DetectQRCode(arg1:string):Promise<boolean>;
 
+export function DisableEncryption(arg1:string):Promise<void>;
+
 export function DisableScriptHTTPService(arg1:string):Promise<void>;
 
+export function EnableEncryption(arg1:string):Promise<void>;
+
 export function EnableScriptHTTPService(arg1:string):Promise<void>;
 
 export function EnterItem():Promise<void>;
 
+export function ExpandSnippet(arg1:string,arg2:Record<string, string>):Promise<common.SnippetExpansion>;
+
+export function ExportHistory(arg1:common.ExportFilter,arg2:string):Promise<common.ExportResult>;
+
 export function ForceQuit():Promise<void>;
 
 export function GenerateQRCode(arg1:string,arg2:number):Promise<string>;
 
+export function GetAllCaptureRules():Promise<Array<common.CaptureRule>>;
+
+export function GetAllTags():Promise<Array<common.Tag>>;
+
 export function GetAllUserScripts():Promise<Array<common.UserScript>>;
 
 export function GetAppSettings():Promise<string>;
 
+export function GetCaptureStatus():Promise<common.MonitorStatus>;
+
 export function GetClipboardItemByID(arg1:string):Promise<common.ClipboardItem>;
 
 export function GetClipboardItems(arg1:number):Promise<Array<common.ClipboardItem>>;
@@ -52,14 +80,34 @@ export function GetCurrentLanguage():Promise<string>;
 
 export function GetEnabledUserScriptsByTrigger(arg1:string):Promise<Array<common.UserScript>>;
 
+export function GetEncryptionStatus():Promise<common.EncryptionStatus>;
+
 export function GetFileInfo(arg1:string):Promise<Array<common.FileInfo>>;
 
+export function GetPasswordStatus():Promise<common.PasswordStatus>;
+
+export function GetRetentionStatus():Promise<common.RetentionResult>;
+
+export function GetSchemaMigrations():Promise<Array<common.MigrationInfo>>;
+
 export function GetScriptHTTPURL(arg1:string):Promise<string>;
 
+export function GetScriptRunSummaries():Promise<Array<common.ScriptRunSummary>>;
+
+export function GetScriptStorage(arg1:string,arg2:string):Promise<string>;
+
+export function GetSensitiveCategories():Promise<Array<common.SensitiveCategoryInfo>>;
+
+export function GetSettings():Promise<common.Settings>;
+
 export function GetStatistics():Promise<Record<string, any>>;
 
 export function GetSupportedLanguages():Promise<Array<string>>;
 
+export function GetTagsForItems(arg1:Array<string>):Promise<Record<string, Array<common.Tag>>>;
+
+export function GetThumbnails(arg1:Array<string>):Promise<Record<string, Array<number>>>;
+
 export function GetUserScriptByID(arg1:string):Promise<common.UserScript>;
 
 export function GetUserScriptsByIDs(arg1:Array<string>):Promise<Array<common.UserScript>>;
@@ -70,37 +118,67 @@ export function HideWindowAndQuit():Promise<void>;
 
 export function HttpRequest(arg1:string,arg2:string,arg3:string,arg4:string):Promise<string>;
 
+export function ImportHistory(arg1:string,arg2:string):Promise<common.ImportReport>;
+
 export function IsAutoStartEnabled():Promise<boolean>;
 
 export function IsSayPlaying():Promise<boolean>;
 
 export function IsScriptHTTPServiceEnabled(arg1:string):Promise<boolean>;
 
+export function ListScriptRuns(arg1:common.ScriptRunFilter):Promise<Array<common.ScriptRun>>;
+
+export function LockApp():Promise<void>;
+
+export function MergeTags(arg1:Array<string>,arg2:string):Promise<void>;
+
 export function NextItem():Promise<void>;
 
 export function OpenFileInFinder(arg1:string):Promise<void>;
 
 export function OpenURL(arg1:string):Promise<void>;
 
+export function PauseCapture(arg1:number):Promise<void>;
+
 export function PlayCurrentItem():Promise<void>;
 
 export function PrevItem():Promise<void>;
 
+export function PurgeScriptRuns(arg1:common.ScriptRunFilter):Promise<number>;
+
 export function RecognizeQRCode(arg1:string):Promise<string>;
 
+export function RecordActivity():Promise<void>;
+
+export function RemoveTagsFromItems(arg1:Array<string>,arg2:Array<string>):Promise<void>;
+
+export function RenameTag(arg1:string,arg2:string):Promise<void>;
+
+export function ReorderCaptureRules(arg1:Array<string>):Promise<void>;
+
 export function RestartRegisterHotkey():Promise<void>;
 
+export function ResumeCapture():Promise<void>;
+
+export function RunRetentionNow():Promise<common.RetentionResult>;
+
 export function RunScript():Promise<void>;
 
 export function SaveAppSettings(arg1:string):Promise<void>;
 
+export function SaveCaptureRule(arg1:common.CaptureRule):Promise<common.CaptureRule>;
+
 export function SaveImagePNG(arg1:string,arg2:string):Promise<string>;
 
+export function SaveSnippet(arg1:string,arg2:string):Promise<string>;
+
 export function SaveUserScript(arg1:string):Promise<void>;
 
 export function SayText(arg1:string):Promise<void>;
 
-export function SearchClipboardItems(arg1:boolean,arg2:string,arg3:string,arg4:number,arg5:boolean):Promise<Array<common.ClipboardItem>>;
+export function SearchClipboardItems(arg1:boolean,arg2:string,arg3:string,arg4:string,arg5:number,arg6:string):Promise<Array<common.ClipboardItem>>;
+
+export function SearchClipboardItemsRanked(arg1:boolean,arg2:string,arg3:string,arg4:number):Promise<Array<common.SearchHit>>;
 
 export function SearchItem():Promise<void>;
 
@@ -108,9 +186,17 @@ export function SetAutoStart(arg1:boolean):Promise<void>;
 
 export function SetDockIconVisibility(arg1:number):Promise<void>;
 
+export function SetItemExpiry(arg1:string,arg2:number,arg3:number):Promise<void>;
+
 export function SetLanguage(arg1:string):Promise<void>;
 
-export function SetScriptHTTPResult(arg1:string,arg2:string):Promise<void>;
+export function SetPassword(arg1:string,arg2:string):Promise<void>;
+
+export function SetScriptStorage(arg1:string,arg2:string,arg3:string):Promise<void>;
+
+export function SetTagColor(arg1:string,arg2:string):Promise<void>;
+
+export function SetTagProtected(arg1:string,arg2:boolean):Promise<void>;
 
 export function SetWindowAlwaysOnTop(arg1:boolean):Promise<void>;
 
@@ -130,4 +216,6 @@ export function TranslateCurrentItem():Promise<void>;
 
 export function UpdateUserScriptOrder(arg1:string,arg2:number):Promise<void>;
 
+export function ValidateHotkey(arg1:string):Promise<common.HotkeyValidation>;
+
 export function VerifyPassword(arg1:string):Promise<boolean>;
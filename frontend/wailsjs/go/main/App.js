@@ -6,6 +6,10 @@ export function ActivatePreviousApp() {
   return window['go']['main']['App']['ActivatePreviousApp']();
 }
 
+export function AddTagsToItems(arg1, arg2) {
+  return window['go']['main']['App']['AddTagsToItems'](arg1, arg2);
+}
+
 export function AutoPasteCurrentItem() {
   return window['go']['main']['App']['AutoPasteCurrentItem']();
 }
@@ -22,6 +26,10 @@ export function ClearItemsOlderThanDays(arg1) {
   return window['go']['main']['App']['ClearItemsOlderThanDays'](arg1);
 }
 
+export function ClearPassword(arg1) {
+  return window['go']['main']['App']['ClearPassword'](arg1);
+}
+
 export function CollectCurrentItem() {
   return window['go']['main']['App']['CollectCurrentItem']();
 }
@@ -34,6 +42,10 @@ export function CopyImageToClipboard(arg1) {
   return window['go']['main']['App']['CopyImageToClipboard'](arg1);
 }
 
+export function CopySnippetToClipboard(arg1, arg2) {
+  return window['go']['main']['App']['CopySnippetToClipboard'](arg1, arg2);
+}
+
 export function CopyTextToClipboard(arg1) {
   return window['go']['main']['App']['CopyTextToClipboard'](arg1);
 }
@@ -42,6 +54,14 @@ export function CopyToClipboard(arg1) {
   return window['go']['main']['App']['CopyToClipboard'](arg1);
 }
 
+export function CreateTag(arg1, arg2) {
+  return window['go']['main']['App']['CreateTag'](arg1, arg2);
+}
+
+export function DeleteCaptureRule(arg1) {
+  return window['go']['main']['App']['DeleteCaptureRule'](arg1);
+}
+
 export function DeleteClipboardItem(arg1) {
   return window['go']['main']['App']['DeleteClipboardItem'](arg1);
 }
@@ -50,6 +70,14 @@ export function DeleteCurrentItem() {
   return window['go']['main']['App']['DeleteCurrentItem']();
 }
 
+export function DeleteScriptStorage(arg1, arg2) {
+  return window['go']['main']['App']['DeleteScriptStorage'](arg1, arg2);
+}
+
+export function DeleteTag(arg1) {
+  return window['go']['main']['App']['DeleteTag'](arg1);
+}
+
 export function DeleteUserScript(arg1) {
   return window['go']['main']['App']['DeleteUserScript'](arg1);
 }
@@ -58,10 +86,18 @@ export function DetectQRCode(arg1) {
   return window['go']['main']['App']['DetectQRCode'](arg1);
 }
 
+export function DisableEncryption(arg1) {
+  return window['go']['main']['App']['DisableEncryption'](arg1);
+}
+
 export function DisableScriptHTTPService(arg1) {
   return window['go']['main']['App']['DisableScriptHTTPService'](arg1);
 }
 
+export function EnableEncryption(arg1) {
+  return window['go']['main']['App']['EnableEncryption'](arg1);
+}
+
 export function EnableScriptHTTPService(arg1) {
   return window['go']['main']['App']['EnableScriptHTTPService'](arg1);
 }
@@ -70,6 +106,14 @@ export function EnterItem() {
   return window['go']['main']['App']['EnterItem']();
 }
 
+export function ExpandSnippet(arg1, arg2) {
+  return window['go']['main']['App']['ExpandSnippet'](arg1, arg2);
+}
+
+export function ExportHistory(arg1, arg2) {
+  return window['go']['main']['App']['ExportHistory'](arg1, arg2);
+}
+
 export function ForceQuit() {
   return window['go']['main']['App']['ForceQuit']();
 }
@@ -78,6 +122,14 @@ export function GenerateQRCode(arg1, arg2) {
   return window['go']['main']['App']['GenerateQRCode'](arg1, arg2);
 }
 
+export function GetAllCaptureRules() {
+  return window['go']['main']['App']['GetAllCaptureRules']();
+}
+
+export function GetAllTags() {
+  return window['go']['main']['App']['GetAllTags']();
+}
+
 export function GetAllUserScripts() {
   return window['go']['main']['App']['GetAllUserScripts']();
 }
@@ -86,6 +138,10 @@ export function GetAppSettings() {
   return window['go']['main']['App']['GetAppSettings']();
 }
 
+export function GetCaptureStatus() {
+  return window['go']['main']['App']['GetCaptureStatus']();
+}
+
 export function GetClipboardItemByID(arg1) {
   return window['go']['main']['App']['GetClipboardItemByID'](arg1);
 }
@@ -102,14 +158,46 @@ export function GetEnabledUserScriptsByTrigger(arg1) {
   return window['go']['main']['App']['GetEnabledUserScriptsByTrigger'](arg1);
 }
 
+export function GetEncryptionStatus() {
+  return window['go']['main']['App']['GetEncryptionStatus']();
+}
+
 export function GetFileInfo(arg1) {
   return window['go']['main']['App']['GetFileInfo'](arg1);
 }
 
+export function GetPasswordStatus() {
+  return window['go']['main']['App']['GetPasswordStatus']();
+}
+
+export function GetRetentionStatus() {
+  return window['go']['main']['App']['GetRetentionStatus']();
+}
+
+export function GetSchemaMigrations() {
+  return window['go']['main']['App']['GetSchemaMigrations']();
+}
+
 export function GetScriptHTTPURL(arg1) {
   return window['go']['main']['App']['GetScriptHTTPURL'](arg1);
 }
 
+export function GetScriptRunSummaries() {
+  return window['go']['main']['App']['GetScriptRunSummaries']();
+}
+
+export function GetScriptStorage(arg1, arg2) {
+  return window['go']['main']['App']['GetScriptStorage'](arg1, arg2);
+}
+
+export function GetSensitiveCategories() {
+  return window['go']['main']['App']['GetSensitiveCategories']();
+}
+
+export function GetSettings() {
+  return window['go']['main']['App']['GetSettings']();
+}
+
 export function GetStatistics() {
   return window['go']['main']['App']['GetStatistics']();
 }
@@ -118,6 +206,14 @@ export function GetSupportedLanguages() {
   return window['go']['main']['App']['GetSupportedLanguages']();
 }
 
+export function GetTagsForItems(arg1) {
+  return window['go']['main']['App']['GetTagsForItems'](arg1);
+}
+
+export function GetThumbnails(arg1) {
+  return window['go']['main']['App']['GetThumbnails'](arg1);
+}
+
 export function GetUserScriptByID(arg1) {
   return window['go']['main']['App']['GetUserScriptByID'](arg1);
 }
@@ -138,6 +234,10 @@ export function HttpRequest(arg1, arg2, arg3, arg4) {
   return window['go']['main']['App']['HttpRequest'](arg1, arg2, arg3, arg4);
 }
 
+export function ImportHistory(arg1, arg2) {
+  return window['go']['main']['App']['ImportHistory'](arg1, arg2);
+}
+
 export function IsAutoStartEnabled() {
   return window['go']['main']['App']['IsAutoStartEnabled']();
 }
@@ -150,6 +250,18 @@ export function IsScriptHTTPServiceEnabled(arg1) {
   return window['go']['main']['App']['IsScriptHTTPServiceEnabled'](arg1);
 }
 
+export function ListScriptRuns(arg1) {
+  return window['go']['main']['App']['ListScriptRuns'](arg1);
+}
+
+export function LockApp() {
+  return window['go']['main']['App']['LockApp']();
+}
+
+export function MergeTags(arg1, arg2) {
+  return window['go']['main']['App']['MergeTags'](arg1, arg2);
+}
+
 export function NextItem() {
   return window['go']['main']['App']['NextItem']();
 }
@@ -162,6 +274,10 @@ export function OpenURL(arg1) {
   return window['go']['main']['App']['OpenURL'](arg1);
 }
 
+export function PauseCapture(arg1) {
+  return window['go']['main']['App']['PauseCapture'](arg1);
+}
+
 export function PlayCurrentItem() {
   return window['go']['main']['App']['PlayCurrentItem']();
 }
@@ -170,14 +286,42 @@ export function PrevItem() {
   return window['go']['main']['App']['PrevItem']();
 }
 
+export function PurgeScriptRuns(arg1) {
+  return window['go']['main']['App']['PurgeScriptRuns'](arg1);
+}
+
 export function RecognizeQRCode(arg1) {
   return window['go']['main']['App']['RecognizeQRCode'](arg1);
 }
 
+export function RecordActivity() {
+  return window['go']['main']['App']['RecordActivity']();
+}
+
+export function RemoveTagsFromItems(arg1, arg2) {
+  return window['go']['main']['App']['RemoveTagsFromItems'](arg1, arg2);
+}
+
+export function RenameTag(arg1, arg2) {
+  return window['go']['main']['App']['RenameTag'](arg1, arg2);
+}
+
+export function ReorderCaptureRules(arg1) {
+  return window['go']['main']['App']['ReorderCaptureRules'](arg1);
+}
+
 export function RestartRegisterHotkey() {
   return window['go']['main']['App']['RestartRegisterHotkey']();
 }
 
+export function ResumeCapture() {
+  return window['go']['main']['App']['ResumeCapture']();
+}
+
+export function RunRetentionNow() {
+  return window['go']['main']['App']['RunRetentionNow']();
+}
+
 export function RunScript() {
   return window['go']['main']['App']['RunScript']();
 }
@@ -186,10 +330,18 @@ export function SaveAppSettings(arg1) {
   return window['go']['main']['App']['SaveAppSettings'](arg1);
 }
 
+export function SaveCaptureRule(arg1) {
+  return window['go']['main']['App']['SaveCaptureRule'](arg1);
+}
+
 export function SaveImagePNG(arg1, arg2) {
   return window['go']['main']['App']['SaveImagePNG'](arg1, arg2);
 }
 
+export function SaveSnippet(arg1, arg2) {
+  return window['go']['main']['App']['SaveSnippet'](arg1, arg2);
+}
+
 export function SaveUserScript(arg1) {
   return window['go']['main']['App']['SaveUserScript'](arg1);
 }
@@ -198,8 +350,12 @@ export function SayText(arg1) {
   return window['go']['main']['App']['SayText'](arg1);
 }
 
-export function SearchClipboardItems(arg1, arg2, arg3, arg4, arg5) {
-  return window['go']['main']['App']['SearchClipboardItems'](arg1, arg2, arg3, arg4, arg5);
+export function SearchClipboardItems(arg1, arg2, arg3, arg4, arg5, arg6) {
+  return window['go']['main']['App']['SearchClipboardItems'](arg1, arg2, arg3, arg4, arg5, arg6);
+}
+
+export function SearchClipboardItemsRanked(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['SearchClipboardItemsRanked'](arg1, arg2, arg3, arg4);
 }
 
 export function SearchItem() {
@@ -214,12 +370,28 @@ export function SetDockIconVisibility(arg1) {
   return window['go']['main']['App']['SetDockIconVisibility'](arg1);
 }
 
+export function SetItemExpiry(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetItemExpiry'](arg1, arg2, arg3);
+}
+
 export function SetLanguage(arg1) {
   return window['go']['main']['App']['SetLanguage'](arg1);
 }
 
-export function SetScriptHTTPResult(arg1, arg2) {
-  return window['go']['main']['App']['SetScriptHTTPResult'](arg1, arg2);
+export function SetPassword(arg1, arg2) {
+  return window['go']['main']['App']['SetPassword'](arg1, arg2);
+}
+
+export function SetScriptStorage(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetScriptStorage'](arg1, arg2, arg3);
+}
+
+export function SetTagColor(arg1, arg2) {
+  return window['go']['main']['App']['SetTagColor'](arg1, arg2);
+}
+
+export function SetTagProtected(arg1, arg2) {
+  return window['go']['main']['App']['SetTagProtected'](arg1, arg2);
 }
 
 export function SetWindowAlwaysOnTop(arg1) {
@@ -258,6 +430,10 @@ export function UpdateUserScriptOrder(arg1, arg2) {
   return window['go']['main']['App']['UpdateUserScriptOrder'](arg1, arg2);
 }
 
+export function ValidateHotkey(arg1) {
+  return window['go']['main']['App']['ValidateHotkey'](arg1);
+}
+
 export function VerifyPassword(arg1) {
   return window['go']['main']['App']['VerifyPassword'](arg1);
 }
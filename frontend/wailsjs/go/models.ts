@@ -1,11 +1,68 @@
 export namespace common {
 	
+	export class CaptureRule {
+	    ID: string;
+	    Name: string;
+	    Enabled: boolean;
+	    AppPattern: string;
+	    MatchMode: string;
+	    ContentTypes: string[];
+	    MinSize: number;
+	    MaxSize: number;
+	    Action: string;
+	    ExpireMinutes: number;
+	    SortOrder: number;
+	    // Go type: time
+	    CreatedAt: any;
+	    // Go type: time
+	    UpdatedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new CaptureRule(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ID = source["ID"];
+	        this.Name = source["Name"];
+	        this.Enabled = source["Enabled"];
+	        this.AppPattern = source["AppPattern"];
+	        this.MatchMode = source["MatchMode"];
+	        this.ContentTypes = source["ContentTypes"];
+	        this.MinSize = source["MinSize"];
+	        this.MaxSize = source["MaxSize"];
+	        this.Action = source["Action"];
+	        this.ExpireMinutes = source["ExpireMinutes"];
+	        this.SortOrder = source["SortOrder"];
+	        this.CreatedAt = this.convertValues(source["CreatedAt"], null);
+	        this.UpdatedAt = this.convertValues(source["UpdatedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class ClipboardItem {
 	    ID: string;
 	    Content: string;
 	    ContentType: string;
 	    ContentHash: string;
 	    ImageData: number[];
+	    Thumbnail: number[];
 	    FilePaths: string;
 	    FileInfo: string;
 	    // Go type: time
@@ -15,6 +72,12 @@ export namespace common {
 	    WordCount: number;
 	    IsFavorite: number;
 	    OCRText: string;
+	    SensitiveCategory: string;
+	    SensitiveAction: string;
+	    // Go type: time
+	    ExpiresAt?: any;
+	    MaxPastes: number;
+	    PasteCount: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new ClipboardItem(source);
@@ -27,6 +90,7 @@ export namespace common {
 	        this.ContentType = source["ContentType"];
 	        this.ContentHash = source["ContentHash"];
 	        this.ImageData = source["ImageData"];
+	        this.Thumbnail = source["Thumbnail"];
 	        this.FilePaths = source["FilePaths"];
 	        this.FileInfo = source["FileInfo"];
 	        this.Timestamp = this.convertValues(source["Timestamp"], null);
@@ -35,6 +99,11 @@ export namespace common {
 	        this.WordCount = source["WordCount"];
 	        this.IsFavorite = source["IsFavorite"];
 	        this.OCRText = source["OCRText"];
+	        this.SensitiveCategory = source["SensitiveCategory"];
+	        this.SensitiveAction = source["SensitiveAction"];
+	        this.ExpiresAt = this.convertValues(source["ExpiresAt"], null);
+	        this.MaxPastes = source["MaxPastes"];
+	        this.PasteCount = source["PasteCount"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -55,6 +124,60 @@ export namespace common {
 		    return a;
 		}
 	}
+	export class EncryptionStatus {
+	    enabled: boolean;
+	    locked: boolean;
+	    migrating: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new EncryptionStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.locked = source["locked"];
+	        this.migrating = source["migrating"];
+	    }
+	}
+	export class ExportFilter {
+	    FavoritesOnly: boolean;
+	    Keyword: string;
+	    ContentType: string;
+	    TagID: string;
+	    Days: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExportFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.FavoritesOnly = source["FavoritesOnly"];
+	        this.Keyword = source["Keyword"];
+	        this.ContentType = source["ContentType"];
+	        this.TagID = source["TagID"];
+	        this.Days = source["Days"];
+	    }
+	}
+	export class ExportResult {
+	    Path: string;
+	    Items: number;
+	    Images: number;
+	    Scripts: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExportResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Path = source["Path"];
+	        this.Items = source["Items"];
+	        this.Images = source["Images"];
+	        this.Scripts = source["Scripts"];
+	    }
+	}
 	export class FileInfo {
 	    name: string;
 	    path: string;
@@ -77,6 +200,581 @@ export namespace common {
 	        this.extension = source["extension"];
 	    }
 	}
+	export class HotkeyBinding {
+	    id: string;
+	    action: string;
+	    hotkey: string;
+	    index?: number;
+	    scriptId?: string;
+	    enabled: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new HotkeyBinding(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.action = source["action"];
+	        this.hotkey = source["hotkey"];
+	        this.index = source["index"];
+	        this.scriptId = source["scriptId"];
+	        this.enabled = source["enabled"];
+	    }
+	}
+	export class HotkeyValidation {
+	    valid: boolean;
+	    canonical: string;
+	    error: string;
+	    conflicts: string[];
+	    registerError: string;
+	    globalHotkeys: boolean;
+	    supportedModifiers: string[];
+	    supportedKeys: string[];
+	    chordTimeoutMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new HotkeyValidation(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.valid = source["valid"];
+	        this.canonical = source["canonical"];
+	        this.error = source["error"];
+	        this.conflicts = source["conflicts"];
+	        this.registerError = source["registerError"];
+	        this.globalHotkeys = source["globalHotkeys"];
+	        this.supportedModifiers = source["supportedModifiers"];
+	        this.supportedKeys = source["supportedKeys"];
+	        this.chordTimeoutMs = source["chordTimeoutMs"];
+	    }
+	}
+	export class ImportReport {
+	    Added: number;
+	    Skipped: number;
+	    Conflicted: number;
+	    Failed: number;
+	    ScriptsAdded: number;
+	    ScriptsSkipped: number;
+	    ScriptsReplaced: number;
+	    SettingsUpdated: boolean;
+	    Errors: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ImportReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Added = source["Added"];
+	        this.Skipped = source["Skipped"];
+	        this.Conflicted = source["Conflicted"];
+	        this.Failed = source["Failed"];
+	        this.ScriptsAdded = source["ScriptsAdded"];
+	        this.ScriptsSkipped = source["ScriptsSkipped"];
+	        this.ScriptsReplaced = source["ScriptsReplaced"];
+	        this.SettingsUpdated = source["SettingsUpdated"];
+	        this.Errors = source["Errors"];
+	    }
+	}
+	export class MatchRange {
+	    start: number;
+	    end: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new MatchRange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.start = source["start"];
+	        this.end = source["end"];
+	    }
+	}
+	export class MigrationInfo {
+	    version: number;
+	    name: string;
+	    applied: boolean;
+	    // Go type: time
+	    appliedAt: any;
+	    appVersion: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new MigrationInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.name = source["name"];
+	        this.applied = source["applied"];
+	        this.appliedAt = this.convertValues(source["appliedAt"], null);
+	        this.appVersion = source["appVersion"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class MonitorStatus {
+	    running: boolean;
+	    paused: boolean;
+	    // Go type: time
+	    pausedUntil: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new MonitorStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.running = source["running"];
+	        this.paused = source["paused"];
+	        this.pausedUntil = this.convertValues(source["pausedUntil"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PasswordStatus {
+	    hasPassword: boolean;
+	    locked: boolean;
+	    // Go type: time
+	    lockedOutUntil: any;
+	    failedAttempts: number;
+	    autoLockMinutes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PasswordStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.hasPassword = source["hasPassword"];
+	        this.locked = source["locked"];
+	        this.lockedOutUntil = this.convertValues(source["lockedOutUntil"], null);
+	        this.failedAttempts = source["failedAttempts"];
+	        this.autoLockMinutes = source["autoLockMinutes"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RetentionResult {
+	    // Go type: time
+	    RanAt: any;
+	    Duration: number;
+	    Removed: number;
+	    ByPolicy: Record<string, number>;
+	    Skipped: boolean;
+	    Error: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RetentionResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.RanAt = this.convertValues(source["RanAt"], null);
+	        this.Duration = source["Duration"];
+	        this.Removed = source["Removed"];
+	        this.ByPolicy = source["ByPolicy"];
+	        this.Skipped = source["Skipped"];
+	        this.Error = source["Error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ScriptRun {
+	    ID: number;
+	    ScriptID: string;
+	    ScriptName: string;
+	    ScriptVersion: string;
+	    ScriptHash: string;
+	    Trigger: string;
+	    ItemID: string;
+	    // Go type: time
+	    StartedAt: any;
+	    DurationMs: number;
+	    Status: string;
+	    Console: string;
+	    ReturnValue: string;
+	    Error: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ScriptRun(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ID = source["ID"];
+	        this.ScriptID = source["ScriptID"];
+	        this.ScriptName = source["ScriptName"];
+	        this.ScriptVersion = source["ScriptVersion"];
+	        this.ScriptHash = source["ScriptHash"];
+	        this.Trigger = source["Trigger"];
+	        this.ItemID = source["ItemID"];
+	        this.StartedAt = this.convertValues(source["StartedAt"], null);
+	        this.DurationMs = source["DurationMs"];
+	        this.Status = source["Status"];
+	        this.Console = source["Console"];
+	        this.ReturnValue = source["ReturnValue"];
+	        this.Error = source["Error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ScriptRunFilter {
+	    ScriptID: string;
+	    Trigger: string;
+	    Status: string;
+	    ItemID: string;
+	    // Go type: time
+	    Since: any;
+	    // Go type: time
+	    Before: any;
+	    Limit: number;
+	    Offset: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ScriptRunFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ScriptID = source["ScriptID"];
+	        this.Trigger = source["Trigger"];
+	        this.Status = source["Status"];
+	        this.ItemID = source["ItemID"];
+	        this.Since = this.convertValues(source["Since"], null);
+	        this.Before = this.convertValues(source["Before"], null);
+	        this.Limit = source["Limit"];
+	        this.Offset = source["Offset"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ScriptRunSummary {
+	    ScriptID: string;
+	    ScriptName: string;
+	    Total: number;
+	    Succeeded: number;
+	    Failed: number;
+	    AvgDurationMs: number;
+	    // Go type: time
+	    LastRunAt: any;
+	    LastStatus: string;
+	    LastError: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ScriptRunSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ScriptID = source["ScriptID"];
+	        this.ScriptName = source["ScriptName"];
+	        this.Total = source["Total"];
+	        this.Succeeded = source["Succeeded"];
+	        this.Failed = source["Failed"];
+	        this.AvgDurationMs = source["AvgDurationMs"];
+	        this.LastRunAt = this.convertValues(source["LastRunAt"], null);
+	        this.LastStatus = source["LastStatus"];
+	        this.LastError = source["LastError"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SearchHit {
+	    item: ClipboardItem;
+	    score: number;
+	    matchedField: string;
+	    snippet: string;
+	    highlights: MatchRange[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SearchHit(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.item = this.convertValues(source["item"], ClipboardItem);
+	        this.score = source["score"];
+	        this.matchedField = source["matchedField"];
+	        this.snippet = source["snippet"];
+	        this.highlights = this.convertValues(source["highlights"], MatchRange);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SensitiveCategoryInfo {
+	    Category: string;
+	    Action: string;
+	    DefaultAction: string;
+	    Detectors: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SensitiveCategoryInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Category = source["Category"];
+	        this.Action = source["Action"];
+	        this.DefaultAction = source["DefaultAction"];
+	        this.Detectors = source["Detectors"];
+	    }
+	}
+	export class Settings {
+	    version: number;
+	    autoClean: boolean;
+	    retentionDays: number;
+	    retentionMaxItems: number;
+	    retentionMaxStorageMB: number;
+	    retentionTypeLimits: Record<string, number>;
+	    pageSize: number;
+	    hotkey: string;
+	    hotkeys: HotkeyBinding[];
+	    backgroundMode: boolean;
+	    doubleClickPaste: boolean;
+	    autoStart: boolean;
+	    language: string;
+	    autoLockMinutes: number;
+	    sensitiveDetection: boolean;
+	    sensitiveActions: Record<string, string>;
+	    sensitiveExpireMinutes: number;
+	    scriptHTTPPort: number;
+	    scriptHTTPBind: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Settings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.autoClean = source["autoClean"];
+	        this.retentionDays = source["retentionDays"];
+	        this.retentionMaxItems = source["retentionMaxItems"];
+	        this.retentionMaxStorageMB = source["retentionMaxStorageMB"];
+	        this.retentionTypeLimits = source["retentionTypeLimits"];
+	        this.pageSize = source["pageSize"];
+	        this.hotkey = source["hotkey"];
+	        this.hotkeys = this.convertValues(source["hotkeys"], HotkeyBinding);
+	        this.backgroundMode = source["backgroundMode"];
+	        this.doubleClickPaste = source["doubleClickPaste"];
+	        this.autoStart = source["autoStart"];
+	        this.language = source["language"];
+	        this.autoLockMinutes = source["autoLockMinutes"];
+	        this.sensitiveDetection = source["sensitiveDetection"];
+	        this.sensitiveActions = source["sensitiveActions"];
+	        this.sensitiveExpireMinutes = source["sensitiveExpireMinutes"];
+	        this.scriptHTTPPort = source["scriptHTTPPort"];
+	        this.scriptHTTPBind = source["scriptHTTPBind"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SnippetExpansion {
+	    Text: string;
+	    CursorOffset: number;
+	    Inputs: string[];
+	    MissingInputs: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SnippetExpansion(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Text = source["Text"];
+	        this.CursorOffset = source["CursorOffset"];
+	        this.Inputs = source["Inputs"];
+	        this.MissingInputs = source["MissingInputs"];
+	    }
+	}
+	export class Tag {
+	    ID: string;
+	    Name: string;
+	    Color: string;
+	    Protected: boolean;
+	    ItemCount: number;
+	    // Go type: time
+	    CreatedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Tag(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ID = source["ID"];
+	        this.Name = source["Name"];
+	        this.Color = source["Color"];
+	        this.Protected = source["Protected"];
+	        this.ItemCount = source["ItemCount"];
+	        this.CreatedAt = this.convertValues(source["CreatedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class UserScript {
 	    ID: string;
 	    Name: string;
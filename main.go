@@ -51,7 +51,7 @@ func main() {
 			log.Printf("关闭数据库失败: %v", err)
 		}
 		// 取消注册快捷键
-		common.UnregisterHotkey()
+		common.UnregisterAllHotkeys()
 	}()
 
 	// Create an instance of the app structure
@@ -127,7 +127,7 @@ func main() {
 		app.TranslateCurrentItem()
 	})
 
-	// 注册剪贴板（后台持续运行）- 添加错误处理
+	// 注册剪贴板更新监听（捕获器在 app.startup 中启动）- 添加错误处理
 	func() {
 		defer func() {
 			if r := recover(); r != nil {
@@ -135,7 +135,7 @@ func main() {
 			}
 		}()
 
-		// 注册剪贴板（后台持续运行）
+		// 注册剪贴板更新监听
 		clipboardListener := common.RegisterClipboardListener()
 		go func() {
 			for range clipboardListener {